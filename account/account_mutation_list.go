@@ -8,6 +8,7 @@ import (
 
 	"connectrpc.com/connect"
 	"github.com/pdcgo/accounting_service/accounting_model"
+	"github.com/pdcgo/accounting_service/query_dialect"
 	"github.com/pdcgo/schema/services/accounting_iface/v1"
 	"github.com/pdcgo/schema/services/common/v1"
 	"github.com/pdcgo/shared/custom_connect"
@@ -66,6 +67,7 @@ func (a *accountServiceImpl) AccountMutationList(
 		return connect.NewResponse(&result), err
 	}
 
+	dialect := query_dialect.NewDialect(db)
 	createQuery := func() *gorm.DB {
 
 		query := db.
@@ -79,8 +81,20 @@ func (a *accountServiceImpl) AccountMutationList(
 				"bth.fee_amount",
 				"bth.purpose",
 				"bth.desc",
-				"(EXTRACT(EPOCH FROM bth.transfer_at) * 1000000)::BIGINT AS transfer_at",
-				"(EXTRACT(EPOCH FROM bth.created) * 1000000)::BIGINT AS created",
+				fmt.Sprintf(`
+				case
+					when bth.status = '%s' then %d
+					else %d
+				end as status`,
+					accounting_model.TransferCancelled,
+					accounting_iface.TransferStatus_TRANSFER_STATUS_CANCELLED,
+					accounting_iface.TransferStatus_TRANSFER_STATUS_ACTIVE,
+				),
+				"bth.cancel_reason",
+				fmt.Sprintf("coalesce(%s, 0) AS cancelled_at", dialect.EpochMicro("bth.cancelled_at")),
+				// kolom transfer_at sudah tidak diisi model, waktu transfer sama dengan created
+				dialect.EpochMicro("bth.created")+" AS transfer_at",
+				dialect.EpochMicro("bth.created")+" AS created",
 				fmt.Sprintf(`
 				case 
 					when fac.team_id = %d then 1
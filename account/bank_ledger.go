@@ -0,0 +1,806 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/pdcgo/accounting_service/accounting_core"
+	"github.com/pdcgo/accounting_service/accounting_model"
+	"github.com/pdcgo/accounting_service/query_dialect"
+	"github.com/pdcgo/schema/services/accounting_iface/v1"
+	"github.com/pdcgo/schema/services/common/v1"
+	"github.com/pdcgo/shared/authorization"
+	"github.com/pdcgo/shared/interfaces/authorization_iface"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const defaultStatementLimit = 100
+
+// BankLedgerBalance saldo bank account dari dimensi bank di journal entry, before kosong berarti semua
+func BankLedgerBalance(db *gorm.DB, bankIDs []uint, before time.Time) (map[uint]float64, error) {
+	rows := []*struct {
+		BankAccountID uint
+		Balance       float64
+	}{}
+
+	query := db.
+		Model(&accounting_core.JournalEntryBank{}).
+		Select("bank_account_id, sum(debit - credit) as balance").
+		Where("bank_account_id IN ?", bankIDs).
+		Group("bank_account_id")
+
+	if !before.IsZero() {
+		query = query.Where("entry_time < ?", before)
+	}
+
+	err := query.Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[uint]float64{}
+	for _, row := range rows {
+		result[row.BankAccountID] = row.Balance
+	}
+	return result, nil
+}
+
+// SyncBankBalance field Balance hanya cache dari ledger, dihitung ulang setiap ada mutasi
+func SyncBankBalance(tx *gorm.DB, bankIDs ...uint) error {
+	balances, err := BankLedgerBalance(tx, bankIDs, time.Time{})
+	if err != nil {
+		return err
+	}
+
+	for _, bankID := range bankIDs {
+		err = tx.
+			Model(&accounting_model.BankAccountV2{}).
+			Where("id = ?", bankID).
+			Update("balance", balances[bankID]).
+			Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CashBank bank account tempat kas team diposting, dikunci sampai transaksi selesai.
+// Posting kas wajib menyebut bank supaya ledger bank tetap lengkap
+func CashBank(tx *gorm.DB, teamID uint, bankID uint64) (*accounting_model.BankAccountV2, error) {
+	if bankID == 0 {
+		return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("bank_account_id required"))
+	}
+
+	var bank accounting_model.BankAccountV2
+	err := tx.
+		Clauses(clause.Locking{
+			Strength: "UPDATE",
+		}).
+		Model(&accounting_model.BankAccountV2{}).
+		Where("id = ?", bankID).
+		Find(&bank).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case bank.ID == 0:
+		return nil, connect.NewError(connect.CodeNotFound, fmt.Errorf("account id %d not found", bankID))
+	case bank.TeamID != teamID:
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("your team not have %s", bank.Name))
+	case bank.Deleted || bank.Disabled:
+		return nil, connect.NewError(connect.CodeFailedPrecondition, fmt.Errorf("account %s not active", bank.Name))
+	}
+
+	return &bank, nil
+}
+
+func (a *accountServiceImpl) bankAccount(
+	db *gorm.DB,
+	identity authorization_iface.AuthIdentity,
+	bankID uint64,
+) (*accounting_model.BankAccountV2, error) {
+	var bank accounting_model.BankAccountV2
+	err := db.
+		Model(&accounting_model.BankAccountV2{}).
+		Where("id = ?", bankID).
+		Find(&bank).
+		Error
+	if err != nil {
+		return nil, err
+	}
+	if bank.ID == 0 {
+		return nil, connect.NewError(connect.CodeNotFound, fmt.Errorf("account id %d not found", bankID))
+	}
+
+	err = identity.
+		HasPermission(authorization_iface.CheckPermissionGroup{
+			&accounting_model.BankAccountV2{}: &authorization_iface.CheckPermission{
+				DomainID: bank.TeamID,
+				Actions:  []authorization_iface.Action{authorization_iface.Read},
+			},
+		}).
+		Err()
+
+	return &bank, err
+}
+
+// AccountBankBalance saldo semua bank account team dihitung dari ledger
+func (a *accountServiceImpl) AccountBankBalance(
+	ctx context.Context,
+	req *connect.Request[accounting_iface.AccountBankBalanceRequest],
+) (*connect.Response[accounting_iface.AccountBankBalanceResponse], error) {
+	var err error
+	pay := req.Msg
+
+	result := accounting_iface.AccountBankBalanceResponse{
+		Data: []*accounting_iface.BankBalanceItem{},
+	}
+
+	if pay.TeamId == 0 {
+		return connect.NewResponse(&result), connect.NewError(connect.CodeInvalidArgument, errors.New("team_id required"))
+	}
+
+	err = a.
+		auth.
+		AuthIdentityFromHeader(req.Header()).
+		HasPermission(authorization_iface.CheckPermissionGroup{
+			&accounting_model.BankAccountV2{}: &authorization_iface.CheckPermission{
+				DomainID: uint(pay.TeamId),
+				Actions:  []authorization_iface.Action{authorization_iface.Read},
+			},
+		}).
+		Err()
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	db := a.db.WithContext(ctx)
+
+	banks := []*accounting_model.BankAccountV2{}
+	err = db.
+		Model(&accounting_model.BankAccountV2{}).
+		Where("team_id = ?", pay.TeamId).
+		Where("deleted = ?", false).
+		Order("id asc").
+		Find(&banks).
+		Error
+	if err != nil || len(banks) == 0 {
+		return connect.NewResponse(&result), err
+	}
+
+	bankIDs := make([]uint, len(banks))
+	for i, bank := range banks {
+		bankIDs[i] = bank.ID
+	}
+
+	var before time.Time
+	if pay.At != nil {
+		before = query_dialect.ReportDayStart(query_dialect.ReportDay(pay.At.AsTime()).AddDate(0, 0, 1))
+	}
+
+	balances, err := BankLedgerBalance(db, bankIDs, before)
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	for _, bank := range banks {
+		result.Data = append(result.Data, &accounting_iface.BankBalanceItem{
+			AccountId: uint64(bank.ID),
+			Name:      bank.Name,
+			NumberId:  bank.NumberID,
+			Balance:   balances[bank.ID],
+		})
+		result.Total += balances[bank.ID]
+	}
+
+	return connect.NewResponse(&result), nil
+}
+
+// AccountBankDailyBalance saldo akhir harian bank account, hari tanpa mutasi tetap muncul
+func (a *accountServiceImpl) AccountBankDailyBalance(
+	ctx context.Context,
+	req *connect.Request[accounting_iface.AccountBankDailyBalanceRequest],
+) (*connect.Response[accounting_iface.AccountBankDailyBalanceResponse], error) {
+	var err error
+	pay := req.Msg
+
+	result := accounting_iface.AccountBankDailyBalanceResponse{
+		Data: []*accounting_iface.BankDailyBalance{},
+	}
+
+	startAt, endAt, err := bankLedgerRange(pay.TimeRange)
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	startDay := query_dialect.ReportDay(startAt)
+	endDay := query_dialect.ReportDay(endAt)
+	if endDay.Sub(startDay) > 366*24*time.Hour {
+		return connect.NewResponse(&result), connect.NewError(connect.CodeInvalidArgument, errors.New("time range max 1 year"))
+	}
+
+	db := a.db.WithContext(ctx)
+	identity := a.auth.AuthIdentityFromHeader(req.Header())
+	bank, err := a.bankAccount(db, identity, pay.AccountId)
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	start := query_dialect.ReportDayStart(startDay)
+	end := query_dialect.ReportDayStart(endDay.AddDate(0, 0, 1))
+
+	opening, err := BankLedgerBalance(db, []uint{bank.ID}, start)
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+	result.OpeningBalance = opening[bank.ID]
+
+	entries := []*accounting_core.JournalEntryBank{}
+	err = db.
+		Model(&accounting_core.JournalEntryBank{}).
+		Where("bank_account_id = ?", bank.ID).
+		Where("entry_time >= ? AND entry_time < ?", start, end).
+		Order("entry_time asc, id asc").
+		Find(&entries).
+		Error
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	dayMap := map[time.Time]*accounting_iface.BankDailyBalance{}
+	for day := startDay; !day.After(endDay); day = day.AddDate(0, 0, 1) {
+		item := &accounting_iface.BankDailyBalance{Day: timestamppb.New(day)}
+		dayMap[day] = item
+		result.Data = append(result.Data, item)
+	}
+
+	for _, entry := range entries {
+		item := dayMap[query_dialect.ReportDay(entry.EntryTime)]
+		if item == nil {
+			continue
+		}
+		item.Debit += entry.Debit
+		item.Credit += entry.Credit
+	}
+
+	balance := result.OpeningBalance
+	for _, item := range result.Data {
+		balance += item.Debit - item.Credit
+		item.Balance = balance
+	}
+
+	return connect.NewResponse(&result), nil
+}
+
+type bankStatementRow struct {
+	ID             uint
+	JournalEntryID uint
+	TransactionID  uint
+	RefID          accounting_core.RefID
+	EntryTime      time.Time
+	Desc           string
+	Debit          float64
+	Credit         float64
+	Rollback       bool
+}
+
+// bankLedgerRange range tanggal laporan ledger bank, akhir range wajib diisi
+func bankLedgerRange(timeRange *common.TimeFilterRange) (time.Time, time.Time, error) {
+	if timeRange == nil || timeRange.StartDate == nil || timeRange.EndDate == nil {
+		return time.Time{}, time.Time{}, connect.NewError(connect.CodeInvalidArgument, errors.New("invalid time range"))
+	}
+
+	start := timeRange.StartDate.AsTime()
+	end := timeRange.EndDate.AsTime()
+	if end.Before(start) {
+		return time.Time{}, time.Time{}, connect.NewError(connect.CodeInvalidArgument, errors.New("invalid time range"))
+	}
+
+	return start, end, nil
+}
+
+// AccountBankStatement mutasi bank account dari ledger dengan saldo berjalan
+func (a *accountServiceImpl) AccountBankStatement(
+	ctx context.Context,
+	req *connect.Request[accounting_iface.AccountBankStatementRequest],
+) (*connect.Response[accounting_iface.AccountBankStatementResponse], error) {
+	var err error
+	pay := req.Msg
+
+	result := accounting_iface.AccountBankStatementResponse{
+		Data: []*accounting_iface.BankStatementItem{},
+	}
+
+	startAt, endAt, err := bankLedgerRange(pay.TimeRange)
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	db := a.db.WithContext(ctx)
+	identity := a.auth.AuthIdentityFromHeader(req.Header())
+	bank, err := a.bankAccount(db, identity, pay.AccountId)
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	start := query_dialect.ReportDayStart(query_dialect.ReportDay(startAt))
+	end := query_dialect.ReportDayStart(query_dialect.ReportDay(endAt).AddDate(0, 0, 1))
+
+	opening, err := BankLedgerBalance(db, []uint{bank.ID}, start)
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+	result.OpeningBalance = opening[bank.ID]
+
+	query := db.
+		Table("journal_entry_banks jeb").
+		Where("jeb.bank_account_id = ?", bank.ID).
+		Where("jeb.entry_time >= ? AND jeb.entry_time < ?", start, end)
+
+	var total struct {
+		Count  int64
+		Change float64
+	}
+	err = query.
+		Session(&gorm.Session{}).
+		Select("count(*) as count, coalesce(sum(jeb.debit - jeb.credit), 0) as change").
+		Scan(&total).
+		Error
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+	result.ClosingBalance = result.OpeningBalance + total.Change
+
+	var page, limit int64 = 1, defaultStatementLimit
+	if pay.Page != nil {
+		page = max(pay.Page.Page, 1)
+		if pay.Page.Limit > 0 {
+			limit = pay.Page.Limit
+		}
+	}
+	offset := int((page - 1) * limit)
+
+	result.PageInfo = &common.PageInfo{
+		CurrentPage: page,
+		TotalPage:   max((total.Count+limit-1)/limit, 1),
+		TotalItems:  total.Count,
+	}
+
+	rows := []*bankStatementRow{}
+	err = query.
+		Session(&gorm.Session{}).
+		Joins("left join transactions t on t.id = jeb.transaction_id").
+		Select(
+			"jeb.id",
+			"jeb.journal_entry_id",
+			"jeb.transaction_id",
+			"t.ref_id",
+			"jeb.entry_time",
+			"jeb.desc",
+			"jeb.debit",
+			"jeb.credit",
+			"jeb.rollback",
+		).
+		Order("jeb.entry_time asc, jeb.id asc").
+		Offset(offset).
+		Limit(int(limit)).
+		Scan(&rows).
+		Error
+	if err != nil || len(rows) == 0 {
+		return connect.NewResponse(&result), err
+	}
+
+	// saldo sebelum halaman ini
+	var before float64
+	if offset > 0 {
+		err = db.
+			Table("(?) as prev", query.
+				Session(&gorm.Session{}).
+				Select("jeb.debit - jeb.credit as change").
+				Order("jeb.entry_time asc, jeb.id asc").
+				Limit(offset),
+			).
+			Select("coalesce(sum(change), 0)").
+			Scan(&before).
+			Error
+		if err != nil {
+			return connect.NewResponse(&result), err
+		}
+	}
+
+	balance := result.OpeningBalance + before
+	for _, row := range rows {
+		balance += row.Debit - row.Credit
+		result.Data = append(result.Data, &accounting_iface.BankStatementItem{
+			Id:             uint64(row.ID),
+			JournalEntryId: uint64(row.JournalEntryID),
+			TransactionId:  uint64(row.TransactionID),
+			RefId:          string(row.RefID),
+			EntryTime:      timestamppb.New(row.EntryTime),
+			Desc:           row.Desc,
+			Debit:          row.Debit,
+			Credit:         row.Credit,
+			Rollback:       row.Rollback,
+			Balance:        balance,
+		})
+	}
+
+	return connect.NewResponse(&result), nil
+}
+
+// BackfillBankLedger isi dimensi bank dari saldo awal dan riwayat transfer lama kalau tabel masih kosong,
+// kalau sudah terisi hanya menghubungkan baris lama ke journal entry kas
+func BackfillBankLedger(db *gorm.DB) error {
+	var count int64
+	err := db.
+		Model(&accounting_core.JournalEntryBank{}).
+		Count(&count).
+		Error
+	if err != nil {
+		return err
+	}
+
+	if count > 0 {
+		return relinkBankLedger(db)
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		banks := []*accounting_model.BankAccountV2{}
+		err := tx.
+			Model(&accounting_model.BankAccountV2{}).
+			Find(&banks).
+			Error
+		if err != nil {
+			return err
+		}
+
+		bankMap := map[uint]*accounting_model.BankAccountV2{}
+		rows := []*accounting_core.JournalEntryBank{}
+		for _, bank := range banks {
+			bankMap[bank.ID] = bank
+
+			// sebelumnya Balance hanya diisi saat inisialisasi saldo
+			if bank.Balance == 0 {
+				continue
+			}
+
+			// dibuat langsung supaya entry kas lama tidak terpakai dua bank
+			row, err := openingBankEntry(tx, bank, bank.Balance)
+			if err != nil {
+				return err
+			}
+			err = tx.Create(row).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		hists := []*accounting_model.BankTransferHistory{}
+		err = tx.
+			Model(&accounting_model.BankTransferHistory{}).
+			Order("id asc").
+			Find(&hists).
+			Error
+		if err != nil {
+			return err
+		}
+
+		for _, hist := range hists {
+			if facc := bankMap[hist.FromAccountID]; facc != nil {
+				journalID, err := cashEntryID(tx, hist.TxID, facc.TeamID, false)
+				if err != nil {
+					return err
+				}
+
+				rows = append(rows, &accounting_core.JournalEntryBank{
+					JournalEntryID: journalID,
+					TransactionID:  hist.TxID,
+					BankAccountID:  facc.ID,
+					TeamID:         facc.TeamID,
+					EntryTime:      hist.Created,
+					Credit:         hist.Amount + hist.FeeAmount,
+					Desc:           hist.Desc,
+				})
+			}
+			if tacc := bankMap[hist.ToAccountID]; tacc != nil {
+				journalID, err := cashEntryID(tx, hist.TxID, tacc.TeamID, true)
+				if err != nil {
+					return err
+				}
+
+				rows = append(rows, &accounting_core.JournalEntryBank{
+					JournalEntryID: journalID,
+					TransactionID:  hist.TxID,
+					BankAccountID:  tacc.ID,
+					TeamID:         tacc.TeamID,
+					EntryTime:      hist.Created,
+					Debit:          hist.Amount,
+					Desc:           hist.Desc,
+				})
+			}
+		}
+
+		if len(rows) > 0 {
+			err = tx.CreateInBatches(&rows, 500).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		return SyncBankBalance(tx, slices.Collect(maps.Keys(bankMap))...)
+	})
+}
+
+// relinkBankLedger backfill versi lama belum mengisi journal_entry_id,
+// saldo awal versi lama juga belum terhubung ke transaksi apapun
+func relinkBankLedger(db *gorm.DB) error {
+	openings := []*accounting_core.JournalEntryBank{}
+	err := db.
+		Model(&accounting_core.JournalEntryBank{}).
+		Where("journal_entry_id = 0").
+		Where("transaction_id = 0").
+		Find(&openings).
+		Error
+	if err != nil {
+		return err
+	}
+
+	for _, old := range openings {
+		err = db.Transaction(func(tx *gorm.DB) error {
+			var bank accounting_model.BankAccountV2
+			err := tx.First(&bank, old.BankAccountID).Error
+			if err != nil {
+				return err
+			}
+
+			row, err := openingBankEntry(tx, &bank, old.Debit-old.Credit)
+			if err != nil {
+				return err
+			}
+
+			return tx.
+				Model(&accounting_core.JournalEntryBank{}).
+				Where("id = ?", old.ID).
+				Updates(map[string]any{
+					"journal_entry_id": row.JournalEntryID,
+					"transaction_id":   row.TransactionID,
+				}).
+				Error
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	rows := []*accounting_core.JournalEntryBank{}
+	err = db.
+		Model(&accounting_core.JournalEntryBank{}).
+		Where("journal_entry_id = 0").
+		Where("transaction_id <> 0").
+		Find(&rows).
+		Error
+	if err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		journalID, err := cashEntryID(db, row.TransactionID, row.TeamID, row.Debit > 0)
+		if err != nil {
+			return err
+		}
+
+		if journalID == 0 {
+			continue
+		}
+
+		err = db.
+			Model(&accounting_core.JournalEntryBank{}).
+			Where("id = ?", row.ID).
+			Update("journal_entry_id", journalID).
+			Error
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// cashEntryID journal entry kas team di buku team itu sendiri, supaya dimensi bank hasil
+// backfill ikut dibalik saat transaksi di rollback
+func cashEntryID(tx *gorm.DB, txID uint, teamID uint, debit bool) (uint, error) {
+	var journalID uint
+	if txID == 0 {
+		return journalID, nil
+	}
+
+	side := "je.credit > 0"
+	if debit {
+		side = "je.debit > 0"
+	}
+
+	err := tx.
+		Table("journal_entries je").
+		Joins("join accounts a on a.id = je.account_id").
+		Select("je.id").
+		Where("je.transaction_id = ?", txID).
+		Where("je.team_id = ?", teamID).
+		Where("je.rollback = ?", false).
+		Where("a.team_id = ?", teamID).
+		Where("a.account_key = ?", accounting_core.CashAccount).
+		Where(side).
+		Order("je.id asc").
+		Limit(1).
+		Scan(&journalID).
+		Error
+
+	return journalID, err
+}
+
+func openingRefID(bankID uint) accounting_core.RefID {
+	return accounting_core.NewRefID(&accounting_core.RefData{
+		RefType: accounting_core.BankOpeningRef,
+		ID:      bankID,
+	})
+}
+
+// openingBankEntry baris saldo awal bank yang terhubung ke transaksi inisialisasi saldo.
+// Inisialisasi lama tidak punya ref, dicari dari entry kas senilai saldo dengan lawan modal awal
+// yang belum punya dimensi bank. Kalau tidak ada sama sekali transaksi pembukaan diposting
+func openingBankEntry(tx *gorm.DB, bank *accounting_model.BankAccountV2, amount float64) (*accounting_core.JournalEntryBank, error) {
+	row := accounting_core.JournalEntryBank{
+		BankAccountID: bank.ID,
+		TeamID:        bank.TeamID,
+		EntryTime:     bank.CreatedAt,
+		Debit:         max(amount, 0),
+		Credit:        max(-amount, 0),
+		Desc:          "saldo awal",
+	}
+
+	var txID uint
+	err := tx.
+		Model(&accounting_core.Transaction{}).
+		Select("id").
+		Where("ref_id = ?", openingRefID(bank.ID)).
+		Limit(1).
+		Scan(&txID).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	if txID != 0 {
+		row.TransactionID = txID
+		row.JournalEntryID, err = cashEntryID(tx, txID, bank.TeamID, amount > 0)
+		if err != nil {
+			return nil, err
+		}
+		return &row, openingEntryTime(tx, &row)
+	}
+
+	side := "je.credit = ?"
+	if amount > 0 {
+		side = "je.debit = ?"
+	}
+
+	var legacy struct {
+		ID            uint
+		TransactionID uint
+	}
+	err = tx.
+		Table("journal_entries je").
+		Joins("join accounts a on a.id = je.account_id").
+		Joins("join transactions t on t.id = je.transaction_id").
+		Select("je.id, je.transaction_id").
+		Where("je.team_id = ?", bank.TeamID).
+		Where("je.rollback = ?", false).
+		Where("a.team_id = ?", bank.TeamID).
+		Where("a.account_key = ?", accounting_core.CashAccount).
+		Where("t.ref_id = ?", "").
+		Where(side, math.Abs(amount)).
+		Where(`exists (
+			select 1 from journal_entries ce
+			join accounts ca on ca.id = ce.account_id
+			where ce.transaction_id = je.transaction_id and ca.account_key = ?
+		)`, accounting_core.CapitalStartAccount).
+		Where("not exists (select 1 from journal_entry_banks jeb where jeb.journal_entry_id = je.id)").
+		Order("je.id asc").
+		Limit(1).
+		Scan(&legacy).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	if legacy.ID != 0 {
+		row.TransactionID = legacy.TransactionID
+		row.JournalEntryID = legacy.ID
+		return &row, openingEntryTime(tx, &row)
+	}
+
+	row.TransactionID, err = postOpening(tx, bank, amount)
+	if err != nil {
+		return nil, err
+	}
+	row.JournalEntryID, err = cashEntryID(tx, row.TransactionID, bank.TeamID, amount > 0)
+	return &row, err
+}
+
+// openingEntryTime waktu saldo awal mengikuti journal entry kas yang dihubungkan
+func openingEntryTime(tx *gorm.DB, row *accounting_core.JournalEntryBank) error {
+	if row.JournalEntryID == 0 {
+		return nil
+	}
+
+	return tx.
+		Model(&accounting_core.JournalEntry{}).
+		Select("entry_time").
+		Where("id = ?", row.JournalEntryID).
+		Scan(&row.EntryTime).
+		Error
+}
+
+// postOpening jurnal saldo awal dari modal awal seperti AccountBalanceInit,
+// dimensi bank diisi pemanggil
+func postOpening(tx *gorm.DB, bank *accounting_model.BankAccountV2, amount float64) (uint, error) {
+	ctx := tx.Statement.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	trans := accounting_core.Transaction{
+		TeamID:  bank.TeamID,
+		RefID:   openingRefID(bank.ID),
+		Desc:    fmt.Sprintf("saldo awal %s", bank.Name),
+		Created: bank.CreatedAt,
+	}
+
+	capital := &accounting_core.EntryAccountPayload{
+		Key:    accounting_core.CapitalStartAccount,
+		TeamID: authorization.RootDomain,
+	}
+	cash := &accounting_core.EntryAccountPayload{
+		Key:    accounting_core.CashAccount,
+		TeamID: bank.TeamID,
+	}
+
+	err := accounting_core.OpenTransaction(ctx, tx, func(tx *gorm.DB, bookmng accounting_core.BookManage) error {
+		err := bookmng.
+			NewTransaction().
+			Create(&trans).
+			Err()
+		if err != nil {
+			return err
+		}
+
+		entry := bookmng.NewCreateEntry(bank.TeamID, 0)
+		if amount > 0 {
+			entry.
+				From(capital, amount).
+				To(cash, amount)
+		} else {
+			entry.
+				From(cash, -amount).
+				To(capital, -amount)
+		}
+
+		return entry.
+			EntryTime(bank.CreatedAt).
+			Transaction(&trans).
+			Commit().
+			Err()
+	})
+
+	return trans.ID, err
+}
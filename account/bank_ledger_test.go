@@ -0,0 +1,336 @@
+package account_test
+
+import (
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/pdcgo/accounting_service/account"
+	"github.com/pdcgo/accounting_service/accounting_core"
+	"github.com/pdcgo/accounting_service/accounting_mock"
+	"github.com/pdcgo/accounting_service/accounting_model"
+	"github.com/pdcgo/schema/services/accounting_iface/v1"
+	"github.com/pdcgo/schema/services/common/v1"
+	"github.com/pdcgo/shared/authorization"
+	"github.com/pdcgo/shared/authorization/authorization_mock"
+	"github.com/pdcgo/shared/pkg/moretest"
+	"github.com/pdcgo/shared/pkg/moretest/moretest_mock"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/gorm"
+)
+
+var bankAuthMock = authorization_mock.EmptyAuthorizationMock{
+	AuthIdentityMock: &authorization_mock.AuthIdentityMock{
+		IdentityMock: &authorization_mock.IdentityMock{
+			ID: 1,
+		},
+	},
+}
+
+// bankLedgerSetup tiga bank account, dua di team 5 dan satu di team 6
+func bankLedgerSetup(db *gorm.DB) moretest.SetupListFunc {
+	return moretest.SetupListFunc{
+		moretest_mock.MockSqliteDatabase(db),
+		func(t *testing.T) func() error {
+			err := db.AutoMigrate(
+				&accounting_core.Account{},
+				&accounting_core.Transaction{},
+				&accounting_core.JournalEntry{},
+				&accounting_core.JournalEntryBank{},
+				&accounting_core.TypeLabel{},
+				&accounting_core.TransactionTypeLabel{},
+				&accounting_model.BankAccountV2{},
+				&accounting_model.BankTransferHistory{},
+			)
+			assert.Nil(t, err)
+
+			err = db.Create([]*accounting_model.BankAccountV2{
+				{ID: 1, TeamID: 5, Name: "bca operasional", NumberID: "1001"},
+				{ID: 2, TeamID: 5, Name: "bni iklan", NumberID: "1002"},
+				{ID: 3, TeamID: 6, Name: "bca gudang", NumberID: "1003"},
+			}).Error
+			assert.Nil(t, err)
+			return nil
+		},
+		accounting_mock.PopulateAccountKey(db, 5),
+		accounting_mock.PopulateAccountKey(db, 6),
+	}
+}
+
+func TestBankLedger(t *testing.T) {
+	var db gorm.DB
+
+	moretest.Suite(t, "testing saldo bank account dari ledger",
+		bankLedgerSetup(&db),
+		func(t *testing.T) {
+			service := account.NewAccountService(&db, &bankAuthMock)
+
+			bankBalance := func(t *testing.T, id uint) float64 {
+				var bank accounting_model.BankAccountV2
+				err := db.First(&bank, id).Error
+				assert.Nil(t, err)
+				return bank.Balance
+			}
+
+			// saldo awal dari team lain
+			_, err := service.TransferCreate(t.Context(), connect.NewRequest(&accounting_iface.TransferCreateRequest{
+				TeamId:        6,
+				FromAccountId: 3,
+				ToAccountId:   1,
+				Amount:        1000000,
+				Desc:          "modal dari gudang",
+			}))
+			assert.Nil(t, err)
+
+			_, err = service.TransferCreate(t.Context(), connect.NewRequest(&accounting_iface.TransferCreateRequest{
+				TeamId:        5,
+				FromAccountId: 1,
+				ToAccountId:   2,
+				Amount:        300000,
+				FeeAmount:     2500,
+				Desc:          "topup iklan",
+			}))
+			assert.Nil(t, err)
+
+			_, err = service.TransferCreate(t.Context(), connect.NewRequest(&accounting_iface.TransferCreateRequest{
+				TeamId:        5,
+				FromAccountId: 1,
+				ToAccountId:   3,
+				Amount:        100000,
+				Desc:          "kirim gudang",
+			}))
+			assert.Nil(t, err)
+
+			t.Run("saldo field ikut ledger", func(t *testing.T) {
+				assert.Equal(t, 597500.0, bankBalance(t, 1))
+				assert.Equal(t, 300000.0, bankBalance(t, 2))
+				assert.Equal(t, -900000.0, bankBalance(t, 3))
+			})
+
+			t.Run("saldo per team", func(t *testing.T) {
+				res, err := service.AccountBankBalance(t.Context(), connect.NewRequest(&accounting_iface.AccountBankBalanceRequest{
+					TeamId: 5,
+				}))
+				assert.Nil(t, err)
+				assert.Len(t, res.Msg.Data, 2)
+				assert.Equal(t, 597500.0, res.Msg.Data[0].Balance)
+				assert.Equal(t, 897500.0, res.Msg.Total)
+
+				res, err = service.AccountBankBalance(t.Context(), connect.NewRequest(&accounting_iface.AccountBankBalanceRequest{
+					TeamId: 5,
+					At:     timestamppb.New(time.Now().AddDate(0, 0, -1)),
+				}))
+				assert.Nil(t, err)
+				assert.Equal(t, 0.0, res.Msg.Total)
+			})
+
+			t.Run("mutasi dengan saldo berjalan", func(t *testing.T) {
+				req := &accounting_iface.AccountBankStatementRequest{
+					AccountId: 1,
+					TimeRange: &common.TimeFilterRange{
+						StartDate: timestamppb.Now(),
+						EndDate:   timestamppb.Now(),
+					},
+				}
+				res, err := service.AccountBankStatement(t.Context(), connect.NewRequest(req))
+				assert.Nil(t, err)
+				assert.Equal(t, int64(3), res.Msg.PageInfo.TotalItems)
+				assert.Equal(t, 0.0, res.Msg.OpeningBalance)
+				assert.Equal(t, 597500.0, res.Msg.ClosingBalance)
+
+				balances := []float64{}
+				for _, item := range res.Msg.Data {
+					balances = append(balances, item.Balance)
+				}
+				assert.Equal(t, []float64{1000000, 697500, 597500}, balances)
+				assert.Equal(t, 302500.0, res.Msg.Data[1].Credit)
+				assert.NotEmpty(t, res.Msg.Data[1].RefId)
+
+				req.Page = &common.PageFilter{
+					Page:  2,
+					Limit: 1,
+				}
+				res, err = service.AccountBankStatement(t.Context(), connect.NewRequest(req))
+				assert.Nil(t, err)
+				assert.Len(t, res.Msg.Data, 1)
+				assert.Equal(t, 697500.0, res.Msg.Data[0].Balance)
+			})
+
+			t.Run("saldo harian", func(t *testing.T) {
+				res, err := service.AccountBankDailyBalance(t.Context(), connect.NewRequest(&accounting_iface.AccountBankDailyBalanceRequest{
+					AccountId: 2,
+					TimeRange: &common.TimeFilterRange{
+						StartDate: timestamppb.New(time.Now().AddDate(0, 0, -1)),
+						EndDate:   timestamppb.Now(),
+					},
+				}))
+				assert.Nil(t, err)
+				assert.Len(t, res.Msg.Data, 2)
+				assert.Equal(t, 0.0, res.Msg.Data[0].Balance)
+				assert.Equal(t, 300000.0, res.Msg.Data[1].Debit)
+				assert.Equal(t, 300000.0, res.Msg.Data[1].Balance)
+			})
+
+			t.Run("rollback transfer ikut membalik saldo bank", func(t *testing.T) {
+				var hist accounting_model.BankTransferHistory
+				err := db.Where("to_account_id = ?", 2).First(&hist).Error
+				assert.Nil(t, err)
+
+				err = accounting_core.
+					NewTransactionMutation(t.Context(), &db).
+					ByRefID(accounting_core.NewRefID(&accounting_core.RefData{
+						RefType: accounting_core.TransferRef,
+						ID:      hist.ID,
+					}), true).
+					RollbackEntry(1, "batal").
+					Err()
+				assert.Nil(t, err)
+
+				res, err := service.AccountBankBalance(t.Context(), connect.NewRequest(&accounting_iface.AccountBankBalanceRequest{
+					TeamId: 5,
+				}))
+				assert.Nil(t, err)
+				assert.Equal(t, 900000.0, res.Msg.Data[0].Balance)
+				assert.Equal(t, 0.0, res.Msg.Data[1].Balance)
+
+				var rollback int64
+				db.Model(&accounting_core.JournalEntryBank{}).Where("rollback = ?", true).Count(&rollback)
+				assert.Equal(t, int64(2), rollback)
+			})
+		},
+	)
+}
+
+func TestBackfillOpeningBalance(t *testing.T) {
+	var db gorm.DB
+
+	moretest.Suite(t, "testing backfill saldo awal bank",
+		append(
+			bankLedgerSetup(&db),
+			func(t *testing.T) func() error {
+				err := accounting_core.
+					NewCreateAccount(&db).
+					Create(accounting_core.DebitBalance, accounting_core.EQUITY, authorization.RootDomain, accounting_core.CapitalStartAccount, "modal awal")
+				assert.Nil(t, err)
+				return nil
+			},
+		),
+		func(t *testing.T) {
+			service := account.NewAccountService(&db, &bankAuthMock)
+
+			bankBalance := func(t *testing.T, id uint) float64 {
+				var bank accounting_model.BankAccountV2
+				err := db.First(&bank, id).Error
+				assert.Nil(t, err)
+				return bank.Balance
+			}
+
+			countTransaction := func(t *testing.T) int64 {
+				var count int64
+				err := db.Model(&accounting_core.Transaction{}).Count(&count).Error
+				assert.Nil(t, err)
+				return count
+			}
+
+			for _, init := range []struct {
+				id     uint64
+				amount float64
+			}{{1, 500000}, {3, 150000}} {
+				_, err := service.AccountBalanceInit(t.Context(), connect.NewRequest(&accounting_iface.AccountBalanceInitRequest{
+					AccountId: init.id,
+					Amount:    init.amount,
+					Desc:      "saldo awal",
+				}))
+				assert.Nil(t, err)
+			}
+
+			// inisialisasi lama tanpa ref dan dimensi bank, bank 2 saldonya tidak pernah dijurnal
+			err := db.Where("1 = 1").Delete(&accounting_core.JournalEntryBank{}).Error
+			assert.Nil(t, err)
+			err = db.
+				Model(&accounting_core.Transaction{}).
+				Where("ref_id = ?", accounting_core.NewRefID(&accounting_core.RefData{
+					RefType: accounting_core.BankOpeningRef,
+					ID:      1,
+				})).
+				Update("ref_id", "").
+				Error
+			assert.Nil(t, err)
+			err = db.Model(&accounting_model.BankAccountV2{}).Where("id = ?", 2).Update("balance", 250000).Error
+			assert.Nil(t, err)
+
+			assert.Equal(t, int64(2), countTransaction(t))
+
+			err = account.BackfillBankLedger(&db)
+			assert.Nil(t, err)
+
+			t.Run("saldo awal terhubung ke transaksi pembukaan", func(t *testing.T) {
+				var unlinked int64
+				err := db.
+					Model(&accounting_core.JournalEntryBank{}).
+					Where("journal_entry_id = 0 OR transaction_id = 0").
+					Count(&unlinked).
+					Error
+				assert.Nil(t, err)
+				assert.Equal(t, int64(0), unlinked)
+
+				assert.Equal(t, 500000.0, bankBalance(t, 1))
+				assert.Equal(t, 250000.0, bankBalance(t, 2))
+				assert.Equal(t, 150000.0, bankBalance(t, 3))
+			})
+
+			t.Run("bank tanpa jurnal saldo awal dibuatkan transaksi", func(t *testing.T) {
+				assert.Equal(t, int64(3), countTransaction(t))
+
+				var tran accounting_core.Transaction
+				err := db.
+					Where("ref_id = ?", accounting_core.NewRefID(&accounting_core.RefData{
+						RefType: accounting_core.BankOpeningRef,
+						ID:      2,
+					})).
+					First(&tran).
+					Error
+				assert.Nil(t, err)
+
+				var cash float64
+				err = db.
+					Table("journal_entries je").
+					Joins("join accounts a on a.id = je.account_id").
+					Select("coalesce(sum(je.debit - je.credit), 0)").
+					Where("je.transaction_id = ?", tran.ID).
+					Where("je.team_id = ?", 5).
+					Where("a.account_key = ?", accounting_core.CashAccount).
+					Scan(&cash).
+					Error
+				assert.Nil(t, err)
+				assert.Equal(t, 250000.0, cash)
+			})
+
+			t.Run("saldo awal versi lama dihubungkan ulang", func(t *testing.T) {
+				err := db.
+					Model(&accounting_core.JournalEntryBank{}).
+					Where("1 = 1").
+					Updates(map[string]any{
+						"journal_entry_id": 0,
+						"transaction_id":   0,
+					}).
+					Error
+				assert.Nil(t, err)
+
+				err = account.BackfillBankLedger(&db)
+				assert.Nil(t, err)
+
+				var unlinked int64
+				err = db.
+					Model(&accounting_core.JournalEntryBank{}).
+					Where("journal_entry_id = 0 OR transaction_id = 0").
+					Count(&unlinked).
+					Error
+				assert.Nil(t, err)
+				assert.Equal(t, int64(0), unlinked)
+				assert.Equal(t, int64(3), countTransaction(t))
+			})
+		},
+	)
+}
@@ -34,11 +34,6 @@ type accountServiceImpl struct {
 	db   *gorm.DB
 }
 
-// TransferCancel implements accounting_ifaceconnect.AccountServiceHandler.
-func (a *accountServiceImpl) TransferCancel(context.Context, *connect.Request[accounting_iface.TransferCancelRequest]) (*connect.Response[accounting_iface.TransferCancelResponse], error) {
-	panic("unimplemented")
-}
-
 // AccountBalanceInit implements accounting_ifaceconnect.AccountServiceHandler.
 func (a *accountServiceImpl) AccountBalanceInit(
 	ctx context.Context,
@@ -69,11 +64,6 @@ func (a *accountServiceImpl) AccountBalanceInit(
 
 	err = accounting_core.OpenTransaction(ctx, db, func(tx *gorm.DB, bookmng accounting_core.BookManage) error {
 		var account accounting_model.BankAccountV2
-		trans := accounting_core.Transaction{
-			CreatedByID: agent.GetUserID(),
-			Desc:        pay.Desc,
-			Created:     time.Now(),
-		}
 		err = tx.
 			Clauses(clause.Locking{
 				Strength: "UPDATE",
@@ -90,6 +80,14 @@ func (a *accountServiceImpl) AccountBalanceInit(
 			return errors.New("account balance not Empty")
 		}
 
+		trans := accounting_core.Transaction{
+			CreatedByID: agent.GetUserID(),
+			TeamID:      account.TeamID,
+			RefID:       openingRefID(account.ID),
+			Desc:        pay.Desc,
+			Created:     time.Now(),
+		}
+
 		err = bookmng.
 			NewTransaction().
 			Create(&trans).
@@ -106,8 +104,9 @@ func (a *accountServiceImpl) AccountBalanceInit(
 				TeamID: authorization.RootDomain,
 			}, pay.Amount).
 			To(&accounting_core.EntryAccountPayload{
-				Key:    accounting_core.CashAccount,
-				TeamID: account.TeamID,
+				Key:           accounting_core.CashAccount,
+				TeamID:        account.TeamID,
+				BankAccountID: account.ID,
 			}, pay.Amount).
 			Transaction(&trans).
 			Commit().
@@ -117,13 +116,7 @@ func (a *accountServiceImpl) AccountBalanceInit(
 			return err
 		}
 
-		account.Balance = pay.Amount
-		err = tx.Save(&account).Error
-		if err != nil {
-			return err
-		}
-
-		return nil
+		return SyncBankBalance(tx, account.ID)
 	})
 
 	if err != nil {
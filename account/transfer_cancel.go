@@ -0,0 +1,124 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/pdcgo/accounting_service/accounting_core"
+	"github.com/pdcgo/accounting_service/accounting_model"
+	"github.com/pdcgo/schema/services/accounting_iface/v1"
+	"github.com/pdcgo/shared/interfaces/authorization_iface"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TransferCancel membalik transaksi transfer#id di buku pengirim dan penerima termasuk biaya transfer
+func (a *accountServiceImpl) TransferCancel(
+	ctx context.Context,
+	req *connect.Request[accounting_iface.TransferCancelRequest],
+) (*connect.Response[accounting_iface.TransferCancelResponse], error) {
+	var err error
+	result := accounting_iface.TransferCancelResponse{}
+
+	pay := req.Msg
+	pay.Reason = strings.TrimSpace(pay.Reason)
+	if pay.TransferId == 0 {
+		return connect.NewResponse(&result), connect.NewError(connect.CodeInvalidArgument, errors.New("transfer id required"))
+	}
+	if pay.Reason == "" {
+		return connect.NewResponse(&result), connect.NewError(connect.CodeInvalidArgument, errors.New("cancel reason required"))
+	}
+
+	identity := a.
+		auth.
+		AuthIdentityFromHeader(req.Header())
+
+	agent := identity.Identity()
+	err = identity.
+		HasPermission(authorization_iface.CheckPermissionGroup{
+			&accounting_model.BankTransfer{}: &authorization_iface.CheckPermission{
+				DomainID: uint(pay.TeamId),
+				Actions:  []authorization_iface.Action{authorization_iface.Create},
+			},
+		}).
+		Err()
+
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	db := a.db.WithContext(ctx)
+	err = db.Transaction(func(tx *gorm.DB) error {
+		var hist accounting_model.BankTransferHistory
+		err := tx.
+			Clauses(clause.Locking{
+				Strength: "UPDATE",
+			}).
+			Model(&accounting_model.BankTransferHistory{}).
+			Where("id = ?", pay.TransferId).
+			Find(&hist).
+			Error
+		if err != nil {
+			return err
+		}
+
+		if hist.ID == 0 {
+			return connect.NewError(connect.CodeNotFound, fmt.Errorf("transfer id %d not found", pay.TransferId))
+		}
+		if hist.TeamID != uint(pay.TeamId) {
+			return connect.NewError(connect.CodePermissionDenied, errors.New("transfer not created by your team"))
+		}
+		if hist.Status == accounting_model.TransferCancelled {
+			return connect.NewError(connect.CodeFailedPrecondition, errors.New("transfer already cancelled"))
+		}
+
+		ref := accounting_core.NewRefID(&accounting_core.RefData{
+			RefType: accounting_core.TransferRef,
+			ID:      hist.ID,
+		})
+		err = accounting_core.
+			NewTransactionMutation(ctx, tx).
+			ByRefID(ref, true).
+			RollbackEntry(agent.GetUserID(), fmt.Sprintf("cancel transfer: %s", pay.Reason)).
+			Err()
+		if errors.Is(err, accounting_core.ErrTransactionLocked) {
+			return connect.NewError(connect.CodeFailedPrecondition, err)
+		}
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		hist.Status = accounting_model.TransferCancelled
+		hist.CancelReason = pay.Reason
+		hist.CancelledByID = agent.GetUserID()
+		hist.CancelledAt = &now
+		err = tx.Save(&hist).Error
+		if err != nil {
+			return err
+		}
+
+		result.TransferId = uint64(hist.ID)
+		result.Status = transferStatusProto(hist.Status)
+		result.CancelReason = hist.CancelReason
+		result.CancelledAt = now.UnixMicro()
+		return SyncBankBalance(tx, hist.FromAccountID, hist.ToAccountID)
+	})
+
+	return connect.NewResponse(&result), err
+}
+
+func transferStatusProto(status accounting_model.TransferStatus) accounting_iface.TransferStatus {
+	switch status {
+	case accounting_model.TransferActive:
+		return accounting_iface.TransferStatus_TRANSFER_STATUS_ACTIVE
+	case accounting_model.TransferCancelled:
+		return accounting_iface.TransferStatus_TRANSFER_STATUS_CANCELLED
+	default:
+		return accounting_iface.TransferStatus_TRANSFER_STATUS_UNSPECIFIED
+	}
+}
@@ -0,0 +1,276 @@
+package account_test
+
+import (
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/pdcgo/accounting_service/account"
+	"github.com/pdcgo/accounting_service/accounting_core"
+	"github.com/pdcgo/accounting_service/accounting_model"
+	"github.com/pdcgo/schema/services/access_iface/v1"
+	"github.com/pdcgo/schema/services/accounting_iface/v1"
+	"github.com/pdcgo/schema/services/common/v1"
+	"github.com/pdcgo/shared/custom_connect"
+	"github.com/pdcgo/shared/pkg/moretest"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestTransferCancel(t *testing.T) {
+	var db gorm.DB
+
+	moretest.Suite(t, "testing cancel transfer",
+		bankLedgerSetup(&db),
+		func(t *testing.T) {
+			service := account.NewAccountService(&db, &bankAuthMock)
+
+			transfer := func(t *testing.T, from, to uint64, amount, fee float64) *accounting_model.BankTransferHistory {
+				_, err := service.TransferCreate(t.Context(), connect.NewRequest(&accounting_iface.TransferCreateRequest{
+					TeamId:        5,
+					FromAccountId: from,
+					ToAccountId:   to,
+					Amount:        amount,
+					FeeAmount:     fee,
+					Desc:          "transfer",
+				}))
+				assert.Nil(t, err)
+
+				var hist accounting_model.BankTransferHistory
+				err = db.Order("id desc").First(&hist).Error
+				assert.Nil(t, err)
+				assert.Equal(t, accounting_model.TransferActive, hist.Status)
+				return &hist
+			}
+
+			cancel := func(t *testing.T, teamID uint64, hist *accounting_model.BankTransferHistory, reason string) (*accounting_iface.TransferCancelResponse, error) {
+				res, err := service.TransferCancel(t.Context(), connect.NewRequest(&accounting_iface.TransferCancelRequest{
+					TeamId:     teamID,
+					TransferId: uint64(hist.ID),
+					Reason:     reason,
+				}))
+				return res.Msg, err
+			}
+
+			// saldo akun per team untuk transaksi, harus nol setelah dibatalkan
+			accountChange := func(t *testing.T, txID uint, key accounting_core.AccountKey, teamID uint) float64 {
+				var change float64
+				err := db.
+					Table("journal_entries je").
+					Joins("join accounts a on a.id = je.account_id").
+					Select("coalesce(sum(je.debit - je.credit), 0)").
+					Where("je.transaction_id = ?", txID).
+					Where("je.team_id = ?", teamID).
+					Where("a.account_key = ?", key).
+					Scan(&change).
+					Error
+				assert.Nil(t, err)
+				return change
+			}
+
+			bankBalance := func(t *testing.T, id uint) float64 {
+				var bank accounting_model.BankAccountV2
+				err := db.First(&bank, id).Error
+				assert.Nil(t, err)
+				return bank.Balance
+			}
+
+			internal := transfer(t, 1, 2, 300000, 2500)
+			cross := transfer(t, 1, 3, 100000, 0)
+
+			t.Run("tanpa id transfer", func(t *testing.T) {
+				_, err := service.TransferCancel(t.Context(), connect.NewRequest(&accounting_iface.TransferCancelRequest{
+					TeamId: 5,
+					Reason: "salah rekening",
+				}))
+				assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+			})
+
+			t.Run("alasan wajib dan team harus pembuat transfer", func(t *testing.T) {
+				_, err := cancel(t, 5, cross, " ")
+				assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+
+				_, err = cancel(t, 6, cross, "salah rekening")
+				assert.Equal(t, connect.CodePermissionDenied, connect.CodeOf(err))
+			})
+
+			t.Run("cancel transfer antar team", func(t *testing.T) {
+				res, err := cancel(t, 5, cross, "salah rekening")
+				assert.Nil(t, err)
+				assert.Equal(t, uint64(cross.ID), res.TransferId)
+				assert.Equal(t, accounting_iface.TransferStatus_TRANSFER_STATUS_CANCELLED, res.Status)
+				assert.Equal(t, "salah rekening", res.CancelReason)
+				assert.NotZero(t, res.CancelledAt)
+
+				var hist accounting_model.BankTransferHistory
+				db.First(&hist, cross.ID)
+				assert.Equal(t, accounting_model.TransferCancelled, hist.Status)
+				assert.NotNil(t, hist.CancelledAt)
+
+				assert.Equal(t, 0.0, accountChange(t, cross.TxID, accounting_core.CashAccount, 5))
+				assert.Equal(t, 0.0, accountChange(t, cross.TxID, accounting_core.CashAccount, 6))
+				assert.Equal(t, -302500.0, bankBalance(t, 1))
+				assert.Equal(t, 0.0, bankBalance(t, 3))
+
+				_, err = cancel(t, 5, cross, "salah rekening")
+				assert.Equal(t, connect.CodeFailedPrecondition, connect.CodeOf(err))
+			})
+
+			t.Run("mutation list menampilkan status cancel", func(t *testing.T) {
+				err := db.Exec("create table if not exists account_types (id integer primary key, key text)").Error
+				assert.Nil(t, err)
+				err = db.Exec("insert into account_types (id, key) values (0, 'bank')").Error
+				assert.Nil(t, err)
+
+				ctx := custom_connect.SetRequestSource(t.Context(), &access_iface.RequestSource{
+					TeamId:      5,
+					RequestFrom: access_iface.RequestFrom_REQUEST_FROM_ADMIN,
+				})
+				res, err := service.AccountMutationList(ctx, connect.NewRequest(&accounting_iface.AccountMutationListRequest{
+					TeamId: 5,
+					Page: &common.PageFilter{
+						Page:  1,
+						Limit: 10,
+					},
+				}))
+				assert.Nil(t, err)
+				assert.Len(t, res.Msg.Data, 2)
+
+				items := map[uint64]*accounting_iface.MutationItem{}
+				for _, item := range res.Msg.Data {
+					items[item.Id] = item
+				}
+
+				item := items[uint64(cross.ID)]
+				assert.Equal(t, accounting_iface.TransferStatus_TRANSFER_STATUS_CANCELLED, item.Status)
+				assert.Equal(t, "salah rekening", item.CancelReason)
+				assert.NotZero(t, item.CancelledAt)
+				// julianday sqlite hanya presisi sampai milidetik
+				assert.InDelta(t, cross.Created.UnixMicro(), item.Created, 1000)
+				assert.Equal(t, "bca gudang", item.ToAccount.Name)
+
+				item = items[uint64(internal.ID)]
+				assert.Equal(t, accounting_iface.TransferStatus_TRANSFER_STATUS_ACTIVE, item.Status)
+				assert.Zero(t, item.CancelledAt)
+			})
+
+			t.Run("transaksi yang sudah direkonsiliasi tidak bisa dibatalkan", func(t *testing.T) {
+				err := db.Model(&accounting_core.Transaction{}).Where("id = ?", internal.TxID).Update("locked", true).Error
+				assert.Nil(t, err)
+
+				_, err = cancel(t, 5, internal, "double input")
+				assert.Equal(t, connect.CodeFailedPrecondition, connect.CodeOf(err))
+
+				var hist accounting_model.BankTransferHistory
+				db.First(&hist, internal.ID)
+				assert.Equal(t, accounting_model.TransferActive, hist.Status)
+
+				err = db.Model(&accounting_core.Transaction{}).Where("id = ?", internal.TxID).Update("locked", false).Error
+				assert.Nil(t, err)
+			})
+
+			t.Run("cancel transfer dengan biaya", func(t *testing.T) {
+				assert.Equal(t, 2500.0, accountChange(t, internal.TxID, accounting_core.BankFeeAccount, 5))
+
+				_, err := cancel(t, 5, internal, "double input")
+				assert.Nil(t, err)
+
+				assert.Equal(t, 0.0, accountChange(t, internal.TxID, accounting_core.BankFeeAccount, 5))
+				assert.Equal(t, 0.0, accountChange(t, internal.TxID, accounting_core.CashAccount, 5))
+				assert.Equal(t, 0.0, bankBalance(t, 1))
+				assert.Equal(t, 0.0, bankBalance(t, 2))
+			})
+		},
+	)
+}
+
+func TestBackfillTransferCancel(t *testing.T) {
+	var db gorm.DB
+
+	moretest.Suite(t, "testing cancel transfer lama hasil backfill",
+		bankLedgerSetup(&db),
+		func(t *testing.T) {
+			service := account.NewAccountService(&db, &bankAuthMock)
+
+			transfer := func(t *testing.T, from, to uint64, amount, fee float64) *accounting_model.BankTransferHistory {
+				_, err := service.TransferCreate(t.Context(), connect.NewRequest(&accounting_iface.TransferCreateRequest{
+					TeamId:        5,
+					FromAccountId: from,
+					ToAccountId:   to,
+					Amount:        amount,
+					FeeAmount:     fee,
+					Desc:          "transfer lama",
+				}))
+				assert.Nil(t, err)
+
+				var hist accounting_model.BankTransferHistory
+				err = db.Order("id desc").First(&hist).Error
+				assert.Nil(t, err)
+				return &hist
+			}
+
+			bankBalance := func(t *testing.T, id uint) float64 {
+				var bank accounting_model.BankAccountV2
+				err := db.First(&bank, id).Error
+				assert.Nil(t, err)
+				return bank.Balance
+			}
+
+			internal := transfer(t, 1, 2, 300000, 2500)
+			cross := transfer(t, 1, 3, 100000, 0)
+
+			// kondisi sebelum ada dimensi bank, saldo hanya diisi saat inisialisasi
+			err := db.Where("1 = 1").Delete(&accounting_core.JournalEntryBank{}).Error
+			assert.Nil(t, err)
+			err = db.Model(&accounting_model.BankAccountV2{}).Where("1 = 1").Update("balance", 0).Error
+			assert.Nil(t, err)
+
+			err = account.BackfillBankLedger(&db)
+			assert.Nil(t, err)
+
+			t.Run("backfill terhubung ke journal entry kas", func(t *testing.T) {
+				var unlinked int64
+				err := db.Model(&accounting_core.JournalEntryBank{}).Where("journal_entry_id = 0").Count(&unlinked).Error
+				assert.Nil(t, err)
+				assert.Equal(t, int64(0), unlinked)
+
+				assert.Equal(t, -402500.0, bankBalance(t, 1))
+				assert.Equal(t, 300000.0, bankBalance(t, 2))
+				assert.Equal(t, 100000.0, bankBalance(t, 3))
+			})
+
+			t.Run("backfill ulang menghubungkan baris lama", func(t *testing.T) {
+				err := db.Model(&accounting_core.JournalEntryBank{}).Where("1 = 1").Update("journal_entry_id", 0).Error
+				assert.Nil(t, err)
+
+				err = account.BackfillBankLedger(&db)
+				assert.Nil(t, err)
+
+				var unlinked int64
+				err = db.Model(&accounting_core.JournalEntryBank{}).Where("journal_entry_id = 0").Count(&unlinked).Error
+				assert.Nil(t, err)
+				assert.Equal(t, int64(0), unlinked)
+				assert.Equal(t, -402500.0, bankBalance(t, 1))
+			})
+
+			t.Run("cancel transfer lama membalik saldo bank", func(t *testing.T) {
+				_, err := service.TransferCancel(t.Context(), connect.NewRequest(&accounting_iface.TransferCancelRequest{
+					TeamId:     5,
+					TransferId: uint64(cross.ID),
+					Reason:     "salah rekening",
+				}))
+				assert.Nil(t, err)
+				assert.Equal(t, -302500.0, bankBalance(t, 1))
+				assert.Equal(t, 0.0, bankBalance(t, 3))
+
+				_, err = service.TransferCancel(t.Context(), connect.NewRequest(&accounting_iface.TransferCancelRequest{
+					TeamId:     5,
+					TransferId: uint64(internal.ID),
+					Reason:     "double input",
+				}))
+				assert.Nil(t, err)
+				assert.Equal(t, 0.0, bankBalance(t, 1))
+				assert.Equal(t, 0.0, bankBalance(t, 2))
+			})
+		},
+	)
+}
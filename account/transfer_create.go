@@ -87,6 +87,7 @@ func (a *accountServiceImpl) TransferCreate(
 			FeeAmount: pay.FeeAmount,
 			Purpose:   pay.Purpose,
 			Desc:      pay.Desc,
+			Status:    accounting_model.TransferActive,
 			// TransferAt:    time.UnixMicro(pay.TransferAt).Local(),
 			Created: time.Now(),
 		}
@@ -135,16 +136,27 @@ func (a *accountServiceImpl) TransferCreate(
 
 		entryopt := accounting_core.IncludeDebitCreditEqual()
 
+		// dimensi bank account dicatat sekali, di buku team pemilik bank account
+		sameTeam := facc.TeamID == tacc.TeamID
+		bookBank := func(acc *accounting_model.BankAccountV2, linked bool) uint {
+			if !linked {
+				return 0
+			}
+			return acc.ID
+		}
+
 		// book from
 		entry := bookmng.
 			NewCreateEntry(uint(pay.TeamId), agent.GetUserID()).
 			From(&accounting_core.EntryAccountPayload{
-				Key:    accounting_core.CashAccount,
-				TeamID: facc.TeamID,
+				Key:           accounting_core.CashAccount,
+				TeamID:        facc.TeamID,
+				BankAccountID: facc.ID,
 			}, pay.Amount+pay.FeeAmount).
 			To(&accounting_core.EntryAccountPayload{
-				Key:    accounting_core.CashAccount,
-				TeamID: tacc.TeamID,
+				Key:           accounting_core.CashAccount,
+				TeamID:        tacc.TeamID,
+				BankAccountID: bookBank(&tacc, sameTeam),
 			}, pay.Amount)
 
 		if pay.FeeAmount != 0 {
@@ -170,8 +182,9 @@ func (a *accountServiceImpl) TransferCreate(
 				TeamID: facc.TeamID,
 			}, pay.Amount).
 			To(&accounting_core.EntryAccountPayload{
-				Key:    accounting_core.CashAccount,
-				TeamID: tacc.TeamID,
+				Key:           accounting_core.CashAccount,
+				TeamID:        tacc.TeamID,
+				BankAccountID: bookBank(&tacc, !sameTeam),
 			}, pay.Amount)
 
 		err = entry.
@@ -182,7 +195,7 @@ func (a *accountServiceImpl) TransferCreate(
 			return err
 		}
 
-		return nil
+		return SyncBankBalance(tx, facc.ID, tacc.ID)
 	})
 
 	return connect.NewResponse(&result), err
@@ -116,6 +116,21 @@ func (h *bookManageImpl) afterCommit(c *createEntryImpl) error {
 
 var ErrSkipTransaction = errors.New("skip transaction")
 
+func (h *bookManageImpl) closeTransaction() (*report_iface.DailyUpdateBalanceRequest, error) {
+	if len(h.entries) == 0 {
+		return nil, errors.New("entries empty in ending transaction")
+	}
+
+	for _, entry := range h.entries {
+		if entry.ID == 0 {
+			// h.entries.PrintJournalEntries(tx)
+			return nil, fmt.Errorf("theres entry not save desc %s", entry.Desc)
+		}
+	}
+
+	return h.DailyUpdateData()
+}
+
 func OpenTransaction(ctx context.Context, tx *gorm.DB, handle func(tx *gorm.DB, bookmng BookManage) error) error {
 	var err error
 
@@ -131,23 +146,8 @@ func OpenTransaction(ctx context.Context, tx *gorm.DB, handle func(tx *gorm.DB,
 			return err
 		}
 
-		if len(hdlr.entries) == 0 {
-			return errors.New("entries empty in ending transaction")
-		}
-
-		for _, entry := range hdlr.entries {
-			if entry.ID == 0 {
-				// hdlr.entries.PrintJournalEntries(tx)
-				return fmt.Errorf("theres entry not save desc %s", entry.Desc)
-			}
-		}
-
-		updata, err = hdlr.DailyUpdateData()
-		if err != nil {
-			return err
-		}
-
-		return nil
+		updata, err = hdlr.closeTransaction()
+		return err
 	})
 
 	if err != nil {
@@ -167,3 +167,57 @@ func OpenTransaction(ctx context.Context, tx *gorm.DB, handle func(tx *gorm.DB,
 
 	return nil
 }
+
+// OpenBatchTransaction seperti OpenTransaction untuk banyak transaksi sekaligus dalam satu db transaction.
+// ErrSkipTransaction hanya melewati transaksi index tersebut, daily update dikirim setelah semua commit.
+func OpenBatchTransaction(ctx context.Context, tx *gorm.DB, count int, handle func(tx *gorm.DB, index int, bookmng BookManage) error) error {
+	var err error
+
+	updates := []*report_iface.DailyUpdateBalanceRequest{}
+
+	err = tx.Transaction(func(tx *gorm.DB) error {
+		for i := 0; i < count; i++ {
+			var updata *report_iface.DailyUpdateBalanceRequest
+
+			err := tx.Transaction(func(tx *gorm.DB) error {
+				hdlr := bookManageImpl{
+					tx: tx,
+				}
+
+				err := handle(tx, i, &hdlr)
+				if err != nil {
+					return err
+				}
+
+				updata, err = hdlr.closeTransaction()
+				return err
+			})
+
+			if errors.Is(err, ErrSkipTransaction) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+
+			updates = append(updates, updata)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	for _, updata := range updates {
+		for _, handler := range customHandler {
+			err = handler(ctx, updata)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -45,6 +45,8 @@ func (e *ErrEntryInvalid) Error() string {
 type EntryAccountPayload struct {
 	Key    AccountKey
 	TeamID uint
+	// opsional, bank account pemilik mutasi kas
+	BankAccountID uint
 }
 
 type EntryOption func(entry *JournalEntry) error
@@ -102,10 +104,18 @@ type createEntryImpl struct {
 	createdByID uint
 	entries     map[uint]*JournalEntry
 	accountMap  map[uint]*Account
+	bankLinks   []*bankLink
 	afterCommit func(c *createEntryImpl) error
 	err         error
 }
 
+type bankLink struct {
+	accountID     uint
+	bankAccountID uint
+	debit         float64
+	credit        float64
+}
+
 // Rollback implements CreateEntry.
 func (c *createEntryImpl) Rollback(oldentries map[uint]*ChangeBalance, opts ...EntryOption) CreateEntry {
 	for _, ch := range oldentries {
@@ -254,6 +264,11 @@ func (c *createEntryImpl) Commit(opts ...CommitOption) CreateEntry {
 		return c.setErr(err)
 	}
 
+	err = c.saveBankLinks(entries)
+	if err != nil {
+		return c.setErr(err)
+	}
+
 	if c.afterCommit != nil {
 		err = c.afterCommit(c)
 		if err != nil {
@@ -350,6 +365,15 @@ func (c *createEntryImpl) To(account *EntryAccountPayload, amount float64, opts
 		}
 	}
 
+	if account.BankAccountID != 0 {
+		c.bankLinks = append(c.bankLinks, &bankLink{
+			accountID:     acc.ID,
+			bankAccountID: account.BankAccountID,
+			debit:         entry.Debit,
+			credit:        entry.Credit,
+		})
+	}
+
 	c.mergeEntry(entry.AccountID, entry)
 
 	return c
@@ -389,6 +413,39 @@ func (c *createEntryImpl) getAccount(accp *EntryAccountPayload) (*Account, error
 	return &acc, nil
 }
 
+// saveBankLinks simpan dimensi bank account, entry yang terpecah debit dan credit dipilih sesuai sisi mutasinya
+func (c *createEntryImpl) saveBankLinks(entries JournalEntriesList) error {
+	if len(c.bankLinks) == 0 {
+		return nil
+	}
+
+	banks := []*JournalEntryBank{}
+	for _, link := range c.bankLinks {
+		merged := c.entries[link.accountID]
+		journalID := merged.ID
+		for _, entry := range entries {
+			if entry.AccountID == link.accountID && (link.debit > 0) == (entry.Debit > 0) {
+				journalID = entry.ID
+				break
+			}
+		}
+
+		banks = append(banks, &JournalEntryBank{
+			JournalEntryID: journalID,
+			TransactionID:  merged.TransactionID,
+			BankAccountID:  link.bankAccountID,
+			TeamID:         c.teamID,
+			EntryTime:      merged.EntryTime,
+			Debit:          link.debit,
+			Credit:         link.credit,
+			Desc:           merged.Desc,
+			Rollback:       merged.Rollback,
+		})
+	}
+
+	return c.tx.Create(&banks).Error
+}
+
 func (c *createEntryImpl) mergeEntry(accID uint, entry *JournalEntry) {
 	if c.entries[accID] != nil {
 		c.entries[accID].Credit += entry.Credit
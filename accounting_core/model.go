@@ -76,6 +76,21 @@ type JournalEntry struct {
 	Transaction *Transaction `json:"-"`
 }
 
+// JournalEntryBank dimensi bank account pada journal entry kas.
+// satu journal entry bisa terpecah ke beberapa bank account kalau transfer di team yang sama
+type JournalEntryBank struct {
+	ID             uint      `json:"id" gorm:"primarykey"`
+	JournalEntryID uint      `json:"journal_entry_id" gorm:"index"`
+	TransactionID  uint      `json:"transaction_id" gorm:"index"`
+	BankAccountID  uint      `json:"bank_account_id" gorm:"index:journal_entry_bank_time"`
+	TeamID         uint      `json:"team_id"`
+	EntryTime      time.Time `json:"entry_time" gorm:"index:journal_entry_bank_time"`
+	Debit          float64   `json:"debit"`
+	Credit         float64   `json:"credit"`
+	Desc           string    `json:"desc"`
+	Rollback       bool      `json:"rollback"`
+}
+
 type JournalEntriesList []*JournalEntry
 
 type ChangeBalance struct {
@@ -259,10 +274,17 @@ const (
 	ExpenseRef                     RefType = "expense"
 	RestockRef                     RefType = "restock"
 	PaymentRef                     RefType = "payment"
+	PaymentAcceptRef               RefType = "payment_accept"
+	PaymentVoidRef                 RefType = "payment_void"
 	AdminAdjustmentRef             RefType = "admin_adjustment"
 	AdsPaymentRef                  RefType = "ads_payment"
 	AdjustmentRef                  RefType = "common_adjustment"
 	TransferRef                    RefType = "transfer"
+	CsCommissionRef                RefType = "cs_commission"
+	JournalImportRef               RefType = "journal_import"
+	BankReconAdjustRef             RefType = "bank_recon_adjust"
+	TeamTransferRef                RefType = "team_transfer"
+	BankOpeningRef                 RefType = "bank_opening"
 )
 
 type RefData struct {
@@ -307,6 +329,8 @@ type Transaction struct {
 	// Type        SourceType `json:"type" gorm:"not null"`
 	Desc    string    `json:"desc"`
 	Created time.Time `json:"created"`
+	// dikunci setelah rekonsiliasi bank selesai, tidak bisa di rollback
+	Locked bool `json:"locked"`
 }
 
 // lawas
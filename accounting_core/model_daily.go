@@ -511,3 +511,32 @@ func (t *TypeLabelDailyBalance) Empty() DailyBalance {
 func (t *TypeLabelDailyBalance) GetDebitCredit() (debit float64, credit float64, balance float64) {
 	return t.Debit, t.Credit, t.Balance
 }
+
+// DailyAppliedEntry catatan lama journal entry yang sudah masuk ke semua tabel daily balance,
+// sekarang hanya dibaca. Catatan baru per tabel ada di ProjectionAppliedEntry
+type DailyAppliedEntry struct {
+	EntryID uint      `json:"entry_id" gorm:"primarykey;autoIncrement:false"`
+	TeamID  uint      `json:"team_id" gorm:"index"`
+	Applied time.Time `json:"applied"`
+}
+
+// ProjectionAppliedEntry catatan journal entry yang sudah masuk ke satu tabel daily balance,
+// dipakai supaya push dan projector idempotent
+type ProjectionAppliedEntry struct {
+	Projection string    `json:"projection" gorm:"primarykey"`
+	EntryID    uint      `json:"entry_id" gorm:"primarykey;autoIncrement:false"`
+	TeamID     uint      `json:"team_id"`
+	Applied    time.Time `json:"applied"`
+}
+
+// ProjectionCheckpoint posisi journal entry yang sudah dibaca projector untuk satu tabel daily balance.
+// Entry sampai SafeEntryID pasti sudah commit, di atasnya dibaca ulang sampai grace period lewat
+type ProjectionCheckpoint struct {
+	Name        string `json:"name" gorm:"primarykey"`
+	LastEntryID uint   `json:"last_entry_id"`
+	SafeEntryID uint   `json:"safe_entry_id"`
+	// id terbesar saat WatermarkAt, jadi SafeEntryID setelah grace period
+	WatermarkID uint      `json:"watermark_id"`
+	WatermarkAt time.Time `json:"watermark_at"`
+	Updated     time.Time `json:"updated"`
+}
@@ -35,6 +35,7 @@ const (
 	// StockPendingFeeAccount       AccountKey = "stock_pending_fee"
 	StockCodFeeAccount           AccountKey = "stock_cod_fee"
 	ReceivableAccount            AccountKey = "receivable"
+	LoanReceivableAccount        AccountKey = "loan_receivable" // pinjaman ke team lain
 	PendingPaymentReceiveAccount AccountKey = "pending_payment_receive"
 	PendingPaymentPayAccount     AccountKey = "pending_payment_pay"
 	// PaymentInTransitAccount      AccountKey = "payment_in_transit"
@@ -51,6 +52,7 @@ const (
 // liability
 const (
 	PayableAccount      AccountKey = "payable"
+	LoanPayableAccount  AccountKey = "loan_payable" // pinjaman dari team lain
 	AdjLiabilityAccount AccountKey = "adj_liability"
 )
 
@@ -169,6 +171,11 @@ func DefaultSeedAccount() []*Account {
 			Coa:         ASSET,
 			BalanceType: DebitBalance,
 		},
+		{
+			AccountKey:  LoanReceivableAccount,
+			Coa:         ASSET,
+			BalanceType: DebitBalance,
+		},
 		{
 			AccountKey:  SellingEstReceivableAccount,
 			Coa:         ASSET,
@@ -309,6 +316,11 @@ func DefaultSeedAccount() []*Account {
 			Coa:         LIABILITY,
 			BalanceType: CreditBalance,
 		},
+		{
+			AccountKey:  LoanPayableAccount,
+			Coa:         LIABILITY,
+			BalanceType: CreditBalance,
+		},
 
 		// equity
 
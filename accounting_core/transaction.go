@@ -336,6 +336,7 @@ func (c *createTansactionImpl) setErr(err error) *createTansactionImpl {
 // }
 
 var ErrTransactionNotLoaded = errors.New("transaction not loaded")
+var ErrTransactionLocked = errors.New("transaction locked by reconciliation")
 
 type TransactionMutation interface {
 	ByRefID(refid RefID, lock bool) TransactionMutation
@@ -448,6 +449,10 @@ func (t *transactionMutationImpl) RollbackEntry(userID uint, desc string) Transa
 		return t.setErr(ErrTransactionNotLoaded)
 	}
 
+	if t.data.Locked {
+		return t.setErr(ErrTransactionLocked)
+	}
+
 	err = t.
 		tx.
 		Model(&JournalEntry{}).
@@ -464,6 +469,11 @@ func (t *transactionMutationImpl) RollbackEntry(userID uint, desc string) Transa
 		return t.setErr(errors.New("entries on transaction is empty"))
 	}
 
+	banks, err := t.bankBalances(entries)
+	if err != nil {
+		return t.setErr(err)
+	}
+
 	// accbalance, _ := entries.AccountBalance()
 	// debugtool.LogJson(accbalance)
 
@@ -513,6 +523,21 @@ func (t *transactionMutationImpl) RollbackEntry(userID uint, desc string) Transa
 
 		}
 
+		// dimensi bank account ikut dibalik
+		for _, bank := range banks {
+			nentries, ok := teamBookEntry[bank.TeamID].(*createEntryImpl)
+			if !ok || CompareFloatSafe(bank.Amount, 0, PrecisionEpsilon) {
+				continue
+			}
+
+			nentries.bankLinks = append(nentries.bankLinks, &bankLink{
+				accountID:     bank.AccountID,
+				bankAccountID: bank.BankAccountID,
+				debit:         math.Max(-bank.Amount, 0),
+				credit:        math.Max(bank.Amount, 0),
+			})
+		}
+
 		for _, nentries := range teamBookEntry {
 			err = nentries.
 				TransactionID(t.data.ID).
@@ -535,6 +560,46 @@ func (t *transactionMutationImpl) RollbackEntry(userID uint, desc string) Transa
 	return t
 }
 
+type bankBalance struct {
+	TeamID        uint
+	BankAccountID uint
+	AccountID     uint
+	Amount        float64
+}
+
+// bankBalances saldo dimensi bank account per transaksi, hanya dicek kalau ada entry kas
+func (t *transactionMutationImpl) bankBalances(entries JournalEntriesList) ([]*bankBalance, error) {
+	result := []*bankBalance{}
+
+	hasCash := false
+	for _, entry := range entries {
+		if entry.Account != nil && entry.Account.AccountKey == CashAccount {
+			hasCash = true
+			break
+		}
+	}
+	if !hasCash {
+		return result, nil
+	}
+
+	err := t.
+		tx.
+		Table("journal_entry_banks jeb").
+		Joins("join journal_entries je on je.id = jeb.journal_entry_id").
+		Select(
+			"jeb.team_id",
+			"jeb.bank_account_id",
+			"je.account_id",
+			"sum(jeb.debit - jeb.credit) as amount",
+		).
+		Where("jeb.transaction_id = ?", t.data.ID).
+		Group("jeb.team_id, jeb.bank_account_id, je.account_id").
+		Scan(&result).
+		Error
+
+	return result, err
+}
+
 func (t *transactionMutationImpl) setErr(err error) *transactionMutationImpl {
 	if t.err != nil {
 		return t
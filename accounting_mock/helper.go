@@ -0,0 +1,10 @@
+package accounting_mock
+
+import "time"
+
+var Jkt = time.FixedZone("Asia/Jakarta", 7*60*60)
+
+// Date jam 10 pagi waktu jakarta di tahun 2025
+func Date(month time.Month, day int) time.Time {
+	return time.Date(2025, month, day, 10, 0, 0, 0, Jkt)
+}
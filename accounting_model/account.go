@@ -41,6 +41,13 @@ type BankAccountLabel struct {
 	Value string `json:"value"`
 }
 
+type TransferStatus string
+
+const (
+	TransferActive    TransferStatus = "active"
+	TransferCancelled TransferStatus = "cancelled"
+)
+
 type BankTransferHistory struct {
 	ID            uint `json:"id" gorm:"primarykey"`
 	TxID          uint `json:"tx_id"`
@@ -54,6 +61,11 @@ type BankTransferHistory struct {
 	Purpose   accounting_iface.MutationPurpose `json:"purpose"`
 	Created   time.Time                        `json:"created"`
 
+	Status        TransferStatus `json:"status" gorm:"default:active;index"`
+	CancelReason  string         `json:"cancel_reason"`
+	CancelledByID uint           `json:"cancelled_by_id"`
+	CancelledAt   *time.Time     `json:"cancelled_at"`
+
 	FromAccount *BankAccountV2
 	ToAccount   *BankAccountV2
 	Team        *db_models.Team
@@ -14,7 +14,9 @@ type Expense struct {
 	ExpenseType accounting_iface.ExpenseType
 	ExpenseKey  string
 	Amount      float64
-	CreatedAt   time.Time
+	// bank account yang membayar, 0 untuk expense lama
+	BankAccountID uint
+	CreatedAt     time.Time
 }
 
 type ExpenseEntity struct{} // hanya untuk memberikan akses
@@ -13,12 +13,45 @@ type Payment struct {
 	Status      payment_iface.PaymentStatus `json:"status"`
 	PaymentType payment_iface.PaymentType   `json:"payment_type"`
 	Amount      float64                     `json:"amount"`
-	CreatedByID uint                        `json:"created_by_id"`
-	CreatedAt   time.Time                   `json:"created_at"`
-	AcceptedAt  time.Time                   `json:"accepted_at"`
+	// total yang sudah diterima, payment selesai saat sama dengan Amount
+	AcceptedAmount float64 `json:"accepted_amount"`
+	// transaksi pending payment saat dibuat, 0 untuk payment lama yang belum dijurnal saat dibuat
+	TxID uint `json:"tx_id"`
+	// bank account kas pengirim, 0 untuk payment lama sebelum ada dimensi bank
+	FromBankAccountID uint      `json:"from_bank_account_id"`
+	CreatedByID       uint      `json:"created_by_id"`
+	CreatedAt         time.Time `json:"created_at"`
+	// waktu penerimaan terakhir
+	AcceptedAt time.Time `json:"accepted_at"`
+}
+
+func (p *Payment) Remaining() float64 {
+	return p.Amount - p.AcceptedAmount
 }
 
 // GetEntityID implements authorization_iface.Entity.
 func (p *Payment) GetEntityID() string {
 	return "accounting/payment"
 }
+
+type PaymentEventType string
+
+const (
+	PaymentEventCreated   PaymentEventType = "created"
+	PaymentEventAccepted  PaymentEventType = "accepted"
+	PaymentEventRejected  PaymentEventType = "rejected"
+	PaymentEventCancelled PaymentEventType = "cancelled"
+)
+
+// PaymentEvent riwayat perubahan payment, amount untuk penerimaan sebagian atau sisa yang dibatalkan
+type PaymentEvent struct {
+	ID          uint             `json:"id" gorm:"primarykey"`
+	PaymentID   uint             `json:"payment_id" gorm:"index"`
+	TeamID      uint             `json:"team_id"`
+	Type        PaymentEventType `json:"type"`
+	Amount      float64          `json:"amount"`
+	TxID        uint             `json:"tx_id"`
+	Reason      string           `json:"reason"`
+	CreatedByID uint             `json:"created_by_id"`
+	CreatedAt   time.Time        `json:"created_at"`
+}
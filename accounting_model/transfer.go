@@ -18,3 +18,55 @@ type Transfer struct {
 	Description   string
 	CreatedAt     time.Time
 }
+
+type TeamTransferPurpose string
+
+const (
+	// pinjaman, pengirim catat piutang dan penerima catat hutang
+	TeamTransferLoan TeamTransferPurpose = "loan"
+	// pelunasan pinjaman, kebalikan dari loan
+	TeamTransferRepayment TeamTransferPurpose = "repayment"
+	// setoran modal ke team penerima
+	TeamTransferCapital TeamTransferPurpose = "capital"
+)
+
+func (p TeamTransferPurpose) Valid() bool {
+	switch p {
+	case TeamTransferLoan, TeamTransferRepayment, TeamTransferCapital:
+		return true
+	}
+	return false
+}
+
+type TeamTransferStatus string
+
+const (
+	TeamTransferPending   TeamTransferStatus = "pending"
+	TeamTransferCompleted TeamTransferStatus = "completed"
+	TeamTransferRejected  TeamTransferStatus = "rejected"
+	// dibatalkan team pengirim sebelum diputuskan team penerima
+	TeamTransferCancelled TeamTransferStatus = "cancelled"
+)
+
+// TeamTransfer transfer kas antar team, jurnal dibuat saat status completed
+type TeamTransfer struct {
+	ID         uint                `json:"id" gorm:"primarykey"`
+	FromTeamID uint                `json:"from_team_id" gorm:"index"`
+	ToTeamID   uint                `json:"to_team_id" gorm:"index"`
+	TxID       uint                `json:"tx_id"`
+	Purpose    TeamTransferPurpose `json:"purpose"`
+	Status     TeamTransferStatus  `json:"status" gorm:"index"`
+	Amount     float64             `json:"amount"`
+	FeeAmount  float64             `json:"fee_amount"`
+	// bank account kas pengirim dan penerima, penerima diisi saat approve kalau perlu approval
+	FromBankAccountID uint   `json:"from_bank_account_id"`
+	ToBankAccountID   uint   `json:"to_bank_account_id"`
+	Desc              string `json:"desc"`
+	CreatedByID       uint   `json:"created_by_id"`
+	// user yang approve, reject atau cancel
+	DecidedByID uint `json:"decided_by_id"`
+	// alasan reject team penerima atau cancel team pengirim
+	Reason    string     `json:"reason"`
+	CreatedAt time.Time  `json:"created_at" gorm:"index"`
+	DecidedAt *time.Time `json:"decided_at"`
+}
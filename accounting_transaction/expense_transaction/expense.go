@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/pdcgo/accounting_service/account"
 	"github.com/pdcgo/accounting_service/accounting_core"
 	"github.com/pdcgo/schema/services/accounting_iface/v1"
 	"github.com/pdcgo/shared/interfaces/identity_iface"
@@ -17,6 +18,8 @@ type CreatePayload struct {
 	ExpenseType accounting_iface.ExpenseType
 	Amount      float64
 	Desc        string
+	// bank account team yang membayar
+	BankAccountID uint64
 }
 
 type ExpenseTransaction interface {
@@ -46,6 +49,11 @@ func (e *expenseTransactonImpl) ExpenseCreate(payload *CreatePayload) error {
 		CreatedByID: e.agent.GetUserID(),
 	}
 	err = accounting_core.OpenTransaction(e.ctx, e.tx, func(tx *gorm.DB, bookmng accounting_core.BookManage) error {
+		bank, err := account.CashBank(tx, payload.TeamID, payload.BankAccountID)
+		if err != nil {
+			return err
+		}
+
 		err = bookmng.
 			NewTransaction().
 			Create(&tran).
@@ -58,8 +66,9 @@ func (e *expenseTransactonImpl) ExpenseCreate(payload *CreatePayload) error {
 		err = bookmng.
 			NewCreateEntry(payload.TeamID, e.agent.GetUserID()).
 			From(&accounting_core.EntryAccountPayload{
-				Key:    accounting_core.CashAccount,
-				TeamID: payload.TeamID,
+				Key:           accounting_core.CashAccount,
+				TeamID:        payload.TeamID,
+				BankAccountID: bank.ID,
 			}, payload.Amount).
 			To(&accounting_core.EntryAccountPayload{
 				Key:    payload.ExpenseKey,
@@ -73,7 +82,7 @@ func (e *expenseTransactonImpl) ExpenseCreate(payload *CreatePayload) error {
 			return err
 		}
 
-		return nil
+		return account.SyncBankBalance(tx, bank.ID)
 	})
 
 	return err
@@ -0,0 +1,279 @@
+package adjustment
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"io"
+	"slices"
+
+	"connectrpc.com/connect"
+	"github.com/pdcgo/accounting_service/accounting_core"
+	"github.com/pdcgo/schema/services/accounting_iface/v1"
+	"github.com/pdcgo/shared/authorization"
+	"github.com/pdcgo/shared/db_models"
+	"github.com/pdcgo/shared/interfaces/authorization_iface"
+	"gorm.io/gorm"
+)
+
+// chunkReader membaca data chunk stream sebagai io.Reader
+type chunkReader struct {
+	stream *connect.ClientStream[accounting_iface.AdjImportRequest]
+	buf    []byte
+}
+
+// Read implements io.Reader.
+func (c *chunkReader) Read(p []byte) (int, error) {
+	for len(c.buf) == 0 {
+		if !c.stream.Receive() {
+			err := c.stream.Err()
+			if err != nil {
+				return 0, err
+			}
+			return 0, io.EOF
+		}
+		c.buf = c.stream.Msg().Data
+	}
+
+	n := copy(p, c.buf)
+	c.buf = c.buf[n:]
+	return n, nil
+}
+
+type accountRef struct {
+	teamID uint
+	key    accounting_core.AccountKey
+}
+
+// validateRefs cek akun, shop dan ref yang sudah ada
+func (a *adjServiceImpl) validateRefs(db *gorm.DB, parser *importParser, trans []*importTransaction) (map[accountRef]*accounting_core.Account, error) {
+	var err error
+	accounts := map[accountRef]*accounting_core.Account{}
+
+	teamIDs := []uint{}
+	keys := []accounting_core.AccountKey{}
+	shopIDs := []uint{}
+	refs := []accounting_core.RefID{}
+	for _, tran := range trans {
+		refs = append(refs, tran.ref)
+		if tran.shopID != 0 {
+			shopIDs = append(shopIDs, tran.shopID)
+		}
+		for _, row := range tran.rows {
+			teamIDs = append(teamIDs, row.teamID)
+			keys = append(keys, row.accountKey)
+		}
+	}
+
+	if len(trans) == 0 {
+		return accounts, nil
+	}
+
+	accList := []*accounting_core.Account{}
+	err = db.
+		Model(&accounting_core.Account{}).
+		Where("team_id IN ?", teamIDs).
+		Where("account_key IN ?", keys).
+		Find(&accList).
+		Error
+	if err != nil {
+		return accounts, err
+	}
+	for _, acc := range accList {
+		accounts[accountRef{acc.TeamID, acc.AccountKey}] = acc
+	}
+
+	shops := map[uint]bool{}
+	if len(shopIDs) > 0 {
+		ids := []uint{}
+		err = db.
+			Model(&db_models.Marketplace{}).
+			Where("id IN ?", shopIDs).
+			Pluck("id", &ids).
+			Error
+		if err != nil {
+			return accounts, err
+		}
+		for _, id := range ids {
+			shops[id] = true
+		}
+	}
+
+	exists := map[accounting_core.RefID]bool{}
+	existRefs := []accounting_core.RefID{}
+	err = db.
+		Model(&accounting_core.Transaction{}).
+		Where("ref_id IN ?", refs).
+		Pluck("ref_id", &existRefs).
+		Error
+	if err != nil {
+		return accounts, err
+	}
+	for _, ref := range existRefs {
+		exists[ref] = true
+	}
+
+	for _, tran := range trans {
+		tran.skip = exists[tran.ref]
+
+		if tran.shopID != 0 && !shops[tran.shopID] {
+			parser.addError(tran.rows[0].row, tran.rows[0].ref, "shop %d tidak ditemukan", tran.shopID)
+		}
+
+		for _, row := range tran.rows {
+			if accounts[accountRef{row.teamID, row.accountKey}] == nil {
+				parser.addError(row.row, row.ref, "account %s tidak ditemukan di team %d", row.accountKey, row.teamID)
+			}
+		}
+
+		parser.validateBalance(tran)
+	}
+
+	return accounts, nil
+}
+
+// AdjImport import journal manual dan saldo awal dari csv. Kalau ada baris error tidak ada yang disimpan,
+// semua transaksi disimpan dalam satu db transaction.
+func (a *adjServiceImpl) AdjImport(
+	ctx context.Context,
+	stream *connect.ClientStream[accounting_iface.AdjImportRequest],
+) (*connect.Response[accounting_iface.AdjImportResponse], error) {
+	var err error
+
+	result := accounting_iface.AdjImportResponse{
+		Skipped: []string{},
+		Errors:  []*accounting_iface.AdjImportRowError{},
+	}
+
+	identity := a.
+		auth.
+		AuthIdentityFromHeader(stream.RequestHeader())
+	agent := identity.Identity()
+
+	err = identity.
+		HasPermission(authorization_iface.CheckPermissionGroup{
+			&Adjustment{}: &authorization_iface.CheckPermission{
+				DomainID: authorization.RootDomain,
+				Actions:  []authorization_iface.Action{authorization_iface.Create},
+			},
+		}).
+		Err()
+
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	if !stream.Receive() {
+		err = stream.Err()
+		if err == nil {
+			err = connect.NewError(connect.CodeInvalidArgument, errors.New("file import kosong"))
+		}
+		return connect.NewResponse(&result), err
+	}
+
+	first := stream.Msg()
+	result.DryRun = first.DryRun
+
+	parser := newImportParser(&chunkReader{
+		stream: stream,
+		buf:    first.Data,
+	})
+
+	trans, rows, err := parser.parse()
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+	result.Rows = int64(rows)
+	result.Transactions = int64(len(trans))
+
+	db := a.db.WithContext(ctx)
+	accounts, err := a.validateRefs(db, parser, trans)
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	for _, tran := range trans {
+		if tran.skip {
+			result.Skipped = append(result.Skipped, tran.rows[0].ref)
+		}
+	}
+
+	result.Errors = parser.errors
+	slices.SortStableFunc(result.Errors, func(a, b *accounting_iface.AdjImportRowError) int {
+		return cmp.Compare(a.Row, b.Row)
+	})
+	if len(result.Errors) > 0 || result.DryRun {
+		return connect.NewResponse(&result), nil
+	}
+
+	err = accounting_core.OpenBatchTransaction(ctx, db, len(trans), func(tx *gorm.DB, index int, bookmng accounting_core.BookManage) error {
+		itran := trans[index]
+		if itran.skip {
+			return accounting_core.ErrSkipTransaction
+		}
+
+		tran := accounting_core.Transaction{
+			RefID:       itran.ref,
+			TeamID:      itran.teamID,
+			CreatedByID: agent.IdentityID(),
+			Desc:        itran.desc,
+			Created:     itran.created(),
+		}
+
+		ctran := bookmng.
+			NewTransaction().
+			Create(&tran).
+			AddTags(itran.tags)
+		if itran.shopID != 0 {
+			ctran = ctran.AddShopID(itran.shopID)
+		}
+
+		err := ctran.Err()
+		if err != nil {
+			return err
+		}
+
+		teams := []uint{}
+		entries := map[uint]accounting_core.CreateEntry{}
+		for _, row := range itran.rows {
+			entry := entries[row.teamID]
+			if entry == nil {
+				entry = bookmng.NewCreateEntry(row.teamID, agent.IdentityID())
+				entries[row.teamID] = entry
+				teams = append(teams, row.teamID)
+			}
+
+			acc := accounts[accountRef{row.teamID, row.accountKey}]
+			amount := acc.BalanceType.DiffBalance(row.debit, row.credit)
+
+			opts := []accounting_core.EntryOption{}
+			if row.desc != "" {
+				opts = append(opts, accounting_core.EntryDescOption(row.desc))
+			}
+
+			entry.To(&accounting_core.EntryAccountPayload{
+				Key:    row.accountKey,
+				TeamID: row.teamID,
+			}, amount, opts...)
+		}
+
+		for _, teamID := range teams {
+			err = entries[teamID].
+				Transaction(&tran).
+				Commit(accounting_core.CustomTimeOption(tran.Created)).
+				Err()
+			if err != nil {
+				return err
+			}
+		}
+
+		result.Imported++
+		return nil
+	})
+
+	if err != nil {
+		result.Imported = 0
+	}
+
+	return connect.NewResponse(&result), err
+}
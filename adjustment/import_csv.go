@@ -0,0 +1,275 @@
+package adjustment
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pdcgo/accounting_service/accounting_core"
+	"github.com/pdcgo/accounting_service/query_dialect"
+	"github.com/pdcgo/schema/services/accounting_iface/v1"
+)
+
+// kolom csv import journal, header boleh tidak ada kalau urutan kolom sama
+var importColumns = []string{"date", "ref", "team", "account_key", "debit", "credit", "description", "tags", "shop"}
+
+const importDateLayout = "2006-01-02"
+
+type importRow struct {
+	row        int
+	date       time.Time
+	ref        string
+	teamID     uint
+	accountKey accounting_core.AccountKey
+	debit      float64
+	credit     float64
+	desc       string
+	tags       []string
+	shopID     uint
+}
+
+// importTransaction baris dengan ref yang sama menjadi satu transaksi
+type importTransaction struct {
+	ref    accounting_core.RefID
+	teamID uint
+	date   time.Time
+	desc   string
+	tags   []string
+	shopID uint
+	rows   []*importRow
+	skip   bool
+}
+
+func (t *importTransaction) created() time.Time {
+	return query_dialect.ReportDayStart(t.date)
+}
+
+type importParser struct {
+	reader *csv.Reader
+	index  map[string]int
+	errors []*accounting_iface.AdjImportRowError
+}
+
+func newImportParser(r io.Reader) *importParser {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	return &importParser{
+		reader: reader,
+		errors: []*accounting_iface.AdjImportRowError{},
+	}
+}
+
+func (p *importParser) addError(row int, ref string, format string, args ...any) {
+	p.errors = append(p.errors, &accounting_iface.AdjImportRowError{
+		Row:     int64(row),
+		Ref:     ref,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+func (p *importParser) setHeader(record []string) bool {
+	p.index = map[string]int{}
+	isHeader := len(record) > 0 && strings.EqualFold(strings.TrimSpace(record[0]), importColumns[0])
+
+	for i, col := range importColumns {
+		p.index[col] = i
+	}
+	if !isHeader {
+		return false
+	}
+
+	for i, col := range record {
+		p.index[strings.ToLower(strings.TrimSpace(col))] = i
+	}
+	return true
+}
+
+func (p *importParser) field(record []string, col string) string {
+	idx, ok := p.index[col]
+	if !ok || idx >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[idx])
+}
+
+func parseImportAmount(value string) (float64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	amount, err := strconv.ParseFloat(strings.ReplaceAll(value, ",", ""), 64)
+	if err != nil {
+		return 0, fmt.Errorf("amount %s tidak valid", value)
+	}
+	if amount < 0 {
+		return 0, fmt.Errorf("amount %s negatif", value)
+	}
+	return amount, nil
+}
+
+func (p *importParser) parseRow(rowNum int, record []string) (*importRow, error) {
+	var err error
+
+	row := importRow{
+		row:        rowNum,
+		ref:        p.field(record, "ref"),
+		accountKey: accounting_core.AccountKey(p.field(record, "account_key")),
+		desc:       p.field(record, "description"),
+		tags:       []string{},
+	}
+
+	if row.ref == "" {
+		return &row, errors.New("ref kosong")
+	}
+
+	row.date, err = time.Parse(importDateLayout, p.field(record, "date"))
+	if err != nil {
+		return &row, fmt.Errorf("tanggal %s tidak valid", p.field(record, "date"))
+	}
+
+	teamID, err := strconv.ParseUint(p.field(record, "team"), 10, 64)
+	if err != nil || teamID == 0 {
+		return &row, fmt.Errorf("team %s tidak valid", p.field(record, "team"))
+	}
+	row.teamID = uint(teamID)
+
+	if row.accountKey == "" {
+		return &row, errors.New("account_key kosong")
+	}
+
+	row.debit, err = parseImportAmount(p.field(record, "debit"))
+	if err != nil {
+		return &row, err
+	}
+	row.credit, err = parseImportAmount(p.field(record, "credit"))
+	if err != nil {
+		return &row, err
+	}
+	if (row.debit == 0) == (row.credit == 0) {
+		return &row, errors.New("isi salah satu debit atau credit")
+	}
+
+	for _, tag := range strings.Split(p.field(record, "tags"), "|") {
+		tag = strings.TrimSpace(tag)
+		if tag != "" {
+			row.tags = append(row.tags, tag)
+		}
+	}
+
+	if shop := p.field(record, "shop"); shop != "" {
+		shopID, err := strconv.ParseUint(shop, 10, 64)
+		if err != nil {
+			return &row, fmt.Errorf("shop %s tidak valid", shop)
+		}
+		row.shopID = uint(shopID)
+	}
+
+	return &row, nil
+}
+
+// parse membaca semua baris dan mengelompokkan per ref, error dicatat per baris
+func (p *importParser) parse() ([]*importTransaction, int, error) {
+	result := []*importTransaction{}
+	tranMap := map[string]*importTransaction{}
+
+	rowCount := 0
+	for {
+		record, err := p.reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			var perr *csv.ParseError
+			if errors.As(err, &perr) {
+				p.addError(perr.Line, "", "%s", perr.Err)
+				continue
+			}
+			return result, rowCount, err
+		}
+
+		// nomor baris file, bukan nomor record
+		rowNum, _ := p.reader.FieldPos(0)
+
+		if p.index == nil && p.setHeader(record) {
+			continue
+		}
+
+		if len(record) == 1 && strings.TrimSpace(record[0]) == "" {
+			continue
+		}
+		rowCount++
+
+		row, err := p.parseRow(rowNum, record)
+		if err != nil {
+			p.addError(rowNum, row.ref, "%s", err)
+			continue
+		}
+
+		tran := tranMap[row.ref]
+		if tran == nil {
+			tran = &importTransaction{
+				ref: accounting_core.NewStringRefID(&accounting_core.StringRefData{
+					RefType: accounting_core.JournalImportRef,
+					ID:      row.ref,
+				}),
+				teamID: row.teamID,
+				date:   row.date,
+				desc:   row.desc,
+				tags:   []string{},
+				rows:   []*importRow{},
+			}
+			tranMap[row.ref] = tran
+			result = append(result, tran)
+		}
+
+		if !tran.date.Equal(row.date) {
+			p.addError(rowNum, row.ref, "tanggal berbeda dengan baris lain ref %s", row.ref)
+			continue
+		}
+		if row.shopID != 0 {
+			if tran.shopID != 0 && tran.shopID != row.shopID {
+				p.addError(rowNum, row.ref, "shop berbeda dengan baris lain ref %s", row.ref)
+				continue
+			}
+			tran.shopID = row.shopID
+		}
+
+		for _, tag := range row.tags {
+			if !slices.Contains(tran.tags, tag) {
+				tran.tags = append(tran.tags, tag)
+			}
+		}
+		tran.rows = append(tran.rows, row)
+	}
+
+	return result, rowCount, nil
+}
+
+// validateBalance debit dan credit tiap book team harus sama
+func (p *importParser) validateBalance(tran *importTransaction) {
+	debit := map[uint]float64{}
+	credit := map[uint]float64{}
+	teams := []uint{}
+
+	for _, row := range tran.rows {
+		if _, ok := debit[row.teamID]; !ok {
+			teams = append(teams, row.teamID)
+		}
+		debit[row.teamID] += row.debit
+		credit[row.teamID] += row.credit
+	}
+
+	for _, teamID := range teams {
+		if !accounting_core.CompareFloatSafe(debit[teamID], credit[teamID], accounting_core.PrecisionEpsilon) {
+			p.addError(tran.rows[0].row, tran.rows[0].ref,
+				"book team %d tidak balance debit %.2f credit %.2f", teamID, debit[teamID], credit[teamID])
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package adjustment_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pdcgo/accounting_service/accounting_core"
+	"github.com/pdcgo/accounting_service/accounting_mock"
+	"github.com/pdcgo/accounting_service/adjustment"
+	"github.com/pdcgo/schema/services/accounting_iface/v1"
+	"github.com/pdcgo/schema/services/accounting_iface/v1/accounting_ifaceconnect"
+	"github.com/pdcgo/shared/authorization/authorization_mock"
+	"github.com/pdcgo/shared/custom_connect"
+	"github.com/pdcgo/shared/db_models"
+	"github.com/pdcgo/shared/pkg/moretest"
+	"github.com/pdcgo/shared/pkg/moretest/moretest_mock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+const importCsv = `date,ref,team,account_key,debit,credit,description,tags,shop
+2025-10-01,opening-1,5,cash,150000,,saldo awal kas,opening,
+2025-10-01,opening-1,5,adj_liability,,150000,saldo awal modal,opening,
+2025-10-02,manual-1,5,ads_expense,2500.5,,biaya iklan lama,manual|iklan,3
+2025-10-02,manual-1,5,cash,,2500.5,,manual,3
+2025-10-02,manual-1,6,cash,1000,,setoran,manual,
+2025-10-02,manual-1,6,adj_liability,,1000,,manual,
+`
+
+func TestAdjImport(t *testing.T) {
+	var db gorm.DB
+
+	moretest.Suite(t, "testing import csv journal",
+		moretest.SetupListFunc{
+			moretest_mock.MockSqliteDatabase(&db),
+			func(t *testing.T) func() error {
+				err := db.AutoMigrate(
+					&db_models.Marketplace{},
+					&accounting_core.Account{},
+					&accounting_core.Transaction{},
+					&accounting_core.JournalEntry{},
+					&accounting_core.AccountingTag{},
+					&accounting_core.TransactionTag{},
+					&accounting_core.TransactionShop{},
+					&accounting_core.TypeLabel{},
+					&accounting_core.TransactionTypeLabel{},
+				)
+				assert.Nil(t, err)
+
+				err = db.Create(&db_models.Marketplace{ID: 3, TeamID: 5, MpUsername: "shop3", MpName: "shop 3", MpType: db_models.MpShopee}).Error
+				assert.Nil(t, err)
+				return nil
+			},
+			accounting_mock.PopulateAccountKey(&db, 5),
+			accounting_mock.PopulateAccountKey(&db, 6),
+		},
+		func(t *testing.T) {
+			adjSrv := adjustment.NewAdjustmentService(&db, &authorization_mock.EmptyAuthorizationMock{
+				AuthIdentityMock: &authorization_mock.AuthIdentityMock{
+					IdentityMock: &authorization_mock.IdentityMock{
+						ID: 1,
+					},
+				},
+			})
+
+			defaultInterceptor, err := custom_connect.NewDefaultInterceptor()
+			assert.Nil(t, err)
+
+			mux := http.NewServeMux()
+			mux.Handle(accounting_ifaceconnect.NewAdjustmentServiceHandler(adjSrv, defaultInterceptor))
+			server := httptest.NewServer(mux)
+			defer server.Close()
+
+			client := accounting_ifaceconnect.NewAdjustmentServiceClient(server.Client(), server.URL)
+
+			upload := func(t *testing.T, data string, dryRun bool) *accounting_iface.AdjImportResponse {
+				stream := client.AdjImport(t.Context())
+				// dipotong kecil supaya baris terbelah antar chunk
+				raw := []byte(data)
+				for i := 0; i < len(raw); i += 50 {
+					end := min(i+50, len(raw))
+					err := stream.Send(&accounting_iface.AdjImportRequest{
+						DryRun: dryRun,
+						Data:   raw[i:end],
+					})
+					assert.Nil(t, err)
+				}
+
+				res, err := stream.CloseAndReceive()
+				assert.Nil(t, err)
+				return res.Msg
+			}
+
+			countTransaction := func() int64 {
+				var count int64
+				db.Model(&accounting_core.Transaction{}).Count(&count)
+				return count
+			}
+
+			t.Run("baris error tidak ada yang disimpan", func(t *testing.T) {
+				data := strings.Join([]string{
+					"2025-10-01,bad-1,5,cash,1000,,,,",
+					"2025-10-01,bad-1,5,sales_revenue,,900,,,",
+					"2025-10-01,bad-2,5,unknown_key,100,,,,",
+					"2025-10-01,bad-2,5,cash,,100,,,",
+					"2025-10-01,bad-3,5,cash,10,10,,,",
+					"",
+				}, "\n")
+
+				res := upload(t, data, false)
+				assert.Equal(t, int64(5), res.Rows)
+				assert.Len(t, res.Errors, 3)
+				assert.Equal(t, int64(1), res.Errors[0].Row)
+				assert.Equal(t, "bad-1", res.Errors[0].Ref)
+				assert.Contains(t, res.Errors[0].Message, "tidak balance")
+				assert.Equal(t, int64(3), res.Errors[1].Row)
+				assert.Contains(t, res.Errors[1].Message, "unknown_key")
+				assert.Equal(t, int64(5), res.Errors[2].Row)
+				assert.Equal(t, int64(0), countTransaction())
+			})
+
+			t.Run("dry run", func(t *testing.T) {
+				res := upload(t, importCsv, true)
+				assert.Empty(t, res.Errors)
+				assert.Equal(t, int64(6), res.Rows)
+				assert.Equal(t, int64(2), res.Transactions)
+				assert.Equal(t, int64(0), res.Imported)
+				assert.Equal(t, int64(0), countTransaction())
+			})
+
+			t.Run("import csv", func(t *testing.T) {
+				res := upload(t, importCsv, false)
+				assert.Empty(t, res.Errors)
+				assert.Equal(t, int64(2), res.Imported)
+
+				var tran accounting_core.Transaction
+				err := db.
+					Model(&accounting_core.Transaction{}).
+					Where("ref_id = ?", "journal_import#manual-1").
+					First(&tran).
+					Error
+				assert.Nil(t, err)
+				assert.Equal(t, uint(5), tran.TeamID)
+				assert.Equal(t, "biaya iklan lama", tran.Desc)
+
+				entries := []*accounting_core.JournalEntry{}
+				err = db.
+					Model(&accounting_core.JournalEntry{}).
+					Where("transaction_id = ?", tran.ID).
+					Find(&entries).
+					Error
+				assert.Nil(t, err)
+				assert.Len(t, entries, 4)
+
+				var tagCount int64
+				db.Model(&accounting_core.TransactionTag{}).Where("transaction_id = ?", tran.ID).Count(&tagCount)
+				assert.Equal(t, int64(3), tagCount)
+
+				var shopCount int64
+				db.Model(&accounting_core.TransactionShop{}).Where("transaction_id = ? AND shop_id = ?", tran.ID, 3).Count(&shopCount)
+				assert.Equal(t, int64(1), shopCount)
+			})
+
+			t.Run("import ulang dilewati", func(t *testing.T) {
+				res := upload(t, importCsv, false)
+				assert.Empty(t, res.Errors)
+				assert.Equal(t, int64(0), res.Imported)
+				assert.Equal(t, []string{"opening-1", "manual-1"}, res.Skipped)
+				assert.Equal(t, int64(2), countTransaction())
+			})
+		},
+	)
+}
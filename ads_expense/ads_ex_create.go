@@ -7,7 +7,10 @@ import (
 	"time"
 
 	"connectrpc.com/connect"
+	"github.com/pdcgo/accounting_service/account"
 	"github.com/pdcgo/accounting_service/accounting_core"
+	"github.com/pdcgo/accounting_service/accounting_model"
+	"github.com/pdcgo/accounting_service/budget"
 	"github.com/pdcgo/schema/services/access_iface/v1"
 	"github.com/pdcgo/schema/services/accounting_iface/v1"
 	"github.com/pdcgo/schema/services/common/v1"
@@ -62,6 +65,7 @@ func (a *adsExpenseImpl) AdsExCreate(
 	err = accounting_core.OpenTransaction(ctx, db, func(tx *gorm.DB, bookmng accounting_core.BookManage) error {
 		var extRef string
 		var tran accounting_core.Transaction
+		var bank *accounting_model.BankAccountV2
 
 		switch pay.Source {
 		case accounting_iface.AccountSource_ACCOUNT_SOURCE_SHOP:
@@ -72,6 +76,11 @@ func (a *adsExpenseImpl) AdsExCreate(
 			extRef = pay.ExternalRefId
 
 		default:
+			bank, err = account.CashBank(tx, uint(pay.TeamId), pay.BankAccountId)
+			if err != nil {
+				return err
+			}
+
 			extRef = time.Now().String()
 		}
 
@@ -141,6 +150,34 @@ func (a *adsExpenseImpl) AdsExCreate(
 			return err
 		}
 
+		tagIDs := []uint{}
+		err = tx.
+			Model(&accounting_core.AccountingTag{}).
+			Where("name in ?", fixtags).
+			Pluck("id", &tagIDs).
+			Error
+		if err != nil {
+			return err
+		}
+
+		check, err := budget.
+			NewBudgetCheck(tx).
+			Check(&budget.PostingPayload{
+				TeamID:     uint(pay.TeamId),
+				AccountKey: accounting_core.AdsExpenseAccount,
+				ShopID:     uint(pay.ShopId),
+				TagIDs:     tagIDs,
+				At:         tran.Created,
+				Amount:     pay.Amount,
+			})
+		if err != nil {
+			if errors.Is(err, budget.ErrBudgetExceeded) {
+				return connect.NewError(connect.CodeFailedPrecondition, err)
+			}
+			return err
+		}
+		result.BudgetWarnings = check.Warnings
+
 		entry := bookmng.
 			NewCreateEntry(uint(pay.TeamId), agent.IdentityID())
 
@@ -153,8 +190,9 @@ func (a *adsExpenseImpl) AdsExCreate(
 		default:
 			entry.
 				From(&accounting_core.EntryAccountPayload{
-					Key:    accounting_core.CashAccount,
-					TeamID: uint(pay.TeamId),
+					Key:           accounting_core.CashAccount,
+					TeamID:        uint(pay.TeamId),
+					BankAccountID: bank.ID,
 				}, pay.Amount)
 		}
 
@@ -173,7 +211,10 @@ func (a *adsExpenseImpl) AdsExCreate(
 
 		result.TransactionId = uint64(tran.ID)
 
-		return nil
+		if bank == nil {
+			return nil
+		}
+		return account.SyncBankBalance(tx, bank.ID)
 	})
 
 	return connect.NewResponse(&result), err
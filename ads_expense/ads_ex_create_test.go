@@ -2,11 +2,14 @@ package ads_expense_test
 
 import (
 	"testing"
+	"time"
 
 	"connectrpc.com/connect"
 	"github.com/pdcgo/accounting_service/accounting_core"
 	"github.com/pdcgo/accounting_service/accounting_mock"
+	"github.com/pdcgo/accounting_service/accounting_model"
 	"github.com/pdcgo/accounting_service/ads_expense"
+	"github.com/pdcgo/accounting_service/budget"
 	"github.com/pdcgo/schema/services/access_iface/v1"
 	"github.com/pdcgo/schema/services/accounting_iface/v1"
 	"github.com/pdcgo/schema/services/common/v1"
@@ -36,8 +39,17 @@ func TestCreateAdsExpense(t *testing.T) {
 					&accounting_core.TypeLabel{},
 					&accounting_core.TransactionTypeLabel{},
 					&db_models.Marketplace{},
+					&budget.Budget{},
+					&accounting_core.AccountKeyDailyBalance{},
+					&accounting_core.ShopDailyBalance{},
+					&accounting_core.CustomLabelDailyBalance{},
+					&accounting_core.JournalEntryBank{},
+					&accounting_model.BankAccountV2{},
 				)
 				assert.Nil(t, err)
+
+				err = db.Create(&accounting_model.BankAccountV2{ID: 1, TeamID: 1, Name: "bca iklan", NumberID: "1001"}).Error
+				assert.Nil(t, err)
 				return nil
 			},
 			func(t *testing.T) func() error {
@@ -114,6 +126,77 @@ func TestCreateAdsExpense(t *testing.T) {
 				assert.Nil(t, err)
 			})
 
+			t.Run("testing bayar dari kas wajib bank", func(t *testing.T) {
+				payload := &accounting_iface.AdsExCreateRequest{
+					TeamId: 1,
+					ShopId: 2,
+					MpType: common.MarketplaceType_MARKETPLACE_TYPE_SHOPEE,
+					Amount: 3000,
+					Desc:   "topup iklan dari kas",
+				}
+				_, err := service.AdsExCreate(ctx, &connect.Request[accounting_iface.AdsExCreateRequest]{
+					Msg: payload,
+				})
+				assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+
+				payload.BankAccountId = 1
+				_, err = service.AdsExCreate(ctx, &connect.Request[accounting_iface.AdsExCreateRequest]{
+					Msg: payload,
+				})
+				assert.Nil(t, err)
+
+				var bank accounting_model.BankAccountV2
+				err = db.First(&bank, 1).Error
+				assert.Nil(t, err)
+				assert.Equal(t, -3000.0, bank.Balance)
+			})
+
+			t.Run("testing budget", func(t *testing.T) {
+				limit := budget.Budget{
+					TeamID:     1,
+					AccountKey: accounting_core.AdsExpenseAccount,
+					Month:      budget.MonthOf(time.Now()),
+					ShopID:     2,
+					Version:    1,
+					Amount:     1000,
+					Mode:       budget.BudgetModeSoft,
+				}
+				err := db.Create(&limit).Error
+				assert.Nil(t, err)
+
+				res, err := service.AdsExCreate(ctx, &connect.Request[accounting_iface.AdsExCreateRequest]{
+					Msg: &accounting_iface.AdsExCreateRequest{
+						TeamId:        1,
+						ShopId:        2,
+						ExternalRefId: "budget-soft",
+						Source:        accounting_iface.AccountSource_ACCOUNT_SOURCE_SHOP,
+						MpType:        common.MarketplaceType_MARKETPLACE_TYPE_SHOPEE,
+						Amount:        5000,
+					},
+				})
+				assert.Nil(t, err)
+				assert.Len(t, res.Msg.BudgetWarnings, 1)
+
+				limit.ID = 0
+				limit.Version = 2
+				limit.Mode = budget.BudgetModeHard
+				err = db.Create(&limit).Error
+				assert.Nil(t, err)
+
+				_, err = service.AdsExCreate(ctx, &connect.Request[accounting_iface.AdsExCreateRequest]{
+					Msg: &accounting_iface.AdsExCreateRequest{
+						TeamId:        1,
+						ShopId:        2,
+						ExternalRefId: "budget-hard",
+						Source:        accounting_iface.AccountSource_ACCOUNT_SOURCE_SHOP,
+						MpType:        common.MarketplaceType_MARKETPLACE_TYPE_SHOPEE,
+						Amount:        5000,
+					},
+				})
+				assert.ErrorIs(t, err, budget.ErrBudgetExceeded)
+				assert.Equal(t, connect.CodeFailedPrecondition, connect.CodeOf(err))
+			})
+
 		},
 	)
 }
@@ -0,0 +1,322 @@
+package bank_statement
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/pdcgo/accounting_service/accounting_core"
+	"github.com/pdcgo/accounting_service/accounting_model"
+	"github.com/pdcgo/accounting_service/query_dialect"
+	"gorm.io/gorm"
+)
+
+const (
+	// selisih hari maksimal tanggal bank dan tanggal buku
+	defaultMatchWindow = 3
+	// jumlah saran per baris mutasi
+	suggestionLimit = 3
+)
+
+type candidate struct {
+	targetType    MatchTarget
+	targetID      uint
+	transactionID uint
+	at            time.Time
+	amount        float64
+	desc          string
+	// sudah tercatat di dimensi bank account ini
+	inLedger bool
+}
+
+func (c *candidate) key() targetKey {
+	return targetKey{c.targetType, c.targetID}
+}
+
+type targetKey struct {
+	targetType MatchTarget
+	targetID   uint
+}
+
+type matcher struct {
+	db     *gorm.DB
+	bank   *accounting_model.BankAccountV2
+	window int
+}
+
+func newMatcher(db *gorm.DB, bank *accounting_model.BankAccountV2) *matcher {
+	return &matcher{
+		db:     db,
+		bank:   bank,
+		window: defaultMatchWindow,
+	}
+}
+
+// transferCandidates transfer dari atau ke bank account, nominal keluar termasuk biaya transfer
+func (m *matcher) transferCandidates(start, end time.Time) ([]*candidate, error) {
+	hists := []*accounting_model.BankTransferHistory{}
+	err := m.
+		db.
+		Model(&accounting_model.BankTransferHistory{}).
+		Where("from_account_id = ? OR to_account_id = ?", m.bank.ID, m.bank.ID).
+		Where("status <> ?", accounting_model.TransferCancelled).
+		Where("created >= ? AND created < ?", start, end).
+		Find(&hists).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	result := []*candidate{}
+	for _, hist := range hists {
+		cand := candidate{
+			targetType:    TargetTransfer,
+			targetID:      hist.ID,
+			transactionID: hist.TxID,
+			at:            hist.Created,
+			desc:          hist.Desc,
+			inLedger:      true,
+		}
+		if hist.ToAccountID == m.bank.ID {
+			cand.amount = hist.Amount
+		} else {
+			cand.amount = -(hist.Amount + hist.FeeAmount)
+		}
+		result = append(result, &cand)
+	}
+	return result, nil
+}
+
+type entryCandidateRow struct {
+	ID            uint
+	TransactionID uint
+	EntryTime     time.Time
+	Debit         float64
+	Credit        float64
+	Desc          string
+	TranDesc      string
+	BankAccountID uint
+}
+
+// entryCandidates journal entry kas team yang bukan transfer antar bank account,
+// entry yang sudah tercatat di bank account lain tidak ikut
+func (m *matcher) entryCandidates(start, end time.Time) ([]*candidate, error) {
+	rows := []*entryCandidateRow{}
+	err := m.
+		db.
+		Table("journal_entries je").
+		Joins("join accounts a on a.id = je.account_id").
+		Joins("join transactions t on t.id = je.transaction_id").
+		Joins("left join journal_entry_banks jeb on jeb.journal_entry_id = je.id").
+		Select(strings.Join([]string{
+			"je.id",
+			"je.transaction_id",
+			"je.entry_time",
+			"coalesce(jeb.debit, je.debit) as debit",
+			"coalesce(jeb.credit, je.credit) as credit",
+			"je.desc",
+			"t.desc as tran_desc",
+			"coalesce(jeb.bank_account_id, 0) as bank_account_id",
+		}, ", ")).
+		Where("a.account_key = ?", accounting_core.CashAccount).
+		Where("a.team_id = ?", m.bank.TeamID).
+		Where("je.team_id = ?", m.bank.TeamID).
+		Where("je.entry_time >= ? AND je.entry_time < ?", start, end).
+		Where("je.transaction_id NOT IN (?)", m.db.Model(&accounting_model.BankTransferHistory{}).Select("tx_id")).
+		Where("jeb.id IS NULL OR jeb.bank_account_id = ?", m.bank.ID).
+		Scan(&rows).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	result := []*candidate{}
+	for _, row := range rows {
+		desc := row.Desc
+		if desc == "" {
+			desc = row.TranDesc
+		}
+		result = append(result, &candidate{
+			targetType:    TargetEntry,
+			targetID:      row.ID,
+			transactionID: row.TransactionID,
+			at:            row.EntryTime,
+			amount:        row.Debit - row.Credit,
+			desc:          desc,
+			inLedger:      row.BankAccountID == m.bank.ID,
+		})
+	}
+	return result, nil
+}
+
+func tokens(text string) map[string]bool {
+	result := map[string]bool{}
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		if len(word) >= 3 {
+			result[word] = true
+		}
+	}
+	return result
+}
+
+// similarity kemiripan keterangan, jumlah kata sama dibagi kata keterangan terpendek
+func similarity(a, b string) float64 {
+	ta := tokens(a)
+	tb := tokens(b)
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+
+	same := 0
+	for word := range ta {
+		if tb[word] {
+			same++
+		}
+	}
+	return float64(same) / float64(min(len(ta), len(tb)))
+}
+
+// score nominal harus sama, tanggal dan keterangan menambah confidence
+func (m *matcher) score(line *StatementLine, cand *candidate) float64 {
+	if !accounting_core.CompareFloatSafe(line.Amount, cand.amount, 0.01) {
+		return 0
+	}
+
+	lineDay := query_dialect.ReportDay(line.TxDate)
+	candDay := query_dialect.ReportDay(cand.at)
+	days := math.Abs(candDay.Sub(lineDay).Hours() / 24)
+	if days > float64(m.window) {
+		return 0
+	}
+
+	dateScore := 1 - days/float64(m.window+1)
+	descScore := similarity(line.Desc+" "+line.Reference, cand.desc)
+	return math.Round((0.5+0.3*dateScore+0.2*descScore)*10000) / 10000
+}
+
+// run menghitung ulang saran untuk semua baris yang belum match
+func (m *matcher) run() (int, error) {
+	var err error
+
+	lines := []*StatementLine{}
+	err = m.
+		db.
+		Model(&StatementLine{}).
+		Where("bank_account_id = ?", m.bank.ID).
+		Where("status = ?", LineUnmatched).
+		Order("tx_date asc, id asc").
+		Find(&lines).
+		Error
+	if err != nil || len(lines) == 0 {
+		return 0, err
+	}
+
+	lineIDs := make([]uint, len(lines))
+	for i, line := range lines {
+		lineIDs[i] = line.ID
+	}
+
+	err = m.
+		db.
+		Where("line_id IN ?", lineIDs).
+		Where("status = ?", MatchSuggested).
+		Delete(&StatementMatch{}).
+		Error
+	if err != nil {
+		return 0, err
+	}
+
+	start := lines[0].TxDate.AddDate(0, 0, -m.window)
+	end := lines[len(lines)-1].TxDate.AddDate(0, 0, m.window+1)
+
+	transfers, err := m.transferCandidates(start, end)
+	if err != nil {
+		return 0, err
+	}
+	entries, err := m.entryCandidates(start, end)
+	if err != nil {
+		return 0, err
+	}
+	candidates := append(transfers, entries...)
+
+	decided := []*StatementMatch{}
+	err = m.
+		db.
+		Model(&StatementMatch{}).
+		Where("status IN ?", []MatchStatus{MatchConfirmed, MatchRejected}).
+		Where("status = ? OR line_id IN ?", MatchConfirmed, lineIDs).
+		Find(&decided).
+		Error
+	if err != nil {
+		return 0, err
+	}
+
+	confirmed := map[targetKey]bool{}
+	rejected := map[uint]map[targetKey]bool{}
+	for _, match := range decided {
+		key := targetKey{match.TargetType, match.TargetID}
+		if match.Status == MatchConfirmed {
+			confirmed[key] = true
+			continue
+		}
+		if rejected[match.LineID] == nil {
+			rejected[match.LineID] = map[targetKey]bool{}
+		}
+		rejected[match.LineID][key] = true
+	}
+
+	now := time.Now()
+	suggestions := []*StatementMatch{}
+	for _, line := range lines {
+		matches := []*StatementMatch{}
+		for _, cand := range candidates {
+			if confirmed[cand.key()] || rejected[line.ID][cand.key()] {
+				continue
+			}
+
+			confidence := m.score(line, cand)
+			if confidence == 0 {
+				continue
+			}
+
+			matches = append(matches, &StatementMatch{
+				LineID:        line.ID,
+				TargetType:    cand.targetType,
+				TargetID:      cand.targetID,
+				TransactionID: cand.transactionID,
+				TargetAt:      cand.at,
+				TargetAmount:  cand.amount,
+				TargetDesc:    cand.desc,
+				Confidence:    confidence,
+				Status:        MatchSuggested,
+				CreatedAt:     now,
+			})
+		}
+
+		slices.SortStableFunc(matches, func(a, b *StatementMatch) int {
+			switch {
+			case a.Confidence > b.Confidence:
+				return -1
+			case a.Confidence < b.Confidence:
+				return 1
+			}
+			return 0
+		})
+		if len(matches) > suggestionLimit {
+			matches = matches[:suggestionLimit]
+		}
+		suggestions = append(suggestions, matches...)
+	}
+
+	if len(suggestions) == 0 {
+		return 0, nil
+	}
+
+	err = m.db.CreateInBatches(&suggestions, 100).Error
+	return len(suggestions), err
+}
@@ -0,0 +1,315 @@
+package bank_statement
+
+import (
+	"time"
+
+	"github.com/pdcgo/schema/services/accounting_iface/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type LineStatus string
+
+const (
+	LineUnmatched LineStatus = "unmatched"
+	LineMatched   LineStatus = "matched"
+)
+
+type MatchTarget string
+
+const (
+	TargetTransfer MatchTarget = "transfer"
+	TargetEntry    MatchTarget = "entry"
+)
+
+type MatchStatus string
+
+const (
+	MatchSuggested MatchStatus = "suggested"
+	MatchConfirmed MatchStatus = "confirmed"
+	MatchRejected  MatchStatus = "rejected"
+)
+
+// StatementLine satu baris mutasi rekening koran per bank account
+type StatementLine struct {
+	ID            uint   `json:"id" gorm:"primarykey"`
+	BankAccountID uint   `json:"bank_account_id" gorm:"index:statement_line_unique,unique"`
+	TeamID        uint   `json:"team_id" gorm:"index"`
+	Format        Format `json:"format"`
+	// hash isi baris supaya import ulang file yang sama tidak dobel
+	Hash string `json:"-" gorm:"index:statement_line_unique,unique"`
+	// awal hari tanggal mutasi di timezone laporan
+	TxDate time.Time `json:"tx_date" gorm:"index"`
+	// positif uang masuk, negatif uang keluar
+	Amount    float64    `json:"amount"`
+	Balance   *float64   `json:"balance"`
+	Desc      string     `json:"desc"`
+	Reference string     `json:"reference"`
+	Status    LineStatus `json:"status" gorm:"index"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// StatementMatch pasangan baris mutasi dengan transfer atau journal entry kas
+type StatementMatch struct {
+	ID            uint        `json:"id" gorm:"primarykey"`
+	LineID        uint        `json:"line_id" gorm:"index"`
+	TargetType    MatchTarget `json:"target_type" gorm:"index:statement_match_target"`
+	TargetID      uint        `json:"target_id" gorm:"index:statement_match_target"`
+	TransactionID uint        `json:"transaction_id"`
+	TargetAt      time.Time   `json:"target_at"`
+	TargetAmount  float64     `json:"target_amount"`
+	TargetDesc    string      `json:"target_desc"`
+	// 0 sampai 1
+	Confidence  float64     `json:"confidence"`
+	Status      MatchStatus `json:"status" gorm:"index"`
+	DecidedByID uint        `json:"decided_by_id"`
+	DecidedAt   *time.Time  `json:"decided_at"`
+	CreatedAt   time.Time   `json:"created_at"`
+}
+
+func formatProto(format Format) accounting_iface.BankStatementFormat {
+	switch format {
+	case FormatBCA:
+		return accounting_iface.BankStatementFormat_BANK_STATEMENT_FORMAT_BCA
+	case FormatBNI:
+		return accounting_iface.BankStatementFormat_BANK_STATEMENT_FORMAT_BNI
+	case FormatBRI:
+		return accounting_iface.BankStatementFormat_BANK_STATEMENT_FORMAT_BRI
+	case FormatMandiri:
+		return accounting_iface.BankStatementFormat_BANK_STATEMENT_FORMAT_MANDIRI
+	case FormatMT940:
+		return accounting_iface.BankStatementFormat_BANK_STATEMENT_FORMAT_MT940
+	default:
+		return accounting_iface.BankStatementFormat_BANK_STATEMENT_FORMAT_UNSPECIFIED
+	}
+}
+
+// formatFromProto format kosong atau tidak dikenal jadi string kosong supaya ditolak parser
+func formatFromProto(format accounting_iface.BankStatementFormat) Format {
+	switch format {
+	case accounting_iface.BankStatementFormat_BANK_STATEMENT_FORMAT_BCA:
+		return FormatBCA
+	case accounting_iface.BankStatementFormat_BANK_STATEMENT_FORMAT_BNI:
+		return FormatBNI
+	case accounting_iface.BankStatementFormat_BANK_STATEMENT_FORMAT_BRI:
+		return FormatBRI
+	case accounting_iface.BankStatementFormat_BANK_STATEMENT_FORMAT_MANDIRI:
+		return FormatMandiri
+	case accounting_iface.BankStatementFormat_BANK_STATEMENT_FORMAT_MT940:
+		return FormatMT940
+	default:
+		return ""
+	}
+}
+
+func lineStatusProto(status LineStatus) accounting_iface.StatementLineStatus {
+	switch status {
+	case LineUnmatched:
+		return accounting_iface.StatementLineStatus_STATEMENT_LINE_STATUS_UNMATCHED
+	case LineMatched:
+		return accounting_iface.StatementLineStatus_STATEMENT_LINE_STATUS_MATCHED
+	default:
+		return accounting_iface.StatementLineStatus_STATEMENT_LINE_STATUS_UNSPECIFIED
+	}
+}
+
+func lineStatusFromProto(status accounting_iface.StatementLineStatus) LineStatus {
+	switch status {
+	case accounting_iface.StatementLineStatus_STATEMENT_LINE_STATUS_UNMATCHED:
+		return LineUnmatched
+	case accounting_iface.StatementLineStatus_STATEMENT_LINE_STATUS_MATCHED:
+		return LineMatched
+	default:
+		return ""
+	}
+}
+
+func matchTargetProto(target MatchTarget) accounting_iface.StatementMatchTarget {
+	switch target {
+	case TargetTransfer:
+		return accounting_iface.StatementMatchTarget_STATEMENT_MATCH_TARGET_TRANSFER
+	case TargetEntry:
+		return accounting_iface.StatementMatchTarget_STATEMENT_MATCH_TARGET_ENTRY
+	default:
+		return accounting_iface.StatementMatchTarget_STATEMENT_MATCH_TARGET_UNSPECIFIED
+	}
+}
+
+func matchStatusProto(status MatchStatus) accounting_iface.StatementMatchStatus {
+	switch status {
+	case MatchSuggested:
+		return accounting_iface.StatementMatchStatus_STATEMENT_MATCH_STATUS_SUGGESTED
+	case MatchConfirmed:
+		return accounting_iface.StatementMatchStatus_STATEMENT_MATCH_STATUS_CONFIRMED
+	case MatchRejected:
+		return accounting_iface.StatementMatchStatus_STATEMENT_MATCH_STATUS_REJECTED
+	default:
+		return accounting_iface.StatementMatchStatus_STATEMENT_MATCH_STATUS_UNSPECIFIED
+	}
+}
+
+func (l *StatementLine) proto() *accounting_iface.StatementLine {
+	return &accounting_iface.StatementLine{
+		Id:            uint64(l.ID),
+		BankAccountId: uint64(l.BankAccountID),
+		TeamId:        uint64(l.TeamID),
+		Format:        formatProto(l.Format),
+		TxDate:        timestamppb.New(l.TxDate),
+		Amount:        l.Amount,
+		Balance:       l.Balance,
+		Desc:          l.Desc,
+		Reference:     l.Reference,
+		Status:        lineStatusProto(l.Status),
+		CreatedAt:     timestamppb.New(l.CreatedAt),
+	}
+}
+
+func (m *StatementMatch) proto() *accounting_iface.StatementMatch {
+	item := accounting_iface.StatementMatch{
+		Id:            uint64(m.ID),
+		LineId:        uint64(m.LineID),
+		TargetType:    matchTargetProto(m.TargetType),
+		TargetId:      uint64(m.TargetID),
+		TransactionId: uint64(m.TransactionID),
+		TargetAt:      timestamppb.New(m.TargetAt),
+		TargetAmount:  m.TargetAmount,
+		TargetDesc:    m.TargetDesc,
+		Confidence:    m.Confidence,
+		Status:        matchStatusProto(m.Status),
+		DecidedById:   uint64(m.DecidedByID),
+		CreatedAt:     timestamppb.New(m.CreatedAt),
+	}
+
+	if m.DecidedAt != nil {
+		item.DecidedAt = timestamppb.New(*m.DecidedAt)
+	}
+
+	return &item
+}
+
+type ReconStatus string
+
+const (
+	ReconOpen     ReconStatus = "open"
+	ReconFinished ReconStatus = "finished"
+)
+
+// ReconSession sesi rekonsiliasi bank account untuk satu periode, PeriodEnd tidak termasuk
+type ReconSession struct {
+	ID            uint        `json:"id" gorm:"primarykey"`
+	BankAccountID uint        `json:"bank_account_id" gorm:"index"`
+	TeamID        uint        `json:"team_id" gorm:"index"`
+	PeriodStart   time.Time   `json:"period_start"`
+	PeriodEnd     time.Time   `json:"period_end"`
+	Status        ReconStatus `json:"status" gorm:"index"`
+
+	OpeningBalance   float64 `json:"opening_balance"`
+	StatementBalance float64 `json:"statement_balance"`
+	BookBalance      float64 `json:"book_balance"`
+
+	CreatedByID  uint       `json:"created_by_id"`
+	FinishedByID uint       `json:"finished_by_id"`
+	CreatedAt    time.Time  `json:"created_at"`
+	FinishedAt   *time.Time `json:"finished_at"`
+
+	// laporan disimpan saat sesi selesai
+	Report *ReconReport `json:"report" gorm:"serializer:json"`
+}
+
+type ReconMatchedItem struct {
+	Line  *StatementLine  `json:"line"`
+	Match *StatementMatch `json:"match"`
+}
+
+// ReconBookItem transfer atau journal entry kas yang belum ada pasangan di rekening koran
+type ReconBookItem struct {
+	TargetType    MatchTarget `json:"target_type"`
+	TargetID      uint        `json:"target_id"`
+	TransactionID uint        `json:"transaction_id"`
+	At            time.Time   `json:"at"`
+	Amount        float64     `json:"amount"`
+	Desc          string      `json:"desc"`
+}
+
+// ReconReport saldo bank dibanding saldo buku, Outstanding transfer bank account yang belum muncul di rekening koran
+type ReconReport struct {
+	OpeningBalance     float64 `json:"opening_balance"`
+	StatementBalance   float64 `json:"statement_balance"`
+	BookBalance        float64 `json:"book_balance"`
+	Outstanding        float64 `json:"outstanding"`
+	Difference         float64 `json:"difference"`
+	AdjustedDifference float64 `json:"adjusted_difference"`
+
+	Matched        []*ReconMatchedItem `json:"matched"`
+	UnmatchedLines []*StatementLine    `json:"unmatched_lines"`
+	UnmatchedBook  []*ReconBookItem    `json:"unmatched_book"`
+}
+
+func reconStatusProto(status ReconStatus) accounting_iface.BankReconStatus {
+	switch status {
+	case ReconOpen:
+		return accounting_iface.BankReconStatus_BANK_RECON_STATUS_OPEN
+	case ReconFinished:
+		return accounting_iface.BankReconStatus_BANK_RECON_STATUS_FINISHED
+	default:
+		return accounting_iface.BankReconStatus_BANK_RECON_STATUS_UNSPECIFIED
+	}
+}
+
+func (s *ReconSession) proto() *accounting_iface.BankReconSession {
+	item := accounting_iface.BankReconSession{
+		Id:               uint64(s.ID),
+		BankAccountId:    uint64(s.BankAccountID),
+		TeamId:           uint64(s.TeamID),
+		PeriodStart:      timestamppb.New(s.PeriodStart),
+		PeriodEnd:        timestamppb.New(s.PeriodEnd),
+		Status:           reconStatusProto(s.Status),
+		OpeningBalance:   s.OpeningBalance,
+		StatementBalance: s.StatementBalance,
+		BookBalance:      s.BookBalance,
+		CreatedById:      uint64(s.CreatedByID),
+		FinishedById:     uint64(s.FinishedByID),
+		CreatedAt:        timestamppb.New(s.CreatedAt),
+	}
+
+	if s.FinishedAt != nil {
+		item.FinishedAt = timestamppb.New(*s.FinishedAt)
+	}
+
+	return &item
+}
+
+func (r *ReconReport) proto() *accounting_iface.BankReconReport {
+	report := accounting_iface.BankReconReport{
+		OpeningBalance:     r.OpeningBalance,
+		StatementBalance:   r.StatementBalance,
+		BookBalance:        r.BookBalance,
+		Outstanding:        r.Outstanding,
+		Difference:         r.Difference,
+		AdjustedDifference: r.AdjustedDifference,
+		Matched:            make([]*accounting_iface.BankReconMatchedItem, len(r.Matched)),
+		UnmatchedLines:     make([]*accounting_iface.StatementLine, len(r.UnmatchedLines)),
+		UnmatchedBook:      make([]*accounting_iface.BankReconBookItem, len(r.UnmatchedBook)),
+	}
+
+	for i, item := range r.Matched {
+		report.Matched[i] = &accounting_iface.BankReconMatchedItem{
+			Line:  item.Line.proto(),
+			Match: item.Match.proto(),
+		}
+	}
+	for i, line := range r.UnmatchedLines {
+		report.UnmatchedLines[i] = line.proto()
+	}
+	for i, item := range r.UnmatchedBook {
+		report.UnmatchedBook[i] = &accounting_iface.BankReconBookItem{
+			TargetType:    matchTargetProto(item.TargetType),
+			TargetId:      uint64(item.TargetID),
+			TransactionId: uint64(item.TransactionID),
+			At:            timestamppb.New(item.At),
+			Amount:        item.Amount,
+			Desc:          item.Desc,
+		}
+	}
+
+	return &report
+}
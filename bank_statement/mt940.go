@@ -0,0 +1,175 @@
+package bank_statement
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// parseMT940 membaca swift mt940, satu :61: dengan :86: sesudahnya menjadi satu baris mutasi
+func parseMT940(data []byte) ([]*ParsedLine, error) {
+	result := []*ParsedLine{}
+
+	type field struct {
+		line  int
+		tag   string
+		value string
+	}
+	fields := []*field{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		text := strings.TrimRight(scanner.Text(), "\r ")
+		if text == "" || text == "-" || strings.HasPrefix(text, "{") {
+			continue
+		}
+
+		if strings.HasPrefix(text, ":") {
+			tag, value, ok := strings.Cut(text[1:], ":")
+			if ok {
+				fields = append(fields, &field{lineNum, tag, value})
+				continue
+			}
+		}
+
+		// lanjutan field sebelumnya
+		if len(fields) > 0 {
+			last := fields[len(fields)-1]
+			last.value += "\n" + text
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return result, err
+	}
+
+	var current *ParsedLine
+	for _, f := range fields {
+		switch f.tag {
+		case "61":
+			line, err := parseMT940Line(f.value)
+			if err != nil {
+				return result, fmt.Errorf("baris %d: %w", f.line, err)
+			}
+			line.Line = f.line
+			current = line
+			result = append(result, line)
+		case "86":
+			if current != nil {
+				current.Desc = strings.Join(strings.Fields(f.value), " ")
+			}
+		case "62F", "62M":
+			// saldo akhir ditaruh di baris terakhir
+			if len(result) > 0 {
+				balance, err := parseMT940Balance(f.value)
+				if err == nil {
+					result[len(result)-1].Balance = &balance
+				}
+			}
+			current = nil
+		default:
+			current = nil
+		}
+	}
+
+	return result, nil
+}
+
+func mt940Amount(value string) (float64, string, error) {
+	end := 0
+	for end < len(value) && (value[end] >= '0' && value[end] <= '9' || value[end] == ',') {
+		end++
+	}
+	if end == 0 {
+		return 0, value, errors.New("nominal mt940 kosong")
+	}
+
+	amount, err := parseBankAmount(strings.Replace(value[:end], ",", ".", 1))
+	return amount, value[end:], err
+}
+
+// parseMT940Line format :61: YYMMDD[MMDD]{C|D|RC|RD}[funds code]amount{N|F|S}xxx ref[//bank ref]
+func parseMT940Line(value string) (*ParsedLine, error) {
+	first, _, _ := strings.Cut(value, "\n")
+	if len(first) < 7 {
+		return nil, errors.New("statement line mt940 tidak valid")
+	}
+
+	date, err := time.Parse("060102", first[:6])
+	if err != nil {
+		return nil, fmt.Errorf("tanggal mt940 %s tidak valid", first[:6])
+	}
+	rest := first[6:]
+
+	// tanggal entry opsional
+	if len(rest) >= 4 && isDigits(rest[:4]) {
+		rest = rest[4:]
+	}
+
+	sign := 1.0
+	switch {
+	case strings.HasPrefix(rest, "RC"):
+		sign = -1
+		rest = rest[2:]
+	case strings.HasPrefix(rest, "RD"):
+		rest = rest[2:]
+	case strings.HasPrefix(rest, "C"):
+		rest = rest[1:]
+	case strings.HasPrefix(rest, "D"):
+		sign = -1
+		rest = rest[1:]
+	default:
+		return nil, errors.New("tanda debit credit mt940 tidak valid")
+	}
+
+	// funds code opsional
+	if len(rest) > 0 && rest[0] >= 'A' && rest[0] <= 'Z' {
+		rest = rest[1:]
+	}
+
+	amount, rest, err := mt940Amount(rest)
+	if err != nil {
+		return nil, err
+	}
+
+	// kode tipe transaksi 4 karakter
+	if len(rest) >= 4 {
+		rest = rest[4:]
+	}
+	ref, bankRef, _ := strings.Cut(rest, "//")
+	if ref == "NONREF" || ref == "" {
+		ref = bankRef
+	}
+
+	return &ParsedLine{
+		Date:      date,
+		Amount:    sign * amount,
+		Reference: strings.TrimSpace(ref),
+	}, nil
+}
+
+// parseMT940Balance format {C|D}YYMMDD CCY amount
+func parseMT940Balance(value string) (float64, error) {
+	if len(value) < 11 {
+		return 0, errors.New("saldo mt940 tidak valid")
+	}
+
+	amount, _, err := mt940Amount(value[10:])
+	if value[0] == 'D' {
+		amount = -amount
+	}
+	return amount, err
+}
+
+func isDigits(value string) bool {
+	for _, c := range value {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,310 @@
+package bank_statement
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Format string
+
+const (
+	FormatBCA     Format = "bca"
+	FormatBNI     Format = "bni"
+	FormatBRI     Format = "bri"
+	FormatMandiri Format = "mandiri"
+	FormatMT940   Format = "mt940"
+)
+
+var ErrUnknownFormat = errors.New("format rekening koran tidak dikenal")
+
+// ParsedLine baris mutasi hasil parse file bank, Date tanggal lokal tanpa jam
+type ParsedLine struct {
+	Line      int
+	Date      time.Time
+	Amount    float64
+	Balance   *float64
+	Desc      string
+	Reference string
+}
+
+// csvLayout nama header kolom per bank, kolom yang cocok lebih dari satu digabung
+type csvLayout struct {
+	date    []string
+	desc    []string
+	ref     []string
+	balance []string
+	// jumlah dengan akhiran CR / DB
+	amount []string
+	debit  []string
+	credit []string
+
+	dateLayouts []string
+}
+
+var csvLayouts = map[Format]*csvLayout{
+	// export csv klikbca bisnis
+	FormatBCA: {
+		date:        []string{"tanggal transaksi", "tanggal"},
+		desc:        []string{"keterangan"},
+		balance:     []string{"saldo"},
+		amount:      []string{"jumlah", "mutasi"},
+		dateLayouts: []string{"02/01/2006", "02/01/06"},
+	},
+	// bni direct
+	FormatBNI: {
+		date:        []string{"post date", "tanggal transaksi", "tgl transaksi"},
+		desc:        []string{"description", "uraian transaksi", "keterangan"},
+		ref:         []string{"journal no", "no jurnal"},
+		balance:     []string{"balance", "saldo"},
+		debit:       []string{"debit", "debet"},
+		credit:      []string{"credit", "kredit"},
+		dateLayouts: []string{"02/01/2006 15:04:05", "02/01/2006", "02-Jan-06", "2006-01-02"},
+	},
+	// bri cms
+	FormatBRI: {
+		date:        []string{"tgl_tran", "tanggal transaksi", "tanggal"},
+		desc:        []string{"desk_tran", "uraian transaksi", "keterangan"},
+		ref:         []string{"no_ref", "referensi"},
+		balance:     []string{"saldo_akhir_mutasi", "saldo"},
+		debit:       []string{"mutasi_debet", "debet"},
+		credit:      []string{"mutasi_kredit", "kredit"},
+		dateLayouts: []string{"2006-01-02 15:04:05", "2006-01-02", "02/01/2006", "02/01/06"},
+	},
+	// mandiri mcm
+	FormatMandiri: {
+		date:        []string{"date", "tanggal"},
+		desc:        []string{"description", "keterangan"},
+		ref:         []string{"reference no", "no referensi"},
+		balance:     []string{"balance", "saldo"},
+		debit:       []string{"debit", "debet"},
+		credit:      []string{"credit", "kredit"},
+		dateLayouts: []string{"02/01/06", "02/01/2006", "02 Jan 2006"},
+	},
+}
+
+// Parse membaca isi file rekening koran sesuai format bank
+func Parse(format Format, data []byte) ([]*ParsedLine, error) {
+	if format == FormatMT940 {
+		return parseMT940(data)
+	}
+
+	layout := csvLayouts[format]
+	if layout == nil {
+		return nil, ErrUnknownFormat
+	}
+	return layout.parse(data)
+}
+
+func normalizeHeader(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	name = strings.ReplaceAll(name, ".", "")
+	return strings.Join(strings.Fields(name), " ")
+}
+
+func findColumns(header []string, names []string) []int {
+	for _, name := range names {
+		cols := []int{}
+		for i, col := range header {
+			if normalizeHeader(col) == name {
+				cols = append(cols, i)
+			}
+		}
+		if len(cols) > 0 {
+			return cols
+		}
+	}
+	return []int{}
+}
+
+func cellValue(record []string, cols []int) string {
+	values := []string{}
+	for _, col := range cols {
+		if col < len(record) {
+			value := strings.TrimSpace(record[col])
+			if value != "" {
+				values = append(values, value)
+			}
+		}
+	}
+	return strings.Join(values, " ")
+}
+
+func csvDelimiter(data []byte) rune {
+	line, _, _ := bytes.Cut(data, []byte("\n"))
+	if bytes.Count(line, []byte(";")) > bytes.Count(line, []byte(",")) {
+		return ';'
+	}
+	return ','
+}
+
+func (l *csvLayout) parseDate(value string) (time.Time, bool) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "'")
+	for _, layout := range l.dateLayouts {
+		t, err := time.Parse(layout, value)
+		if err == nil {
+			return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC), true
+		}
+	}
+	return time.Time{}, false
+}
+
+func (l *csvLayout) parse(data []byte) ([]*ParsedLine, error) {
+	result := []*ParsedLine{}
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comma = csvDelimiter(data)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return result, err
+	}
+
+	var dateCols, descCols, refCols, balanceCols, amountCols, debitCols, creditCols []int
+	headerFound := false
+
+	for i, record := range records {
+		// baris sebelum header berisi info rekening dan periode
+		if !headerFound {
+			dateCols = findColumns(record, l.date)
+			descCols = findColumns(record, l.desc)
+			if len(dateCols) == 0 || len(descCols) == 0 {
+				continue
+			}
+
+			headerFound = true
+			refCols = findColumns(record, l.ref)
+			balanceCols = findColumns(record, l.balance)
+			amountCols = findColumns(record, l.amount)
+			debitCols = findColumns(record, l.debit)
+			creditCols = findColumns(record, l.credit)
+			if len(amountCols) == 0 && (len(debitCols) == 0 || len(creditCols) == 0) {
+				return result, errors.New("kolom nominal tidak ditemukan")
+			}
+			continue
+		}
+
+		// baris footer seperti saldo awal dan total mutasi tidak punya tanggal
+		date, ok := l.parseDate(cellValue(record, dateCols[:1]))
+		if !ok {
+			continue
+		}
+
+		line := ParsedLine{
+			Line:      i + 1,
+			Date:      date,
+			Desc:      cellValue(record, descCols),
+			Reference: cellValue(record, refCols),
+		}
+
+		if len(amountCols) > 0 {
+			line.Amount, err = parseSignedAmount(cellValue(record, amountCols))
+		} else {
+			var debit, credit float64
+			debit, err = parseBankAmount(cellValue(record, debitCols))
+			if err == nil {
+				credit, err = parseBankAmount(cellValue(record, creditCols))
+			}
+			line.Amount = credit - debit
+		}
+		if err != nil {
+			return result, fmt.Errorf("baris %d: %w", line.Line, err)
+		}
+
+		if balance := cellValue(record, balanceCols); balance != "" {
+			value, err := parseSignedAmount(balance)
+			if err != nil {
+				return result, fmt.Errorf("baris %d: %w", line.Line, err)
+			}
+			line.Balance = &value
+		}
+
+		if line.Amount == 0 {
+			continue
+		}
+		result = append(result, &line)
+	}
+
+	if !headerFound {
+		return result, errors.New("header rekening koran tidak ditemukan")
+	}
+
+	return result, nil
+}
+
+// parseSignedAmount nominal dengan tanda di akhir, CR / K uang masuk dan DB / D uang keluar
+func parseSignedAmount(value string) (float64, error) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	sign := 1.0
+	for _, suffix := range []string{"CR", "DB", "K", "D", "C"} {
+		if strings.HasSuffix(value, suffix) {
+			if suffix == "DB" || suffix == "D" {
+				sign = -1
+			}
+			value = strings.TrimSpace(strings.TrimSuffix(value, suffix))
+			break
+		}
+	}
+
+	amount, err := parseBankAmount(value)
+	return sign * amount, err
+}
+
+// parseBankAmount nominal format 1,500,000.00 atau 1.500.000,00
+func parseBankAmount(value string) (float64, error) {
+	raw := value
+	value = strings.TrimSpace(value)
+	value = strings.TrimPrefix(strings.TrimPrefix(value, "Rp"), "IDR")
+	value = strings.TrimSpace(value)
+	if value == "" || value == "-" {
+		return 0, nil
+	}
+
+	negative := false
+	if strings.HasPrefix(value, "(") && strings.HasSuffix(value, ")") {
+		negative = true
+		value = strings.Trim(value, "()")
+	}
+	if strings.HasPrefix(value, "-") {
+		negative = true
+		value = strings.TrimPrefix(value, "-")
+	}
+
+	lastDot := strings.LastIndex(value, ".")
+	lastComma := strings.LastIndex(value, ",")
+
+	switch {
+	case lastDot >= 0 && lastComma >= 0:
+		if lastComma > lastDot {
+			value = strings.ReplaceAll(value, ".", "")
+			value = strings.Replace(value, ",", ".", 1)
+		} else {
+			value = strings.ReplaceAll(value, ",", "")
+		}
+	case lastComma >= 0:
+		if strings.Count(value, ",") == 1 && len(value)-lastComma-1 <= 2 {
+			value = strings.Replace(value, ",", ".", 1)
+		} else {
+			value = strings.ReplaceAll(value, ",", "")
+		}
+	case lastDot >= 0:
+		if strings.Count(value, ".") > 1 || len(value)-lastDot-1 == 3 {
+			value = strings.ReplaceAll(value, ".", "")
+		}
+	}
+
+	amount, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("nominal %s tidak valid", raw)
+	}
+	if negative {
+		amount = -amount
+	}
+	return amount, nil
+}
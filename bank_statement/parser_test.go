@@ -0,0 +1,114 @@
+package bank_statement_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pdcgo/accounting_service/bank_statement"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	day := func(d int) time.Time {
+		return time.Date(2025, 10, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	t.Run("bca", func(t *testing.T) {
+		data := `Informasi Rekening - Mutasi Rekening
+No. rekening : ,'1234567890
+Tanggal Transaksi,Keterangan,Cabang,Jumlah,Saldo
+'01/10/2025,TRSF E-BANKING CR 0110/FTSCY transfer toko,0000,"200,000.00 CR","1,200,000.00"
+'02/10/2025,BIAYA ADM,0000,"15,000.00 DB","1,185,000.00"
+Saldo Awal,"1,000,000.00"
+Mutasi Debet,"15,000.00"
+`
+		lines, err := bank_statement.Parse(bank_statement.FormatBCA, []byte(data))
+		assert.Nil(t, err)
+		assert.Len(t, lines, 2)
+
+		assert.Equal(t, day(1), lines[0].Date)
+		assert.Equal(t, 200000.0, lines[0].Amount)
+		assert.Equal(t, 1200000.0, *lines[0].Balance)
+		assert.Equal(t, "TRSF E-BANKING CR 0110/FTSCY transfer toko", lines[0].Desc)
+		assert.Equal(t, 4, lines[0].Line)
+
+		assert.Equal(t, -15000.0, lines[1].Amount)
+	})
+
+	t.Run("bni debit credit", func(t *testing.T) {
+		data := `Post Date;Value Date;Branch;Journal No.;Description;Debit;Credit;Balance
+01/10/2025 08:10:11;01/10/2025;0001;123;TRANSFER DARI BUDI;0,00;250.000,00;1.250.000,00
+02/10/2025 09:00:00;02/10/2025;0001;124;PEMBAYARAN SUPPLIER;100.000,50;0,00;1.149.999,50
+`
+		lines, err := bank_statement.Parse(bank_statement.FormatBNI, []byte(data))
+		assert.Nil(t, err)
+		assert.Len(t, lines, 2)
+		assert.Equal(t, 250000.0, lines[0].Amount)
+		assert.Equal(t, "123", lines[0].Reference)
+		assert.Equal(t, -100000.5, lines[1].Amount)
+		assert.Equal(t, 1149999.5, *lines[1].Balance)
+	})
+
+	t.Run("bri", func(t *testing.T) {
+		data := `NOREK,TGL_TRAN,DESK_TRAN,MUTASI_DEBET,MUTASI_KREDIT,SALDO_AKHIR_MUTASI,NO_REF
+0001,2025-10-03 10:00:00,SETOR TUNAI,0,500000,1500000,REF1
+`
+		lines, err := bank_statement.Parse(bank_statement.FormatBRI, []byte(data))
+		assert.Nil(t, err)
+		assert.Len(t, lines, 1)
+		assert.Equal(t, day(3), lines[0].Date)
+		assert.Equal(t, 500000.0, lines[0].Amount)
+		assert.Equal(t, "REF1", lines[0].Reference)
+	})
+
+	t.Run("mandiri", func(t *testing.T) {
+		data := `Account No,Date,Val. Date,Transaction Code,Description,Description,Reference No.,Debit,Credit,Balance
+1230001,04/10/25,04/10/25,100,TRANSFER,KE SUPPLIER,FT001,"75,000.00",0.00,"925,000.00"
+`
+		lines, err := bank_statement.Parse(bank_statement.FormatMandiri, []byte(data))
+		assert.Nil(t, err)
+		assert.Len(t, lines, 1)
+		assert.Equal(t, day(4), lines[0].Date)
+		assert.Equal(t, -75000.0, lines[0].Amount)
+		assert.Equal(t, "TRANSFER KE SUPPLIER", lines[0].Desc)
+		assert.Equal(t, "FT001", lines[0].Reference)
+	})
+
+	t.Run("mt940", func(t *testing.T) {
+		data := `:20:STMT251001
+:25:1234567890
+:28C:00001/001
+:60F:C251001IDR1000000,00
+:61:2510011001C200000,00NTRFNONREF//FT123
+:86:TRANSFER DARI
+ TOKO MAJU
+:61:251002D15000,NCHGADM01
+:86:BIAYA ADMIN
+:62F:C251002IDR1185000,00
+-
+`
+		lines, err := bank_statement.Parse(bank_statement.FormatMT940, []byte(data))
+		assert.Nil(t, err)
+		assert.Len(t, lines, 2)
+
+		assert.Equal(t, day(1), lines[0].Date)
+		assert.Equal(t, 200000.0, lines[0].Amount)
+		assert.Equal(t, "FT123", lines[0].Reference)
+		assert.Equal(t, "TRANSFER DARI TOKO MAJU", lines[0].Desc)
+		assert.Nil(t, lines[0].Balance)
+
+		assert.Equal(t, -15000.0, lines[1].Amount)
+		assert.Equal(t, "ADM01", lines[1].Reference)
+		assert.Equal(t, 1185000.0, *lines[1].Balance)
+	})
+
+	t.Run("format tidak dikenal", func(t *testing.T) {
+		_, err := bank_statement.Parse("bsi", []byte("a,b"))
+		assert.ErrorIs(t, err, bank_statement.ErrUnknownFormat)
+	})
+
+	t.Run("header tidak ada", func(t *testing.T) {
+		_, err := bank_statement.Parse(bank_statement.FormatBCA, []byte("a,b\n1,2\n"))
+		assert.NotNil(t, err)
+	})
+}
@@ -0,0 +1,639 @@
+package bank_statement
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/pdcgo/accounting_service/account"
+	"github.com/pdcgo/accounting_service/accounting_core"
+	"github.com/pdcgo/accounting_service/accounting_model"
+	"github.com/pdcgo/accounting_service/query_dialect"
+	"github.com/pdcgo/schema/services/accounting_iface/v1"
+	"github.com/pdcgo/shared/interfaces/authorization_iface"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type reconBuilder struct {
+	db      *gorm.DB
+	bank    *accounting_model.BankAccountV2
+	session *ReconSession
+}
+
+func roundAmount(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// openingBalance saldo akhir sesi sebelumnya, kalau belum ada dihitung dari saldo baris pertama
+func (r *reconBuilder) openingBalance(lines []*StatementLine) (float64, error) {
+	var prev ReconSession
+	err := r.
+		db.
+		Model(&ReconSession{}).
+		Where("bank_account_id = ?", r.bank.ID).
+		Where("status = ?", ReconFinished).
+		Where("period_end <= ?", r.session.PeriodStart).
+		Order("period_end desc").
+		Limit(1).
+		Find(&prev).
+		Error
+	if err != nil {
+		return 0, err
+	}
+	if prev.ID != 0 {
+		return prev.StatementBalance, nil
+	}
+
+	if len(lines) > 0 && lines[0].Balance != nil {
+		return *lines[0].Balance - lines[0].Amount, nil
+	}
+	return 0, nil
+}
+
+func (r *reconBuilder) build() (*ReconReport, error) {
+	var err error
+
+	report := ReconReport{
+		Matched:        []*ReconMatchedItem{},
+		UnmatchedLines: []*StatementLine{},
+		UnmatchedBook:  []*ReconBookItem{},
+	}
+
+	lines := []*StatementLine{}
+	err = r.
+		db.
+		Model(&StatementLine{}).
+		Where("bank_account_id = ?", r.bank.ID).
+		Where("tx_date >= ? AND tx_date < ?", r.session.PeriodStart, r.session.PeriodEnd).
+		Order("tx_date asc, id asc").
+		Find(&lines).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	report.OpeningBalance, err = r.openingBalance(lines)
+	if err != nil {
+		return nil, err
+	}
+
+	lineIDs := make([]uint, len(lines))
+	for i, line := range lines {
+		lineIDs[i] = line.ID
+	}
+
+	matches := []*StatementMatch{}
+	err = r.
+		db.
+		Model(&StatementMatch{}).
+		Where("line_id IN ?", lineIDs).
+		Where("status = ?", MatchConfirmed).
+		Find(&matches).
+		Error
+	if err != nil {
+		return nil, err
+	}
+	lineMatch := map[uint]*StatementMatch{}
+	for _, match := range matches {
+		lineMatch[match.LineID] = match
+	}
+
+	report.StatementBalance = report.OpeningBalance
+	for _, line := range lines {
+		report.StatementBalance += line.Amount
+		if line.Balance != nil {
+			report.StatementBalance = *line.Balance
+		}
+
+		match := lineMatch[line.ID]
+		if match == nil {
+			report.UnmatchedLines = append(report.UnmatchedLines, line)
+			continue
+		}
+
+		report.Matched = append(report.Matched, &ReconMatchedItem{
+			Line:  line,
+			Match: match,
+		})
+	}
+
+	// transaksi buku di periode yang belum dipasangkan ke baris mutasi manapun
+	mt := newMatcher(r.db, r.bank)
+	transfers, err := mt.transferCandidates(r.session.PeriodStart, r.session.PeriodEnd)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := mt.entryCandidates(r.session.PeriodStart, r.session.PeriodEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	transferIDs := []uint{}
+	for _, cand := range transfers {
+		transferIDs = append(transferIDs, cand.targetID)
+	}
+	entryIDs := []uint{}
+	for _, cand := range entries {
+		entryIDs = append(entryIDs, cand.targetID)
+	}
+
+	confirmed := []*StatementMatch{}
+	err = r.
+		db.
+		Model(&StatementMatch{}).
+		Where("status = ?", MatchConfirmed).
+		Where(
+			"(target_type = ? AND target_id IN ?) OR (target_type = ? AND target_id IN ?)",
+			TargetTransfer, transferIDs,
+			TargetEntry, entryIDs,
+		).
+		Find(&confirmed).
+		Error
+	if err != nil {
+		return nil, err
+	}
+	confirmedKeys := map[targetKey]bool{}
+	for _, match := range confirmed {
+		confirmedKeys[targetKey{match.TargetType, match.TargetID}] = true
+	}
+
+	for _, cand := range append(transfers, entries...) {
+		if confirmedKeys[cand.key()] {
+			continue
+		}
+
+		// journal entry kas yang belum tercatat di bank account ini tidak ada di saldo buku
+		if cand.inLedger {
+			report.Outstanding += cand.amount
+		}
+		report.UnmatchedBook = append(report.UnmatchedBook, &ReconBookItem{
+			TargetType:    cand.targetType,
+			TargetID:      cand.targetID,
+			TransactionID: cand.transactionID,
+			At:            cand.at,
+			Amount:        cand.amount,
+			Desc:          cand.desc,
+		})
+	}
+
+	// saldo buku dari dimensi bank di ledger sampai akhir periode
+	books, err := account.BankLedgerBalance(r.db, []uint{r.bank.ID}, r.session.PeriodEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	report.BookBalance = roundAmount(books[r.bank.ID])
+	report.StatementBalance = roundAmount(report.StatementBalance)
+	report.Outstanding = roundAmount(report.Outstanding)
+	report.Difference = roundAmount(report.StatementBalance - report.BookBalance)
+	report.AdjustedDifference = roundAmount(report.StatementBalance + report.Outstanding - report.BookBalance)
+
+	return &report, nil
+}
+
+// snapshot saldo laporan disimpan di sesi, supaya list sesi tidak perlu hitung ulang
+func (s *ReconSession) snapshot(report *ReconReport) {
+	s.OpeningBalance = report.OpeningBalance
+	s.StatementBalance = report.StatementBalance
+	s.BookBalance = report.BookBalance
+}
+
+// linkEntryBank journal entry kas yang dipasangkan ke baris mutasi dicatat di dimensi bank account baris itu
+func linkEntryBank(tx *gorm.DB, entryID uint, bankID uint) error {
+	var linked int64
+	err := tx.
+		Model(&accounting_core.JournalEntryBank{}).
+		Where("journal_entry_id = ?", entryID).
+		Count(&linked).
+		Error
+	if err != nil || linked > 0 {
+		return err
+	}
+
+	var entry accounting_core.JournalEntry
+	err = tx.First(&entry, entryID).Error
+	if err != nil {
+		return err
+	}
+
+	err = tx.Create(&accounting_core.JournalEntryBank{
+		JournalEntryID: entry.ID,
+		TransactionID:  entry.TransactionID,
+		BankAccountID:  bankID,
+		TeamID:         entry.TeamID,
+		EntryTime:      entry.EntryTime,
+		Debit:          entry.Debit,
+		Credit:         entry.Credit,
+		Desc:           entry.Desc,
+		Rollback:       entry.Rollback,
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	return account.SyncBankBalance(tx, bankID)
+}
+
+// periodLocked baris mutasi di periode sesi yang sudah selesai tidak bisa diubah
+func periodLocked(db *gorm.DB, bankID uint, at time.Time) (bool, error) {
+	var count int64
+	err := db.
+		Model(&ReconSession{}).
+		Where("bank_account_id = ?", bankID).
+		Where("status = ?", ReconFinished).
+		Where("period_start <= ? AND period_end > ?", at, at).
+		Count(&count).
+		Error
+	return count > 0, err
+}
+
+// reconSession ambil sesi beserta bank account dan cek permission
+func (b *bankStatementServiceImpl) reconSession(
+	tx *gorm.DB,
+	identity authorization_iface.AuthIdentity,
+	sessionID uint64,
+	action authorization_iface.Action,
+) (*ReconSession, *accounting_model.BankAccountV2, error) {
+	var session ReconSession
+	err := tx.
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Model(&ReconSession{}).
+		Where("id = ?", sessionID).
+		Find(&session).
+		Error
+	if err != nil {
+		return nil, nil, err
+	}
+	if session.ID == 0 {
+		return nil, nil, connect.NewError(connect.CodeNotFound, fmt.Errorf("reconciliation session %d not found", sessionID))
+	}
+
+	bank, err := bankAccount(tx, identity, uint64(session.BankAccountID), action)
+	return &session, bank, err
+}
+
+// BankReconOpen buka sesi rekonsiliasi, periode tidak boleh bertumpuk dengan sesi lain
+func (b *bankStatementServiceImpl) BankReconOpen(
+	ctx context.Context,
+	req *connect.Request[accounting_iface.BankReconOpenRequest],
+) (*connect.Response[accounting_iface.BankReconResponse], error) {
+	var err error
+	pay := req.Msg
+
+	result := accounting_iface.BankReconResponse{}
+
+	if pay.Start == nil || pay.End == nil || pay.End.AsTime().Before(pay.Start.AsTime()) {
+		return connect.NewResponse(&result), connect.NewError(connect.CodeInvalidArgument, errors.New("invalid reconciliation period"))
+	}
+
+	identity := b.auth.AuthIdentityFromHeader(req.Header())
+	agent := identity.Identity()
+
+	err = b.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		bank, err := bankAccount(tx, identity, pay.BankAccountId, authorization_iface.Update)
+		if err != nil {
+			return err
+		}
+
+		session := ReconSession{
+			BankAccountID: bank.ID,
+			TeamID:        bank.TeamID,
+			PeriodStart:   query_dialect.ReportDayStart(query_dialect.ReportDay(pay.Start.AsTime())),
+			PeriodEnd:     query_dialect.ReportDayStart(query_dialect.ReportDay(pay.End.AsTime()).AddDate(0, 0, 1)),
+			Status:        ReconOpen,
+			CreatedByID:   agent.IdentityID(),
+			CreatedAt:     time.Now(),
+		}
+
+		var overlap int64
+		err = tx.
+			Model(&ReconSession{}).
+			Where("bank_account_id = ?", bank.ID).
+			Where("period_start < ? AND period_end > ?", session.PeriodEnd, session.PeriodStart).
+			Count(&overlap).
+			Error
+		if err != nil {
+			return err
+		}
+		if overlap > 0 {
+			return connect.NewError(connect.CodeAlreadyExists, errors.New("reconciliation period overlaps with other session"))
+		}
+
+		builder := reconBuilder{db: tx, bank: bank, session: &session}
+		report, err := builder.build()
+		if err != nil {
+			return err
+		}
+
+		session.snapshot(report)
+		err = tx.Create(&session).Error
+		if err != nil {
+			return err
+		}
+
+		result.Session = session.proto()
+		result.Report = report.proto()
+		return nil
+	})
+
+	return connect.NewResponse(&result), err
+}
+
+// BankReconGet sesi yang masih open dihitung ulang, sesi selesai pakai laporan tersimpan
+func (b *bankStatementServiceImpl) BankReconGet(
+	ctx context.Context,
+	req *connect.Request[accounting_iface.BankReconGetRequest],
+) (*connect.Response[accounting_iface.BankReconResponse], error) {
+	var err error
+	pay := req.Msg
+
+	result := accounting_iface.BankReconResponse{}
+
+	identity := b.auth.AuthIdentityFromHeader(req.Header())
+
+	err = b.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		session, bank, err := b.reconSession(tx, identity, pay.SessionId, authorization_iface.Read)
+		if err != nil {
+			return err
+		}
+
+		if session.Status == ReconFinished {
+			result.Session = session.proto()
+			result.Report = session.Report.proto()
+			return nil
+		}
+
+		builder := reconBuilder{db: tx, bank: bank, session: session}
+		report, err := builder.build()
+		if err != nil {
+			return err
+		}
+
+		session.snapshot(report)
+		err = tx.
+			Model(session).
+			Select("opening_balance", "statement_balance", "book_balance").
+			Updates(session).
+			Error
+		if err != nil {
+			return err
+		}
+
+		result.Session = session.proto()
+		result.Report = report.proto()
+		return nil
+	})
+
+	return connect.NewResponse(&result), err
+}
+
+// BankReconAdjust buat journal penyesuaian dari baris mutasi yang tidak ada di buku, biaya bank atau bunga
+func (b *bankStatementServiceImpl) BankReconAdjust(
+	ctx context.Context,
+	req *connect.Request[accounting_iface.BankReconAdjustRequest],
+) (*connect.Response[accounting_iface.BankReconAdjustResponse], error) {
+	var err error
+	pay := req.Msg
+
+	result := accounting_iface.BankReconAdjustResponse{}
+
+	if pay.Kind != accounting_iface.BankReconAdjustKind_BANK_RECON_ADJUST_KIND_BANK_FEE &&
+		pay.Kind != accounting_iface.BankReconAdjustKind_BANK_RECON_ADJUST_KIND_INTEREST {
+		return connect.NewResponse(&result), connect.NewError(connect.CodeInvalidArgument, errors.New("kind must be bank_fee or interest"))
+	}
+
+	identity := b.auth.AuthIdentityFromHeader(req.Header())
+	agent := identity.Identity()
+
+	err = accounting_core.OpenTransaction(ctx, b.db.WithContext(ctx), func(tx *gorm.DB, bookmng accounting_core.BookManage) error {
+		session, bank, err := b.reconSession(tx, identity, pay.SessionId, authorization_iface.Update)
+		if err != nil {
+			return err
+		}
+		if session.Status != ReconOpen {
+			return connect.NewError(connect.CodeFailedPrecondition, errors.New("reconciliation session already finished"))
+		}
+
+		var line StatementLine
+		err = tx.
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Model(&StatementLine{}).
+			Where("id = ?", pay.LineId).
+			Where("bank_account_id = ?", bank.ID).
+			Where("tx_date >= ? AND tx_date < ?", session.PeriodStart, session.PeriodEnd).
+			Find(&line).
+			Error
+		if err != nil {
+			return err
+		}
+		if line.ID == 0 {
+			return connect.NewError(connect.CodeNotFound, fmt.Errorf("statement line %d not in session period", pay.LineId))
+		}
+		if line.Status != LineUnmatched {
+			return connect.NewError(connect.CodeFailedPrecondition, errors.New("statement line already matched"))
+		}
+
+		var key accounting_core.AccountKey
+		switch pay.Kind {
+		case accounting_iface.BankReconAdjustKind_BANK_RECON_ADJUST_KIND_BANK_FEE:
+			if line.Amount >= 0 {
+				return connect.NewError(connect.CodeInvalidArgument, errors.New("bank fee must be outgoing statement line"))
+			}
+			key = accounting_core.BankFeeAccount
+		case accounting_iface.BankReconAdjustKind_BANK_RECON_ADJUST_KIND_INTEREST:
+			if line.Amount <= 0 {
+				return connect.NewError(connect.CodeInvalidArgument, errors.New("interest must be incoming statement line"))
+			}
+			key = accounting_core.OtherRevenueAccount
+		}
+
+		desc := pay.Desc
+		if desc == "" {
+			desc = line.Desc
+		}
+
+		tran := accounting_core.Transaction{
+			RefID: accounting_core.NewStringRefID(&accounting_core.StringRefData{
+				RefType: accounting_core.BankReconAdjustRef,
+				ID:      fmt.Sprintf("%d", line.ID),
+			}),
+			TeamID:      bank.TeamID,
+			CreatedByID: agent.IdentityID(),
+			Desc:        desc,
+			Created:     time.Now(),
+		}
+		err = bookmng.
+			NewTransaction().
+			Create(&tran).
+			Err()
+		if err != nil {
+			return err
+		}
+
+		// kas bertambah untuk bunga, berkurang untuk biaya bank
+		amount := math.Abs(line.Amount)
+		sign := 1.0
+		if pay.Kind == accounting_iface.BankReconAdjustKind_BANK_RECON_ADJUST_KIND_BANK_FEE {
+			sign = -1
+		}
+		err = bookmng.
+			NewCreateEntry(bank.TeamID, agent.IdentityID()).
+			To(&accounting_core.EntryAccountPayload{
+				Key:           accounting_core.CashAccount,
+				TeamID:        bank.TeamID,
+				BankAccountID: bank.ID,
+			}, sign*amount).
+			To(&accounting_core.EntryAccountPayload{
+				Key:    key,
+				TeamID: bank.TeamID,
+			}, amount).
+			Transaction(&tran).
+			Desc(desc).
+			Commit(accounting_core.CustomTimeOption(line.TxDate)).
+			Err()
+		if err != nil {
+			return err
+		}
+
+		err = account.SyncBankBalance(tx, bank.ID)
+		if err != nil {
+			return err
+		}
+
+		var cashEntry accounting_core.JournalEntry
+		err = tx.
+			Model(&accounting_core.JournalEntry{}).
+			Joins("join accounts a on a.id = journal_entries.account_id").
+			Where("journal_entries.transaction_id = ?", tran.ID).
+			Where("a.account_key = ?", accounting_core.CashAccount).
+			First(&cashEntry).
+			Error
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		match := StatementMatch{
+			LineID:        line.ID,
+			TargetType:    TargetEntry,
+			TargetID:      cashEntry.ID,
+			TransactionID: tran.ID,
+			TargetAt:      cashEntry.EntryTime,
+			TargetAmount:  line.Amount,
+			TargetDesc:    desc,
+			Confidence:    1,
+			Status:        MatchConfirmed,
+			DecidedByID:   agent.IdentityID(),
+			DecidedAt:     &now,
+			CreatedAt:     now,
+		}
+
+		err = tx.
+			Where("line_id = ?", line.ID).
+			Where("status = ?", MatchSuggested).
+			Delete(&StatementMatch{}).
+			Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Create(&match).Error
+		if err != nil {
+			return err
+		}
+
+		line.Status = LineMatched
+		err = tx.Save(&line).Error
+		if err != nil {
+			return err
+		}
+
+		result.Transaction = &accounting_iface.Transaction{
+			Id:          uint64(tran.ID),
+			RefId:       string(tran.RefID),
+			TeamId:      uint64(tran.TeamID),
+			CreatedById: uint64(tran.CreatedByID),
+			Desc:        tran.Desc,
+			Created:     timestamppb.New(tran.Created),
+		}
+		result.Match = match.proto()
+		return nil
+	})
+
+	return connect.NewResponse(&result), err
+}
+
+// BankReconFinish selesaikan sesi, semua baris mutasi harus sudah match dan saldo cocok.
+// transaksi yang sudah direkonsiliasi dikunci dan laporan disimpan
+func (b *bankStatementServiceImpl) BankReconFinish(
+	ctx context.Context,
+	req *connect.Request[accounting_iface.BankReconFinishRequest],
+) (*connect.Response[accounting_iface.BankReconResponse], error) {
+	var err error
+	pay := req.Msg
+
+	result := accounting_iface.BankReconResponse{}
+
+	identity := b.auth.AuthIdentityFromHeader(req.Header())
+	agent := identity.Identity()
+
+	err = b.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		session, bank, err := b.reconSession(tx, identity, pay.SessionId, authorization_iface.Update)
+		if err != nil {
+			return err
+		}
+		if session.Status != ReconOpen {
+			return connect.NewError(connect.CodeFailedPrecondition, errors.New("reconciliation session already finished"))
+		}
+
+		builder := reconBuilder{db: tx, bank: bank, session: session}
+		report, err := builder.build()
+		if err != nil {
+			return err
+		}
+
+		if len(report.UnmatchedLines) > 0 {
+			return connect.NewError(connect.CodeFailedPrecondition, fmt.Errorf("%d statement lines not matched", len(report.UnmatchedLines)))
+		}
+		if report.AdjustedDifference != 0 {
+			return connect.NewError(connect.CodeFailedPrecondition, fmt.Errorf("statement and book balance differ %.2f", report.AdjustedDifference))
+		}
+
+		tranIDs := []uint{}
+		for _, item := range report.Matched {
+			tranIDs = append(tranIDs, item.Match.TransactionID)
+		}
+		if len(tranIDs) > 0 {
+			err = tx.
+				Model(&accounting_core.Transaction{}).
+				Where("id IN ?", tranIDs).
+				Update("locked", true).
+				Error
+			if err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		session.Status = ReconFinished
+		session.snapshot(report)
+		session.FinishedByID = agent.IdentityID()
+		session.FinishedAt = &now
+		session.Report = report
+		err = tx.Save(session).Error
+		if err != nil {
+			return err
+		}
+
+		result.Session = session.proto()
+		result.Report = report.proto()
+		return nil
+	})
+
+	return connect.NewResponse(&result), err
+}
@@ -0,0 +1,261 @@
+package bank_statement_test
+
+import (
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/pdcgo/accounting_service/accounting_core"
+	"github.com/pdcgo/accounting_service/accounting_mock"
+	"github.com/pdcgo/accounting_service/accounting_model"
+	"github.com/pdcgo/accounting_service/bank_statement"
+	"github.com/pdcgo/accounting_service/query_dialect"
+	"github.com/pdcgo/schema/services/accounting_iface/v1"
+	"github.com/pdcgo/schema/services/accounting_iface/v1/accounting_ifaceconnect"
+	"github.com/pdcgo/shared/pkg/moretest"
+	"github.com/pdcgo/shared/pkg/moretest/moretest_mock"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/gorm"
+)
+
+const reconStatement = `Tanggal Transaksi,Keterangan,Cabang,Jumlah,Saldo
+'01/10/2025,TRSF E-BANKING CR TOKO MAJU,0000,"200,000.00 CR","1,200,000.00"
+'02/10/2025,TRSF E-BANKING DB BAYAR SUPPLIER,0000,"500,000.00 DB","700,000.00"
+'02/10/2025,BIAYA ADM,0000,"15,000.00 DB","685,000.00"
+'03/10/2025,BUNGA,0000,"1,250.00 CR","686,250.00"
+`
+
+func TestBankRecon(t *testing.T) {
+	var db gorm.DB
+
+	at := func(day, hour int) time.Time {
+		return time.Date(2025, 10, day, hour, 0, 0, 0, query_dialect.ReportLocation())
+	}
+
+	moretest.Suite(t, "testing rekonsiliasi bank",
+		moretest.SetupListFunc{
+			moretest_mock.MockSqliteDatabase(&db),
+			func(t *testing.T) func() error {
+				err := db.AutoMigrate(
+					&accounting_core.Account{},
+					&accounting_core.Transaction{},
+					&accounting_core.JournalEntry{},
+					&accounting_core.JournalEntryBank{},
+					&accounting_model.BankAccountV2{},
+					&accounting_model.BankTransferHistory{},
+					&bank_statement.StatementLine{},
+					&bank_statement.StatementMatch{},
+					&bank_statement.ReconSession{},
+				)
+				assert.Nil(t, err)
+
+				err = db.Create([]*accounting_model.BankAccountV2{
+					{ID: 1, TeamID: 5, Name: "bca operasional", NumberID: "1234567890"},
+					{ID: 2, TeamID: 5, Name: "bca supplier", NumberID: "1234567891"},
+				}).Error
+				assert.Nil(t, err)
+
+				err = db.Create([]*accounting_model.BankTransferHistory{
+					{
+						TxID:          999,
+						TeamID:        5,
+						FromAccountID: 1,
+						ToAccountID:   2,
+						Amount:        497500,
+						FeeAmount:     2500,
+						Desc:          "bayar supplier",
+						Created:       at(2, 14),
+					},
+					{
+						// belum masuk rekening koran
+						TxID:          998,
+						TeamID:        5,
+						FromAccountID: 2,
+						ToAccountID:   1,
+						Amount:        100000,
+						Desc:          "pindah dana",
+						Created:       at(3, 20),
+					},
+				}).Error
+				assert.Nil(t, err)
+
+				// dimensi bank dari saldo awal dan transfer di atas
+				err = db.Create([]*accounting_core.JournalEntryBank{
+					{BankAccountID: 1, TeamID: 5, EntryTime: at(1, 0).AddDate(0, 0, -1), Debit: 1000000, Desc: "saldo awal"},
+					{TransactionID: 999, BankAccountID: 1, TeamID: 5, EntryTime: at(2, 14), Credit: 500000},
+					{TransactionID: 998, BankAccountID: 1, TeamID: 5, EntryTime: at(3, 20), Debit: 100000},
+				}).Error
+				assert.Nil(t, err)
+				return nil
+			},
+			accounting_mock.PopulateAccountKey(&db, 5),
+		},
+		func(t *testing.T) {
+			createCashEntry(t, &db, "toko-maju", 200000, at(1, 10), "transfer toko maju")
+			createCashEntry(t, &db, "setoran-lain", 50000, at(2, 10), "setoran lain")
+
+			server := newBankStatementServer(&db)
+			defer server.Close()
+
+			client := accounting_ifaceconnect.NewBankStatementServiceClient(server.Client(), server.URL)
+
+			_, err := uploadStatement(t, client, 1, accounting_iface.BankStatementFormat_BANK_STATEMENT_FORMAT_BCA, reconStatement)
+			assert.Nil(t, err)
+
+			lines, err := client.BankStatementLineList(t.Context(), connect.NewRequest(&accounting_iface.BankStatementLineListRequest{
+				BankAccountId: 1,
+			}))
+			assert.Nil(t, err)
+			assert.Len(t, lines.Msg.Data, 4)
+
+			for _, item := range lines.Msg.Data[:2] {
+				_, err := client.BankStatementMatchDecide(t.Context(), connect.NewRequest(&accounting_iface.BankStatementMatchDecideRequest{
+					MatchId: item.Matches[0].Id,
+					Action:  accounting_iface.StatementMatchAction_STATEMENT_MATCH_ACTION_CONFIRM,
+				}))
+				assert.Nil(t, err)
+			}
+			feeLine := lines.Msg.Data[2].Line
+			interestLine := lines.Msg.Data[3].Line
+
+			var session *accounting_iface.BankReconSession
+
+			t.Run("buka sesi", func(t *testing.T) {
+				res, err := client.BankReconOpen(t.Context(), connect.NewRequest(&accounting_iface.BankReconOpenRequest{
+					BankAccountId: 1,
+					Start:         timestamppb.New(at(1, 0)),
+					End:           timestamppb.New(at(3, 0)),
+				}))
+				assert.Nil(t, err)
+				session = res.Msg.Session
+
+				report := res.Msg.Report
+				assert.Equal(t, 1000000.0, report.OpeningBalance)
+				assert.Equal(t, 686250.0, report.StatementBalance)
+				assert.Equal(t, 100000.0, report.Outstanding)
+				assert.Equal(t, 800000.0, report.BookBalance)
+				assert.Equal(t, -13750.0, report.AdjustedDifference)
+				assert.Len(t, report.Matched, 2)
+				assert.Len(t, report.UnmatchedLines, 2)
+				assert.Len(t, report.UnmatchedBook, 2)
+			})
+
+			t.Run("periode bertumpuk", func(t *testing.T) {
+				_, err := client.BankReconOpen(t.Context(), connect.NewRequest(&accounting_iface.BankReconOpenRequest{
+					BankAccountId: 1,
+					Start:         timestamppb.New(at(3, 0)),
+					End:           timestamppb.New(at(5, 0)),
+				}))
+				assert.Equal(t, connect.CodeAlreadyExists, connect.CodeOf(err))
+			})
+
+			t.Run("belum bisa selesai", func(t *testing.T) {
+				_, err := client.BankReconFinish(t.Context(), connect.NewRequest(&accounting_iface.BankReconFinishRequest{
+					SessionId: session.Id,
+				}))
+				assert.Equal(t, connect.CodeFailedPrecondition, connect.CodeOf(err))
+			})
+
+			adjust := func(t *testing.T, lineID uint64, kind accounting_iface.BankReconAdjustKind) (*accounting_iface.BankReconAdjustResponse, error) {
+				res, err := client.BankReconAdjust(t.Context(), connect.NewRequest(&accounting_iface.BankReconAdjustRequest{
+					SessionId: session.Id,
+					LineId:    lineID,
+					Kind:      kind,
+				}))
+				if err != nil {
+					return nil, err
+				}
+				return res.Msg, nil
+			}
+
+			reconGet := func(t *testing.T) *accounting_iface.BankReconResponse {
+				res, err := client.BankReconGet(t.Context(), connect.NewRequest(&accounting_iface.BankReconGetRequest{
+					SessionId: session.Id,
+				}))
+				assert.Nil(t, err)
+				return res.Msg
+			}
+
+			t.Run("penyesuaian biaya bank dan bunga", func(t *testing.T) {
+				_, err := adjust(t, interestLine.Id, accounting_iface.BankReconAdjustKind_BANK_RECON_ADJUST_KIND_BANK_FEE)
+				assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+
+				res, err := adjust(t, feeLine.Id, accounting_iface.BankReconAdjustKind_BANK_RECON_ADJUST_KIND_BANK_FEE)
+				assert.Nil(t, err)
+				assert.Equal(t, accounting_iface.StatementMatchStatus_STATEMENT_MATCH_STATUS_CONFIRMED, res.Match.Status)
+				assert.Equal(t, -15000.0, res.Match.TargetAmount)
+
+				var fee accounting_core.JournalEntry
+				err = db.
+					Model(&accounting_core.JournalEntry{}).
+					Joins("join accounts a on a.id = journal_entries.account_id").
+					Where("journal_entries.transaction_id = ?", res.Transaction.Id).
+					Where("a.account_key = ?", accounting_core.BankFeeAccount).
+					First(&fee).
+					Error
+				assert.Nil(t, err)
+				assert.Equal(t, 15000.0, fee.Debit)
+
+				_, err = adjust(t, feeLine.Id, accounting_iface.BankReconAdjustKind_BANK_RECON_ADJUST_KIND_BANK_FEE)
+				assert.Equal(t, connect.CodeFailedPrecondition, connect.CodeOf(err))
+
+				_, err = adjust(t, interestLine.Id, accounting_iface.BankReconAdjustKind_BANK_RECON_ADJUST_KIND_INTEREST)
+				assert.Nil(t, err)
+
+				report := reconGet(t).Report
+				assert.Len(t, report.Matched, 4)
+				assert.Empty(t, report.UnmatchedLines)
+				assert.Equal(t, 786250.0, report.BookBalance)
+				assert.Equal(t, 0.0, report.AdjustedDifference)
+
+				// entry yang dipasangkan dan penyesuaian masuk ke saldo bank account
+				var bank accounting_model.BankAccountV2
+				err = db.First(&bank, 1).Error
+				assert.Nil(t, err)
+				assert.Equal(t, 786250.0, bank.Balance)
+			})
+
+			t.Run("selesai dan kunci transaksi", func(t *testing.T) {
+				res, err := client.BankReconFinish(t.Context(), connect.NewRequest(&accounting_iface.BankReconFinishRequest{
+					SessionId: session.Id,
+				}))
+				assert.Nil(t, err)
+				assert.Equal(t, accounting_iface.BankReconStatus_BANK_RECON_STATUS_FINISHED, res.Msg.Session.Status)
+				assert.NotNil(t, res.Msg.Session.FinishedAt)
+
+				ref := accounting_core.NewStringRefID(&accounting_core.StringRefData{
+					RefType: accounting_core.JournalImportRef,
+					ID:      "toko-maju",
+				})
+				err = accounting_core.
+					NewTransactionMutation(t.Context(), &db).
+					ByRefID(ref, false).
+					RollbackEntry(1, "rollback").
+					Err()
+				assert.ErrorIs(t, err, accounting_core.ErrTransactionLocked)
+
+				var unlocked int64
+				db.Model(&accounting_core.Transaction{}).Where("locked = ?", false).Count(&unlocked)
+				assert.Equal(t, int64(1), unlocked)
+
+				saved := reconGet(t)
+				assert.Len(t, saved.Report.Matched, 4)
+				assert.Equal(t, 686250.0, saved.Session.StatementBalance)
+
+				_, err = adjust(t, interestLine.Id, accounting_iface.BankReconAdjustKind_BANK_RECON_ADJUST_KIND_INTEREST)
+				assert.Equal(t, connect.CodeFailedPrecondition, connect.CodeOf(err))
+			})
+
+			t.Run("sesi berikutnya pakai saldo akhir", func(t *testing.T) {
+				res, err := client.BankReconOpen(t.Context(), connect.NewRequest(&accounting_iface.BankReconOpenRequest{
+					BankAccountId: 1,
+					Start:         timestamppb.New(at(4, 0)),
+					End:           timestamppb.New(at(6, 0)),
+				}))
+				assert.Nil(t, err)
+				assert.Equal(t, 686250.0, res.Msg.Report.OpeningBalance)
+			})
+		},
+	)
+}
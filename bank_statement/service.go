@@ -0,0 +1,420 @@
+package bank_statement
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/pdcgo/accounting_service/accounting_model"
+	"github.com/pdcgo/accounting_service/query_dialect"
+	"github.com/pdcgo/schema/services/accounting_iface/v1"
+	"github.com/pdcgo/schema/services/common/v1"
+	"github.com/pdcgo/shared/interfaces/authorization_iface"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultListLimit = 100
+	// ukuran maksimal file rekening koran
+	maxImportSize = 20 * 1024 * 1024
+)
+
+type bankStatementServiceImpl struct {
+	db   *gorm.DB
+	auth authorization_iface.Authorization
+}
+
+// bankAccount ambil bank account sekalian cek permission team pemilik
+func bankAccount(db *gorm.DB, identity authorization_iface.AuthIdentity, bankID uint64, action authorization_iface.Action) (*accounting_model.BankAccountV2, error) {
+	var bank accounting_model.BankAccountV2
+	err := db.
+		Model(&accounting_model.BankAccountV2{}).
+		Where("id = ?", bankID).
+		Where("deleted = ?", false).
+		Find(&bank).
+		Error
+	if err != nil {
+		return nil, err
+	}
+	if bank.ID == 0 {
+		return nil, connect.NewError(connect.CodeNotFound, fmt.Errorf("bank account %d not found", bankID))
+	}
+
+	err = identity.
+		HasPermission(authorization_iface.CheckPermissionGroup{
+			&accounting_model.BankAccountV2{}: &authorization_iface.CheckPermission{
+				DomainID: bank.TeamID,
+				Actions:  []authorization_iface.Action{action},
+			},
+		}).
+		Err()
+
+	return &bank, err
+}
+
+func lineHash(bankID uint, line *ParsedLine, occurrence int) string {
+	balance := ""
+	if line.Balance != nil {
+		balance = fmt.Sprintf("%.2f", *line.Balance)
+	}
+
+	raw := fmt.Sprintf("%d|%s|%.2f|%s|%s|%s|%d",
+		bankID,
+		line.Date.Format("2006-01-02"),
+		line.Amount,
+		balance,
+		line.Desc,
+		line.Reference,
+		occurrence,
+	)
+	sum := sha1.Sum([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+// BankStatementImport import file rekening koran, baris yang sudah ada dilewati lalu dicarikan pasangan
+func (b *bankStatementServiceImpl) BankStatementImport(
+	ctx context.Context,
+	stream *connect.ClientStream[accounting_iface.BankStatementImportRequest],
+) (*connect.Response[accounting_iface.BankStatementImportResponse], error) {
+	var err error
+
+	result := accounting_iface.BankStatementImportResponse{}
+
+	if !stream.Receive() {
+		err = stream.Err()
+		if err == nil {
+			err = connect.NewError(connect.CodeInvalidArgument, errors.New("file rekening koran kosong"))
+		}
+		return connect.NewResponse(&result), err
+	}
+
+	first := stream.Msg()
+	if first.BankAccountId == 0 {
+		return connect.NewResponse(&result), connect.NewError(connect.CodeInvalidArgument, errors.New("bank_account_id required"))
+	}
+
+	db := b.db.WithContext(ctx)
+	identity := b.auth.AuthIdentityFromHeader(stream.RequestHeader())
+	bank, err := bankAccount(db, identity, first.BankAccountId, authorization_iface.Update)
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	data := bytes.NewBuffer(first.Data)
+	for stream.Receive() {
+		data.Write(stream.Msg().Data)
+		if data.Len() > maxImportSize {
+			return connect.NewResponse(&result), connect.NewError(connect.CodeInvalidArgument, errors.New("file rekening koran terlalu besar"))
+		}
+	}
+	if err = stream.Err(); err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	format := formatFromProto(first.Format)
+	parsed, err := Parse(format, data.Bytes())
+	if err != nil {
+		return connect.NewResponse(&result), connect.NewError(connect.CodeInvalidArgument, err)
+	}
+	result.Lines = int64(len(parsed))
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		occurrences := map[string]int{}
+		for _, pline := range parsed {
+			key := lineHash(bank.ID, pline, 0)
+			occurrence := occurrences[key]
+			occurrences[key]++
+
+			line := StatementLine{
+				BankAccountID: bank.ID,
+				TeamID:        bank.TeamID,
+				Format:        format,
+				Hash:          lineHash(bank.ID, pline, occurrence),
+				TxDate:        query_dialect.ReportDayStart(pline.Date),
+				Amount:        pline.Amount,
+				Balance:       pline.Balance,
+				Desc:          pline.Desc,
+				Reference:     pline.Reference,
+				Status:        LineUnmatched,
+				CreatedAt:     time.Now(),
+			}
+
+			res := tx.
+				Clauses(clause.OnConflict{DoNothing: true}).
+				Create(&line)
+			if res.Error != nil {
+				return res.Error
+			}
+
+			if res.RowsAffected == 0 {
+				result.Duplicated++
+			} else {
+				result.Imported++
+			}
+		}
+
+		suggestions, err := newMatcher(tx, bank).run()
+		result.Suggestions = int64(suggestions)
+		return err
+	})
+
+	return connect.NewResponse(&result), err
+}
+
+// BankStatementLineList baris rekening koran beserta saran dan pasangan yang sudah dikonfirmasi
+func (b *bankStatementServiceImpl) BankStatementLineList(
+	ctx context.Context,
+	req *connect.Request[accounting_iface.BankStatementLineListRequest],
+) (*connect.Response[accounting_iface.BankStatementLineListResponse], error) {
+	var err error
+	pay := req.Msg
+
+	result := accounting_iface.BankStatementLineListResponse{
+		Data: []*accounting_iface.StatementLineItem{},
+	}
+
+	db := b.db.WithContext(ctx)
+	identity := b.auth.AuthIdentityFromHeader(req.Header())
+	bank, err := bankAccount(db, identity, pay.BankAccountId, authorization_iface.Read)
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	query := db.
+		Model(&StatementLine{}).
+		Where("bank_account_id = ?", bank.ID)
+
+	if pay.Status != accounting_iface.StatementLineStatus_STATEMENT_LINE_STATUS_UNSPECIFIED {
+		query = query.Where("status = ?", lineStatusFromProto(pay.Status))
+	}
+	if pay.TimeRange != nil {
+		if pay.TimeRange.StartDate != nil {
+			query = query.Where("tx_date >= ?", query_dialect.ReportDayStart(query_dialect.ReportDay(pay.TimeRange.StartDate.AsTime())))
+		}
+		if pay.TimeRange.EndDate != nil {
+			query = query.Where("tx_date < ?", query_dialect.ReportDayStart(query_dialect.ReportDay(pay.TimeRange.EndDate.AsTime()).AddDate(0, 0, 1)))
+		}
+	}
+
+	var total int64
+	err = query.
+		Session(&gorm.Session{}).
+		Count(&total).
+		Error
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	var page, limit int64 = 1, defaultListLimit
+	if pay.Page != nil {
+		page = max(pay.Page.Page, 1)
+		if pay.Page.Limit > 0 {
+			limit = pay.Page.Limit
+		}
+	}
+
+	result.PageInfo = &common.PageInfo{
+		CurrentPage: page,
+		TotalPage:   max((total+limit-1)/limit, 1),
+		TotalItems:  total,
+	}
+
+	lines := []*StatementLine{}
+	err = query.
+		Order("tx_date asc, id asc").
+		Offset(int((page - 1) * limit)).
+		Limit(int(limit)).
+		Find(&lines).
+		Error
+	if err != nil || len(lines) == 0 {
+		return connect.NewResponse(&result), err
+	}
+
+	lineIDs := make([]uint, len(lines))
+	itemMap := map[uint]*accounting_iface.StatementLineItem{}
+	for i, line := range lines {
+		lineIDs[i] = line.ID
+		item := &accounting_iface.StatementLineItem{
+			Line:    line.proto(),
+			Matches: []*accounting_iface.StatementMatch{},
+		}
+		itemMap[line.ID] = item
+		result.Data = append(result.Data, item)
+	}
+
+	matches := []*StatementMatch{}
+	err = db.
+		Model(&StatementMatch{}).
+		Where("line_id IN ?", lineIDs).
+		Where("status IN ?", []MatchStatus{MatchSuggested, MatchConfirmed}).
+		Order("confidence desc, id asc").
+		Find(&matches).
+		Error
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	for _, match := range matches {
+		itemMap[match.LineID].Matches = append(itemMap[match.LineID].Matches, match.proto())
+	}
+
+	return connect.NewResponse(&result), nil
+}
+
+// BankStatementAutoMatch hitung ulang saran pasangan untuk baris yang belum match
+func (b *bankStatementServiceImpl) BankStatementAutoMatch(
+	ctx context.Context,
+	req *connect.Request[accounting_iface.BankStatementAutoMatchRequest],
+) (*connect.Response[accounting_iface.BankStatementAutoMatchResponse], error) {
+	var err error
+	pay := req.Msg
+
+	result := accounting_iface.BankStatementAutoMatchResponse{}
+
+	db := b.db.WithContext(ctx)
+	identity := b.auth.AuthIdentityFromHeader(req.Header())
+	bank, err := bankAccount(db, identity, pay.BankAccountId, authorization_iface.Update)
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		suggestions, err := newMatcher(tx, bank).run()
+		result.Suggestions = int64(suggestions)
+		return err
+	})
+
+	return connect.NewResponse(&result), err
+}
+
+// BankStatementMatchDecide konfirmasi atau tolak saran pasangan
+func (b *bankStatementServiceImpl) BankStatementMatchDecide(
+	ctx context.Context,
+	req *connect.Request[accounting_iface.BankStatementMatchDecideRequest],
+) (*connect.Response[accounting_iface.BankStatementMatchDecideResponse], error) {
+	var err error
+	pay := req.Msg
+
+	result := accounting_iface.BankStatementMatchDecideResponse{}
+
+	if pay.Action != accounting_iface.StatementMatchAction_STATEMENT_MATCH_ACTION_CONFIRM &&
+		pay.Action != accounting_iface.StatementMatchAction_STATEMENT_MATCH_ACTION_REJECT {
+		return connect.NewResponse(&result), connect.NewError(connect.CodeInvalidArgument, errors.New("action must be confirm or reject"))
+	}
+
+	identity := b.auth.AuthIdentityFromHeader(req.Header())
+	agent := identity.Identity()
+
+	err = b.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var match StatementMatch
+		err := tx.
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Model(&StatementMatch{}).
+			Where("id = ?", pay.MatchId).
+			Find(&match).
+			Error
+		if err != nil {
+			return err
+		}
+		if match.ID == 0 {
+			return connect.NewError(connect.CodeNotFound, fmt.Errorf("match %d not found", pay.MatchId))
+		}
+
+		var line StatementLine
+		err = tx.
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&line, match.LineID).
+			Error
+		if err != nil {
+			return err
+		}
+
+		_, err = bankAccount(tx, identity, uint64(line.BankAccountID), authorization_iface.Update)
+		if err != nil {
+			return err
+		}
+
+		locked, err := periodLocked(tx, line.BankAccountID, line.TxDate)
+		if err != nil {
+			return err
+		}
+		if locked {
+			return connect.NewError(connect.CodeFailedPrecondition, errors.New("statement line already reconciled"))
+		}
+
+		if match.Status != MatchSuggested {
+			return connect.NewError(connect.CodeFailedPrecondition, fmt.Errorf("match already %s", match.Status))
+		}
+
+		now := time.Now()
+		match.DecidedByID = agent.IdentityID()
+		match.DecidedAt = &now
+		match.Status = MatchRejected
+
+		if pay.Action == accounting_iface.StatementMatchAction_STATEMENT_MATCH_ACTION_CONFIRM {
+			var used int64
+			err = tx.
+				Model(&StatementMatch{}).
+				Where("target_type = ? AND target_id = ?", match.TargetType, match.TargetID).
+				Where("status = ?", MatchConfirmed).
+				Count(&used).
+				Error
+			if err != nil {
+				return err
+			}
+			if used > 0 {
+				return connect.NewError(connect.CodeFailedPrecondition, errors.New("target already matched with other statement line"))
+			}
+
+			match.Status = MatchConfirmed
+			line.Status = LineMatched
+
+			if match.TargetType == TargetEntry {
+				err = linkEntryBank(tx, match.TargetID, line.BankAccountID)
+				if err != nil {
+					return err
+				}
+			}
+
+			// saran lain untuk baris dan target yang sama tidak berlaku lagi
+			err = tx.
+				Where("id != ?", match.ID).
+				Where("status = ?", MatchSuggested).
+				Where("line_id = ? OR (target_type = ? AND target_id = ?)", line.ID, match.TargetType, match.TargetID).
+				Delete(&StatementMatch{}).
+				Error
+			if err != nil {
+				return err
+			}
+
+			err = tx.Save(&line).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		err = tx.Save(&match).Error
+		if err != nil {
+			return err
+		}
+
+		result.Match = match.proto()
+		result.Line = line.proto()
+		return nil
+	})
+
+	return connect.NewResponse(&result), err
+}
+
+func NewBankStatementService(db *gorm.DB, auth authorization_iface.Authorization) *bankStatementServiceImpl {
+	return &bankStatementServiceImpl{
+		db:   db,
+		auth: auth,
+	}
+}
@@ -0,0 +1,262 @@
+package bank_statement_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/pdcgo/accounting_service/accounting_core"
+	"github.com/pdcgo/accounting_service/accounting_mock"
+	"github.com/pdcgo/accounting_service/accounting_model"
+	"github.com/pdcgo/accounting_service/bank_statement"
+	"github.com/pdcgo/accounting_service/query_dialect"
+	"github.com/pdcgo/schema/services/accounting_iface/v1"
+	"github.com/pdcgo/schema/services/accounting_iface/v1/accounting_ifaceconnect"
+	"github.com/pdcgo/shared/authorization/authorization_mock"
+	"github.com/pdcgo/shared/pkg/moretest"
+	"github.com/pdcgo/shared/pkg/moretest/moretest_mock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func createCashEntry(t *testing.T, db *gorm.DB, ref string, amount float64, created time.Time, desc string) {
+	err := accounting_core.OpenTransaction(t.Context(), db, func(tx *gorm.DB, bookmng accounting_core.BookManage) error {
+		tran := accounting_core.Transaction{
+			TeamID: 5,
+			RefID: accounting_core.NewStringRefID(&accounting_core.StringRefData{
+				RefType: accounting_core.JournalImportRef,
+				ID:      ref,
+			}),
+			Desc:    desc,
+			Created: created,
+		}
+		err := bookmng.
+			NewTransaction().
+			Create(&tran).
+			Err()
+		if err != nil {
+			return err
+		}
+
+		return bookmng.
+			NewCreateEntry(5, 1).
+			To(&accounting_core.EntryAccountPayload{
+				Key:    accounting_core.CashAccount,
+				TeamID: 5,
+			}, amount).
+			To(&accounting_core.EntryAccountPayload{
+				Key:    accounting_core.SalesRevenueAccount,
+				TeamID: 5,
+			}, amount).
+			Transaction(&tran).
+			Commit(accounting_core.CustomTimeOption(created)).
+			Err()
+	})
+	assert.Nil(t, err)
+}
+
+func newBankStatementServer(db *gorm.DB) *httptest.Server {
+	auth := &authorization_mock.EmptyAuthorizationMock{
+		AuthIdentityMock: &authorization_mock.AuthIdentityMock{
+			IdentityMock: &authorization_mock.IdentityMock{
+				ID: 1,
+			},
+		},
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(accounting_ifaceconnect.NewBankStatementServiceHandler(bank_statement.NewBankStatementService(db, auth)))
+	return httptest.NewServer(mux)
+}
+
+func uploadStatement(t *testing.T, client accounting_ifaceconnect.BankStatementServiceClient, bankID uint64, format accounting_iface.BankStatementFormat, data string) (*accounting_iface.BankStatementImportResponse, error) {
+	stream := client.BankStatementImport(t.Context())
+	raw := []byte(data)
+	for i := 0; i < len(raw); i += 64 {
+		end := min(i+64, len(raw))
+		err := stream.Send(&accounting_iface.BankStatementImportRequest{
+			BankAccountId: bankID,
+			Format:        format,
+			Data:          raw[i:end],
+		})
+		assert.Nil(t, err)
+	}
+
+	res, err := stream.CloseAndReceive()
+	if err != nil {
+		return nil, err
+	}
+	return res.Msg, nil
+}
+
+const bcaStatement = `Informasi Rekening - Mutasi Rekening
+Tanggal Transaksi,Keterangan,Cabang,Jumlah,Saldo
+'01/10/2025,TRSF E-BANKING CR TOKO MAJU,0000,"200,000.00 CR","1,200,000.00"
+'02/10/2025,TRSF E-BANKING DB BAYAR SUPPLIER,0000,"500,000.00 DB","700,000.00"
+'02/10/2025,BIAYA ADM,0000,"15,000.00 DB","685,000.00"
+`
+
+func TestBankStatement(t *testing.T) {
+	var db gorm.DB
+
+	at := func(day, hour int) time.Time {
+		return time.Date(2025, 10, day, hour, 0, 0, 0, query_dialect.ReportLocation())
+	}
+
+	moretest.Suite(t, "testing import rekening koran",
+		moretest.SetupListFunc{
+			moretest_mock.MockSqliteDatabase(&db),
+			func(t *testing.T) func() error {
+				err := db.AutoMigrate(
+					&accounting_core.Account{},
+					&accounting_core.Transaction{},
+					&accounting_core.JournalEntry{},
+					&accounting_core.JournalEntryBank{},
+					&accounting_model.BankAccountV2{},
+					&accounting_model.BankTransferHistory{},
+					&bank_statement.StatementLine{},
+					&bank_statement.StatementMatch{},
+					&bank_statement.ReconSession{},
+				)
+				assert.Nil(t, err)
+
+				err = db.Create([]*accounting_model.BankAccountV2{
+					{ID: 1, TeamID: 5, Name: "bca operasional", NumberID: "1234567890"},
+					{ID: 2, TeamID: 5, Name: "bca supplier", NumberID: "1234567891"},
+				}).Error
+				assert.Nil(t, err)
+
+				err = db.Create(&accounting_model.BankTransferHistory{
+					TxID:          999,
+					TeamID:        5,
+					FromAccountID: 1,
+					ToAccountID:   2,
+					Amount:        497500,
+					FeeAmount:     2500,
+					Desc:          "bayar supplier",
+					Created:       at(2, 14),
+				}).Error
+				assert.Nil(t, err)
+				return nil
+			},
+			accounting_mock.PopulateAccountKey(&db, 5),
+		},
+		func(t *testing.T) {
+			createCashEntry(t, &db, "toko-maju", 200000, at(1, 10), "transfer toko maju")
+			createCashEntry(t, &db, "penjualan-lain", 200000, at(3, 10), "penjualan lain")
+
+			server := newBankStatementServer(&db)
+			defer server.Close()
+
+			client := accounting_ifaceconnect.NewBankStatementServiceClient(server.Client(), server.URL)
+
+			upload := func(t *testing.T, format accounting_iface.BankStatementFormat, data string) (*accounting_iface.BankStatementImportResponse, error) {
+				return uploadStatement(t, client, 1, format, data)
+			}
+
+			listLines := func(t *testing.T, status accounting_iface.StatementLineStatus) *accounting_iface.BankStatementLineListResponse {
+				res, err := client.BankStatementLineList(t.Context(), connect.NewRequest(&accounting_iface.BankStatementLineListRequest{
+					BankAccountId: 1,
+					Status:        status,
+				}))
+				assert.Nil(t, err)
+				return res.Msg
+			}
+
+			decide := func(t *testing.T, matchID uint64, action accounting_iface.StatementMatchAction) error {
+				_, err := client.BankStatementMatchDecide(t.Context(), connect.NewRequest(&accounting_iface.BankStatementMatchDecideRequest{
+					MatchId: matchID,
+					Action:  action,
+				}))
+				return err
+			}
+
+			autoMatch := func(t *testing.T) int64 {
+				res, err := client.BankStatementAutoMatch(t.Context(), connect.NewRequest(&accounting_iface.BankStatementAutoMatchRequest{
+					BankAccountId: 1,
+				}))
+				assert.Nil(t, err)
+				return res.Msg.Suggestions
+			}
+
+			t.Run("format salah", func(t *testing.T) {
+				_, err := upload(t, accounting_iface.BankStatementFormat_BANK_STATEMENT_FORMAT_MT940, bcaStatement)
+				assert.Nil(t, err)
+
+				_, err = upload(t, accounting_iface.BankStatementFormat(99), bcaStatement)
+				assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+			})
+
+			t.Run("import dan saran pasangan", func(t *testing.T) {
+				res, err := upload(t, accounting_iface.BankStatementFormat_BANK_STATEMENT_FORMAT_BCA, bcaStatement)
+				assert.Nil(t, err)
+				assert.Equal(t, int64(3), res.Lines)
+				assert.Equal(t, int64(3), res.Imported)
+				assert.Equal(t, int64(0), res.Duplicated)
+				assert.Equal(t, int64(3), res.Suggestions)
+
+				list := listLines(t, accounting_iface.StatementLineStatus_STATEMENT_LINE_STATUS_UNMATCHED)
+				assert.Equal(t, int64(3), list.PageInfo.TotalItems)
+
+				first := list.Data[0]
+				assert.Equal(t, 200000.0, first.Line.Amount)
+				assert.Equal(t, query_dialect.ReportDayStart(time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC)).Unix(), first.Line.TxDate.AsTime().Unix())
+				assert.Len(t, first.Matches, 2)
+				assert.Equal(t, accounting_iface.StatementMatchTarget_STATEMENT_MATCH_TARGET_ENTRY, first.Matches[0].TargetType)
+				assert.Equal(t, "transfer toko maju", first.Matches[0].TargetDesc)
+				assert.Greater(t, first.Matches[0].Confidence, first.Matches[1].Confidence)
+
+				transfer := list.Data[1]
+				assert.Equal(t, -500000.0, transfer.Line.Amount)
+				assert.Len(t, transfer.Matches, 1)
+				assert.Equal(t, accounting_iface.StatementMatchTarget_STATEMENT_MATCH_TARGET_TRANSFER, transfer.Matches[0].TargetType)
+				assert.Equal(t, uint64(999), transfer.Matches[0].TransactionId)
+
+				assert.Empty(t, list.Data[2].Matches)
+			})
+
+			t.Run("import ulang tidak dobel", func(t *testing.T) {
+				res, err := upload(t, accounting_iface.BankStatementFormat_BANK_STATEMENT_FORMAT_BCA, bcaStatement)
+				assert.Nil(t, err)
+				assert.Equal(t, int64(0), res.Imported)
+				assert.Equal(t, int64(3), res.Duplicated)
+				assert.Equal(t, int64(3), res.Suggestions)
+			})
+
+			t.Run("tolak dan konfirmasi", func(t *testing.T) {
+				first := listLines(t, accounting_iface.StatementLineStatus_STATEMENT_LINE_STATUS_UNMATCHED).Data[0]
+
+				err := decide(t, first.Matches[1].Id, accounting_iface.StatementMatchAction_STATEMENT_MATCH_ACTION_REJECT)
+				assert.Nil(t, err)
+
+				// saran yang ditolak tidak muncul lagi
+				assert.Equal(t, int64(2), autoMatch(t))
+
+				first = listLines(t, accounting_iface.StatementLineStatus_STATEMENT_LINE_STATUS_UNMATCHED).Data[0]
+				assert.Len(t, first.Matches, 1)
+
+				err = decide(t, first.Matches[0].Id, accounting_iface.StatementMatchAction_STATEMENT_MATCH_ACTION_CONFIRM)
+				assert.Nil(t, err)
+
+				err = decide(t, first.Matches[0].Id, accounting_iface.StatementMatchAction_STATEMENT_MATCH_ACTION_CONFIRM)
+				assert.Equal(t, connect.CodeFailedPrecondition, connect.CodeOf(err))
+
+				matched := listLines(t, accounting_iface.StatementLineStatus_STATEMENT_LINE_STATUS_MATCHED)
+				assert.Equal(t, int64(1), matched.PageInfo.TotalItems)
+				assert.Len(t, matched.Data[0].Matches, 1)
+				assert.Equal(t, accounting_iface.StatementMatchStatus_STATEMENT_MATCH_STATUS_CONFIRMED, matched.Data[0].Matches[0].Status)
+				assert.Equal(t, uint64(1), matched.Data[0].Matches[0].DecidedById)
+
+				assert.Equal(t, int64(2), listLines(t, accounting_iface.StatementLineStatus_STATEMENT_LINE_STATUS_UNMATCHED).PageInfo.TotalItems)
+				assert.Equal(t, int64(1), autoMatch(t))
+			})
+
+			t.Run("action tidak valid", func(t *testing.T) {
+				err := decide(t, 1, accounting_iface.StatementMatchAction_STATEMENT_MATCH_ACTION_UNSPECIFIED)
+				assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+			})
+		},
+	)
+}
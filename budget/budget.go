@@ -0,0 +1,225 @@
+package budget
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/pdcgo/accounting_service/accounting_core"
+	"github.com/pdcgo/accounting_service/query_dialect"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrBudgetExceeded = errors.New("budget exceeded")
+
+// MonthOf awal bulan laporan dari waktu posting
+func MonthOf(t time.Time) time.Time {
+	day := query_dialect.ReportDay(t)
+	return time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// effectiveBudgets versi terakhir per dimensi
+func effectiveBudgets(query *gorm.DB) ([]*Budget, error) {
+	items := []*Budget{}
+	err := query.
+		Order("version desc").
+		Find(&items).
+		Error
+
+	if err != nil {
+		return items, err
+	}
+
+	seen := map[budgetDimension]bool{}
+	result := []*Budget{}
+	for _, item := range items {
+		dim := item.dimension()
+		if seen[dim] {
+			continue
+		}
+		seen[dim] = true
+		result = append(result, item)
+	}
+
+	slices.SortFunc(result, func(a, b *Budget) int {
+		switch {
+		case !a.Month.Equal(b.Month):
+			return a.Month.Compare(b.Month)
+		case a.AccountKey != b.AccountKey:
+			if a.AccountKey < b.AccountKey {
+				return -1
+			}
+			return 1
+		case a.ShopID != b.ShopID:
+			return int(a.ShopID) - int(b.ShopID)
+		}
+		return int(a.TagID) - int(b.TagID)
+	})
+
+	return result, nil
+}
+
+// actualSpend realisasi budget dari tabel daily sesuai dimensi
+func actualSpend(db *gorm.DB, teamID uint, budget *Budget) (float64, error) {
+	var query *gorm.DB
+	start := budget.Month
+	end := budget.Month.AddDate(0, 1, -1)
+
+	switch {
+	case budget.ShopID != 0:
+		query = db.
+			Table("shop_daily_balances adb").
+			Joins("join accounts a on a.id = adb.account_id").
+			Where("a.account_key = ?", budget.AccountKey).
+			Where("adb.shop_id = ?", budget.ShopID)
+
+	case budget.TagID != 0:
+		query = db.
+			Table("custom_label_daily_balances adb").
+			Joins("join accounts a on a.id = adb.account_id").
+			Where("a.account_key = ?", budget.AccountKey).
+			Where("adb.custom_id = ?", budget.TagID)
+
+	default:
+		query = db.
+			Table("account_key_daily_balances adb").
+			Where("adb.account_key = ?", budget.AccountKey)
+	}
+
+	var amount float64
+	err := query.
+		Select("coalesce(sum(adb.debit - adb.credit), 0)").
+		Where("adb.journal_team_id = ?", teamID).
+		Where("adb.day >= ?", start).
+		Where("adb.day <= ?", end).
+		Scan(&amount).
+		Error
+
+	return amount, err
+}
+
+// journalSpend realisasi budget langsung dari journal entry, termasuk posting
+// yang sudah commit tapi belum terproyeksi ke tabel daily
+func journalSpend(db *gorm.DB, teamID uint, budget *Budget) (float64, error) {
+	query := db.
+		Table("journal_entries je").
+		Joins("join accounts a on a.id = je.account_id").
+		Where("a.account_key = ?", budget.AccountKey).
+		Where("je.team_id = ?", teamID).
+		Where("je.entry_time >= ?", query_dialect.ReportDayStart(budget.Month)).
+		Where("je.entry_time < ?", query_dialect.ReportDayStart(budget.Month.AddDate(0, 1, 0)))
+
+	switch {
+	case budget.ShopID != 0:
+		query = query.
+			Joins("join transaction_shops ts on ts.transaction_id = je.transaction_id").
+			Where("ts.shop_id = ?", budget.ShopID)
+
+	case budget.TagID != 0:
+		query = query.
+			Joins("join transaction_tags tt on tt.transaction_id = je.transaction_id").
+			Where("tt.tag_id = ?", budget.TagID)
+	}
+
+	// debit - credit entry rollback sama dengan versi yang dibalik di tabel daily
+	var amount float64
+	err := query.
+		Select("coalesce(sum(je.debit - je.credit), 0)").
+		Scan(&amount).
+		Error
+
+	return amount, err
+}
+
+type PostingPayload struct {
+	TeamID     uint
+	AccountKey accounting_core.AccountKey
+	ShopID     uint
+	TagIDs     []uint
+	At         time.Time
+	Amount     float64
+}
+
+type CheckResult struct {
+	Warnings []string
+}
+
+type BudgetCheck struct {
+	db *gorm.DB
+}
+
+func NewBudgetCheck(db *gorm.DB) *BudgetCheck {
+	return &BudgetCheck{
+		db: db,
+	}
+}
+
+// Check mengecek posting ke semua budget yang berlaku (team, shop, tag).
+// Dipanggil di dalam transaksi posting, baris budget dikunci sampai posting commit
+// supaya posting paralel ke budget yang sama tidak membaca realisasi yang sama.
+func (b *BudgetCheck) Check(pay *PostingPayload) (*CheckResult, error) {
+	result := CheckResult{
+		Warnings: []string{},
+	}
+
+	shopIDs := []uint{0}
+	if pay.ShopID != 0 {
+		shopIDs = append(shopIDs, pay.ShopID)
+	}
+	tagIDs := append([]uint{0}, pay.TagIDs...)
+
+	budgets, err := effectiveBudgets(
+		b.
+			db.
+			Model(&Budget{}).
+			Clauses(clause.Locking{
+				Strength: "UPDATE",
+			}).
+			Where("team_id = ?", pay.TeamID).
+			Where("account_key = ?", pay.AccountKey).
+			Where("month = ?", MonthOf(pay.At)).
+			Where("shop_id in ?", shopIDs).
+			Where("tag_id in ?", tagIDs).
+			// budget gabungan shop dan tag tidak didukung
+			Where("shop_id = 0 or tag_id = 0"),
+	)
+
+	if err != nil {
+		return &result, err
+	}
+
+	for _, item := range budgets {
+		if item.Mode == BudgetModeNone {
+			continue
+		}
+
+		actual, err := journalSpend(b.db, pay.TeamID, item)
+		if err != nil {
+			return &result, err
+		}
+
+		remaining := item.Amount - actual
+		if pay.Amount <= remaining || accounting_core.CompareFloatSafe(pay.Amount, remaining, accounting_core.PrecisionEpsilon) {
+			continue
+		}
+
+		msg := fmt.Sprintf("budget %s %s shop %d tag %d sisa %.2f, posting %.2f",
+			item.AccountKey,
+			item.Month.Format("2006-01"),
+			item.ShopID,
+			item.TagID,
+			remaining,
+			pay.Amount,
+		)
+
+		if item.Mode == BudgetModeHard {
+			return &result, fmt.Errorf("%w: %s", ErrBudgetExceeded, msg)
+		}
+
+		result.Warnings = append(result.Warnings, msg)
+	}
+
+	return &result, nil
+}
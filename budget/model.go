@@ -0,0 +1,51 @@
+package budget
+
+import (
+	"time"
+
+	"github.com/pdcgo/accounting_service/accounting_core"
+)
+
+type BudgetMode string
+
+const (
+	// hanya dipakai di laporan budget vs actual
+	BudgetModeNone BudgetMode = ""
+	// posting tetap jalan, response diberi peringatan
+	BudgetModeSoft BudgetMode = "soft"
+	// posting ditolak kalau melebihi sisa budget
+	BudgetModeHard BudgetMode = "hard"
+)
+
+// Budget satu baris per versi, versi terbesar yang berlaku.
+// ShopID dan TagID 0 berarti budget untuk semua shop atau tag.
+type Budget struct {
+	ID          uint                       `json:"id" gorm:"primarykey"`
+	TeamID      uint                       `json:"team_id" gorm:"index:budget_version,unique"`
+	AccountKey  accounting_core.AccountKey `json:"account_key" gorm:"index:budget_version,unique"`
+	Month       time.Time                  `json:"month" gorm:"index:budget_version,unique"`
+	ShopID      uint                       `json:"shop_id" gorm:"index:budget_version,unique"`
+	TagID       uint                       `json:"tag_id" gorm:"index:budget_version,unique"`
+	Version     int                        `json:"version" gorm:"index:budget_version,unique"`
+	Amount      float64                    `json:"amount"`
+	Mode        BudgetMode                 `json:"mode"`
+	Desc        string                     `json:"desc"`
+	CreatedByID uint                       `json:"created_by_id"`
+	Created     time.Time                  `json:"created"`
+}
+
+type budgetDimension struct {
+	AccountKey accounting_core.AccountKey
+	Month      time.Time
+	ShopID     uint
+	TagID      uint
+}
+
+func (b *Budget) dimension() budgetDimension {
+	return budgetDimension{
+		AccountKey: b.AccountKey,
+		Month:      b.Month,
+		ShopID:     b.ShopID,
+		TagID:      b.TagID,
+	}
+}
@@ -0,0 +1,296 @@
+package budget
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/pdcgo/accounting_service/accounting_core"
+	"github.com/pdcgo/accounting_service/accounting_model"
+	"github.com/pdcgo/schema/services/accounting_iface/v1"
+	"github.com/pdcgo/schema/services/common/v1"
+	"github.com/pdcgo/shared/interfaces/authorization_iface"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/gorm"
+)
+
+// range bulan default kalau end tidak diisi
+const defaultBudgetMonthWindow = 12
+
+func modeFromProto(mode accounting_iface.BudgetMode) BudgetMode {
+	switch mode {
+	case accounting_iface.BudgetMode_BUDGET_MODE_SOFT:
+		return BudgetModeSoft
+	case accounting_iface.BudgetMode_BUDGET_MODE_HARD:
+		return BudgetModeHard
+	default:
+		return BudgetModeNone
+	}
+}
+
+func modeProto(mode BudgetMode) accounting_iface.BudgetMode {
+	switch mode {
+	case BudgetModeSoft:
+		return accounting_iface.BudgetMode_BUDGET_MODE_SOFT
+	case BudgetModeHard:
+		return accounting_iface.BudgetMode_BUDGET_MODE_HARD
+	default:
+		return accounting_iface.BudgetMode_BUDGET_MODE_UNSPECIFIED
+	}
+}
+
+func budgetItem(budget *Budget) *accounting_iface.BudgetItem {
+	return &accounting_iface.BudgetItem{
+		Id:          uint64(budget.ID),
+		TeamId:      uint64(budget.TeamID),
+		AccountKey:  string(budget.AccountKey),
+		Month:       timestamppb.New(budget.Month),
+		ShopId:      uint64(budget.ShopID),
+		TagId:       uint64(budget.TagID),
+		Version:     int64(budget.Version),
+		Amount:      budget.Amount,
+		Mode:        modeProto(budget.Mode),
+		Desc:        budget.Desc,
+		CreatedById: uint64(budget.CreatedByID),
+		Created:     timestamppb.New(budget.Created),
+	}
+}
+
+// timeRange awal dan akhir range bulan, kosong kalau tidak diisi
+func timeRange(filter *common.TimeFilterRange) (start, end time.Time) {
+	if filter == nil {
+		return start, end
+	}
+	if filter.StartDate != nil {
+		start = filter.StartDate.AsTime()
+	}
+	if filter.EndDate != nil {
+		end = filter.EndDate.AsTime()
+	}
+	return start, end
+}
+
+type budgetServiceImpl struct {
+	db   *gorm.DB
+	auth authorization_iface.Authorization
+}
+
+func (b *budgetServiceImpl) checkPermission(header http.Header, teamID uint64, action authorization_iface.Action) (authorization_iface.Identity, error) {
+	identity := b.auth.AuthIdentityFromHeader(header)
+	err := identity.
+		HasPermission(authorization_iface.CheckPermissionGroup{
+			accounting_model.ExpenseEntity{}: &authorization_iface.CheckPermission{
+				DomainID: uint(teamID),
+				Actions:  []authorization_iface.Action{action},
+			},
+		}).
+		Err()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return identity.Identity(), nil
+}
+
+// budgetQuery filter budget per team, account key dan range bulan
+func (b *budgetServiceImpl) budgetQuery(
+	ctx context.Context,
+	teamID uint64,
+	key accounting_core.AccountKey,
+	start, end time.Time,
+) *gorm.DB {
+	if start.IsZero() {
+		start = time.Now()
+	}
+	if end.IsZero() {
+		end = start.AddDate(0, defaultBudgetMonthWindow-1, 0)
+	}
+
+	query := b.
+		db.
+		WithContext(ctx).
+		Model(&Budget{}).
+		Where("team_id = ?", teamID).
+		Where("month >= ?", MonthOf(start)).
+		Where("month <= ?", MonthOf(end))
+
+	if key != "" {
+		query = query.Where("account_key = ?", key)
+	}
+
+	return query
+}
+
+// BudgetSet membuat versi baru budget, versi lama tetap disimpan
+func (b *budgetServiceImpl) BudgetSet(
+	ctx context.Context,
+	req *connect.Request[accounting_iface.BudgetSetRequest],
+) (*connect.Response[accounting_iface.BudgetSetResponse], error) {
+	var err error
+	pay := req.Msg
+	result := accounting_iface.BudgetSetResponse{}
+
+	agent, err := b.checkPermission(req.Header(), pay.TeamId, authorization_iface.Update)
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	switch {
+	case pay.TeamId == 0:
+		err = errors.New("team_id required")
+	case pay.AccountKey == "":
+		err = errors.New("account_key required")
+	case pay.Month == nil:
+		err = errors.New("month required")
+	case pay.Amount < 0:
+		err = errors.New("amount cannot negative")
+	case pay.ShopId != 0 && pay.TagId != 0:
+		err = errors.New("budget hanya bisa per shop atau per tag")
+	}
+
+	if err != nil {
+		return connect.NewResponse(&result), connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	budget := Budget{
+		TeamID:      uint(pay.TeamId),
+		AccountKey:  accounting_core.AccountKey(pay.AccountKey),
+		Month:       MonthOf(pay.Month.AsTime()),
+		ShopID:      uint(pay.ShopId),
+		TagID:       uint(pay.TagId),
+		Amount:      pay.Amount,
+		Mode:        modeFromProto(pay.Mode),
+		Desc:        pay.Desc,
+		CreatedByID: agent.IdentityID(),
+		Created:     time.Now(),
+	}
+
+	err = b.
+		db.
+		WithContext(ctx).
+		Transaction(func(tx *gorm.DB) error {
+			var last int
+			err := tx.
+				Model(&Budget{}).
+				Select("coalesce(max(version), 0)").
+				Where("team_id = ?", budget.TeamID).
+				Where("account_key = ?", budget.AccountKey).
+				Where("month = ?", budget.Month).
+				Where("shop_id = ?", budget.ShopID).
+				Where("tag_id = ?", budget.TagID).
+				Scan(&last).
+				Error
+
+			if err != nil {
+				return err
+			}
+
+			budget.Version = last + 1
+			return tx.Create(&budget).Error
+		})
+
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	result.Budget = budgetItem(&budget)
+	return connect.NewResponse(&result), nil
+}
+
+// BudgetList list budget per bulan
+func (b *budgetServiceImpl) BudgetList(
+	ctx context.Context,
+	req *connect.Request[accounting_iface.BudgetListRequest],
+) (*connect.Response[accounting_iface.BudgetListResponse], error) {
+	var err error
+	pay := req.Msg
+	result := accounting_iface.BudgetListResponse{
+		Data: []*accounting_iface.BudgetItem{},
+	}
+
+	_, err = b.checkPermission(req.Header(), pay.TeamId, authorization_iface.Read)
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	start, end := timeRange(pay.TimeRange)
+	query := b.budgetQuery(ctx, pay.TeamId, accounting_core.AccountKey(pay.AccountKey), start, end)
+
+	budgets := []*Budget{}
+	if pay.AllVersion {
+		err = query.
+			Order("month asc, account_key asc, shop_id asc, tag_id asc, version desc").
+			Find(&budgets).
+			Error
+	} else {
+		budgets, err = effectiveBudgets(query)
+	}
+
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	for _, budget := range budgets {
+		result.Data = append(result.Data, budgetItem(budget))
+	}
+
+	return connect.NewResponse(&result), nil
+}
+
+// BudgetVsActual realisasi budget yang berlaku dari tabel daily balance
+func (b *budgetServiceImpl) BudgetVsActual(
+	ctx context.Context,
+	req *connect.Request[accounting_iface.BudgetVsActualRequest],
+) (*connect.Response[accounting_iface.BudgetVsActualResponse], error) {
+	var err error
+	pay := req.Msg
+	result := accounting_iface.BudgetVsActualResponse{
+		Data: []*accounting_iface.BudgetVsActualItem{},
+	}
+
+	_, err = b.checkPermission(req.Header(), pay.TeamId, authorization_iface.Read)
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	start, end := timeRange(pay.TimeRange)
+	budgets, err := effectiveBudgets(b.budgetQuery(ctx, pay.TeamId, accounting_core.AccountKey(pay.AccountKey), start, end))
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	db := b.db.WithContext(ctx)
+	for _, budget := range budgets {
+		actual, err := actualSpend(db, uint(pay.TeamId), budget)
+		if err != nil {
+			return connect.NewResponse(&result), err
+		}
+
+		item := accounting_iface.BudgetVsActualItem{
+			Budget:    budgetItem(budget),
+			Actual:    actual,
+			Remaining: budget.Amount - actual,
+		}
+		item.Exceeded = item.Remaining < 0 &&
+			!accounting_core.CompareFloatSafe(item.Remaining, 0, accounting_core.PrecisionEpsilon)
+
+		if budget.Amount != 0 {
+			used := actual / budget.Amount * 100
+			item.UsedPercent = &used
+		}
+
+		result.Data = append(result.Data, &item)
+	}
+
+	return connect.NewResponse(&result), nil
+}
+
+func NewBudgetService(db *gorm.DB, auth authorization_iface.Authorization) *budgetServiceImpl {
+	return &budgetServiceImpl{
+		db:   db,
+		auth: auth,
+	}
+}
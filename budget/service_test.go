@@ -0,0 +1,205 @@
+package budget_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/pdcgo/accounting_service/accounting_core"
+	"github.com/pdcgo/accounting_service/accounting_mock"
+	"github.com/pdcgo/accounting_service/budget"
+	"github.com/pdcgo/schema/services/accounting_iface/v1"
+	"github.com/pdcgo/schema/services/common/v1"
+	"github.com/pdcgo/shared/authorization/authorization_mock"
+	"github.com/pdcgo/shared/pkg/moretest"
+	"github.com/pdcgo/shared/pkg/moretest/moretest_mock"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/gorm"
+)
+
+func TestBudget(t *testing.T) {
+	var db gorm.DB
+	var adsAcc accounting_core.Account
+
+	month := time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	moretest.Suite(t, "testing budget",
+		moretest.SetupListFunc{
+			moretest_mock.MockSqliteDatabase(&db),
+			func(t *testing.T) func() error {
+				err := db.AutoMigrate(
+					&accounting_core.Account{},
+					&accounting_core.Transaction{},
+					&accounting_core.JournalEntry{},
+					&accounting_core.TransactionShop{},
+					&accounting_core.TransactionTag{},
+					&accounting_core.AccountKeyDailyBalance{},
+					&accounting_core.ShopDailyBalance{},
+					&accounting_core.CustomLabelDailyBalance{},
+					&budget.Budget{},
+				)
+				assert.Nil(t, err)
+				return nil
+			},
+			accounting_mock.PopulateAccountKey(&db, 1),
+			func(t *testing.T) func() error {
+				err := db.
+					Model(&accounting_core.Account{}).
+					Where("team_id = 1 and account_key = ?", accounting_core.AdsExpenseAccount).
+					First(&adsAcc).
+					Error
+				assert.Nil(t, err)
+
+				err = db.Create(&accounting_core.AccountKeyDailyBalance{
+					Day:           month.AddDate(0, 0, 4),
+					AccountKey:    accounting_core.AdsExpenseAccount,
+					JournalTeamID: 1,
+					Debit:         700,
+				}).Error
+				assert.Nil(t, err)
+
+				err = db.Create(&accounting_core.ShopDailyBalance{
+					Day:           month.AddDate(0, 0, 4),
+					ShopID:        3,
+					AccountID:     adsAcc.ID,
+					JournalTeamID: 1,
+					Debit:         300,
+				}).Error
+				assert.Nil(t, err)
+
+				// journal entry yang sudah terproyeksi ke tabel daily di atas
+				for i, amount := range []float64{400, 300} {
+					tran := accounting_core.Transaction{
+						RefID:   accounting_core.RefID(fmt.Sprintf("ads#%d", i)),
+						TeamID:  1,
+						Created: accounting_mock.Date(10, 5),
+					}
+					err = db.Create(&tran).Error
+					assert.Nil(t, err)
+
+					err = db.Create(&accounting_core.JournalEntry{
+						AccountID:     adsAcc.ID,
+						TeamID:        1,
+						TransactionID: tran.ID,
+						EntryTime:     accounting_mock.Date(10, 5),
+						Debit:         amount,
+					}).Error
+					assert.Nil(t, err)
+
+					if i == 1 {
+						err = db.Create(&accounting_core.TransactionShop{
+							TransactionID: tran.ID,
+							ShopID:        3,
+						}).Error
+						assert.Nil(t, err)
+					}
+				}
+				return nil
+			},
+		},
+		func(t *testing.T) {
+			service := budget.NewBudgetService(&db, &authorization_mock.EmptyAuthorizationMock{
+				AuthIdentityMock: &authorization_mock.AuthIdentityMock{
+					IdentityMock: &authorization_mock.IdentityMock{ID: 1},
+				},
+			})
+
+			monthRange := &common.TimeFilterRange{
+				StartDate: timestamppb.New(month),
+				EndDate:   timestamppb.New(month),
+			}
+
+			set := func(t *testing.T, pay *accounting_iface.BudgetSetRequest) *accounting_iface.BudgetSetResponse {
+				res, err := service.BudgetSet(t.Context(), connect.NewRequest(pay))
+				assert.Nil(t, err)
+				return res.Msg
+			}
+
+			t.Run("set budget membuat versi baru", func(t *testing.T) {
+				res := set(t, &accounting_iface.BudgetSetRequest{TeamId: 1, AccountKey: string(accounting_core.AdsExpenseAccount), Month: timestamppb.New(month.AddDate(0, 0, 10)), Amount: 500})
+				assert.Equal(t, int64(1), res.Budget.Version)
+				assert.Equal(t, month, res.Budget.Month.AsTime())
+
+				res = set(t, &accounting_iface.BudgetSetRequest{TeamId: 1, AccountKey: string(accounting_core.AdsExpenseAccount), Month: timestamppb.New(month), Amount: 1000, Mode: accounting_iface.BudgetMode_BUDGET_MODE_HARD})
+				assert.Equal(t, int64(2), res.Budget.Version)
+
+				set(t, &accounting_iface.BudgetSetRequest{TeamId: 1, AccountKey: string(accounting_core.AdsExpenseAccount), Month: timestamppb.New(month), ShopId: 3, Amount: 200, Mode: accounting_iface.BudgetMode_BUDGET_MODE_SOFT})
+
+				list, err := service.BudgetList(t.Context(), connect.NewRequest(&accounting_iface.BudgetListRequest{TeamId: 1, TimeRange: monthRange}))
+				assert.Nil(t, err)
+				assert.Len(t, list.Msg.Data, 2)
+
+				list, err = service.BudgetList(t.Context(), connect.NewRequest(&accounting_iface.BudgetListRequest{TeamId: 1, TimeRange: monthRange, AllVersion: true}))
+				assert.Nil(t, err)
+				assert.Len(t, list.Msg.Data, 3)
+			})
+
+			t.Run("shop dan tag sekaligus ditolak", func(t *testing.T) {
+				_, err := service.BudgetSet(t.Context(), connect.NewRequest(&accounting_iface.BudgetSetRequest{TeamId: 1, AccountKey: string(accounting_core.AdsExpenseAccount), Month: timestamppb.New(month), ShopId: 3, TagId: 4, Amount: 200}))
+				assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+			})
+
+			t.Run("budget vs actual", func(t *testing.T) {
+				vs, err := service.BudgetVsActual(t.Context(), connect.NewRequest(&accounting_iface.BudgetVsActualRequest{TeamId: 1, TimeRange: monthRange}))
+				assert.Nil(t, err)
+				res := vs.Msg
+				assert.Len(t, res.Data, 2)
+
+				team := res.Data[0]
+				assert.Equal(t, uint64(0), team.Budget.ShopId)
+				assert.Equal(t, 700.0, team.Actual)
+				assert.Equal(t, 300.0, team.Remaining)
+				assert.False(t, team.Exceeded)
+
+				shop := res.Data[1]
+				assert.Equal(t, 300.0, shop.Actual)
+				assert.True(t, shop.Exceeded)
+			})
+
+			t.Run("check posting", func(t *testing.T) {
+				check := budget.NewBudgetCheck(&db)
+				at := time.Date(2025, 10, 20, 10, 0, 0, 0, time.UTC)
+
+				res, err := check.Check(&budget.PostingPayload{TeamID: 1, AccountKey: accounting_core.AdsExpenseAccount, At: at, Amount: 300})
+				assert.Nil(t, err)
+				assert.Empty(t, res.Warnings)
+
+				_, err = check.Check(&budget.PostingPayload{TeamID: 1, AccountKey: accounting_core.AdsExpenseAccount, At: at, Amount: 301})
+				assert.ErrorIs(t, err, budget.ErrBudgetExceeded)
+
+				res, err = check.Check(&budget.PostingPayload{TeamID: 1, AccountKey: accounting_core.AdsExpenseAccount, ShopID: 3, At: at, Amount: 100})
+				assert.Nil(t, err)
+				assert.Len(t, res.Warnings, 1)
+			})
+
+			t.Run("posting yang belum terproyeksi ikut dihitung", func(t *testing.T) {
+				tran := accounting_core.Transaction{
+					RefID:   "ads#unprojected",
+					TeamID:  1,
+					Created: accounting_mock.Date(10, 6),
+				}
+				err := db.Create(&tran).Error
+				assert.Nil(t, err)
+
+				err = db.Create(&accounting_core.JournalEntry{
+					AccountID:     adsAcc.ID,
+					TeamID:        1,
+					TransactionID: tran.ID,
+					EntryTime:     accounting_mock.Date(10, 6),
+					Debit:         100,
+				}).Error
+				assert.Nil(t, err)
+
+				check := budget.NewBudgetCheck(&db)
+				_, err = check.Check(&budget.PostingPayload{TeamID: 1, AccountKey: accounting_core.AdsExpenseAccount, At: accounting_mock.Date(10, 20), Amount: 300})
+				assert.ErrorIs(t, err, budget.ErrBudgetExceeded)
+
+				res, err := check.Check(&budget.PostingPayload{TeamID: 1, AccountKey: accounting_core.AdsExpenseAccount, At: accounting_mock.Date(10, 20), Amount: 200})
+				assert.Nil(t, err)
+				assert.Empty(t, res.Warnings)
+			})
+		},
+	)
+}
@@ -6,11 +6,14 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"time"
 
 	cloudtasks "cloud.google.com/go/cloudtasks/apiv2"
 	"connectrpc.com/connect"
 	"github.com/pdcgo/accounting_service"
 	"github.com/pdcgo/accounting_service/accounting_core"
+	"github.com/pdcgo/accounting_service/report"
+	"github.com/pdcgo/accounting_service/task_queue"
 	"github.com/pdcgo/schema/services/report_iface/v1/report_ifaceconnect"
 	"github.com/pdcgo/shared/authorization"
 	"github.com/pdcgo/shared/configs"
@@ -35,6 +38,20 @@ func NewCloudTaskClient() (*cloudtasks.Client, error) {
 	return cloudtasks.NewClient(context.Background())
 }
 
+// NewReportDispatcher TASK_QUEUE=local memakai antrian database, selain itu cloud task
+func NewReportDispatcher(queue *task_queue.TaskQueue) (report.ReportDispatcher, error) {
+	if os.Getenv("TASK_QUEUE") == "local" {
+		return queue.Dispatcher(), nil
+	}
+
+	client, err := NewCloudTaskClient()
+	if err != nil {
+		return nil, err
+	}
+
+	return report.NewCloudTaskReportDispatcher(client), nil
+}
+
 func NewAccountReportServiceClient(
 	cfg *configs.AppConfig,
 	defaultInterceptor custom_connect.DefaultClientInterceptor,
@@ -68,7 +85,9 @@ func NewApp(
 	accountingRegister accounting_service.RegisterHandler,
 	reportClient report_ifaceconnect.AccountReportServiceClient,
 	reflectorRegister custom_connect.RegisterReflectFunc,
-	// cache ware_cache.Cache
+	db *gorm.DB,
+	cache ware_cache.Cache,
+	queue *task_queue.TaskQueue,
 	// auth authorization_iface.Authorization,
 ) *App {
 	return &App{
@@ -80,10 +99,33 @@ func NewApp(
 
 			defer cancel(context.Background())
 
-			accounting_core.RegisterCustomHandler(
-				"task_daily_update",
-				accounting_core.NewDailyBalanceHandler(reportClient),
-			)
+			// BALANCE_PROJECTION: push (default), pull, atau both
+			projection := os.Getenv("BALANCE_PROJECTION")
+			if projection == "" {
+				projection = "push"
+			}
+
+			if projection == "push" || projection == "both" {
+				accounting_core.RegisterCustomHandler(
+					"task_daily_update",
+					accounting_core.NewDailyBalanceHandler(reportClient),
+				)
+			}
+
+			if projection == "pull" || projection == "both" {
+				projector := report.NewBalanceProjector(db, cache)
+				mux.Handle("/projector/lag", projector.LagHandler())
+
+				ctx, stop := context.WithCancel(context.Background())
+				defer stop()
+				go projector.Run(ctx, time.Second*5)
+			}
+
+			if os.Getenv("TASK_QUEUE") == "local" {
+				ctx, stop := context.WithCancel(context.Background())
+				defer stop()
+				go queue.Run(ctx)
+			}
 
 			accGrpcReflectNames := accountingRegister()
 			reflectorRegister(accGrpcReflectNames)
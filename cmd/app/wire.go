@@ -8,7 +8,7 @@ import (
 
 	"github.com/google/wire"
 	"github.com/pdcgo/accounting_service"
-	"github.com/pdcgo/accounting_service/report"
+	"github.com/pdcgo/accounting_service/task_queue"
 	"github.com/pdcgo/shared/configs"
 	"github.com/pdcgo/shared/custom_connect"
 )
@@ -20,8 +20,8 @@ func InitializeApp() (*App, error) {
 		http.NewServeMux,
 		NewCache,
 		NewDatabase,
-		NewCloudTaskClient,
-		report.NewCloudTaskReportDispatcher,
+		task_queue.NewTaskQueue,
+		NewReportDispatcher,
 		NewAccountReportServiceClient,
 		// accounting_core.NewAccountReportServiceClientDispatcher,
 		custom_connect.NewDefaultInterceptor,
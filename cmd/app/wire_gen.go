@@ -8,7 +8,7 @@ package main
 
 import (
 	"github.com/pdcgo/accounting_service"
-	"github.com/pdcgo/accounting_service/report"
+	"github.com/pdcgo/accounting_service/task_queue"
 	"github.com/pdcgo/shared/configs"
 	"github.com/pdcgo/shared/custom_connect"
 	"net/http"
@@ -35,18 +35,18 @@ func InitializeApp() (*App, error) {
 	if err != nil {
 		return nil, err
 	}
-	client, err := NewCloudTaskClient()
+	taskQueue := task_queue.NewTaskQueue(db)
+	reportDispatcher, err := NewReportDispatcher(taskQueue)
 	if err != nil {
 		return nil, err
 	}
-	reportDispatcher := report.NewCloudTaskReportDispatcher(client)
-	registerHandler := accounting_service.NewRegister(appConfig, db, authorization, serveMux, defaultInterceptor, cache, reportDispatcher)
+	registerHandler := accounting_service.NewRegister(appConfig, db, authorization, serveMux, defaultInterceptor, cache, reportDispatcher, taskQueue)
 	defaultClientInterceptor, err := custom_connect.NewDefaultClientInterceptor()
 	if err != nil {
 		return nil, err
 	}
 	accountReportServiceClient := NewAccountReportServiceClient(appConfig, defaultClientInterceptor)
 	registerReflectFunc := custom_connect.NewRegisterReflect(serveMux)
-	app := NewApp(serveMux, registerHandler, accountReportServiceClient, registerReflectFunc)
+	app := NewApp(serveMux, registerHandler, accountReportServiceClient, registerReflectFunc, db, cache, taskQueue)
 	return app, nil
 }
@@ -79,6 +79,12 @@ var filterExtra = map[accounting_core.AccountKey]*accounting_iface.AccountFilter
 	accounting_core.ReceivableAccount: {
 		Team: true,
 	},
+	accounting_core.LoanPayableAccount: {
+		Team: true,
+	},
+	accounting_core.LoanReceivableAccount: {
+		Team: true,
+	},
 	accounting_core.BorrowStockRevenueAccount: {
 		Team:      true,
 		Shop:      true,
@@ -0,0 +1,251 @@
+package customer_service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/pdcgo/accounting_service/accounting_core"
+	"github.com/pdcgo/accounting_service/query_dialect"
+	"github.com/pdcgo/schema/services/accounting_iface/v1"
+	"github.com/pdcgo/shared/interfaces/authorization_iface"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CommissionRuleSet membuat atau mengganti aturan komisi cs
+func (c *csServiceImpl) CommissionRuleSet(
+	ctx context.Context,
+	req *connect.Request[accounting_iface.CommissionRuleSetRequest],
+) (*connect.Response[accounting_iface.CommissionRuleSetResponse], error) {
+	var err error
+	pay := req.Msg
+	result := accounting_iface.CommissionRuleSetResponse{}
+
+	agent, err := c.checkPermission(req.Header(), pay.TeamId, authorization_iface.Update)
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	ctype := commissionTypeFromProto(pay.Type)
+	switch {
+	case pay.TeamId == 0:
+		err = errors.New("team_id required")
+	case ctype == "":
+		err = errors.New("unknown commission type " + pay.Type.String())
+	case pay.Value < 0:
+		err = errors.New("value cannot negative")
+	case ctype == CommissionPercent && pay.Value > 100:
+		err = errors.New("percent commission cannot more than 100")
+	}
+
+	if err != nil {
+		return connect.NewResponse(&result), connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	rule := CommissionRule{
+		TeamID:      uint(pay.TeamId),
+		CsID:        uint(pay.CsId),
+		Type:        ctype,
+		Value:       pay.Value,
+		UpdatedByID: agent.IdentityID(),
+		Updated:     time.Now(),
+	}
+
+	db := c.db.WithContext(ctx)
+	err = db.
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "team_id"}, {Name: "cs_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"type", "value", "updated_by_id", "updated"}),
+		}).
+		Create(&rule).
+		Error
+
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	err = db.
+		Where("team_id = ? and cs_id = ?", rule.TeamID, rule.CsID).
+		First(&rule).
+		Error
+
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	result.Rule = rule.proto()
+	return connect.NewResponse(&result), nil
+}
+
+// CommissionRuleList aturan komisi team
+func (c *csServiceImpl) CommissionRuleList(
+	ctx context.Context,
+	req *connect.Request[accounting_iface.CommissionRuleListRequest],
+) (*connect.Response[accounting_iface.CommissionRuleListResponse], error) {
+	var err error
+	pay := req.Msg
+	result := accounting_iface.CommissionRuleListResponse{
+		Data: []*accounting_iface.CommissionRule{},
+	}
+
+	_, err = c.checkPermission(req.Header(), pay.TeamId, authorization_iface.Read)
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	rules := []*CommissionRule{}
+	err = c.
+		db.
+		WithContext(ctx).
+		Model(&CommissionRule{}).
+		Where("team_id = ?", pay.TeamId).
+		Order("cs_id asc").
+		Find(&rules).
+		Error
+
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	for _, rule := range rules {
+		result.Data = append(result.Data, rule.proto())
+	}
+
+	return connect.NewResponse(&result), nil
+}
+
+// CommissionPost posting komisi periode ke bonuses expense dengan lawan hutang (payable).
+// Satu transaksi per cs per periode, posting ulang periode yang sama dilewati.
+func (c *csServiceImpl) CommissionPost(
+	ctx context.Context,
+	req *connect.Request[accounting_iface.CommissionPostRequest],
+) (*connect.Response[accounting_iface.CommissionPostResponse], error) {
+	var err error
+	pay := req.Msg
+	result := accounting_iface.CommissionPostResponse{
+		Data: []*accounting_iface.CommissionPostItem{},
+	}
+
+	agent, err := c.checkPermission(req.Header(), pay.TeamId, authorization_iface.Create)
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	if pay.TeamId == 0 {
+		return connect.NewResponse(&result), connect.NewError(connect.CodeInvalidArgument, errors.New("team_id required"))
+	}
+
+	start, end, err := reportPeriod(pay.TimeRange)
+	if err != nil {
+		return connect.NewResponse(&result), connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	db := c.db.WithContext(ctx)
+	query := csPerformanceQuery{
+		db:     db,
+		teamID: uint(pay.TeamId),
+		csID:   uint(pay.CsId),
+		start:  start,
+		end:    end,
+	}
+
+	items, err := query.performances()
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	// entry dicatat di akhir periode supaya masuk laporan periode tersebut
+	entryTime := query_dialect.ReportDayStart(query.end.AddDate(0, 0, 1)).Add(-time.Microsecond)
+	teamID := uint(pay.TeamId)
+
+	for _, item := range items {
+		amount := item.Commission - item.CommissionPosted
+		if amount <= 0 || accounting_core.CompareFloatSafe(amount, 0, accounting_core.PrecisionEpsilon) {
+			continue
+		}
+
+		csID := uint(item.CsId)
+		refID := accounting_core.NewStringRefID(&accounting_core.StringRefData{
+			RefType: accounting_core.CsCommissionRef,
+			ID: fmt.Sprintf("%d-%d-%s-%s",
+				teamID,
+				csID,
+				query.start.Format("20060102"),
+				query.end.Format("20060102"),
+			),
+		})
+		post := accounting_iface.CommissionPostItem{
+			CsId:   item.CsId,
+			RefId:  string(refID),
+			Amount: amount,
+		}
+
+		err = db.
+			Model(&accounting_core.Transaction{}).
+			Select("id").
+			Where("ref_id = ?", refID).
+			Find(&post.TransactionId).
+			Error
+
+		if err != nil {
+			return connect.NewResponse(&result), err
+		}
+
+		if post.TransactionId != 0 {
+			post.Skipped = true
+			result.Data = append(result.Data, &post)
+			continue
+		}
+
+		err = accounting_core.OpenTransaction(ctx, db, func(tx *gorm.DB, bookmng accounting_core.BookManage) error {
+			tran := accounting_core.Transaction{
+				RefID:       refID,
+				TeamID:      teamID,
+				CreatedByID: agent.IdentityID(),
+				Desc: fmt.Sprintf("komisi cs %d periode %s - %s",
+					csID,
+					query.start.Format("2006-01-02"),
+					query.end.Format("2006-01-02"),
+				),
+				Created: time.Now(),
+			}
+
+			err := bookmng.
+				NewTransaction().
+				Create(&tran).
+				AddCustomerServiceID(csID).
+				Err()
+
+			if err != nil {
+				return err
+			}
+
+			post.TransactionId = uint64(tran.ID)
+			return bookmng.
+				NewCreateEntry(teamID, agent.IdentityID()).
+				To(&accounting_core.EntryAccountPayload{
+					Key:    accounting_core.BonusesExpenseAccount,
+					TeamID: teamID,
+				}, amount).
+				To(&accounting_core.EntryAccountPayload{
+					Key:    accounting_core.PayableAccount,
+					TeamID: teamID,
+				}, amount).
+				EntryTime(entryTime).
+				Transaction(&tran).
+				Commit().
+				Err()
+		})
+
+		if err != nil {
+			return connect.NewResponse(&result), err
+		}
+
+		result.Data = append(result.Data, &post)
+	}
+
+	return connect.NewResponse(&result), nil
+}
@@ -0,0 +1,79 @@
+package customer_service
+
+import (
+	"time"
+
+	"github.com/pdcgo/schema/services/accounting_iface/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type CommissionType string
+
+const (
+	// persen dari net revenue cs
+	CommissionPercent CommissionType = "percent"
+	// nominal tetap per order selesai
+	CommissionFlat CommissionType = "flat"
+)
+
+// CommissionRule aturan komisi per team, CsID 0 berlaku untuk semua cs
+// yang tidak punya aturan sendiri.
+type CommissionRule struct {
+	ID          uint           `json:"id" gorm:"primarykey"`
+	TeamID      uint           `json:"team_id" gorm:"index:commission_rule_unique,unique"`
+	CsID        uint           `json:"cs_id" gorm:"index:commission_rule_unique,unique"`
+	Type        CommissionType `json:"type"`
+	Value       float64        `json:"value"`
+	UpdatedByID uint           `json:"updated_by_id"`
+	Updated     time.Time      `json:"updated"`
+}
+
+// Commission nilai komisi dari performa cs
+func (c *CommissionRule) Commission(netRevenue float64, completed int64) float64 {
+	var amount float64
+	switch c.Type {
+	case CommissionPercent:
+		amount = netRevenue * c.Value / 100
+	case CommissionFlat:
+		amount = float64(completed) * c.Value
+	}
+
+	if amount < 0 {
+		return 0
+	}
+	return amount
+}
+
+func commissionTypeFromProto(ctype accounting_iface.CommissionType) CommissionType {
+	switch ctype {
+	case accounting_iface.CommissionType_COMMISSION_TYPE_PERCENT:
+		return CommissionPercent
+	case accounting_iface.CommissionType_COMMISSION_TYPE_FLAT:
+		return CommissionFlat
+	default:
+		return ""
+	}
+}
+
+func commissionTypeProto(ctype CommissionType) accounting_iface.CommissionType {
+	switch ctype {
+	case CommissionPercent:
+		return accounting_iface.CommissionType_COMMISSION_TYPE_PERCENT
+	case CommissionFlat:
+		return accounting_iface.CommissionType_COMMISSION_TYPE_FLAT
+	default:
+		return accounting_iface.CommissionType_COMMISSION_TYPE_UNSPECIFIED
+	}
+}
+
+func (c *CommissionRule) proto() *accounting_iface.CommissionRule {
+	return &accounting_iface.CommissionRule{
+		Id:          uint64(c.ID),
+		TeamId:      uint64(c.TeamID),
+		CsId:        uint64(c.CsID),
+		Type:        commissionTypeProto(c.Type),
+		Value:       c.Value,
+		UpdatedById: uint64(c.UpdatedByID),
+		Updated:     timestamppb.New(c.Updated),
+	}
+}
@@ -0,0 +1,292 @@
+package customer_service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/pdcgo/accounting_service/accounting_core"
+	"github.com/pdcgo/accounting_service/query_dialect"
+	"github.com/pdcgo/schema/services/accounting_iface/v1"
+	"github.com/pdcgo/schema/services/common/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/gorm"
+)
+
+func addCsPerformance(c *accounting_iface.CsPerformance, other *accounting_iface.CsPerformance) {
+	c.OrdersBooked += other.OrdersBooked
+	c.OrdersCancelled += other.OrdersCancelled
+	c.OrdersReturned += other.OrdersReturned
+	c.OrdersCompleted += other.OrdersCompleted
+	c.Revenue += other.Revenue
+	c.Returns += other.Returns
+	c.Expense += other.Expense
+	c.NetRevenue += other.NetRevenue
+	c.NetContribution += other.NetContribution
+	c.Commission += other.Commission
+	c.CommissionPosted += other.CommissionPosted
+}
+
+// reportPeriod hari laporan dari time range request
+func reportPeriod(timeRange *common.TimeFilterRange) (start, end time.Time, err error) {
+	if timeRange == nil ||
+		timeRange.StartDate == nil ||
+		timeRange.EndDate == nil ||
+		timeRange.EndDate.AsTime().Before(timeRange.StartDate.AsTime()) {
+		return start, end, errors.New("invalid start end")
+	}
+
+	return query_dialect.ReportDay(timeRange.StartDate.AsTime()), query_dialect.ReportDay(timeRange.EndDate.AsTime()), nil
+}
+
+// ref transaksi return yang ditandai cs
+var csReturnRefs = []accounting_core.RefType{
+	accounting_core.StockReturnRef,
+	accounting_core.OrderReturnRef,
+}
+
+type csPerformanceQuery struct {
+	db     *gorm.DB
+	teamID uint
+	csID   uint
+	// hari laporan
+	start time.Time
+	end   time.Time
+}
+
+func (q *csPerformanceQuery) refLike(ref accounting_core.RefType) string {
+	return string(ref) + "#%"
+}
+
+// orderCounts jumlah order per cs dari transaksi yang ditandai AddCustomerServiceID
+func (q *csPerformanceQuery) orderCounts(result map[uint]*accounting_iface.CsPerformance) error {
+	returnCase := []string{}
+	args := map[string]any{
+		"order": q.refLike(accounting_core.OrderRef),
+	}
+	for _, ref := range csReturnRefs {
+		returnCase = append(returnCase, "t.ref_id like @"+string(ref))
+		args[string(ref)] = q.refLike(ref)
+	}
+
+	rows := []*struct {
+		CsID      uint
+		Booked    int64
+		Cancelled int64
+		Returned  int64
+	}{}
+
+	query := q.
+		db.
+		Table("journal_entries je").
+		Joins("join transactions t on t.id = je.transaction_id").
+		Joins("join transaction_customer_services tcs on tcs.transaction_id = t.id").
+		Select(strings.Join([]string{
+			"tcs.customer_service_id as cs_id",
+			"count(distinct case when t.ref_id like @order and je.rollback = false then t.id end) as booked",
+			"count(distinct case when t.ref_id like @order and je.rollback = true then t.id end) as cancelled",
+			"count(distinct case when (" + strings.Join(returnCase, " or ") + ") and je.rollback = false then t.id end) as returned",
+		}, ", "), args).
+		Where("je.team_id = ?", q.teamID).
+		Where("je.entry_time >= ?", query_dialect.ReportDayStart(q.start)).
+		Where("je.entry_time < ?", query_dialect.ReportDayStart(q.end.AddDate(0, 0, 1))).
+		Group("tcs.customer_service_id")
+
+	if q.csID != 0 {
+		query = query.Where("tcs.customer_service_id = ?", q.csID)
+	}
+
+	err := query.Find(&rows).Error
+	if err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		item := q.item(result, row.CsID)
+		item.OrdersBooked = row.Booked
+		item.OrdersCancelled = row.Cancelled
+		item.OrdersReturned = row.Returned
+	}
+
+	return nil
+}
+
+// amounts nilai revenue dan beban per cs dari cs daily balance
+func (q *csPerformanceQuery) amounts(result map[uint]*accounting_iface.CsPerformance) error {
+	rows := []*struct {
+		CsID       uint
+		AccountKey accounting_core.AccountKey
+		Coa        accounting_core.CoaCode
+		Debit      float64
+		Credit     float64
+	}{}
+
+	query := q.
+		db.
+		Table("cs_daily_balances adb").
+		Joins("join accounts a on a.id = adb.account_id").
+		Select([]string{
+			"adb.cs_id",
+			"a.account_key",
+			"a.coa",
+			"sum(adb.debit) as debit",
+			"sum(adb.credit) as credit",
+		}).
+		Where("adb.journal_team_id = ?", q.teamID).
+		Where("a.coa in ?", []accounting_core.CoaCode{accounting_core.REVENUE, accounting_core.EXPENSE}).
+		Where("adb.day >= ?", q.start).
+		Where("adb.day <= ?", q.end).
+		Group("adb.cs_id, a.account_key, a.coa")
+
+	if q.csID != 0 {
+		query = query.Where("adb.cs_id = ?", q.csID)
+	}
+
+	err := query.Find(&rows).Error
+	if err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		item := q.item(result, row.CsID)
+		switch {
+		case row.Coa == accounting_core.REVENUE:
+			item.Revenue += row.Credit - row.Debit
+		case row.AccountKey == accounting_core.SellingReturnExpenseAccount:
+			item.Returns += row.Debit - row.Credit
+		case row.AccountKey == accounting_core.BonusesExpenseAccount:
+			item.CommissionPosted += row.Debit - row.Credit
+		default:
+			item.Expense += row.Debit - row.Credit
+		}
+	}
+
+	return nil
+}
+
+func (q *csPerformanceQuery) item(result map[uint]*accounting_iface.CsPerformance, csID uint) *accounting_iface.CsPerformance {
+	if result[csID] == nil {
+		result[csID] = &accounting_iface.CsPerformance{CsId: uint64(csID)}
+	}
+	return result[csID]
+}
+
+// rules aturan komisi per cs, key 0 untuk default team
+func (q *csPerformanceQuery) rules() (map[uint]*CommissionRule, error) {
+	items := []*CommissionRule{}
+	err := q.
+		db.
+		Model(&CommissionRule{}).
+		Where("team_id = ?", q.teamID).
+		Find(&items).
+		Error
+
+	result := map[uint]*CommissionRule{}
+	for _, item := range items {
+		result[item.CsID] = item
+	}
+	return result, err
+}
+
+// performances performa semua cs urut berdasarkan cs id
+func (q *csPerformanceQuery) performances() ([]*accounting_iface.CsPerformance, error) {
+	data := map[uint]*accounting_iface.CsPerformance{}
+
+	err := q.orderCounts(data)
+	if err != nil {
+		return nil, err
+	}
+
+	err = q.amounts(data)
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := q.rules()
+	if err != nil {
+		return nil, err
+	}
+
+	result := []*accounting_iface.CsPerformance{}
+	for csID, item := range data {
+		item.OrdersCompleted = max(item.OrdersBooked-item.OrdersCancelled-item.OrdersReturned, 0)
+		item.NetRevenue = item.Revenue - item.Returns
+		item.NetContribution = item.NetRevenue - item.Expense
+
+		rule := rules[csID]
+		if rule == nil {
+			rule = rules[0]
+		}
+		if rule != nil {
+			item.Rule = rule.proto()
+			item.Commission = rule.Commission(item.NetRevenue, item.OrdersCompleted)
+		}
+
+		result = append(result, item)
+	}
+
+	slices.SortFunc(result, func(a, b *accounting_iface.CsPerformance) int {
+		return int(a.CsId) - int(b.CsId)
+	})
+
+	return result, nil
+}
+
+// CsReport laporan performa dan komisi cs per periode
+func (c *csServiceImpl) CsReport(
+	ctx context.Context,
+	req *connect.Request[accounting_iface.CsReportRequest],
+) (*connect.Response[accounting_iface.CsReportResponse], error) {
+	var err error
+	pay := req.Msg
+
+	result := accounting_iface.CsReportResponse{
+		Data:  []*accounting_iface.CsPerformance{},
+		Total: &accounting_iface.CsPerformance{},
+	}
+
+	err = c.
+		auth.
+		AuthIdentityFromHeader(req.Header()).
+		Err()
+
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	if pay.TeamId == 0 {
+		return connect.NewResponse(&result), connect.NewError(connect.CodeInvalidArgument, errors.New("team_id required"))
+	}
+
+	start, end, err := reportPeriod(pay.TimeRange)
+	if err != nil {
+		return connect.NewResponse(&result), connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	query := csPerformanceQuery{
+		db:     c.db.WithContext(ctx),
+		teamID: uint(pay.TeamId),
+		csID:   uint(pay.CsId),
+		start:  start,
+		end:    end,
+	}
+
+	result.TimeRange = &common.TimeFilterRange{
+		StartDate: timestamppb.New(query.start),
+		EndDate:   timestamppb.New(query.end),
+	}
+
+	result.Data, err = query.performances()
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	for _, item := range result.Data {
+		addCsPerformance(result.Total, item)
+	}
+
+	return connect.NewResponse(&result), nil
+}
@@ -0,0 +1,40 @@
+package customer_service
+
+import (
+	"net/http"
+
+	"github.com/pdcgo/accounting_service/accounting_model"
+	"github.com/pdcgo/shared/interfaces/authorization_iface"
+	"gorm.io/gorm"
+)
+
+type csServiceImpl struct {
+	db   *gorm.DB
+	auth authorization_iface.Authorization
+}
+
+// komisi diposting sebagai beban bonus, jadi memakai permission expense
+func (c *csServiceImpl) checkPermission(header http.Header, teamID uint64, action authorization_iface.Action) (authorization_iface.Identity, error) {
+	identity := c.auth.AuthIdentityFromHeader(header)
+	err := identity.
+		HasPermission(authorization_iface.CheckPermissionGroup{
+			accounting_model.ExpenseEntity{}: &authorization_iface.CheckPermission{
+				DomainID: uint(teamID),
+				Actions:  []authorization_iface.Action{action},
+			},
+		}).
+		Err()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return identity.Identity(), nil
+}
+
+func NewCsService(db *gorm.DB, auth authorization_iface.Authorization) *csServiceImpl {
+	return &csServiceImpl{
+		db:   db,
+		auth: auth,
+	}
+}
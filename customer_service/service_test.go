@@ -0,0 +1,229 @@
+package customer_service_test
+
+import (
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/pdcgo/accounting_service/accounting_core"
+	"github.com/pdcgo/accounting_service/accounting_mock"
+	"github.com/pdcgo/accounting_service/customer_service"
+	"github.com/pdcgo/schema/services/accounting_iface/v1"
+	"github.com/pdcgo/schema/services/common/v1"
+	"github.com/pdcgo/shared/authorization/authorization_mock"
+	"github.com/pdcgo/shared/pkg/moretest"
+	"github.com/pdcgo/shared/pkg/moretest/moretest_mock"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/gorm"
+)
+
+type csEntry struct {
+	refID    accounting_core.RefID
+	csID     uint
+	at       time.Time
+	rollback bool
+}
+
+func TestCsReport(t *testing.T) {
+	var db gorm.DB
+
+	oct1 := time.Date(2025, 10, 1, 10, 0, 0, 0, accounting_mock.Jkt)
+	day := time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	accountID := func(t *testing.T, key accounting_core.AccountKey) uint {
+		acc := accounting_core.Account{}
+		err := db.Where("team_id = 1 and account_key = ?", key).First(&acc).Error
+		assert.Nil(t, err)
+		return acc.ID
+	}
+
+	moretest.Suite(t, "testing cs report",
+		moretest.SetupListFunc{
+			moretest_mock.MockSqliteDatabase(&db),
+			func(t *testing.T) func() error {
+				err := db.AutoMigrate(
+					&accounting_core.Account{},
+					&accounting_core.Transaction{},
+					&accounting_core.TransactionCustomerService{},
+					&accounting_core.JournalEntry{},
+					&accounting_core.AccountDailyBalance{},
+					&accounting_core.CsDailyBalance{},
+					&customer_service.CommissionRule{},
+				)
+				assert.Nil(t, err)
+				return nil
+			},
+			accounting_mock.PopulateAccountKey(&db, 1),
+			func(t *testing.T) func() error {
+				salesID := accountID(t, accounting_core.SalesRevenueAccount)
+				entries := []*csEntry{
+					{refID: "order#1", csID: 7, at: oct1},
+					{refID: "order#2", csID: 7, at: oct1},
+					{refID: "order#2", csID: 7, at: oct1.AddDate(0, 0, 1), rollback: true},
+					{refID: "stock_return#1", csID: 7, at: oct1.AddDate(0, 0, 2)},
+					{refID: "order#3", csID: 8, at: oct1},
+					// di luar periode
+					{refID: "order#4", csID: 8, at: oct1.AddDate(0, 1, 0)},
+				}
+
+				txIDs := map[accounting_core.RefID]uint{}
+				for _, entry := range entries {
+					if txIDs[entry.refID] == 0 {
+						tran := accounting_core.Transaction{RefID: entry.refID, TeamID: 1, Created: entry.at}
+						err := db.Create(&tran).Error
+						assert.Nil(t, err)
+
+						err = db.Create(&accounting_core.TransactionCustomerService{
+							TransactionID:     tran.ID,
+							CustomerServiceID: entry.csID,
+						}).Error
+						assert.Nil(t, err)
+						txIDs[entry.refID] = tran.ID
+					}
+
+					err := db.Create(&accounting_core.JournalEntry{
+						AccountID:     salesID,
+						TeamID:        1,
+						TransactionID: txIDs[entry.refID],
+						EntryTime:     entry.at,
+						Credit:        1000,
+						Rollback:      entry.rollback,
+					}).Error
+					assert.Nil(t, err)
+				}
+
+				err := db.Create([]*accounting_core.CsDailyBalance{
+					{Day: day, CsID: 7, AccountID: salesID, JournalTeamID: 1, Credit: 10000},
+					{Day: day, CsID: 7, AccountID: accountID(t, accounting_core.SellingReturnExpenseAccount), JournalTeamID: 1, Debit: 1000},
+					{Day: day, CsID: 7, AccountID: accountID(t, accounting_core.StockCostAccount), JournalTeamID: 1, Debit: 4000},
+					{Day: day, CsID: 8, AccountID: salesID, JournalTeamID: 1, Credit: 5000},
+				}).Error
+				assert.Nil(t, err)
+				return nil
+			},
+		},
+		func(t *testing.T) {
+			service := customer_service.NewCsService(&db, &authorization_mock.EmptyAuthorizationMock{
+				AuthIdentityMock: &authorization_mock.AuthIdentityMock{
+					IdentityMock: &authorization_mock.IdentityMock{ID: 1},
+				},
+			})
+
+			period := &common.TimeFilterRange{
+				StartDate: timestamppb.New(day),
+				EndDate:   timestamppb.New(time.Date(2025, 10, 31, 0, 0, 0, 0, accounting_mock.Jkt)),
+			}
+
+			setRule := func(pay *accounting_iface.CommissionRuleSetRequest) (*accounting_iface.CommissionRuleSetResponse, error) {
+				res, err := service.CommissionRuleSet(t.Context(), connect.NewRequest(pay))
+				return res.Msg, err
+			}
+
+			t.Run("set aturan komisi", func(t *testing.T) {
+				_, err := setRule(&accounting_iface.CommissionRuleSetRequest{
+					TeamId: 1,
+					Type:   accounting_iface.CommissionType_COMMISSION_TYPE_PERCENT,
+					Value:  5,
+				})
+				assert.Nil(t, err)
+
+				// update default team
+				res, err := setRule(&accounting_iface.CommissionRuleSetRequest{
+					TeamId: 1,
+					Type:   accounting_iface.CommissionType_COMMISSION_TYPE_PERCENT,
+					Value:  10,
+				})
+				assert.Nil(t, err)
+				assert.Equal(t, 10.0, res.Rule.Value)
+
+				_, err = setRule(&accounting_iface.CommissionRuleSetRequest{
+					TeamId: 1,
+					CsId:   8,
+					Type:   accounting_iface.CommissionType_COMMISSION_TYPE_FLAT,
+					Value:  2000,
+				})
+				assert.Nil(t, err)
+
+				_, err = setRule(&accounting_iface.CommissionRuleSetRequest{
+					TeamId: 1,
+				})
+				assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+
+				list, err := service.CommissionRuleList(t.Context(), connect.NewRequest(&accounting_iface.CommissionRuleListRequest{TeamId: 1}))
+				assert.Nil(t, err)
+				assert.Len(t, list.Msg.Data, 2)
+			})
+
+			t.Run("report performa cs", func(t *testing.T) {
+				report, err := service.CsReport(t.Context(), connect.NewRequest(&accounting_iface.CsReportRequest{
+					TeamId:    1,
+					TimeRange: period,
+				}))
+				assert.Nil(t, err)
+
+				res := report.Msg
+				assert.Len(t, res.Data, 2)
+
+				cs := res.Data[0]
+				assert.Equal(t, uint64(7), cs.CsId)
+				assert.Equal(t, int64(2), cs.OrdersBooked)
+				assert.Equal(t, int64(1), cs.OrdersCancelled)
+				assert.Equal(t, int64(1), cs.OrdersReturned)
+				assert.Equal(t, int64(0), cs.OrdersCompleted)
+				assert.Equal(t, 10000.0, cs.Revenue)
+				assert.Equal(t, 1000.0, cs.Returns)
+				assert.Equal(t, 9000.0, cs.NetRevenue)
+				assert.Equal(t, 5000.0, cs.NetContribution)
+				assert.Equal(t, 900.0, cs.Commission)
+				assert.Equal(t, accounting_iface.CommissionType_COMMISSION_TYPE_PERCENT, cs.Rule.Type)
+
+				cs = res.Data[1]
+				assert.Equal(t, int64(1), cs.OrdersBooked)
+				assert.Equal(t, int64(1), cs.OrdersCompleted)
+				assert.Equal(t, 2000.0, cs.Commission)
+
+				assert.Equal(t, 2900.0, res.Total.Commission)
+			})
+
+			t.Run("posting komisi", func(t *testing.T) {
+				pay := accounting_iface.CommissionPostRequest{
+					TeamId:    1,
+					TimeRange: period,
+				}
+
+				post, err := service.CommissionPost(t.Context(), connect.NewRequest(&pay))
+				assert.Nil(t, err)
+
+				res := post.Msg
+				assert.Len(t, res.Data, 2)
+				assert.False(t, res.Data[0].Skipped)
+
+				var payable float64
+				err = db.
+					Model(&accounting_core.JournalEntry{}).
+					Select("coalesce(sum(credit - debit), 0)").
+					Where("account_id = ?", accountID(t, accounting_core.PayableAccount)).
+					Scan(&payable).
+					Error
+				assert.Nil(t, err)
+				assert.Equal(t, 2900.0, payable)
+
+				var csCount int64
+				err = db.
+					Model(&accounting_core.TransactionCustomerService{}).
+					Where("transaction_id = ?", res.Data[1].TransactionId).
+					Where("customer_service_id = ?", 8).
+					Count(&csCount).
+					Error
+				assert.Nil(t, err)
+				assert.Equal(t, int64(1), csCount)
+
+				post, err = service.CommissionPost(t.Context(), connect.NewRequest(&pay))
+				assert.Nil(t, err)
+				assert.True(t, post.Msg.Data[0].Skipped)
+				assert.True(t, post.Msg.Data[1].Skipped)
+			})
+		},
+	)
+}
@@ -2,12 +2,14 @@ package expense
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"connectrpc.com/connect"
 	"github.com/pdcgo/accounting_service/accounting_core"
 	"github.com/pdcgo/accounting_service/accounting_model"
 	"github.com/pdcgo/accounting_service/accounting_transaction/expense_transaction"
+	"github.com/pdcgo/accounting_service/budget"
 	"github.com/pdcgo/schema/services/accounting_iface/v1"
 	"github.com/pdcgo/schema/services/common/v1"
 	"github.com/pdcgo/shared/authorization"
@@ -56,13 +58,14 @@ func (e *expenseServiceImpl) ExpenseCreate(
 		WithContext(ctx).
 		Transaction(func(tx *gorm.DB) error {
 			exp := accounting_model.Expense{
-				TeamID:      uint(pay.TeamId),
-				CreatedByID: agent.GetUserID(),
-				ExpenseType: pay.ExpenseType,
-				ExpenseKey:  pay.ExpenseKey,
-				Desc:        pay.Desc,
-				Amount:      pay.Amount,
-				CreatedAt:   time.Now(),
+				TeamID:        uint(pay.TeamId),
+				CreatedByID:   agent.GetUserID(),
+				ExpenseType:   pay.ExpenseType,
+				ExpenseKey:    pay.ExpenseKey,
+				Desc:          pay.Desc,
+				Amount:        pay.Amount,
+				BankAccountID: uint(pay.BankAccountId),
+				CreatedAt:     time.Now(),
 			}
 
 			err = tx.Save(&exp).Error
@@ -70,14 +73,32 @@ func (e *expenseServiceImpl) ExpenseCreate(
 				return err
 			}
 
+			check, err := budget.
+				NewBudgetCheck(tx).
+				Check(&budget.PostingPayload{
+					TeamID:     uint(pay.TeamId),
+					AccountKey: accounting_core.AccountKey(pay.ExpenseKey),
+					At:         exp.CreatedAt,
+					Amount:     pay.Amount,
+				})
+			if err != nil {
+				if errors.Is(err, budget.ErrBudgetExceeded) {
+					return connect.NewError(connect.CodeFailedPrecondition, err)
+				}
+				return err
+			}
+
+			result.Msg.BudgetWarnings = check.Warnings
+
 			err = expense_transaction.
 				NewExpenseTransaction(ctx, tx, identity.Identity()).
 				ExpenseCreate(&expense_transaction.CreatePayload{
-					TeamID:      uint(pay.TeamId),
-					ExpenseKey:  accounting_core.AccountKey(pay.ExpenseKey),
-					ExpenseType: pay.ExpenseType,
-					Amount:      pay.Amount,
-					Desc:        pay.Desc,
+					TeamID:        uint(pay.TeamId),
+					ExpenseKey:    accounting_core.AccountKey(pay.ExpenseKey),
+					ExpenseType:   pay.ExpenseType,
+					Amount:        pay.Amount,
+					Desc:          pay.Desc,
+					BankAccountID: pay.BankAccountId,
 				})
 
 			return err
@@ -7,6 +7,7 @@ import (
 	"github.com/pdcgo/accounting_service/accounting_core"
 	"github.com/pdcgo/accounting_service/accounting_mock"
 	"github.com/pdcgo/accounting_service/accounting_model"
+	"github.com/pdcgo/accounting_service/budget"
 	"github.com/pdcgo/accounting_service/expense"
 	"github.com/pdcgo/schema/services/accounting_iface/v1"
 	"github.com/pdcgo/schema/services/common/v1"
@@ -26,9 +27,18 @@ func TestExpenseList(t *testing.T) {
 			&accounting_core.Transaction{},
 			&accounting_core.Account{},
 			&accounting_core.JournalEntry{},
+			&accounting_core.JournalEntryBank{},
+			&accounting_model.BankAccountV2{},
+			&budget.Budget{},
 		)
 		assert.Nil(t, err)
 
+		err = db.Create([]*accounting_model.BankAccountV2{
+			{ID: 1, TeamID: 5, Name: "bca operasional", NumberID: "1001"},
+			{ID: 2, TeamID: 6, Name: "bca gudang", NumberID: "1002"},
+		}).Error
+		assert.Nil(t, err)
+
 		return nil
 	}
 
@@ -49,17 +59,64 @@ func TestExpenseList(t *testing.T) {
 
 			_, err := srv.ExpenseCreate(t.Context(), &connect.Request[accounting_iface.ExpenseCreateRequest]{
 				Msg: &accounting_iface.ExpenseCreateRequest{
-					TeamId:      5,
-					Desc:        "asasdasdas ",
-					ExpenseType: accounting_iface.ExpenseType_EXPENSE_TYPE_INTERNAL,
-					ExpenseKey:  string(accounting_core.SalaryAccount),
-					Amount:      12000,
-					RequestFrom: common.RequestFrom_REQUEST_FROM_ADMIN,
+					TeamId:        5,
+					Desc:          "asasdasdas ",
+					ExpenseType:   accounting_iface.ExpenseType_EXPENSE_TYPE_INTERNAL,
+					ExpenseKey:    string(accounting_core.SalaryAccount),
+					Amount:        12000,
+					RequestFrom:   common.RequestFrom_REQUEST_FROM_ADMIN,
+					BankAccountId: 1,
 				},
 			})
 
 			assert.Nil(t, err)
 
+			t.Run("kas keluar tercatat di bank", func(t *testing.T) {
+				var bank accounting_model.BankAccountV2
+				err := db.First(&bank, 1).Error
+				assert.Nil(t, err)
+				assert.Equal(t, -12000.0, bank.Balance)
+
+				var unlinked int64
+				err = db.Model(&accounting_core.JournalEntryBank{}).Where("journal_entry_id = 0").Count(&unlinked).Error
+				assert.Nil(t, err)
+				assert.Equal(t, int64(0), unlinked)
+			})
+
+			t.Run("expense tanpa bank ditolak", func(t *testing.T) {
+				_, err := srv.ExpenseCreate(t.Context(), &connect.Request[accounting_iface.ExpenseCreateRequest]{
+					Msg: &accounting_iface.ExpenseCreateRequest{
+						TeamId:      5,
+						Desc:        "tanpa bank",
+						ExpenseType: accounting_iface.ExpenseType_EXPENSE_TYPE_INTERNAL,
+						ExpenseKey:  string(accounting_core.SalaryAccount),
+						Amount:      5000,
+						RequestFrom: common.RequestFrom_REQUEST_FROM_ADMIN,
+					},
+				})
+				assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+			})
+
+			t.Run("bank team lain ditolak", func(t *testing.T) {
+				_, err := srv.ExpenseCreate(t.Context(), &connect.Request[accounting_iface.ExpenseCreateRequest]{
+					Msg: &accounting_iface.ExpenseCreateRequest{
+						TeamId:        5,
+						Desc:          "bank gudang",
+						ExpenseType:   accounting_iface.ExpenseType_EXPENSE_TYPE_INTERNAL,
+						ExpenseKey:    string(accounting_core.SalaryAccount),
+						Amount:        5000,
+						RequestFrom:   common.RequestFrom_REQUEST_FROM_ADMIN,
+						BankAccountId: 2,
+					},
+				})
+				assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+
+				var count int64
+				err = db.Model(&accounting_model.Expense{}).Count(&count).Error
+				assert.Nil(t, err)
+				assert.Equal(t, int64(1), count)
+			})
+
 			// t.Run("testing expense list", func(t *testing.T) {
 
 			// 	res, err := srv.ExpenseList(t.Context(), &connect.Request[accounting_iface.ExpenseListRequest]{
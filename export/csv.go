@@ -0,0 +1,54 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+)
+
+type csvWriter struct {
+	doc    *Document
+	writer *csv.Writer
+}
+
+func newCsvWriter(doc *Document, w io.Writer) (*csvWriter, error) {
+	c := csvWriter{
+		doc:    doc,
+		writer: csv.NewWriter(w),
+	}
+
+	headers := make([]string, len(doc.Columns))
+	for i, col := range doc.Columns {
+		headers[i] = col.Header
+	}
+
+	err := c.writer.Write(headers)
+	return &c, err
+}
+
+// WriteRow implements Writer.
+func (c *csvWriter) WriteRow(values ...any) error {
+	record := make([]string, len(values))
+	for i, value := range values {
+		// angka tanpa pemisah ribuan supaya bisa dibaca ulang
+		if amount, ok := value.(float64); ok {
+			record[i] = strconv.FormatFloat(amount, 'f', 2, 64)
+			continue
+		}
+		record[i] = formatText(value)
+	}
+
+	err := c.writer.Write(record)
+	if err != nil {
+		return err
+	}
+
+	c.writer.Flush()
+	return c.writer.Error()
+}
+
+// Close implements Writer.
+func (c *csvWriter) Close() error {
+	c.writer.Flush()
+	return c.writer.Error()
+}
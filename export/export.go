@@ -0,0 +1,148 @@
+package export
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pdcgo/accounting_service/query_dialect"
+	"github.com/pdcgo/schema/services/accounting_iface/v1"
+)
+
+// Format format file export, unspecified dianggap csv
+type Format = accounting_iface.ExportFormat
+
+const (
+	FormatCsv  = accounting_iface.ExportFormat_EXPORT_FORMAT_CSV
+	FormatXlsx = accounting_iface.ExportFormat_EXPORT_FORMAT_XLSX
+	FormatPdf  = accounting_iface.ExportFormat_EXPORT_FORMAT_PDF
+)
+
+var ErrUnknownFormat = errors.New("format export tidak dikenal")
+
+type ColumnKind int
+
+const (
+	TextColumn ColumnKind = iota
+	NumberColumn
+	TimeColumn
+)
+
+type Column struct {
+	Header string
+	Kind   ColumnKind
+	// lebar kolom dalam jumlah karakter
+	Width float64
+}
+
+// Document header laporan yang diexport
+type Document struct {
+	Title    string
+	TeamName string
+	// periode laporan, Start kosong untuk laporan per tanggal
+	Start   time.Time
+	End     time.Time
+	Columns []*Column
+}
+
+// Period periode laporan dalam tanggal laporan
+func (d *Document) Period() string {
+	if d.End.IsZero() {
+		return ""
+	}
+
+	end := query_dialect.ReportDay(d.End).Format("02 Jan 2006")
+	if d.Start.IsZero() {
+		return "per " + end
+	}
+	return query_dialect.ReportDay(d.Start).Format("02 Jan 2006") + " - " + end
+}
+
+// Writer menulis baris laporan ke format tertentu
+type Writer interface {
+	// WriteRow nilai sesuai urutan kolom, berupa string, float64 atau time.Time
+	WriteRow(values ...any) error
+	// Close menulis sisa dokumen ke writer tujuan
+	Close() error
+}
+
+func NewWriter(format Format, doc *Document, w io.Writer) (Writer, error) {
+	switch format {
+	case FormatCsv, accounting_iface.ExportFormat_EXPORT_FORMAT_UNSPECIFIED:
+		return newCsvWriter(doc, w)
+	case FormatXlsx:
+		return newXlsxWriter(doc, w)
+	case FormatPdf:
+		return newPdfWriter(doc, w)
+	}
+
+	return nil, ErrUnknownFormat
+}
+
+// ContentType mime type hasil export
+func ContentType(format Format) string {
+	switch format {
+	case FormatXlsx:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	case FormatPdf:
+		return "application/pdf"
+	}
+	return "text/csv"
+}
+
+// Extension ekstensi file hasil export
+func Extension(format Format) string {
+	switch format {
+	case FormatXlsx:
+		return "xlsx"
+	case FormatPdf:
+		return "pdf"
+	}
+	return "csv"
+}
+
+// reportTime waktu dengan jam dinding timezone laporan
+func reportTime(t time.Time) time.Time {
+	local := t.In(query_dialect.ReportLocation())
+	return time.Date(local.Year(), local.Month(), local.Day(), local.Hour(), local.Minute(), local.Second(), 0, time.UTC)
+}
+
+// formatAmount angka dengan dua desimal dan pemisah ribuan
+func formatAmount(amount float64) string {
+	raw := strconv.FormatFloat(amount, 'f', 2, 64)
+	sign := ""
+	if strings.HasPrefix(raw, "-") {
+		sign = "-"
+		raw = raw[1:]
+	}
+
+	whole, frac, _ := strings.Cut(raw, ".")
+	parts := []string{}
+	for len(whole) > 3 {
+		parts = append([]string{whole[len(whole)-3:]}, parts...)
+		whole = whole[:len(whole)-3]
+	}
+	parts = append([]string{whole}, parts...)
+
+	return sign + strings.Join(parts, ",") + "." + frac
+}
+
+func formatText(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return formatAmount(v)
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		return reportTime(v).Format("2006-01-02 15:04")
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,111 @@
+package export_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pdcgo/accounting_service/accounting_mock"
+	"github.com/pdcgo/accounting_service/export"
+	"github.com/stretchr/testify/assert"
+	"github.com/xuri/excelize/v2"
+)
+
+func testDocument() *export.Document {
+	return &export.Document{
+		Title:    "Ledger Entries",
+		TeamName: "team satu",
+		Start:    time.Date(2025, 10, 1, 0, 0, 0, 0, accounting_mock.Jkt),
+		End:      time.Date(2025, 10, 31, 0, 0, 0, 0, accounting_mock.Jkt),
+		Columns: []*export.Column{
+			{Header: "entry_at", Kind: export.TimeColumn},
+			{Header: "desc", Kind: export.TextColumn, Width: 40},
+			{Header: "debit", Kind: export.NumberColumn},
+		},
+	}
+}
+
+func writeRows(t *testing.T, format export.Format) []byte {
+	buf := bytes.NewBuffer(nil)
+	writer, err := export.NewWriter(format, testDocument(), buf)
+	assert.Nil(t, err)
+
+	err = writer.WriteRow(time.Date(2025, 10, 2, 9, 30, 0, 0, accounting_mock.Jkt), "order#1 pendapatan", 1234567.5)
+	assert.Nil(t, err)
+	err = writer.WriteRow(time.Date(2025, 10, 3, 9, 30, 0, 0, accounting_mock.Jkt), "biaya iklan", -20.25)
+	assert.Nil(t, err)
+
+	err = writer.Close()
+	assert.Nil(t, err)
+	return buf.Bytes()
+}
+
+func TestExportWriter(t *testing.T) {
+	t.Run("periode dokumen", func(t *testing.T) {
+		doc := testDocument()
+		assert.Equal(t, "01 Oct 2025 - 31 Oct 2025", doc.Period())
+
+		doc.Start = time.Time{}
+		assert.Equal(t, "per 31 Oct 2025", doc.Period())
+		assert.Equal(t, "ledger_entries_20251031.xlsx", export.Filename(doc, export.FormatXlsx))
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		raw := string(writeRows(t, export.FormatCsv))
+		lines := strings.Split(strings.TrimSpace(raw), "\n")
+		assert.Len(t, lines, 3)
+		assert.Equal(t, "entry_at,desc,debit", lines[0])
+		assert.Equal(t, "2025-10-02 09:30,order#1 pendapatan,1234567.50", lines[1])
+		assert.Equal(t, "2025-10-03 09:30,biaya iklan,-20.25", lines[2])
+	})
+
+	t.Run("xlsx", func(t *testing.T) {
+		raw := writeRows(t, export.FormatXlsx)
+		file, err := excelize.OpenReader(bytes.NewReader(raw))
+		assert.Nil(t, err)
+		defer file.Close()
+
+		sheet := file.GetSheetName(0)
+
+		title, err := file.GetCellValue(sheet, "A1")
+		assert.Nil(t, err)
+		assert.Equal(t, "Ledger Entries", title)
+
+		period, err := file.GetCellValue(sheet, "A3")
+		assert.Nil(t, err)
+		assert.Equal(t, "01 Oct 2025 - 31 Oct 2025", period)
+
+		header, err := file.GetCellValue(sheet, "C5")
+		assert.Nil(t, err)
+		assert.Equal(t, "debit", header)
+
+		cellType, err := file.GetCellType(sheet, "C6")
+		assert.Nil(t, err)
+		assert.NotEqual(t, excelize.CellTypeSharedString, cellType)
+		assert.NotEqual(t, excelize.CellTypeInlineString, cellType)
+
+		amount, err := file.GetCellValue(sheet, "C6", excelize.Options{RawCellValue: true})
+		assert.Nil(t, err)
+		assert.Equal(t, "1234567.5", amount)
+
+		entryAt, err := file.GetCellValue(sheet, "A6")
+		assert.Nil(t, err)
+		assert.Equal(t, "2025-10-02 09:30", entryAt)
+
+		panes, err := file.GetPanes(sheet)
+		assert.Nil(t, err)
+		assert.True(t, panes.Freeze)
+		assert.Equal(t, 5, panes.YSplit)
+	})
+
+	t.Run("pdf", func(t *testing.T) {
+		raw := writeRows(t, export.FormatPdf)
+		assert.True(t, bytes.HasPrefix(raw, []byte("%PDF")))
+	})
+
+	t.Run("format tidak dikenal", func(t *testing.T) {
+		_, err := export.NewWriter(export.Format(99), testDocument(), bytes.NewBuffer(nil))
+		assert.ErrorIs(t, err, export.ErrUnknownFormat)
+	})
+}
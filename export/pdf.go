@@ -0,0 +1,129 @@
+package export
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// total lebar kolom (karakter) yang masih muat di A4 portrait
+const pdfPortraitWidth = 110
+
+const pdfRowHeight = 6
+
+type pdfWriter struct {
+	doc       *Document
+	out       io.Writer
+	pdf       *fpdf.Fpdf
+	widths    []float64
+	translate func(string) string
+}
+
+func newPdfWriter(doc *Document, w io.Writer) (*pdfWriter, error) {
+	var total float64
+	for _, col := range doc.Columns {
+		total += columnWidth(col)
+	}
+
+	orientation := "P"
+	if total > pdfPortraitWidth {
+		orientation = "L"
+	}
+
+	p := pdfWriter{
+		doc: doc,
+		out: w,
+		pdf: fpdf.New(orientation, "mm", "A4", ""),
+	}
+	p.translate = p.pdf.UnicodeTranslatorFromDescriptor("")
+
+	pageWidth, _ := p.pdf.GetPageSize()
+	left, _, right, _ := p.pdf.GetMargins()
+	usable := pageWidth - left - right
+
+	p.widths = make([]float64, len(doc.Columns))
+	for i, col := range doc.Columns {
+		p.widths[i] = usable * columnWidth(col) / total
+	}
+
+	p.pdf.AliasNbPages("")
+	p.pdf.SetHeaderFuncMode(p.header, true)
+	p.pdf.SetFooterFunc(p.footer)
+	p.pdf.AddPage()
+
+	return &p, p.pdf.Error()
+}
+
+func columnWidth(col *Column) float64 {
+	if col.Width == 0 {
+		return 15
+	}
+	return col.Width
+}
+
+// header judul, team dan periode diulang di setiap halaman bersama header kolom
+func (p *pdfWriter) header() {
+	p.pdf.SetFont("Helvetica", "B", 13)
+	p.pdf.CellFormat(0, 7, p.translate(p.doc.Title), "", 1, "L", false, 0, "")
+
+	p.pdf.SetFont("Helvetica", "", 9)
+	if p.doc.TeamName != "" {
+		p.pdf.CellFormat(0, 5, p.translate(p.doc.TeamName), "", 1, "L", false, 0, "")
+	}
+	if period := p.doc.Period(); period != "" {
+		p.pdf.CellFormat(0, 5, period, "", 1, "L", false, 0, "")
+	}
+	p.pdf.Ln(2)
+
+	p.pdf.SetFont("Helvetica", "B", 8)
+	p.pdf.SetFillColor(217, 225, 242)
+	for i, col := range p.doc.Columns {
+		align := "L"
+		if col.Kind == NumberColumn {
+			align = "R"
+		}
+		p.pdf.CellFormat(p.widths[i], pdfRowHeight, p.fit(col.Header, p.widths[i]), "B", 0, align, true, 0, "")
+	}
+	p.pdf.Ln(-1)
+	p.pdf.SetFont("Helvetica", "", 8)
+}
+
+func (p *pdfWriter) footer() {
+	p.pdf.SetY(-12)
+	p.pdf.SetFont("Helvetica", "I", 7)
+	p.pdf.CellFormat(0, 5, fmt.Sprintf("Halaman %d/{nb}", p.pdf.PageNo()), "", 0, "R", false, 0, "")
+}
+
+// fit memotong teks supaya muat satu baris di kolom
+func (p *pdfWriter) fit(text string, width float64) string {
+	text = p.translate(text)
+	lines := p.pdf.SplitText(text, width-1)
+	if len(lines) == 0 {
+		return ""
+	}
+	return lines[0]
+}
+
+// WriteRow implements Writer.
+func (p *pdfWriter) WriteRow(values ...any) error {
+	for i, value := range values {
+		if i >= len(p.widths) {
+			break
+		}
+
+		align := "L"
+		if _, ok := value.(float64); ok {
+			align = "R"
+		}
+		p.pdf.CellFormat(p.widths[i], pdfRowHeight, p.fit(formatText(value), p.widths[i]), "", 0, align, false, 0, "")
+	}
+	p.pdf.Ln(-1)
+
+	return p.pdf.Error()
+}
+
+// Close implements Writer.
+func (p *pdfWriter) Close() error {
+	return p.pdf.Output(p.out)
+}
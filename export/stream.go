@@ -0,0 +1,120 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"connectrpc.com/connect"
+	"github.com/pdcgo/accounting_service/query_dialect"
+	"github.com/pdcgo/schema/services/accounting_iface/v1"
+	"github.com/pdcgo/shared/db_models"
+	"gorm.io/gorm"
+)
+
+// ukuran data per message stream
+const chunkSize = 32 * 1024
+
+type ConnectStreamWriter struct {
+	stream *connect.ServerStream[accounting_iface.ExportChunk]
+	offset int64
+	total  int64
+}
+
+// Write implements io.Writer.
+func (c *ConnectStreamWriter) Write(p []byte) (n int, err error) {
+	c.offset += 1
+	err = c.stream.Send(&accounting_iface.ExportChunk{
+		Offset: c.offset,
+		Total:  c.total,
+		Data:   p,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// Filename nama file dari judul dan tanggal akhir laporan
+func Filename(doc *Document, format Format) string {
+	return Basename(doc) + "." + Extension(format)
+}
+
+// Basename nama file tanpa ekstensi, untuk file yang bukan tabel
+func Basename(doc *Document) string {
+	name := strings.ToLower(strings.Join(strings.Fields(doc.Title), "_"))
+	if !doc.End.IsZero() {
+		name += "_" + query_dialect.ReportDay(doc.End).Format("20060102")
+	}
+	return name
+}
+
+// TeamName nama team untuk header laporan, kosong kalau team tidak ditemukan
+func TeamName(db *gorm.DB, teamID uint64) (string, error) {
+	var team db_models.Team
+	err := db.
+		Model(&db_models.Team{}).
+		Where("id = ?", teamID).
+		Find(&team).
+		Error
+
+	return team.Name, err
+}
+
+// Stream merender dokumen ke stream connect. total jumlah baris untuk progress client.
+func Stream(
+	stream *connect.ServerStream[accounting_iface.ExportChunk],
+	format Format,
+	doc *Document,
+	total int64,
+	rows func(w Writer) error,
+) error {
+	return StreamFile(stream, Filename(doc, format), ContentType(format), total, func(out io.Writer) error {
+		writer, err := NewWriter(format, doc, out)
+		if err != nil {
+			return err
+		}
+
+		err = rows(writer)
+		if err != nil {
+			return err
+		}
+
+		return writer.Close()
+	})
+}
+
+// StreamFile mengirim file apa saja ke stream connect, untuk format yang bukan tabel
+func StreamFile(
+	stream *connect.ServerStream[accounting_iface.ExportChunk],
+	filename string,
+	contentType string,
+	total int64,
+	write func(out io.Writer) error,
+) error {
+	var err error
+
+	err = stream.Send(&accounting_iface.ExportChunk{
+		Message:     fmt.Sprintf("%d data ditemukan..", total),
+		Filename:    filename,
+		ContentType: contentType,
+		Total:       total,
+	})
+	if err != nil {
+		return err
+	}
+
+	out := bufio.NewWriterSize(&ConnectStreamWriter{
+		stream: stream,
+		total:  total,
+	}, chunkSize)
+
+	err = write(out)
+	if err != nil {
+		return err
+	}
+
+	return out.Flush()
+}
@@ -0,0 +1,149 @@
+package export
+
+import (
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+const xlsxSheet = "Sheet1"
+
+// baris header kolom, di atasnya judul, team dan periode
+const xlsxHeaderRow = 5
+
+type xlsxWriter struct {
+	doc    *Document
+	out    io.Writer
+	file   *excelize.File
+	stream *excelize.StreamWriter
+	row    int
+	styles map[ColumnKind]int
+}
+
+func newXlsxWriter(doc *Document, w io.Writer) (*xlsxWriter, error) {
+	var err error
+	x := xlsxWriter{
+		doc:    doc,
+		out:    w,
+		file:   excelize.NewFile(),
+		styles: map[ColumnKind]int{},
+	}
+
+	x.stream, err = x.file.NewStreamWriter(xlsxSheet)
+	if err != nil {
+		return &x, err
+	}
+
+	numFmt := "#,##0.00;-#,##0.00"
+	x.styles[NumberColumn], err = x.file.NewStyle(&excelize.Style{CustomNumFmt: &numFmt})
+	if err != nil {
+		return &x, err
+	}
+
+	timeFmt := "yyyy-mm-dd hh:mm"
+	x.styles[TimeColumn], err = x.file.NewStyle(&excelize.Style{CustomNumFmt: &timeFmt})
+	if err != nil {
+		return &x, err
+	}
+
+	titleStyle, err := x.file.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true, Size: 14}})
+	if err != nil {
+		return &x, err
+	}
+
+	headerStyle, err := x.file.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Bold: true},
+		Fill: excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"D9E1F2"}},
+		Border: []excelize.Border{
+			{Type: "bottom", Color: "000000", Style: 1},
+		},
+	})
+	if err != nil {
+		return &x, err
+	}
+
+	// lebar kolom dan freeze harus diset sebelum baris pertama
+	for i, col := range doc.Columns {
+		width := col.Width
+		if width == 0 {
+			width = 15
+		}
+		err = x.stream.SetColWidth(i+1, i+1, width)
+		if err != nil {
+			return &x, err
+		}
+	}
+
+	topLeft, err := excelize.CoordinatesToCellName(1, xlsxHeaderRow+1)
+	if err != nil {
+		return &x, err
+	}
+	err = x.stream.SetPanes(&excelize.Panes{
+		Freeze:      true,
+		YSplit:      xlsxHeaderRow,
+		TopLeftCell: topLeft,
+		ActivePane:  "bottomLeft",
+	})
+	if err != nil {
+		return &x, err
+	}
+
+	headRows := [][]any{
+		{excelize.Cell{StyleID: titleStyle, Value: doc.Title}},
+		{doc.TeamName},
+		{doc.Period()},
+		{},
+	}
+	for i, values := range headRows {
+		err = x.stream.SetRow("A"+strconv.Itoa(i+1), values)
+		if err != nil {
+			return &x, err
+		}
+	}
+
+	headers := make([]any, len(doc.Columns))
+	for i, col := range doc.Columns {
+		headers[i] = excelize.Cell{StyleID: headerStyle, Value: col.Header}
+	}
+	err = x.stream.SetRow("A"+strconv.Itoa(xlsxHeaderRow), headers)
+	x.row = xlsxHeaderRow
+
+	return &x, err
+}
+
+// WriteRow implements Writer.
+func (x *xlsxWriter) WriteRow(values ...any) error {
+	x.row += 1
+
+	cells := make([]any, len(values))
+	for i, value := range values {
+		switch v := value.(type) {
+		case float64:
+			cells[i] = excelize.Cell{StyleID: x.styles[NumberColumn], Value: v}
+		case time.Time:
+			if v.IsZero() {
+				cells[i] = nil
+				continue
+			}
+			cells[i] = excelize.Cell{StyleID: x.styles[TimeColumn], Value: reportTime(v)}
+		default:
+			cells[i] = formatText(value)
+		}
+	}
+
+	return x.stream.SetRow("A"+strconv.Itoa(x.row), cells)
+}
+
+// Close implements Writer.
+func (x *xlsxWriter) Close() error {
+	defer x.file.Close()
+
+	err := x.stream.Flush()
+	if err != nil {
+		return err
+	}
+
+	return x.file.Write(x.out)
+}
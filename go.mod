@@ -5,10 +5,12 @@ go 1.24.2
 require (
 	cloud.google.com/go/cloudtasks v1.13.7
 	connectrpc.com/connect v1.19.1
+	github.com/go-pdf/fpdf v0.9.0
 	github.com/google/wire v0.7.0
-	github.com/pdcgo/schema v1.0.94
+	github.com/pdcgo/schema v1.0.116
 	github.com/pdcgo/shared v1.0.119
 	github.com/stretchr/testify v1.11.1
+	github.com/xuri/excelize/v2 v2.9.1
 	github.com/zeebo/assert v1.3.1
 	golang.org/x/net v0.47.0
 	gorm.io/gorm v1.31.1
@@ -29,7 +31,12 @@ require (
 	github.com/grpc-ecosystem/grpc-gateway/v2 v2.27.2 // indirect
 	github.com/quic-go/qpack v0.5.1 // indirect
 	github.com/quic-go/quic-go v0.54.0 // indirect
+	github.com/richardlehane/mscfb v1.0.4 // indirect
+	github.com/richardlehane/msoleps v1.0.4 // indirect
 	github.com/stretchr/objx v0.5.3 // indirect
+	github.com/tiendc/go-deepcopy v1.6.0 // indirect
+	github.com/xuri/efp v0.0.1 // indirect
+	github.com/xuri/nfp v0.0.1 // indirect
 	go.opentelemetry.io/otel/sdk v1.38.0 // indirect
 	go.uber.org/mock v0.5.0 // indirect
 	golang.org/x/telemetry v0.0.0-20251128220624-abf20d0e57ec // indirect
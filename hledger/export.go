@@ -0,0 +1,170 @@
+package hledger
+
+import (
+	"io"
+	"strings"
+	"time"
+
+	"github.com/pdcgo/accounting_service/accounting_core"
+	"github.com/pdcgo/accounting_service/query_dialect"
+	"gorm.io/gorm"
+)
+
+// jumlah transaksi yang dibaca per batch
+const exportBatch = 200
+
+type exporter struct {
+	db     *gorm.DB
+	teamID uint
+	start  time.Time
+	end    time.Time
+}
+
+func (e *exporter) query() *gorm.DB {
+	query := e.
+		db.
+		Model(&accounting_core.Transaction{}).
+		Where("team_id = ?", e.teamID)
+
+	if !e.start.IsZero() {
+		query = query.Where("created >= ?", query_dialect.ReportDayStart(query_dialect.ReportDay(e.start)))
+	}
+	if !e.end.IsZero() {
+		query = query.Where("created < ?", query_dialect.ReportDayStart(query_dialect.ReportDay(e.end).AddDate(0, 0, 1)))
+	}
+
+	return query
+}
+
+func (e *exporter) count() (int64, error) {
+	var total int64
+	err := e.query().Count(&total).Error
+	return total, err
+}
+
+type entryRow struct {
+	TransactionID uint
+	TeamID        uint
+	EntryTime     time.Time
+	Debit         float64
+	Credit        float64
+	Desc          string
+	Rollback      bool
+	AccountKey    accounting_core.AccountKey
+	AccountTeamID uint
+	Coa           accounting_core.CoaCode
+}
+
+type tagRow struct {
+	TransactionID uint
+	Name          string
+}
+
+func (e *exporter) load(trans []*accounting_core.Transaction) ([]*Transaction, error) {
+	var err error
+
+	tranIDs := make([]uint, len(trans))
+	result := make([]*Transaction, len(trans))
+	tranMap := map[uint]*Transaction{}
+	for i, tran := range trans {
+		tranIDs[i] = tran.ID
+		result[i] = &Transaction{
+			Desc:     tran.Desc,
+			RefID:    tran.RefID,
+			TeamID:   tran.TeamID,
+			Created:  tran.Created,
+			Tags:     []string{},
+			Postings: []*Posting{},
+		}
+		tranMap[tran.ID] = result[i]
+	}
+
+	tags := []*tagRow{}
+	err = e.
+		db.
+		Table("transaction_tags tt").
+		Joins("join accounting_tags t on t.id = tt.tag_id").
+		Select("tt.transaction_id, t.name").
+		Where("tt.transaction_id IN ?", tranIDs).
+		Order("tt.transaction_id, t.name").
+		Scan(&tags).
+		Error
+	if err != nil {
+		return result, err
+	}
+
+	for _, tag := range tags {
+		tranMap[tag.TransactionID].Tags = append(tranMap[tag.TransactionID].Tags, tag.Name)
+	}
+
+	entries := []*entryRow{}
+	err = e.
+		db.
+		Table("journal_entries je").
+		Joins("join accounts a on a.id = je.account_id").
+		Select(strings.Join([]string{
+			"je.transaction_id",
+			"je.team_id",
+			"je.entry_time",
+			"je.debit",
+			"je.credit",
+			"je.desc",
+			"je.rollback",
+			"a.account_key",
+			"a.team_id as account_team_id",
+			"a.coa",
+		}, ", ")).
+		Where("je.transaction_id IN ?", tranIDs).
+		Order("je.transaction_id, je.id").
+		Scan(&entries).
+		Error
+	if err != nil {
+		return result, err
+	}
+
+	for _, entry := range entries {
+		tran := tranMap[entry.TransactionID]
+
+		account := AccountName{
+			Coa: entry.Coa,
+			Key: entry.AccountKey,
+		}
+		if entry.AccountTeamID != entry.TeamID {
+			account.TeamID = entry.AccountTeamID
+		}
+
+		tran.Postings = append(tran.Postings, &Posting{
+			Account:   &account,
+			Amount:    entry.Debit - entry.Credit,
+			BookID:    entry.TeamID,
+			EntryTime: entry.EntryTime,
+			Desc:      entry.Desc,
+			Rollback:  entry.Rollback,
+		})
+	}
+
+	return result, nil
+}
+
+// write menulis semua transaksi team per batch
+func (e *exporter) write(out io.Writer) error {
+	trans := []*accounting_core.Transaction{}
+	return e.
+		query().
+		Order("created asc, id asc").
+		FindInBatches(&trans, exportBatch, func(tx *gorm.DB, batch int) error {
+			journal, err := e.load(trans)
+			if err != nil {
+				return err
+			}
+
+			for _, tran := range journal {
+				err = WriteTransaction(out, tran)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}).
+		Error
+}
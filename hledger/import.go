@@ -0,0 +1,203 @@
+package hledger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/pdcgo/accounting_service/accounting_core"
+	"gorm.io/gorm"
+)
+
+type accountKey struct {
+	teamID uint
+	key    accounting_core.AccountKey
+}
+
+type importer struct {
+	db       *gorm.DB
+	teamID   uint
+	userID   uint
+	accounts map[accountKey]*accounting_core.Account
+}
+
+func newImporter(db *gorm.DB, teamID, userID uint) *importer {
+	return &importer{
+		db:       db,
+		teamID:   teamID,
+		userID:   userID,
+		accounts: map[accountKey]*accounting_core.Account{},
+	}
+}
+
+func (i *importer) account(posting *Posting) (*accounting_core.Account, error) {
+	key := accountKey{
+		teamID: posting.BookID,
+		key:    posting.Account.Key,
+	}
+	if posting.Account.TeamID != 0 {
+		key.teamID = posting.Account.TeamID
+	}
+
+	if i.accounts[key] != nil {
+		return i.accounts[key], nil
+	}
+
+	var acc accounting_core.Account
+	err := i.
+		db.
+		Model(&accounting_core.Account{}).
+		Where("account_key = ?", key.key).
+		Where("team_id = ?", key.teamID).
+		Find(&acc).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	if acc.ID == 0 {
+		return nil, &parseError{posting.Line, fmt.Errorf("account not found %s in team %d", key.key, key.teamID)}
+	}
+	if acc.Coa != posting.Account.Coa {
+		return nil, &parseError{posting.Line, fmt.Errorf("account %s coa %s bukan %s", key.key, acc.Coa, posting.Account.Coa)}
+	}
+
+	i.accounts[key] = &acc
+	return &acc, nil
+}
+
+// validate cek semua transaksi sebelum ada yang disimpan
+func (i *importer) validate(trans []*Transaction) error {
+	refs := map[accounting_core.RefID]bool{}
+
+	for _, tran := range trans {
+		if tran.TeamID == 0 {
+			tran.TeamID = i.teamID
+		}
+		if tran.TeamID != i.teamID {
+			return &parseError{tran.Line, fmt.Errorf("transaksi team %d bukan team %d", tran.TeamID, i.teamID)}
+		}
+
+		err := tran.Balance()
+		if err != nil {
+			return err
+		}
+
+		if refs[tran.RefID] {
+			return &parseError{tran.Line, fmt.Errorf("ref %s duplikat", tran.RefID)}
+		}
+		refs[tran.RefID] = true
+
+		for _, posting := range tran.Postings {
+			_, err = i.account(posting)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func entryTimeOption(t time.Time) accounting_core.EntryOption {
+	return func(entry *accounting_core.JournalEntry) error {
+		entry.EntryTime = t
+		return nil
+	}
+}
+
+// create menyimpan satu transaksi, false kalau ref sudah pernah diimport
+func (i *importer) create(ctx context.Context, tran *Transaction) (bool, error) {
+	var exist int64
+	err := i.
+		db.
+		Model(&accounting_core.Transaction{}).
+		Where("ref_id = ?", tran.RefID).
+		Count(&exist).
+		Error
+	if err != nil {
+		return false, err
+	}
+	if exist > 0 {
+		return false, nil
+	}
+
+	err = accounting_core.OpenTransaction(ctx, i.db, func(tx *gorm.DB, bookmng accounting_core.BookManage) error {
+		model := accounting_core.Transaction{
+			RefID:       tran.RefID,
+			TeamID:      tran.TeamID,
+			CreatedByID: i.userID,
+			Desc:        tran.Desc,
+			Created:     tran.Created,
+		}
+
+		err := bookmng.
+			NewTransaction().
+			Create(&model).
+			AddTags(tran.Tags).
+			Err()
+		if err != nil {
+			return err
+		}
+
+		books := []bookKey{}
+		postings := map[bookKey][]*Posting{}
+		for _, posting := range tran.Postings {
+			key := bookKey{posting.BookID, posting.Rollback}
+			if postings[key] == nil {
+				books = append(books, key)
+			}
+			postings[key] = append(postings[key], posting)
+		}
+
+		for _, key := range books {
+			entry := bookmng.NewCreateEntry(key.bookID, i.userID)
+			for _, posting := range postings[key] {
+				if posting.Amount == 0 {
+					continue
+				}
+
+				acc, err := i.account(posting)
+				if err != nil {
+					return err
+				}
+
+				amount := posting.Amount
+				if acc.BalanceType == accounting_core.CreditBalance {
+					amount = -amount
+				}
+
+				desc := posting.Desc
+				if desc == "" {
+					desc = tran.Desc
+				}
+
+				entry.To(&accounting_core.EntryAccountPayload{
+					Key:    acc.AccountKey,
+					TeamID: acc.TeamID,
+				}, amount, entryTimeOption(posting.EntryTime), accounting_core.EntryDescOption(desc))
+			}
+
+			opts := []accounting_core.CommitOption{}
+			if key.rollback {
+				opts = append(opts, accounting_core.RollbackOption())
+			}
+
+			err = entry.
+				Transaction(&model).
+				Commit(opts...).
+				Err()
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if errors.Is(err, accounting_core.ErrTransactionAlreadyExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
@@ -0,0 +1,426 @@
+package hledger
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pdcgo/accounting_service/accounting_core"
+	"github.com/pdcgo/accounting_service/query_dialect"
+)
+
+// tag metadata yang dipakai di journal
+const (
+	RefTag      = "ref"
+	TeamTag     = "team"
+	CreatedTag  = "created"
+	LabelTag    = "tag"
+	BookTag     = "book"
+	TimeTag     = "time"
+	DescTag     = "desc"
+	RollbackTag = "rollback"
+)
+
+const dateLayout = "2006-01-02"
+
+// Posting satu journal entry, amount debit positif dan credit negatif
+type Posting struct {
+	Line    int
+	Account *AccountName
+	Amount  float64
+	// team yang mencatat, 0 berarti team transaksi
+	BookID    uint
+	EntryTime time.Time
+	Desc      string
+	Rollback  bool
+
+	elided bool
+}
+
+// Transaction satu transaksi journal beserta entry semua book nya
+type Transaction struct {
+	Line     int
+	Date     time.Time
+	Desc     string
+	RefID    accounting_core.RefID
+	TeamID   uint
+	Created  time.Time
+	Tags     []string
+	Postings []*Posting
+}
+
+// AccountName nama akun hledger, format coa:account_key[:team<id>]
+type AccountName struct {
+	Coa accounting_core.CoaCode
+	Key accounting_core.AccountKey
+	// pemilik akun kalau berbeda dengan book, 0 berarti milik book
+	TeamID uint
+}
+
+func (a *AccountName) String() string {
+	name := a.Coa.String() + ":" + string(a.Key)
+	if a.TeamID != 0 {
+		name += ":team" + strconv.FormatUint(uint64(a.TeamID), 10)
+	}
+	return name
+}
+
+var coaNames = map[string]accounting_core.CoaCode{
+	accounting_core.ASSET.String():     accounting_core.ASSET,
+	accounting_core.LIABILITY.String(): accounting_core.LIABILITY,
+	accounting_core.EQUITY.String():    accounting_core.EQUITY,
+	accounting_core.REVENUE.String():   accounting_core.REVENUE,
+	accounting_core.EXPENSE.String():   accounting_core.EXPENSE,
+}
+
+func ParseAccountName(name string) (*AccountName, error) {
+	parts := strings.Split(strings.TrimSpace(name), ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return nil, fmt.Errorf("nama akun %s tidak valid", name)
+	}
+
+	coa, ok := coaNames[parts[0]]
+	if !ok {
+		return nil, fmt.Errorf("coa %s tidak dikenal", parts[0])
+	}
+
+	acc := AccountName{
+		Coa: coa,
+		Key: accounting_core.AccountKey(parts[1]),
+	}
+	if acc.Key == "" {
+		return nil, fmt.Errorf("nama akun %s tidak valid", name)
+	}
+
+	if len(parts) == 3 {
+		teamID, err := strconv.ParseUint(strings.TrimPrefix(parts[2], "team"), 10, 64)
+		if err != nil || !strings.HasPrefix(parts[2], "team") {
+			return nil, fmt.Errorf("team akun %s tidak valid", name)
+		}
+		acc.TeamID = uint(teamID)
+	}
+
+	return &acc, nil
+}
+
+func formatAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', -1, 64)
+}
+
+func formatTime(t time.Time) string {
+	return t.In(query_dialect.ReportLocation()).Format(time.RFC3339)
+}
+
+// WriteTransaction menulis transaksi dalam format ledger-cli / hledger
+func WriteTransaction(w io.Writer, tran *Transaction) error {
+	lines := []string{
+		query_dialect.ReportDay(tran.Created).Format(dateLayout) + " * " + oneLine(tran.Desc),
+		"    ; " + RefTag + ": " + string(tran.RefID),
+		"    ; " + TeamTag + ": " + strconv.FormatUint(uint64(tran.TeamID), 10),
+		"    ; " + CreatedTag + ": " + formatTime(tran.Created),
+	}
+	for _, tag := range tran.Tags {
+		lines = append(lines, "    ; "+LabelTag+": "+tag)
+	}
+
+	for _, posting := range tran.Postings {
+		lines = append(lines, fmt.Sprintf("    %-50s  %s", posting.Account.String(), formatAmount(posting.Amount)))
+
+		if posting.BookID != 0 && posting.BookID != tran.TeamID {
+			lines = append(lines, "        ; "+BookTag+": "+strconv.FormatUint(uint64(posting.BookID), 10))
+		}
+		if !posting.EntryTime.IsZero() && !posting.EntryTime.Equal(tran.Created) {
+			lines = append(lines, "        ; "+TimeTag+": "+formatTime(posting.EntryTime))
+		}
+		if posting.Desc != "" && posting.Desc != tran.Desc {
+			lines = append(lines, "        ; "+DescTag+": "+oneLine(posting.Desc))
+		}
+		if posting.Rollback {
+			lines = append(lines, "        ; "+RollbackTag+": true")
+		}
+	}
+
+	_, err := io.WriteString(w, strings.Join(lines, "\n")+"\n\n")
+	return err
+}
+
+func oneLine(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+type parseError struct {
+	line int
+	err  error
+}
+
+func (e *parseError) Error() string {
+	return fmt.Sprintf("baris %d: %s", e.line, e.err)
+}
+
+func (e *parseError) Unwrap() error {
+	return e.err
+}
+
+// Parse membaca journal ledger-cli / hledger. Hanya subset yang ditulis WriteTransaction
+// ditambah amount kosong sebagai penyeimbang.
+func Parse(r io.Reader) ([]*Transaction, error) {
+	result := []*Transaction{}
+
+	var tran *Transaction
+	var posting *Posting
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		raw := strings.TrimRight(scanner.Text(), " \t\r")
+		text := strings.TrimSpace(raw)
+
+		if text == "" {
+			tran = nil
+			posting = nil
+			continue
+		}
+
+		indented := raw[0] == ' ' || raw[0] == '\t'
+		if !indented {
+			posting = nil
+			tran = nil
+
+			// komentar dan directive level atas diabaikan
+			if !isDigit(text[0]) {
+				continue
+			}
+
+			parsed, err := parseHeader(lineNum, text)
+			if err != nil {
+				return result, err
+			}
+			tran = parsed
+			result = append(result, tran)
+			continue
+		}
+
+		if tran == nil {
+			return result, &parseError{lineNum, errors.New("posting tanpa transaksi")}
+		}
+
+		if text[0] == ';' {
+			key, value, ok := parseTag(text)
+			if !ok {
+				continue
+			}
+
+			var err error
+			if posting != nil {
+				err = posting.setTag(key, value)
+			} else {
+				err = tran.setTag(key, value)
+			}
+			if err != nil {
+				return result, &parseError{lineNum, err}
+			}
+			continue
+		}
+
+		parsed, err := parsePosting(text)
+		if err != nil {
+			return result, &parseError{lineNum, err}
+		}
+		parsed.Line = lineNum
+		posting = parsed
+		tran.Postings = append(tran.Postings, posting)
+	}
+
+	return result, scanner.Err()
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func parseHeader(lineNum int, text string) (*Transaction, error) {
+	dateStr, desc, _ := strings.Cut(text, " ")
+	// tanggal efektif hledger (date=date2) tidak dipakai
+	dateStr, _, _ = strings.Cut(dateStr, "=")
+	dateStr = strings.ReplaceAll(dateStr, "/", "-")
+
+	date, err := time.Parse(dateLayout, dateStr)
+	if err != nil {
+		return nil, &parseError{lineNum, fmt.Errorf("tanggal %s tidak valid", dateStr)}
+	}
+
+	desc = strings.TrimSpace(desc)
+	desc = strings.TrimLeft(desc, "*! ")
+	if strings.HasPrefix(desc, "(") {
+		if _, rest, ok := strings.Cut(desc, ")"); ok {
+			desc = strings.TrimSpace(rest)
+		}
+	}
+	desc, _, _ = strings.Cut(desc, " ;")
+
+	return &Transaction{
+		Line:     lineNum,
+		Date:     date,
+		Desc:     strings.TrimSpace(desc),
+		Created:  query_dialect.ReportDayStart(date),
+		Tags:     []string{},
+		Postings: []*Posting{},
+	}, nil
+}
+
+func parseTag(text string) (string, string, bool) {
+	text = strings.TrimSpace(strings.TrimPrefix(text, ";"))
+	key, value, ok := strings.Cut(text, ":")
+	if !ok || key == "" || strings.ContainsAny(key, " \t") {
+		return "", "", false
+	}
+	return key, strings.TrimSpace(value), true
+}
+
+func (t *Transaction) setTag(key, value string) error {
+	switch key {
+	case RefTag:
+		t.RefID = accounting_core.RefID(value)
+	case TeamTag:
+		teamID, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("team %s tidak valid", value)
+		}
+		t.TeamID = uint(teamID)
+	case CreatedTag:
+		created, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return fmt.Errorf("created %s tidak valid", value)
+		}
+		t.Created = created
+	case LabelTag:
+		t.Tags = append(t.Tags, value)
+	}
+	return nil
+}
+
+func (p *Posting) setTag(key, value string) error {
+	switch key {
+	case BookTag:
+		bookID, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("book %s tidak valid", value)
+		}
+		p.BookID = uint(bookID)
+	case TimeTag:
+		entryTime, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return fmt.Errorf("time %s tidak valid", value)
+		}
+		p.EntryTime = entryTime
+	case DescTag:
+		p.Desc = value
+	case RollbackTag:
+		p.Rollback = value == "true"
+	}
+	return nil
+}
+
+func parsePosting(text string) (*Posting, error) {
+	text, _, _ = strings.Cut(text, ";")
+	text = strings.TrimSpace(text)
+
+	// akun dan amount dipisah minimal dua spasi atau tab
+	name, amountStr := text, ""
+	if idx := strings.IndexAny(text, "\t"); idx > 0 {
+		name, amountStr = text[:idx], text[idx:]
+	}
+	if idx := strings.Index(name, "  "); idx > 0 {
+		name, amountStr = text[:idx], text[idx:]
+	}
+
+	account, err := ParseAccountName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	posting := Posting{
+		Account: account,
+	}
+
+	amountStr = strings.TrimSpace(amountStr)
+	if amountStr == "" {
+		posting.elided = true
+		return &posting, nil
+	}
+
+	// commodity seperti IDR atau Rp diabaikan
+	amountStr = strings.Trim(amountStr, "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz $")
+	amountStr = strings.ReplaceAll(amountStr, ",", "")
+	posting.Amount, err = strconv.ParseFloat(amountStr, 64)
+	if err != nil {
+		return nil, fmt.Errorf("amount %s tidak valid", amountStr)
+	}
+
+	return &posting, nil
+}
+
+var ErrUnbalanced = errors.New("transaksi tidak balance")
+
+// Balance mengisi amount kosong dan memastikan tiap book balance
+func (t *Transaction) Balance() error {
+	if t.RefID == "" {
+		return &parseError{t.Line, errors.New("ref transaksi kosong")}
+	}
+	if len(t.Postings) < 2 {
+		return &parseError{t.Line, errors.New("transaksi minimal dua posting")}
+	}
+
+	var elided *Posting
+	for _, posting := range t.Postings {
+		if posting.BookID == 0 {
+			posting.BookID = t.TeamID
+		}
+		if posting.EntryTime.IsZero() {
+			posting.EntryTime = t.Created
+		}
+		if !posting.elided {
+			continue
+		}
+		if elided != nil {
+			return &parseError{posting.Line, errors.New("amount kosong lebih dari satu")}
+		}
+		elided = posting
+	}
+
+	if elided != nil {
+		var sum float64
+		for _, posting := range t.Postings {
+			if posting.BookID == elided.BookID && posting.Rollback == elided.Rollback {
+				sum += posting.Amount
+			}
+		}
+		elided.Amount = -sum
+		elided.elided = false
+	}
+
+	sums := map[bookKey]float64{}
+	for _, posting := range t.Postings {
+		sums[bookKey{posting.BookID, posting.Rollback}] += posting.Amount
+	}
+	for key, sum := range sums {
+		if math.Abs(sum) > accounting_core.PrecisionEpsilon {
+			return &parseError{t.Line, fmt.Errorf("%w book %d selisih %s", ErrUnbalanced, key.bookID, formatAmount(sum))}
+		}
+	}
+
+	return nil
+}
+
+type bookKey struct {
+	bookID   uint
+	rollback bool
+}
@@ -0,0 +1,168 @@
+package hledger
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+
+	"connectrpc.com/connect"
+	"github.com/pdcgo/accounting_service/adjustment"
+	"github.com/pdcgo/accounting_service/export"
+	"github.com/pdcgo/accounting_service/query_dialect"
+	"github.com/pdcgo/schema/services/accounting_iface/v1"
+	"github.com/pdcgo/shared/interfaces/authorization_iface"
+	"gorm.io/gorm"
+)
+
+type hledgerServiceImpl struct {
+	db   *gorm.DB
+	auth authorization_iface.Authorization
+}
+
+// JournalExport export transaksi team beserta entry semua book nya sebagai journal ledger-cli
+func (h *hledgerServiceImpl) JournalExport(
+	ctx context.Context,
+	req *connect.Request[accounting_iface.JournalExportRequest],
+	stream *connect.ServerStream[accounting_iface.ExportChunk],
+) error {
+	var err error
+	pay := req.Msg
+
+	err = h.
+		auth.
+		AuthIdentityFromHeader(req.Header()).
+		Err()
+
+	if err != nil {
+		return err
+	}
+
+	if pay.TeamId == 0 {
+		return connect.NewError(connect.CodeInvalidArgument, errors.New("team_id required"))
+	}
+
+	db := h.db.WithContext(ctx)
+	exp := exporter{
+		db:     db,
+		teamID: uint(pay.TeamId),
+	}
+	if pay.TimeRange.GetStartDate().IsValid() {
+		exp.start = pay.TimeRange.StartDate.AsTime()
+	}
+	if pay.TimeRange.GetEndDate().IsValid() {
+		exp.end = pay.TimeRange.EndDate.AsTime()
+	}
+
+	total, err := exp.count()
+	if err != nil {
+		return err
+	}
+
+	teamName, err := export.TeamName(db, pay.TeamId)
+	if err != nil {
+		return err
+	}
+
+	doc := export.Document{
+		Title:    "Journal",
+		TeamName: teamName,
+		Start:    exp.start,
+		End:      exp.end,
+	}
+
+	filename := export.Basename(&doc) + ".journal"
+	return export.StreamFile(stream, filename, "text/plain; charset=utf-8", total, func(out io.Writer) error {
+		header := "; " + doc.Title + " " + doc.TeamName + "\n"
+		if period := doc.Period(); period != "" {
+			header += "; " + period + "\n"
+		}
+		header += "; timezone " + query_dialect.ReportLocation().String() + "\n\n"
+
+		_, err := io.WriteString(out, header)
+		if err != nil {
+			return err
+		}
+		return exp.write(out)
+	})
+}
+
+// JournalImport import journal ledger-cli untuk migrasi buku lama, ref yang sudah ada dilewati
+func (h *hledgerServiceImpl) JournalImport(
+	ctx context.Context,
+	req *connect.Request[accounting_iface.JournalImportRequest],
+) (*connect.Response[accounting_iface.JournalImportResponse], error) {
+	var err error
+	pay := req.Msg
+
+	result := accounting_iface.JournalImportResponse{
+		Skipped: []string{},
+	}
+
+	identity := h.
+		auth.
+		AuthIdentityFromHeader(req.Header())
+
+	agent := identity.Identity()
+
+	if pay.TeamId == 0 {
+		return connect.NewResponse(&result), connect.NewError(connect.CodeInvalidArgument, errors.New("team_id required"))
+	}
+
+	err = identity.
+		HasPermission(authorization_iface.CheckPermissionGroup{
+			&adjustment.AdjustmentAccess{}: &authorization_iface.CheckPermission{
+				DomainID: uint(pay.TeamId),
+				Actions:  []authorization_iface.Action{authorization_iface.Create},
+			},
+		}).
+		Err()
+
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	trans, err := Parse(strings.NewReader(pay.Journal))
+	if err != nil {
+		return connect.NewResponse(&result), connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	db := h.db.WithContext(ctx)
+	imp := newImporter(db, uint(pay.TeamId), agent.IdentityID())
+
+	err = imp.validate(trans)
+	if err != nil {
+		var perr *parseError
+		if errors.As(err, &perr) {
+			return connect.NewResponse(&result), connect.NewError(connect.CodeInvalidArgument, err)
+		}
+		return connect.NewResponse(&result), err
+	}
+
+	result.Total = int64(len(trans))
+	if pay.DryRun {
+		return connect.NewResponse(&result), nil
+	}
+
+	for _, tran := range trans {
+		created, err := imp.create(ctx, tran)
+		if err != nil {
+			return connect.NewResponse(&result), err
+		}
+
+		if created {
+			result.Imported++
+		} else {
+			result.Skipped = append(result.Skipped, string(tran.RefID))
+		}
+	}
+
+	return connect.NewResponse(&result), nil
+}
+
+func NewHledgerService(db *gorm.DB, auth authorization_iface.Authorization) *hledgerServiceImpl {
+	return &hledgerServiceImpl{
+		db:   db,
+		auth: auth,
+	}
+}
@@ -0,0 +1,187 @@
+package hledger_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/pdcgo/accounting_service/accounting_core"
+	"github.com/pdcgo/accounting_service/accounting_mock"
+	"github.com/pdcgo/accounting_service/hledger"
+	"github.com/pdcgo/schema/services/accounting_iface/v1"
+	"github.com/pdcgo/schema/services/accounting_iface/v1/accounting_ifaceconnect"
+	"github.com/pdcgo/shared/authorization/authorization_mock"
+	"github.com/pdcgo/shared/db_models"
+	"github.com/pdcgo/shared/pkg/moretest"
+	"github.com/pdcgo/shared/pkg/moretest/moretest_mock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+const journal = `; buku lama team 1
+2025/10/01 * penjualan order#1
+    ; ref: legacy#1
+    ; tag: shopee
+    asset:cash                       10000
+    revenue:sales_revenue
+
+2025-10-02 biaya dan hutang stock
+    ; ref: legacy#2
+    ; created: 2025-10-02T09:30:00+07:00
+    expense:ads_expense              1000.50
+    asset:cash                      -1000.50
+    liability:payable:team2         -2500
+    asset:stock_pending              2500
+    asset:receivable:team1           2500
+        ; book: 2
+        ; desc: piutang team 1
+    asset:stock_pending             -2500
+        ; book: 2
+`
+
+func TestHledgerJournal(t *testing.T) {
+	var db gorm.DB
+
+	moretest.Suite(t, "testing export import journal hledger",
+		moretest.SetupListFunc{
+			moretest_mock.MockSqliteDatabase(&db),
+			func(t *testing.T) func() error {
+				err := db.AutoMigrate(
+					&db_models.Team{},
+					&accounting_core.Account{},
+					&accounting_core.Transaction{},
+					&accounting_core.JournalEntry{},
+					&accounting_core.AccountingTag{},
+					&accounting_core.TransactionTag{},
+				)
+				assert.Nil(t, err)
+				err = db.Create(&db_models.Team{ID: 1, Name: "team satu", TeamCode: "T1"}).Error
+				assert.Nil(t, err)
+				return nil
+			},
+			accounting_mock.PopulateAccountKey(&db, 1),
+			accounting_mock.PopulateAccountKey(&db, 2),
+		},
+		func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.Handle(accounting_ifaceconnect.NewHledgerServiceHandler(
+				hledger.NewHledgerService(&db, &authorization_mock.EmptyAuthorizationMock{
+					AuthIdentityMock: &authorization_mock.AuthIdentityMock{
+						IdentityMock: &authorization_mock.IdentityMock{ID: 1},
+					},
+				}),
+			))
+			server := httptest.NewServer(mux)
+			defer server.Close()
+
+			client := accounting_ifaceconnect.NewHledgerServiceClient(server.Client(), server.URL)
+
+			t.Run("journal tidak balance ditolak", func(t *testing.T) {
+				_, err := client.JournalImport(t.Context(), connect.NewRequest(&accounting_iface.JournalImportRequest{
+					TeamId: 1,
+					Journal: strings.Join([]string{
+						"2025-10-01 salah",
+						"    ; ref: legacy#x",
+						"    asset:cash  100",
+						"    revenue:sales_revenue  -90",
+					}, "\n"),
+				}))
+				assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+
+				var count int64
+				db.Model(&accounting_core.Transaction{}).Count(&count)
+				assert.Equal(t, int64(0), count)
+			})
+
+			t.Run("dry run", func(t *testing.T) {
+				res, err := client.JournalImport(t.Context(), connect.NewRequest(&accounting_iface.JournalImportRequest{
+					TeamId:  1,
+					Journal: journal,
+					DryRun:  true,
+				}))
+				assert.Nil(t, err)
+				assert.Equal(t, int64(2), res.Msg.Total)
+				assert.Equal(t, int64(0), res.Msg.Imported)
+			})
+
+			t.Run("import journal", func(t *testing.T) {
+				res, err := client.JournalImport(t.Context(), connect.NewRequest(&accounting_iface.JournalImportRequest{
+					TeamId:  1,
+					Journal: journal,
+				}))
+				assert.Nil(t, err)
+				assert.Equal(t, int64(2), res.Msg.Imported)
+
+				var revenue accounting_core.JournalEntry
+				err = db.
+					Model(&accounting_core.JournalEntry{}).
+					Joins("join accounts a on a.id = journal_entries.account_id").
+					Where("a.account_key = ?", accounting_core.SalesRevenueAccount).
+					First(&revenue).
+					Error
+				assert.Nil(t, err)
+				assert.Equal(t, 10000.0, revenue.Credit)
+
+				var cross accounting_core.JournalEntry
+				err = db.
+					Model(&accounting_core.JournalEntry{}).
+					Where("team_id = ?", 2).
+					Where("debit > 0").
+					First(&cross).
+					Error
+				assert.Nil(t, err)
+				assert.Equal(t, "piutang team 1", cross.Desc)
+			})
+
+			t.Run("import ulang dilewati", func(t *testing.T) {
+				res, err := client.JournalImport(t.Context(), connect.NewRequest(&accounting_iface.JournalImportRequest{
+					TeamId:  1,
+					Journal: journal,
+				}))
+				assert.Nil(t, err)
+				assert.Equal(t, int64(0), res.Msg.Imported)
+				assert.Equal(t, []string{"legacy#1", "legacy#2"}, res.Msg.Skipped)
+			})
+
+			t.Run("export journal", func(t *testing.T) {
+				stream, err := client.JournalExport(t.Context(), connect.NewRequest(&accounting_iface.JournalExportRequest{
+					TeamId: 1,
+				}))
+				assert.Nil(t, err)
+				defer stream.Close()
+
+				var info *accounting_iface.ExportChunk
+				data := bytes.NewBuffer(nil)
+				for stream.Receive() {
+					if info == nil {
+						info = stream.Msg()
+					}
+					data.Write(stream.Msg().Data)
+				}
+				assert.Nil(t, stream.Err())
+				assert.Equal(t, "journal.journal", info.Filename)
+				assert.Equal(t, int64(2), info.Total)
+
+				text := data.String()
+				assert.Contains(t, text, "; Journal team satu")
+				assert.Contains(t, text, "2025-10-01 * penjualan order#1")
+				assert.Contains(t, text, "; tag: shopee")
+				assert.Contains(t, text, "; created: 2025-10-02T09:30:00+07:00")
+				assert.Contains(t, text, "liability:payable:team2")
+				assert.Contains(t, text, "; book: 2")
+
+				// hasil export bisa dibaca ulang dan balance
+				trans, err := hledger.Parse(strings.NewReader(text))
+				assert.Nil(t, err)
+				assert.Len(t, trans, 2)
+				for _, tran := range trans {
+					assert.Nil(t, tran.Balance())
+				}
+				assert.Len(t, trans[1].Postings, 6)
+			})
+		},
+	)
+}
@@ -8,6 +8,7 @@ import (
 
 	"connectrpc.com/connect"
 	"github.com/pdcgo/accounting_service/accounting_core"
+	"github.com/pdcgo/accounting_service/query_dialect"
 	"github.com/pdcgo/schema/services/accounting_iface/v1"
 	"github.com/pdcgo/schema/services/common/v1"
 	"github.com/pdcgo/shared/db_connect"
@@ -87,6 +88,7 @@ func (l *ledgerServiceImpl) EntryList(
 	pay := req.Msg
 
 	db := l.db.WithContext(ctx)
+	dialect := query_dialect.NewDialect(db)
 	err = l.
 		auth.
 		AuthIdentityFromHeader(req.Header()).
@@ -159,7 +161,7 @@ func (l *ledgerServiceImpl) EntryList(
 				keyword := strings.ToLower(pay.Keyword)
 				return next(
 					query.
-						Where("je.desc ilike ?", "%"+keyword+"%"),
+						Where(dialect.ILike("je.desc"), "%"+keyword+"%"),
 				)
 			}
 		},
@@ -303,7 +305,7 @@ func (l *ledgerServiceImpl) EntryList(
 							"je.id",
 							"je.account_id",
 							"je.transaction_id",
-							"(EXTRACT(EPOCH FROM je.entry_time) * 1000000)::BIGINT AS entry_time",
+							dialect.EpochMicro("je.entry_time") + " AS entry_time",
 							"je.desc",
 							"je.debit",
 							"je.credit",
@@ -446,7 +448,7 @@ func (l *ledgerViewImpl) TypeLabel(labels []*accounting_iface.TypeLabelFilter) L
 func (l *ledgerViewImpl) SearchDescription(keyword string) LedgerView {
 	l.query = l.
 		query.
-		Where("je.desc ilike ?", "%"+keyword+"%")
+		Where(query_dialect.NewDialect(l.db).ILike("je.desc"), "%"+keyword+"%")
 	return l
 }
 
@@ -487,7 +489,7 @@ func (l *ledgerViewImpl) Search(keyword string) LedgerView {
 	keyword = strings.ToLower(keyword)
 	l.query = l.
 		query.
-		Where("je.desc ilike ?", "%"+keyword+"%")
+		Where(query_dialect.NewDialect(l.db).ILike("je.desc"), "%"+keyword+"%")
 	return l
 }
 
@@ -657,7 +659,7 @@ func (l *ledgerViewImpl) selectFields() []string {
 		"je.id",
 		"je.account_id",
 		"je.transaction_id",
-		"(EXTRACT(EPOCH FROM je.entry_time) * 1000000)::BIGINT AS entry_time",
+		query_dialect.NewDialect(l.db).EpochMicro("je.entry_time") + " AS entry_time",
 		"je.desc",
 		"je.debit",
 		"je.credit",
@@ -0,0 +1,111 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/pdcgo/accounting_service/export"
+	"github.com/pdcgo/schema/services/accounting_iface/v1"
+	"github.com/pdcgo/schema/services/common/v1"
+)
+
+// kolom export ledger, csv lama memakai header yang sama
+func entryDocument() *export.Document {
+	return &export.Document{
+		Title: "Ledger Entries",
+		Columns: []*export.Column{
+			{Header: "entry_at", Kind: export.TimeColumn, Width: 18},
+			{Header: "desc", Kind: export.TextColumn, Width: 45},
+			{Header: "debit", Kind: export.NumberColumn, Width: 15},
+			{Header: "credit", Kind: export.NumberColumn, Width: 15},
+			{Header: "balance", Kind: export.NumberColumn, Width: 15},
+			{Header: "account", Kind: export.TextColumn, Width: 25},
+		},
+	}
+}
+
+func entryRow(d *accounting_iface.EntryItem) []any {
+	account := ""
+	if d.Account != nil {
+		account = d.Account.Name
+	}
+
+	return []any{
+		time.UnixMicro(d.EntryTime),
+		d.Desc,
+		d.Debit,
+		d.Credit,
+		d.Balance,
+		account,
+	}
+}
+
+// EntryExport export ledger entries ke csv, xlsx atau pdf
+func (l *ledgerServiceImpl) EntryExport(
+	ctx context.Context,
+	req *connect.Request[accounting_iface.EntryExportRequest],
+	stream *connect.ServerStream[accounting_iface.ExportChunk],
+) error {
+	var err error
+	pay := req.Msg
+
+	err = l.
+		auth.
+		AuthIdentityFromHeader(req.Header()).
+		Err()
+
+	if err != nil {
+		return err
+	}
+
+	if pay.TeamId == 0 {
+		return connect.NewError(connect.CodeInvalidArgument, errors.New("team_id required"))
+	}
+
+	db := l.db.WithContext(ctx)
+
+	trange := pay.TimeRange
+	if trange == nil {
+		trange = &common.TimeFilterRange{}
+	}
+
+	view := NewLedgerView(db)
+	view.
+		createQuery().
+		TeamID(uint(pay.TeamId)).
+		AccountKey(pay.AccountKey).
+		Search(pay.Keyword).
+		TimeRange(trange)
+
+	var total int64
+	err = view.
+		Count(&total).
+		Err()
+	if err != nil {
+		return err
+	}
+
+	doc := entryDocument()
+	if trange.StartDate != nil {
+		doc.Start = trange.StartDate.AsTime()
+	}
+	if trange.EndDate != nil {
+		doc.End = trange.EndDate.AsTime()
+	}
+	if pay.AccountKey != "" {
+		doc.Title += " " + pay.AccountKey
+	}
+
+	doc.TeamName, err = export.TeamName(db, pay.TeamId)
+	if err != nil {
+		return err
+	}
+
+	return export.Stream(stream, pay.Format, doc, total, func(w export.Writer) error {
+		return view.Iterate(func(d *accounting_iface.EntryItem) error {
+			return w.WriteRow(entryRow(d)...)
+		})
+	})
+}
@@ -2,11 +2,10 @@ package ledger
 
 import (
 	"context"
-	"encoding/csv"
 	"fmt"
-	"time"
 
 	"connectrpc.com/connect"
+	"github.com/pdcgo/accounting_service/export"
 	"github.com/pdcgo/schema/services/accounting_iface/v1"
 	"github.com/pdcgo/shared/interfaces/authorization_iface"
 	"github.com/pdcgo/shared/pkg/ware_cache"
@@ -61,34 +60,19 @@ func (l *ledgerServiceImpl) EntryListExport(
 
 	streamlog(fmt.Sprintf("%d data ditemukan..", writer.total))
 
-	csvWriter := csv.NewWriter(writer)
-
-	headers := []string{
-		"entry_at",
-		"desc",
-		"debit",
-		"credit",
-		"balance",
-		"account",
+	csvWriter, err := export.NewWriter(export.FormatCsv, entryDocument(), writer)
+	if err != nil {
+		return err
 	}
 
-	csvWriter.Write(headers)
-
 	err = view.Iterate(func(d *accounting_iface.EntryItem) error {
-		err = csvWriter.Write([]string{
-			time.UnixMicro(d.EntryTime).String(),
-			d.Desc,
-			fmt.Sprintf("%4.f", d.Debit),
-			fmt.Sprintf("%4.f", d.Credit),
-			fmt.Sprintf("%4.f", d.Balance),
-			d.Account.Name,
-		})
-		csvWriter.Flush()
-
-		return err
+		return csvWriter.WriteRow(entryRow(d)...)
 	})
+	if err != nil {
+		return err
+	}
 
-	return err
+	return csvWriter.Close()
 }
 
 func NewLedgerService(db *gorm.DB, auth authorization_iface.Authorization, cache ware_cache.Cache) *ledgerServiceImpl {
@@ -4,8 +4,15 @@ import (
 	"log"
 	"log/slog"
 
+	"github.com/pdcgo/accounting_service/account"
 	"github.com/pdcgo/accounting_service/accounting_core"
 	"github.com/pdcgo/accounting_service/accounting_model"
+	"github.com/pdcgo/accounting_service/bank_statement"
+	"github.com/pdcgo/accounting_service/budget"
+	"github.com/pdcgo/accounting_service/customer_service"
+	"github.com/pdcgo/accounting_service/payment"
+	"github.com/pdcgo/accounting_service/receivable"
+	"github.com/pdcgo/accounting_service/task_queue"
 	"github.com/pdcgo/schema/services/accounting_iface/v1"
 	"github.com/pdcgo/schema/services/common/v1"
 	"github.com/pdcgo/shared/db_models"
@@ -36,6 +43,7 @@ func NewMigrationHandler(
 		err := db.AutoMigrate(
 			&accounting_core.Account{},
 			&accounting_core.JournalEntry{},
+			&accounting_core.JournalEntryBank{},
 			&accounting_core.Transaction{},
 			&accounting_core.AccountDailyBalance{},
 			&accounting_core.AccountKeyDailyBalance{},
@@ -51,13 +59,28 @@ func NewMigrationHandler(
 			&accounting_core.TypeLabel{},
 			&accounting_core.TransactionTypeLabel{},
 			&accounting_core.TypeLabelDailyBalance{},
+			&accounting_core.DailyAppliedEntry{},
+			&accounting_core.ProjectionAppliedEntry{},
+			&accounting_core.ProjectionCheckpoint{},
 
 			&accounting_model.BankAccountV2{},
 			&accounting_model.BankAccountLabel{},
 			&accounting_model.BankAccountLabelRelation{},
 			&accounting_model.BankTransferHistory{},
+			&accounting_model.TeamTransfer{},
 			&accounting_model.Expense{},
 			&accounting_model.Payment{},
+			&accounting_model.PaymentEvent{},
+
+			&budget.Budget{},
+			&customer_service.CommissionRule{},
+			&receivable.OrderReceivable{},
+			&bank_statement.StatementLine{},
+			&bank_statement.StatementMatch{},
+			&bank_statement.ReconSession{},
+
+			&task_queue.QueueTask{},
+			&task_queue.QueueDeadTask{},
 		)
 		if err != nil {
 			return err
@@ -113,6 +136,16 @@ func NewMigrationHandler(
 			}
 		}
 
+		err = account.BackfillBankLedger(db)
+		if err != nil {
+			slog.Error("backfill bank ledger", slog.Any("error", err))
+		}
+
+		err = payment.BackfillAcceptedAmount(db)
+		if err != nil {
+			slog.Error("backfill payment accepted amount", slog.Any("error", err))
+		}
+
 		slog.Info("seeding, account Type")
 		err = SeedAccountType(db)
 		if err != nil {
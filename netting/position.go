@@ -0,0 +1,218 @@
+package netting
+
+import (
+	"strings"
+	"time"
+
+	"github.com/pdcgo/accounting_service/accounting_core"
+	"github.com/pdcgo/accounting_service/accounting_model"
+	"github.com/pdcgo/accounting_service/query_dialect"
+	"github.com/pdcgo/schema/services/accounting_iface/v1"
+	"github.com/pdcgo/schema/services/payment_iface/v1"
+	"gorm.io/gorm"
+)
+
+var payableKeys = []accounting_core.AccountKey{
+	accounting_core.PayableAccount,
+	accounting_core.LoanPayableAccount,
+	accounting_core.StockCrossPayableAccount,
+}
+
+var receivableKeys = []accounting_core.AccountKey{
+	accounting_core.ReceivableAccount,
+	accounting_core.LoanReceivableAccount,
+	accounting_core.StockCrossReceivableAccount,
+}
+
+// ledgerOwe hutang bersih ke counterparty menurut buku satu team.
+// Akun hutang piutang antar team memakai team_id counterparty, team pencatat ada di journal entry.
+func ledgerOwe(l *accounting_iface.NettingLedgerPosition) float64 {
+	return l.GetPayable() - l.GetReceivable()
+}
+
+type positionQuery struct {
+	db      *gorm.DB
+	teamIDs []uint
+	asOf    time.Time
+}
+
+func pairKey(a, b uint) [2]uint {
+	if a > b {
+		return [2]uint{b, a}
+	}
+	return [2]uint{a, b}
+}
+
+// end batas entry yang dihitung, akhir hari as_of
+func (p *positionQuery) end() time.Time {
+	return query_dialect.ReportDayStart(query_dialect.ReportDay(p.asOf).AddDate(0, 0, 1))
+}
+
+func (p *positionQuery) ledgers() (map[[2]uint]*accounting_iface.NettingLedgerPosition, error) {
+	rows := []*struct {
+		TeamID         uint
+		CounterpartyID uint
+		Payable        float64
+		Receivable     float64
+	}{}
+
+	keys := append(append([]accounting_core.AccountKey{}, payableKeys...), receivableKeys...)
+
+	err := p.
+		db.
+		Table("journal_entries je").
+		Joins("join accounts a on a.id = je.account_id").
+		Select(strings.Join([]string{
+			"je.team_id as team_id",
+			"a.team_id as counterparty_id",
+			"sum(case when a.account_key in ? then je.credit - je.debit else 0 end) as payable",
+			"sum(case when a.account_key in ? then je.debit - je.credit else 0 end) as receivable",
+		}, ", "), payableKeys, receivableKeys).
+		Where("je.team_id in ?", p.teamIDs).
+		Where("a.team_id in ?", p.teamIDs).
+		Where("a.team_id <> je.team_id").
+		Where("a.account_key in ?", keys).
+		Where("je.entry_time < ?", p.end()).
+		Group("je.team_id, a.team_id").
+		Find(&rows).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[[2]uint]*accounting_iface.NettingLedgerPosition{}
+	for _, row := range rows {
+		result[[2]uint{row.TeamID, row.CounterpartyID}] = &accounting_iface.NettingLedgerPosition{
+			Payable:    row.Payable,
+			Receivable: row.Receivable,
+		}
+	}
+	return result, nil
+}
+
+// pendingPosition payment pending antar dua team, positif dari team kecil ke team besar
+type pendingPosition struct {
+	// sisa yang belum diterima
+	remaining float64
+	// sisa yang sudah keluar dari piutang di buku penerima saat payment dibuat,
+	// dikelompokkan per team penerima
+	received map[uint]float64
+}
+
+// inBook sisa pending yang belum tercatat di buku team
+func (p *pendingPosition) inBook(teamID uint) float64 {
+	if p == nil {
+		return 0
+	}
+	return p.received[teamID]
+}
+
+// pending payment pending di dalam group. Buku pengirim baru berubah saat diterima,
+// buku penerima sudah memindah piutang ke pending payment saat payment dibuat.
+func (p *positionQuery) pending() (map[[2]uint]*pendingPosition, error) {
+	payments := []*accounting_model.Payment{}
+	err := p.
+		db.
+		Model(&accounting_model.Payment{}).
+		Where("status = ?", payment_iface.PaymentStatus_PAYMENT_STATUS_PENDING).
+		Where("from_team_id in ?", p.teamIDs).
+		Where("to_team_id in ?", p.teamIDs).
+		Find(&payments).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[[2]uint]*pendingPosition{}
+	for _, payment := range payments {
+		key := pairKey(payment.FromTeamID, payment.ToTeamID)
+		pos := result[key]
+		if pos == nil {
+			pos = &pendingPosition{received: map[uint]float64{}}
+			result[key] = pos
+		}
+
+		// bagian yang sudah diterima sudah masuk buku kedua team
+		remaining := payment.Remaining()
+		if key[0] != payment.FromTeamID {
+			remaining = -remaining
+		}
+
+		pos.remaining += remaining
+		// payment lama tanpa jurnal pending belum mengubah buku penerima,
+		// jurnal pending setelah as_of juga belum terhitung di ledger
+		if payment.TxID != 0 && payment.CreatedAt.Before(p.end()) {
+			pos.received[payment.ToTeamID] += remaining
+		}
+	}
+	return result, nil
+}
+
+// positions posisi bersih setiap pasangan team yang punya saldo.
+// Buku team a dipakai sebagai acuan, buku team b dipakai kalau team a tidak mencatat apa-apa.
+func (p *positionQuery) positions() ([]*accounting_iface.NettingPairPosition, error) {
+	ledgers, err := p.ledgers()
+	if err != nil {
+		return nil, err
+	}
+
+	pendings, err := p.pending()
+	if err != nil {
+		return nil, err
+	}
+
+	pairs := map[[2]uint]bool{}
+	for key := range ledgers {
+		pairs[pairKey(key[0], key[1])] = true
+	}
+	for key := range pendings {
+		pairs[key] = true
+	}
+
+	result := []*accounting_iface.NettingPairPosition{}
+	for _, a := range p.teamIDs {
+		for _, b := range p.teamIDs {
+			if a >= b || !pairs[[2]uint{a, b}] {
+				continue
+			}
+
+			pending := pendings[[2]uint{a, b}]
+			pos := accounting_iface.NettingPairPosition{
+				TeamA:   uint64(a),
+				TeamB:   uint64(b),
+				LedgerA: ledgers[[2]uint{a, b}],
+				LedgerB: ledgers[[2]uint{b, a}],
+			}
+			if pending != nil {
+				pos.Pending = pending.remaining
+			}
+
+			// hutang team a sebelum payment pending menurut masing masing buku
+			oweA := func() float64 {
+				return ledgerOwe(pos.LedgerA) + pending.inBook(a)
+			}
+			oweB := func() float64 {
+				return -ledgerOwe(pos.LedgerB) + pending.inBook(b)
+			}
+
+			var owe float64
+			switch {
+			case pos.LedgerA != nil:
+				owe = oweA()
+			case pos.LedgerB != nil:
+				owe = oweB()
+			}
+
+			if pos.LedgerA != nil && pos.LedgerB != nil {
+				pos.Mismatch = !accounting_core.CompareFloatSafe(oweA(), oweB(), accounting_core.PrecisionEpsilon)
+			}
+
+			pos.Net = owe - pos.Pending
+			result = append(result, &pos)
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,180 @@
+package netting
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/pdcgo/accounting_service/accounting_core"
+	"github.com/pdcgo/accounting_service/accounting_model"
+	"github.com/pdcgo/accounting_service/payment"
+	"github.com/pdcgo/schema/services/accounting_iface/v1"
+	"github.com/pdcgo/schema/services/payment_iface/v1"
+	"github.com/pdcgo/shared/interfaces/authorization_iface"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/gorm"
+)
+
+type nettingServiceImpl struct {
+	db   *gorm.DB
+	auth authorization_iface.Authorization
+}
+
+func (n *nettingServiceImpl) checkPermission(identity authorization_iface.AuthIdentity, teamIDs []uint, action authorization_iface.Action) error {
+	for _, teamID := range teamIDs {
+		err := identity.
+			HasPermission(authorization_iface.CheckPermissionGroup{
+				&accounting_model.Payment{}: &authorization_iface.CheckPermission{
+					DomainID: teamID,
+					Actions:  []authorization_iface.Action{action},
+				},
+			}).
+			Err()
+
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func groupTeamIDs(ids []uint64, asOf *timestamppb.Timestamp) ([]uint, error) {
+	teamIDs := []uint{}
+	for _, teamID := range ids {
+		if teamID == 0 {
+			return teamIDs, errors.New("invalid team_id")
+		}
+		teamIDs = append(teamIDs, uint(teamID))
+	}
+	slices.Sort(teamIDs)
+	teamIDs = slices.Compact(teamIDs)
+
+	if len(teamIDs) < 2 {
+		return teamIDs, errors.New("netting need at least two team")
+	}
+	if asOf == nil {
+		return teamIDs, errors.New("as_of required")
+	}
+	return teamIDs, nil
+}
+
+func (n *nettingServiceImpl) compute(db *gorm.DB, teamIDs []uint, asOf time.Time) (*accounting_iface.NettingPositionResponse, error) {
+	query := positionQuery{
+		db:      db,
+		teamIDs: teamIDs,
+		asOf:    asOf,
+	}
+
+	positions, err := query.positions()
+	if err != nil {
+		return nil, err
+	}
+
+	balances := teamBalances(teamIDs, positions)
+	return &accounting_iface.NettingPositionResponse{
+		Positions: positions,
+		Balances:  balances,
+		Proposals: propose(balances),
+	}, nil
+}
+
+// NettingPosition posisi bersih antar team dan usulan pembayaran minimal
+func (n *nettingServiceImpl) NettingPosition(
+	ctx context.Context,
+	req *connect.Request[accounting_iface.NettingPositionRequest],
+) (*connect.Response[accounting_iface.NettingPositionResponse], error) {
+	var err error
+	pay := req.Msg
+
+	result := accounting_iface.NettingPositionResponse{
+		Positions: []*accounting_iface.NettingPairPosition{},
+		Balances:  []*accounting_iface.NettingTeamBalance{},
+		Proposals: []*accounting_iface.NettingProposal{},
+	}
+
+	teamIDs, err := groupTeamIDs(pay.TeamIds, pay.AsOf)
+	if err != nil {
+		return connect.NewResponse(&result), connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	identity := n.auth.AuthIdentityFromHeader(req.Header())
+	err = n.checkPermission(identity, teamIDs, authorization_iface.Read)
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	computed, err := n.compute(n.db.WithContext(ctx), teamIDs, pay.AsOf.AsTime())
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	return connect.NewResponse(computed), nil
+}
+
+// NettingSettle membuat payment pending dari usulan netting untuk di-approve team penerima
+func (n *nettingServiceImpl) NettingSettle(
+	ctx context.Context,
+	req *connect.Request[accounting_iface.NettingSettleRequest],
+) (*connect.Response[accounting_iface.NettingSettleResponse], error) {
+	var err error
+	pay := req.Msg
+
+	result := accounting_iface.NettingSettleResponse{
+		Proposals: []*accounting_iface.NettingProposal{},
+	}
+
+	teamIDs, err := groupTeamIDs(pay.TeamIds, pay.AsOf)
+	if err != nil {
+		return connect.NewResponse(&result), connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	identity := n.auth.AuthIdentityFromHeader(req.Header())
+	err = n.checkPermission(identity, teamIDs, authorization_iface.Read)
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	agent := identity.Identity()
+
+	err = accounting_core.OpenTransaction(ctx, n.db.WithContext(ctx), func(tx *gorm.DB, bookmng accounting_core.BookManage) error {
+		computed, err := n.compute(tx, teamIDs, pay.AsOf.AsTime())
+		if err != nil {
+			return err
+		}
+
+		for _, proposal := range computed.Proposals {
+			err = n.checkPermission(identity, []uint{uint(proposal.FromTeamId)}, authorization_iface.Create)
+			if err != nil {
+				return err
+			}
+
+			data := accounting_model.Payment{
+				FromTeamID:        uint(proposal.FromTeamId),
+				ToTeamID:          uint(proposal.ToTeamId),
+				Amount:            proposal.Amount,
+				PaymentType:       payment_iface.PaymentType_PAYMENT_TYPE_OTHER,
+				FromBankAccountID: uint(pay.Banks[proposal.FromTeamId]),
+			}
+
+			err = payment.CreatePendingPayment(tx, bookmng, agent, &data, "netting settlement")
+			if err != nil {
+				return err
+			}
+			proposal.PaymentId = uint64(data.ID)
+		}
+
+		result.Proposals = computed.Proposals
+		return nil
+	})
+
+	return connect.NewResponse(&result), err
+}
+
+func NewNettingService(db *gorm.DB, auth authorization_iface.Authorization) *nettingServiceImpl {
+	return &nettingServiceImpl{
+		db:   db,
+		auth: auth,
+	}
+}
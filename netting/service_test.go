@@ -0,0 +1,337 @@
+package netting_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/pdcgo/accounting_service/accounting_core"
+	"github.com/pdcgo/accounting_service/accounting_mock"
+	"github.com/pdcgo/accounting_service/accounting_model"
+	"github.com/pdcgo/accounting_service/netting"
+	"github.com/pdcgo/accounting_service/payment"
+	"github.com/pdcgo/schema/services/accounting_iface/v1"
+	"github.com/pdcgo/schema/services/payment_iface/v1"
+	"github.com/pdcgo/shared/authorization/authorization_mock"
+	"github.com/pdcgo/shared/pkg/moretest"
+	"github.com/pdcgo/shared/pkg/moretest/moretest_mock"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/gorm"
+)
+
+type crossEntry struct {
+	// team yang mencatat
+	teamID uint
+	// pemilik akun, yaitu counterparty
+	accTeamID uint
+	key       accounting_core.AccountKey
+	at        time.Time
+	debit     float64
+	credit    float64
+}
+
+func seedCrossEntries(t *testing.T, db *gorm.DB, entries []*crossEntry) {
+	for i, entry := range entries {
+		var acc accounting_core.Account
+		err := db.
+			Where("team_id = ? and account_key = ?", entry.accTeamID, entry.key).
+			Find(&acc).
+			Error
+		assert.Nil(t, err)
+
+		tran := accounting_core.Transaction{
+			RefID:   accounting_core.RefID(fmt.Sprintf("cross#%d#%d", entry.teamID, i)),
+			TeamID:  entry.teamID,
+			Created: entry.at,
+		}
+		err = db.Create(&tran).Error
+		assert.Nil(t, err)
+
+		err = db.Create(&accounting_core.JournalEntry{
+			AccountID:     acc.ID,
+			TeamID:        entry.teamID,
+			TransactionID: tran.ID,
+			EntryTime:     entry.at,
+			Debit:         entry.debit,
+			Credit:        entry.credit,
+		}).Error
+		assert.Nil(t, err)
+	}
+}
+
+func TestNetting(t *testing.T) {
+	var db gorm.DB
+
+	moretest.Suite(t, "testing netting antar team",
+		moretest.SetupListFunc{
+			moretest_mock.MockSqliteDatabase(&db),
+			func(t *testing.T) func() error {
+				err := db.AutoMigrate(
+					&accounting_core.Account{},
+					&accounting_core.Transaction{},
+					&accounting_core.JournalEntry{},
+					&accounting_core.JournalEntryBank{},
+					&accounting_model.Payment{},
+					&accounting_model.PaymentEvent{},
+					&accounting_model.BankAccountV2{},
+				)
+				assert.Nil(t, err)
+
+				err = db.Create(&accounting_model.BankAccountV2{ID: 1, TeamID: 1, Name: "bca team 1", NumberID: "1001"}).Error
+				assert.Nil(t, err)
+				return nil
+			},
+			accounting_mock.PopulateAccountKey(&db, 1),
+			accounting_mock.PopulateAccountKey(&db, 2),
+			accounting_mock.PopulateAccountKey(&db, 3),
+			accounting_mock.PopulateAccountKey(&db, 4),
+			func(t *testing.T) func() error {
+				err := accounting_core.
+					NewCreateAccount(&db).
+					Create(
+						accounting_core.CreditBalance,
+						accounting_core.LIABILITY,
+						1,
+						accounting_core.StockCrossPayableAccount,
+						"stock cross payable",
+					)
+				assert.Nil(t, err)
+
+				entries := []*crossEntry{
+					{teamID: 1, accTeamID: 2, key: accounting_core.PayableAccount, at: accounting_mock.Date(10, 1), credit: 1000},
+					{teamID: 2, accTeamID: 1, key: accounting_core.ReceivableAccount, at: accounting_mock.Date(10, 1), debit: 1000},
+					{teamID: 2, accTeamID: 3, key: accounting_core.PayableAccount, at: accounting_mock.Date(10, 3), credit: 600},
+					// buku team 1 dan team 3 tidak sama
+					{teamID: 3, accTeamID: 1, key: accounting_core.StockCrossPayableAccount, at: accounting_mock.Date(10, 4), credit: 300},
+					{teamID: 1, accTeamID: 3, key: accounting_core.ReceivableAccount, at: accounting_mock.Date(10, 4), debit: 200},
+					// setelah tanggal netting
+					{teamID: 1, accTeamID: 2, key: accounting_core.PayableAccount, at: accounting_mock.Date(11, 5), credit: 500},
+					// team di luar group
+					{teamID: 1, accTeamID: 4, key: accounting_core.PayableAccount, at: accounting_mock.Date(10, 5), credit: 700},
+				}
+
+				seedCrossEntries(t, &db, entries)
+
+				err = db.Create(&accounting_model.Payment{
+					FromTeamID: 1,
+					ToTeamID:   2,
+					Amount:     100,
+					Status:     payment_iface.PaymentStatus_PAYMENT_STATUS_PENDING,
+					CreatedAt:  accounting_mock.Date(10, 10),
+				}).Error
+				assert.Nil(t, err)
+				return nil
+			},
+		},
+		func(t *testing.T) {
+			service := netting.NewNettingService(&db, &authorization_mock.EmptyAuthorizationMock{
+				AuthIdentityMock: &authorization_mock.AuthIdentityMock{
+					IdentityMock: &authorization_mock.IdentityMock{ID: 1},
+				},
+			})
+
+			teamIDs := []uint64{3, 1, 2}
+			asOf := timestamppb.New(accounting_mock.Date(10, 31))
+
+			t.Run("posisi bersih antar team", func(t *testing.T) {
+				result, err := service.NettingPosition(t.Context(), connect.NewRequest(&accounting_iface.NettingPositionRequest{
+					TeamIds: teamIDs,
+					AsOf:    asOf,
+				}))
+				assert.Nil(t, err)
+				res := result.Msg
+				assert.Len(t, res.Positions, 3)
+
+				pos := res.Positions[0]
+				assert.Equal(t, uint64(1), pos.TeamA)
+				assert.Equal(t, uint64(2), pos.TeamB)
+				assert.False(t, pos.Mismatch)
+				assert.Equal(t, 100.0, pos.Pending)
+				assert.Equal(t, 900.0, pos.Net)
+
+				pos = res.Positions[1]
+				assert.Equal(t, uint64(3), pos.TeamB)
+				assert.True(t, pos.Mismatch)
+				assert.Equal(t, -200.0, pos.Net)
+
+				pos = res.Positions[2]
+				assert.Equal(t, uint64(2), pos.TeamA)
+				assert.Nil(t, pos.LedgerB)
+				assert.Equal(t, 600.0, pos.Net)
+
+				assert.Equal(t, 700.0, res.Balances[0].Owe)
+				assert.Equal(t, -300.0, res.Balances[1].Owe)
+				assert.Equal(t, -400.0, res.Balances[2].Owe)
+
+				assert.Len(t, res.Proposals, 2)
+				assert.Equal(t, uint64(1), res.Proposals[0].FromTeamId)
+				assert.Equal(t, uint64(3), res.Proposals[0].ToTeamId)
+				assert.Equal(t, 400.0, res.Proposals[0].Amount)
+				assert.Equal(t, uint64(2), res.Proposals[1].ToTeamId)
+				assert.Equal(t, 300.0, res.Proposals[1].Amount)
+			})
+
+			t.Run("settle tanpa bank pembayar ditolak", func(t *testing.T) {
+				_, err := service.NettingSettle(t.Context(), connect.NewRequest(&accounting_iface.NettingSettleRequest{
+					TeamIds: teamIDs,
+					AsOf:    asOf,
+				}))
+				assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+
+				var count int64
+				err = db.Model(&accounting_model.Payment{}).Count(&count).Error
+				assert.Nil(t, err)
+				assert.Equal(t, int64(1), count)
+			})
+
+			t.Run("buat payment pending", func(t *testing.T) {
+				result, err := service.NettingSettle(t.Context(), connect.NewRequest(&accounting_iface.NettingSettleRequest{
+					TeamIds: teamIDs,
+					AsOf:    asOf,
+					Banks:   map[uint64]uint64{1: 1},
+				}))
+				assert.Nil(t, err)
+				res := result.Msg
+				assert.Len(t, res.Proposals, 2)
+
+				var bank accounting_model.BankAccountV2
+				err = db.First(&bank, 1).Error
+				assert.Nil(t, err)
+				assert.Equal(t, -700.0, bank.Balance)
+
+				var count int64
+				err = db.
+					Model(&accounting_model.Payment{}).
+					Where("status = ?", payment_iface.PaymentStatus_PAYMENT_STATUS_PENDING).
+					Count(&count).
+					Error
+				assert.Nil(t, err)
+				assert.Equal(t, int64(3), count)
+
+				var payment accounting_model.Payment
+				err = db.First(&payment, res.Proposals[0].PaymentId).Error
+				assert.Nil(t, err)
+				assert.Equal(t, uint(3), payment.ToTeamID)
+				assert.Equal(t, uint(1), payment.CreatedByID)
+				assert.NotZero(t, payment.TxID)
+
+				// dibuat lewat jalur payment create, jurnal pending dan event created
+				var entries int64
+				err = db.
+					Model(&accounting_core.JournalEntry{}).
+					Where("transaction_id = ?", payment.TxID).
+					Count(&entries).
+					Error
+				assert.Nil(t, err)
+				assert.Equal(t, int64(4), entries)
+
+				var event accounting_model.PaymentEvent
+				err = db.Where("payment_id = ?", payment.ID).First(&event).Error
+				assert.Nil(t, err)
+				assert.Equal(t, accounting_model.PaymentEventCreated, event.Type)
+				assert.Equal(t, payment.TxID, event.TxID)
+				assert.Equal(t, payment.Amount, event.Amount)
+
+				// payment pending ikut dihitung, tidak diusulkan ulang
+				position, err := service.NettingPosition(t.Context(), connect.NewRequest(&accounting_iface.NettingPositionRequest{
+					TeamIds: teamIDs,
+					AsOf:    asOf,
+				}))
+				assert.Nil(t, err)
+				assert.Empty(t, position.Msg.Proposals)
+			})
+
+			t.Run("group minimal dua team", func(t *testing.T) {
+				_, err := service.NettingPosition(t.Context(), connect.NewRequest(&accounting_iface.NettingPositionRequest{
+					TeamIds: []uint64{1, 1},
+					AsOf:    asOf,
+				}))
+				assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+			})
+		},
+	)
+}
+
+func TestNettingPartialPayment(t *testing.T) {
+	var db gorm.DB
+
+	moretest.Suite(t, "testing netting dengan payment diterima sebagian",
+		moretest.SetupListFunc{
+			moretest_mock.MockSqliteDatabase(&db),
+			func(t *testing.T) func() error {
+				err := db.AutoMigrate(
+					&accounting_core.Account{},
+					&accounting_core.Transaction{},
+					&accounting_core.JournalEntry{},
+					&accounting_core.JournalEntryBank{},
+					&accounting_model.Payment{},
+					&accounting_model.PaymentEvent{},
+					&accounting_model.BankAccountV2{},
+				)
+				assert.Nil(t, err)
+
+				err = db.Create([]*accounting_model.BankAccountV2{
+					{ID: 1, TeamID: 5, Name: "bca team 5", NumberID: "1001"},
+					{ID: 2, TeamID: 6, Name: "bca team 6", NumberID: "1002"},
+				}).Error
+				assert.Nil(t, err)
+				return nil
+			},
+			accounting_mock.PopulateAccountKey(&db, 5),
+			accounting_mock.PopulateAccountKey(&db, 6),
+			func(t *testing.T) func() error {
+				seedCrossEntries(t, &db, []*crossEntry{
+					{teamID: 5, accTeamID: 6, key: accounting_core.PayableAccount, at: accounting_mock.Date(10, 1), credit: 1000},
+					{teamID: 6, accTeamID: 5, key: accounting_core.ReceivableAccount, at: accounting_mock.Date(10, 1), debit: 1000},
+				})
+				return nil
+			},
+		},
+		func(t *testing.T) {
+			auth := &authorization_mock.EmptyAuthorizationMock{
+				AuthIdentityMock: &authorization_mock.AuthIdentityMock{
+					IdentityMock: &authorization_mock.IdentityMock{ID: 1},
+				},
+			}
+			paymentService := payment.NewPaymentService(&db, auth)
+
+			service := netting.NewNettingService(&db, auth)
+
+			created, err := paymentService.PaymentCreate(t.Context(), connect.NewRequest(&payment_iface.PaymentCreateRequest{
+				FromTeamId:        5,
+				ToTeamId:          6,
+				Amount:            600,
+				PaymentType:       payment_iface.PaymentType_PAYMENT_TYPE_OTHER,
+				FromBankAccountId: 1,
+			}))
+			assert.Nil(t, err)
+
+			_, err = paymentService.PaymentAcceptPartial(t.Context(), connect.NewRequest(&payment_iface.PaymentAcceptPartialRequest{
+				TeamId:          6,
+				PaymentId:       created.Msg.PaymentId,
+				Amount:          250,
+				ToBankAccountId: 2,
+			}))
+			assert.Nil(t, err)
+
+			result, err := service.NettingPosition(t.Context(), connect.NewRequest(&accounting_iface.NettingPositionRequest{
+				TeamIds: []uint64{5, 6},
+				AsOf:    timestamppb.New(time.Now().AddDate(0, 0, 1)),
+			}))
+			assert.Nil(t, err)
+			res := result.Msg
+			assert.Len(t, res.Positions, 1)
+
+			pos := res.Positions[0]
+			assert.Equal(t, 350.0, pos.Pending)
+			assert.Equal(t, 750.0, pos.LedgerA.Payable-pos.LedgerA.Receivable)
+			assert.False(t, pos.Mismatch)
+			assert.Equal(t, 400.0, pos.Net)
+
+			assert.Len(t, res.Proposals, 1)
+			assert.Equal(t, 400.0, res.Proposals[0].Amount)
+		},
+	)
+}
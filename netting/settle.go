@@ -0,0 +1,82 @@
+package netting
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/pdcgo/accounting_service/accounting_core"
+	"github.com/pdcgo/schema/services/accounting_iface/v1"
+)
+
+// teamBalances saldo bersih team di group, positif berarti team harus membayar
+func teamBalances(teamIDs []uint, positions []*accounting_iface.NettingPairPosition) []*accounting_iface.NettingTeamBalance {
+	owes := map[uint64]float64{}
+	for _, pos := range positions {
+		owes[pos.TeamA] += pos.Net
+		owes[pos.TeamB] -= pos.Net
+	}
+
+	result := make([]*accounting_iface.NettingTeamBalance, 0, len(teamIDs))
+	for _, teamID := range teamIDs {
+		result = append(result, &accounting_iface.NettingTeamBalance{
+			TeamId: uint64(teamID),
+			Owe:    owes[uint64(teamID)],
+		})
+	}
+	return result
+}
+
+// propose mencocokkan debitur terbesar dengan kreditur terbesar,
+// jumlah payment paling banyak jumlah team dikurangi satu.
+func propose(balances []*accounting_iface.NettingTeamBalance) []*accounting_iface.NettingProposal {
+	type party struct {
+		teamID uint64
+		amount float64
+	}
+
+	debtors := []*party{}
+	creditors := []*party{}
+	for _, bal := range balances {
+		switch {
+		case bal.Owe > accounting_core.PrecisionEpsilon:
+			debtors = append(debtors, &party{bal.TeamId, bal.Owe})
+		case bal.Owe < -accounting_core.PrecisionEpsilon:
+			creditors = append(creditors, &party{bal.TeamId, -bal.Owe})
+		}
+	}
+
+	byAmount := func(a, b *party) int {
+		if a.amount == b.amount {
+			return cmp.Compare(a.teamID, b.teamID)
+		}
+		if a.amount > b.amount {
+			return -1
+		}
+		return 1
+	}
+
+	result := []*accounting_iface.NettingProposal{}
+	for len(debtors) != 0 && len(creditors) != 0 {
+		slices.SortFunc(debtors, byAmount)
+		slices.SortFunc(creditors, byAmount)
+
+		debtor, creditor := debtors[0], creditors[0]
+		amount := min(debtor.amount, creditor.amount)
+		result = append(result, &accounting_iface.NettingProposal{
+			FromTeamId: debtor.teamID,
+			ToTeamId:   creditor.teamID,
+			Amount:     amount,
+		})
+
+		debtor.amount -= amount
+		creditor.amount -= amount
+		if debtor.amount <= accounting_core.PrecisionEpsilon {
+			debtors = debtors[1:]
+		}
+		if creditor.amount <= accounting_core.PrecisionEpsilon {
+			creditors = creditors[1:]
+		}
+	}
+
+	return result
+}
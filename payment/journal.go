@@ -0,0 +1,294 @@
+package payment
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pdcgo/accounting_service/account"
+	"github.com/pdcgo/accounting_service/accounting_core"
+	"github.com/pdcgo/accounting_service/accounting_model"
+	"github.com/pdcgo/schema/services/payment_iface/v1"
+	"github.com/pdcgo/shared/interfaces/authorization_iface"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type paymentJournal struct {
+	bookmng accounting_core.BookManage
+	agent   authorization_iface.Identity
+	payment *accounting_model.Payment
+	// bank account kas penerima, hanya dipakai saat accept
+	toBankID uint
+}
+
+func (j *paymentJournal) transaction(ref accounting_core.RefID, desc string) (*accounting_core.Transaction, error) {
+	trans := accounting_core.Transaction{
+		RefID:       ref,
+		TeamID:      j.payment.FromTeamID,
+		CreatedByID: j.agent.IdentityID(),
+		Desc:        desc,
+		Created:     time.Now(),
+	}
+
+	err := j.
+		bookmng.
+		NewTransaction().
+		Create(&trans).
+		Err()
+
+	return &trans, err
+}
+
+// pending kas pengirim dan piutang penerima dipindah ke akun pending payment sampai diterima
+func (j *paymentJournal) pending() (uint, error) {
+	payment := j.payment
+	ref := accounting_core.NewRefID(&accounting_core.RefData{
+		RefType: accounting_core.PaymentRef,
+		ID:      payment.ID,
+	})
+
+	trans, err := j.transaction(ref, fmt.Sprintf("payment %s", ref))
+	if err != nil {
+		return 0, err
+	}
+
+	desc := accounting_core.EntryDescOption(fmt.Sprintf("create payment %s", ref))
+
+	// sisi pengirim
+	err = j.
+		bookmng.
+		NewCreateEntry(payment.FromTeamID, j.agent.IdentityID()).
+		From(&accounting_core.EntryAccountPayload{
+			Key:           accounting_core.CashAccount,
+			TeamID:        payment.ToTeamID,
+			BankAccountID: payment.FromBankAccountID,
+		}, payment.Amount, desc).
+		To(&accounting_core.EntryAccountPayload{
+			Key:    accounting_core.PendingPaymentPayAccount,
+			TeamID: payment.ToTeamID,
+		}, payment.Amount, desc).
+		Transaction(trans).
+		Commit().
+		Err()
+
+	if err != nil {
+		return 0, err
+	}
+
+	// sisi penerima
+	err = j.
+		bookmng.
+		NewCreateEntry(payment.ToTeamID, j.agent.IdentityID()).
+		From(&accounting_core.EntryAccountPayload{
+			Key:    accounting_core.ReceivableAccount,
+			TeamID: payment.FromTeamID,
+		}, payment.Amount, desc).
+		To(&accounting_core.EntryAccountPayload{
+			Key:    accounting_core.PendingPaymentReceiveAccount,
+			TeamID: payment.FromTeamID,
+		}, payment.Amount, desc).
+		Transaction(trans).
+		Commit().
+		Err()
+
+	return trans.ID, err
+}
+
+// accept penerimaan sebagian, payment lama tanpa jurnal pending langsung dari kas dan piutang
+func (j *paymentJournal) accept(amount float64, seq int) (uint, error) {
+	payment := j.payment
+	ref := accounting_core.NewStringRefID(&accounting_core.StringRefData{
+		RefType: accounting_core.PaymentAcceptRef,
+		ID:      fmt.Sprintf("%d-%d", payment.ID, seq),
+	})
+
+	trans, err := j.transaction(ref, fmt.Sprintf("payment %s", ref))
+	if err != nil {
+		return 0, err
+	}
+
+	// payment lama belum punya bank pengirim, kas pengirim tercatat tanpa dimensi bank
+	pay := &accounting_core.EntryAccountPayload{
+		Key:    accounting_core.PendingPaymentPayAccount,
+		TeamID: payment.ToTeamID,
+	}
+	receiveKey := accounting_core.PendingPaymentReceiveAccount
+	if payment.TxID == 0 {
+		pay.Key = accounting_core.CashAccount
+		pay.BankAccountID = payment.FromBankAccountID
+		receiveKey = accounting_core.ReceivableAccount
+	}
+
+	desc := accounting_core.EntryDescOption(fmt.Sprintf("accept payment %s", ref))
+
+	// sisi pengirim
+	err = j.
+		bookmng.
+		NewCreateEntry(payment.FromTeamID, j.agent.IdentityID()).
+		From(pay, amount, desc).
+		From(&accounting_core.EntryAccountPayload{
+			Key:    accounting_core.PayableAccount,
+			TeamID: payment.ToTeamID,
+		}, amount, desc).
+		Transaction(trans).
+		Commit().
+		Err()
+
+	if err != nil {
+		return 0, err
+	}
+
+	// sisi penerima
+	err = j.
+		bookmng.
+		NewCreateEntry(payment.ToTeamID, j.agent.IdentityID()).
+		From(&accounting_core.EntryAccountPayload{
+			Key:    receiveKey,
+			TeamID: payment.FromTeamID,
+		}, amount, desc).
+		To(&accounting_core.EntryAccountPayload{
+			Key:           accounting_core.CashAccount,
+			TeamID:        payment.ToTeamID,
+			BankAccountID: j.toBankID,
+		}, amount, desc).
+		Transaction(trans).
+		Commit().
+		Err()
+
+	return trans.ID, err
+}
+
+// void sisa pending payment dikembalikan ke kas pengirim dan piutang penerima
+func (j *paymentJournal) void(amount float64, reason string) (uint, error) {
+	payment := j.payment
+	if payment.TxID == 0 || accounting_core.CompareFloatSafe(amount, 0, accounting_core.PrecisionEpsilon) {
+		return 0, nil
+	}
+
+	ref := accounting_core.NewRefID(&accounting_core.RefData{
+		RefType: accounting_core.PaymentVoidRef,
+		ID:      payment.ID,
+	})
+
+	trans, err := j.transaction(ref, fmt.Sprintf("void payment %s %s", ref, reason))
+	if err != nil {
+		return 0, err
+	}
+
+	desc := accounting_core.EntryDescOption(fmt.Sprintf("void payment %s", ref))
+
+	// sisi pengirim
+	err = j.
+		bookmng.
+		NewCreateEntry(payment.FromTeamID, j.agent.IdentityID()).
+		From(&accounting_core.EntryAccountPayload{
+			Key:    accounting_core.PendingPaymentPayAccount,
+			TeamID: payment.ToTeamID,
+		}, amount, desc).
+		To(&accounting_core.EntryAccountPayload{
+			Key:           accounting_core.CashAccount,
+			TeamID:        payment.ToTeamID,
+			BankAccountID: payment.FromBankAccountID,
+		}, amount, desc).
+		Transaction(trans).
+		Commit().
+		Err()
+
+	if err != nil {
+		return 0, err
+	}
+
+	// sisi penerima
+	err = j.
+		bookmng.
+		NewCreateEntry(payment.ToTeamID, j.agent.IdentityID()).
+		From(&accounting_core.EntryAccountPayload{
+			Key:    accounting_core.PendingPaymentReceiveAccount,
+			TeamID: payment.FromTeamID,
+		}, amount, desc).
+		To(&accounting_core.EntryAccountPayload{
+			Key:    accounting_core.ReceivableAccount,
+			TeamID: payment.FromTeamID,
+		}, amount, desc).
+		Transaction(trans).
+		Commit().
+		Err()
+
+	return trans.ID, err
+}
+
+// syncBanks hitung ulang saldo bank yang ikut diposting, 0 untuk payment lama tanpa bank
+func syncBanks(tx *gorm.DB, bankIDs ...uint) error {
+	ids := []uint{}
+	for _, id := range bankIDs {
+		if id != 0 {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	return account.SyncBankBalance(tx, ids...)
+}
+
+func addEvent(tx *gorm.DB, event *accounting_model.PaymentEvent) error {
+	event.CreatedAt = time.Now()
+	return tx.Create(event).Error
+}
+
+// mutatePayment kunci payment lalu jalankan handle, bookmng nil kalau tidak ada jurnal yang dibuat
+func (p *paymentServiceImpl) mutatePayment(
+	ctx context.Context,
+	paymentID uint64,
+	withJournal func(payment *accounting_model.Payment) bool,
+	handle func(tx *gorm.DB, bookmng accounting_core.BookManage, payment *accounting_model.Payment) error,
+) error {
+	db := p.db.WithContext(ctx)
+
+	var current accounting_model.Payment
+	err := db.First(&current, paymentID).Error
+	if err != nil {
+		return err
+	}
+
+	lock := func(tx *gorm.DB) (*accounting_model.Payment, error) {
+		var payment accounting_model.Payment
+		err := tx.
+			Clauses(clause.Locking{
+				Strength: "UPDATE",
+			}).
+			Model(&accounting_model.Payment{}).
+			First(&payment, paymentID).
+			Error
+		return &payment, err
+	}
+
+	if !withJournal(&current) {
+		return db.Transaction(func(tx *gorm.DB) error {
+			payment, err := lock(tx)
+			if err != nil {
+				return err
+			}
+			return handle(tx, nil, payment)
+		})
+	}
+
+	return accounting_core.OpenTransaction(ctx, db, func(tx *gorm.DB, bookmng accounting_core.BookManage) error {
+		payment, err := lock(tx)
+		if err != nil {
+			return err
+		}
+		return handle(tx, bookmng, payment)
+	})
+}
+
+// BackfillAcceptedAmount payment lama yang sudah accepted diterima penuh
+func BackfillAcceptedAmount(db *gorm.DB) error {
+	return db.
+		Model(&accounting_model.Payment{}).
+		Where("status = ?", payment_iface.PaymentStatus_PAYMENT_STATUS_ACCEPTED).
+		Where("accepted_amount = 0").
+		Update("accepted_amount", gorm.Expr("amount")).
+		Error
+}
@@ -4,9 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
 	"time"
 
 	"connectrpc.com/connect"
+	"github.com/pdcgo/accounting_service/account"
 	"github.com/pdcgo/accounting_service/accounting_core"
 	"github.com/pdcgo/accounting_service/accounting_model"
 	"github.com/pdcgo/schema/services/common/v1"
@@ -14,21 +16,55 @@ import (
 	"github.com/pdcgo/shared/authorization"
 	"github.com/pdcgo/shared/interfaces/authorization_iface"
 	"gorm.io/gorm"
-	"gorm.io/gorm/clause"
 )
 
 // PaymentAccept implements payment_ifaceconnect.PaymentServiceHandler.
+// menerima seluruh sisa payment
 func (p *paymentServiceImpl) PaymentAccept(
 	ctx context.Context,
 	req *connect.Request[payment_iface.PaymentAcceptRequest],
 ) (*connect.Response[payment_iface.PaymentAcceptResponse], error) {
-	var err error
 	result := payment_iface.PaymentAcceptResponse{}
 
-	db := p.db.WithContext(ctx)
 	pay := req.Msg
+	_, err := p.acceptPayment(ctx, req.Header(), &payment_iface.PaymentAcceptPartialRequest{
+		TeamId:          pay.TeamId,
+		PaymentId:       pay.PaymentId,
+		RequestFrom:     pay.RequestFrom,
+		ToBankAccountId: pay.ToBankAccountId,
+	})
+
+	return connect.NewResponse(&result), err
+}
+
+// PaymentAcceptPartial menerima sebagian payment, status accepted setelah diterima penuh
+func (p *paymentServiceImpl) PaymentAcceptPartial(
+	ctx context.Context,
+	req *connect.Request[payment_iface.PaymentAcceptPartialRequest],
+) (*connect.Response[payment_iface.PaymentAcceptPartialResponse], error) {
+	result := payment_iface.PaymentAcceptPartialResponse{}
 
-	identity := p.auth.AuthIdentityFromHeader(req.Header())
+	payment, err := p.acceptPayment(ctx, req.Header(), req.Msg)
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+	result.Payment = paymentProto(payment)
+
+	return connect.NewResponse(&result), nil
+}
+
+func (p *paymentServiceImpl) acceptPayment(
+	ctx context.Context,
+	header http.Header,
+	pay *payment_iface.PaymentAcceptPartialRequest,
+) (*accounting_model.Payment, error) {
+	var err error
+
+	if pay.Amount < 0 {
+		return nil, errors.New("accept amount cannot negative")
+	}
+
+	identity := p.auth.AuthIdentityFromHeader(header)
 	agent := identity.Identity()
 
 	var domainID uint
@@ -49,22 +85,12 @@ func (p *paymentServiceImpl) PaymentAccept(
 		Err()
 
 	if err != nil {
-		return connect.NewResponse(&result), err
+		return nil, err
 	}
 
-	err = accounting_core.OpenTransaction(ctx, db, func(tx *gorm.DB, bookmng accounting_core.BookManage) error {
-		var payment accounting_model.Payment
-		err = tx.
-			Clauses(clause.Locking{
-				Strength: "UPDATE",
-			}).
-			Model(&accounting_model.Payment{}).
-			First(&payment, pay.PaymentId).
-			Error
-		if err != nil {
-			return err
-		}
-
+	var result *accounting_model.Payment
+	withJournal := func(payment *accounting_model.Payment) bool { return true }
+	err = p.mutatePayment(ctx, pay.PaymentId, withJournal, func(tx *gorm.DB, bookmng accounting_core.BookManage, payment *accounting_model.Payment) error {
 		if payment.ToTeamID != uint(pay.TeamId) {
 			return errors.New("payment not you own")
 		}
@@ -72,80 +98,69 @@ func (p *paymentServiceImpl) PaymentAccept(
 			return errors.New("payment not pending")
 		}
 
-		ref := accounting_core.NewRefID(&accounting_core.RefData{
-			RefType: accounting_core.PaymentRef,
-			ID:      uint(pay.PaymentId),
-		})
-
-		trans := &accounting_core.Transaction{
-			RefID:       ref,
-			TeamID:      payment.FromTeamID,
-			CreatedByID: agent.IdentityID(),
-			Desc:        fmt.Sprintf("payment %s", ref),
-			Created:     time.Now(),
+		remaining := payment.Remaining()
+		amount := pay.Amount
+		if amount == 0 {
+			amount = remaining
+		}
+		if amount > remaining+accounting_core.PrecisionEpsilon {
+			return fmt.Errorf("accept amount %.2f more than remaining %.2f", amount, remaining)
 		}
 
-		err = bookmng.
-			NewTransaction().
-			Create(trans).
-			Err()
-
+		bank, err := account.CashBank(tx, payment.ToTeamID, pay.ToBankAccountId)
 		if err != nil {
 			return err
 		}
 
-		desc := accounting_core.EntryDescOption(fmt.Sprintf("accept payment %s", ref))
-
-		// sisi pengirim
-		err = bookmng.
-			NewCreateEntry(payment.FromTeamID, agent.IdentityID()).
-			From(&accounting_core.EntryAccountPayload{
-				Key:    accounting_core.CashAccount,
-				TeamID: payment.ToTeamID,
-			}, payment.Amount, desc).
-			From(&accounting_core.EntryAccountPayload{
-				Key:    accounting_core.PayableAccount,
-				TeamID: payment.ToTeamID,
-			}, payment.Amount, desc).
-			Transaction(trans).
-			Commit().
-			Err()
-
+		var seq int64
+		err = tx.
+			Model(&accounting_model.PaymentEvent{}).
+			Where("payment_id = ?", payment.ID).
+			Where("type = ?", accounting_model.PaymentEventAccepted).
+			Count(&seq).
+			Error
 		if err != nil {
 			return err
 		}
 
-		// sisi penerima
-		err = bookmng.
-			NewCreateEntry(payment.ToTeamID, agent.IdentityID()).
-			From(&accounting_core.EntryAccountPayload{
-				Key:    accounting_core.ReceivableAccount,
-				TeamID: payment.FromTeamID,
-			}, payment.Amount, desc).
-			To(&accounting_core.EntryAccountPayload{
-				Key:    accounting_core.CashAccount,
-				TeamID: payment.ToTeamID,
-			}, payment.Amount, desc).
-			Transaction(trans).
-			Commit().
-			Err()
+		journal := paymentJournal{
+			bookmng:  bookmng,
+			agent:    agent,
+			payment:  payment,
+			toBankID: bank.ID,
+		}
+		txID, err := journal.accept(amount, int(seq)+1)
+		if err != nil {
+			return err
+		}
 
+		err = syncBanks(tx, bank.ID, payment.FromBankAccountID)
 		if err != nil {
 			return err
 		}
 
-		payment.Status = payment_iface.PaymentStatus_PAYMENT_STATUS_ACCEPTED
-		err = tx.Save(&payment).Error
+		payment.AcceptedAmount += amount
+		payment.AcceptedAt = time.Now()
+		if accounting_core.CompareFloatSafe(payment.AcceptedAmount, payment.Amount, accounting_core.PrecisionEpsilon) {
+			payment.AcceptedAmount = payment.Amount
+			payment.Status = payment_iface.PaymentStatus_PAYMENT_STATUS_ACCEPTED
+		}
+
+		err = tx.Save(payment).Error
 		if err != nil {
 			return err
 		}
 
-		return nil
+		result = payment
+		return addEvent(tx, &accounting_model.PaymentEvent{
+			PaymentID:   payment.ID,
+			TeamID:      uint(pay.TeamId),
+			Type:        accounting_model.PaymentEventAccepted,
+			Amount:      amount,
+			TxID:        txID,
+			CreatedByID: agent.IdentityID(),
+		})
 	})
 
-	if err != nil {
-		return connect.NewResponse(&result), err
-	}
-
-	return connect.NewResponse(&result), nil
+	return result, err
 }
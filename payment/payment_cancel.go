@@ -5,11 +5,11 @@ import (
 	"errors"
 
 	"connectrpc.com/connect"
+	"github.com/pdcgo/accounting_service/accounting_core"
 	"github.com/pdcgo/accounting_service/accounting_model"
 	"github.com/pdcgo/schema/services/payment_iface/v1"
 	"github.com/pdcgo/shared/interfaces/authorization_iface"
 	"gorm.io/gorm"
-	"gorm.io/gorm/clause"
 )
 
 // PaymentCancel implements payment_ifaceconnect.PaymentServiceHandler.
@@ -19,7 +19,6 @@ func (p *paymentServiceImpl) PaymentCancel(
 ) (*connect.Response[payment_iface.PaymentCancelResponse], error) {
 	var err error
 
-	db := p.db.WithContext(ctx)
 	result := payment_iface.PaymentCancelResponse{}
 	pay := req.Msg
 
@@ -40,21 +39,11 @@ func (p *paymentServiceImpl) PaymentCancel(
 		return connect.NewResponse(&result), err
 	}
 
-	// agent := identity.Identity()
-
-	err = db.Transaction(func(tx *gorm.DB) error {
-		var payment accounting_model.Payment
-		err = tx.
-			Clauses(clause.Locking{
-				Strength: "UPDATE",
-			}).
-			Model(&accounting_model.Payment{}).
-			First(&payment, pay.PaymentId).
-			Error
-		if err != nil {
-			return err
-		}
-
+	agent := identity.Identity()
+	withJournal := func(payment *accounting_model.Payment) bool {
+		return payment.TxID != 0
+	}
+	err = p.mutatePayment(ctx, pay.PaymentId, withJournal, func(tx *gorm.DB, bookmng accounting_core.BookManage, payment *accounting_model.Payment) error {
 		if payment.FromTeamID != uint(pay.TeamId) {
 			return errors.New("payment not you own")
 		}
@@ -62,27 +51,41 @@ func (p *paymentServiceImpl) PaymentCancel(
 			return errors.New("payment not pending")
 		}
 
-		// ref := accounting_core.NewRefID(&accounting_core.RefData{
-		// 	RefType: accounting_core.PaymentRef,
-		// 	ID:      uint(pay.PaymentId),
-		// })
-		// err = accounting_core.
-		// 	NewTransactionMutation(tx).
-		// 	ByRefID(ref, true).
-		// 	RollbackEntry(agent.IdentityID(), fmt.Sprintf("cancel %s", ref)).
-		// 	Err()
-
-		// if err != nil {
-		// 	return err
-		// }
+		// sisa yang belum diterima dikembalikan, penerimaan sebagian tetap
+		remaining := payment.Remaining()
+		var txID uint
+		if bookmng != nil {
+			journal := paymentJournal{
+				bookmng: bookmng,
+				agent:   agent,
+				payment: payment,
+			}
+			txID, err = journal.void(remaining, pay.Reason)
+			if err != nil {
+				return err
+			}
+
+			err = syncBanks(tx, payment.FromBankAccountID)
+			if err != nil {
+				return err
+			}
+		}
 
 		payment.Status = payment_iface.PaymentStatus_PAYMENT_STATUS_CANCELED
-		err = tx.Save(&payment).Error
+		err = tx.Save(payment).Error
 		if err != nil {
 			return err
 		}
 
-		return nil
+		return addEvent(tx, &accounting_model.PaymentEvent{
+			PaymentID:   payment.ID,
+			TeamID:      uint(pay.TeamId),
+			Type:        accounting_model.PaymentEventCancelled,
+			Amount:      remaining,
+			TxID:        txID,
+			Reason:      pay.Reason,
+			CreatedByID: agent.IdentityID(),
+		})
 	})
 
 	if err != nil {
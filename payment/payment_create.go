@@ -2,9 +2,12 @@ package payment
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"connectrpc.com/connect"
+	"github.com/pdcgo/accounting_service/account"
+	"github.com/pdcgo/accounting_service/accounting_core"
 	"github.com/pdcgo/accounting_service/accounting_model"
 	"github.com/pdcgo/schema/services/payment_iface/v1"
 	"github.com/pdcgo/shared/interfaces/authorization_iface"
@@ -38,79 +41,23 @@ func (p *paymentServiceImpl) PaymentCreate(
 		return connect.NewResponse(&result), err
 	}
 
-	err = db.Transaction(func(tx *gorm.DB) error {
+	if pay.Amount <= 0 {
+		return connect.NewResponse(&result), errors.New("payment amount must greater than zero")
+	}
+
+	err = accounting_core.OpenTransaction(ctx, db, func(tx *gorm.DB, bookmng accounting_core.BookManage) error {
 		payment := accounting_model.Payment{
-			FromTeamID:  uint(pay.FromTeamId),
-			ToTeamID:    uint(pay.ToTeamId),
-			Amount:      pay.Amount,
-			Status:      payment_iface.PaymentStatus_PAYMENT_STATUS_PENDING,
-			PaymentType: pay.PaymentType,
-			CreatedByID: agent.IdentityID(),
-			CreatedAt:   time.Now(),
+			FromTeamID:        uint(pay.FromTeamId),
+			ToTeamID:          uint(pay.ToTeamId),
+			Amount:            pay.Amount,
+			PaymentType:       pay.PaymentType,
+			FromBankAccountID: uint(pay.FromBankAccountId),
 		}
 
-		err = tx.Save(&payment).Error
+		err = CreatePendingPayment(tx, bookmng, agent, &payment, pay.Description)
 		if err != nil {
 			return err
 		}
-		// ref := accounting_core.NewRefID(&accounting_core.RefData{
-		// 	RefType: accounting_core.PaymentRef,
-		// 	ID:      payment.ID,
-		// })
-		// tran := accounting_core.Transaction{
-		// 	RefID:       ref,
-		// 	TeamID:      uint(pay.FromTeamId),
-		// 	CreatedByID: agent.IdentityID(),
-		// 	Desc:        pay.Description + string(ref),
-		// 	Created:     time.Now(),
-		// }
-
-		// err = bookmng.
-		// 	NewTransaction().
-		// 	Create(&tran).
-		// 	Err()
-
-		// if err != nil {
-		// 	return err
-		// }
-
-		// // sisi pengirim
-		// err = bookmng.
-		// 	NewCreateEntry(payment.FromTeamID, agent.IdentityID()).
-		// 	From(&accounting_core.EntryAccountPayload{
-		// 		Key:    accounting_core.CashAccount,
-		// 		TeamID: uint(pay.ToTeamId),
-		// 	}, pay.Amount).
-		// 	To(&accounting_core.EntryAccountPayload{
-		// 		Key:    accounting_core.PendingPaymentPayAccount,
-		// 		TeamID: uint(pay.ToTeamId),
-		// 	}, pay.Amount).
-		// 	Transaction(&tran).
-		// 	Commit().
-		// 	Err()
-
-		// if err != nil {
-		// 	return err
-		// }
-
-		// // sisi penerima
-		// err = bookmng.
-		// 	NewCreateEntry(payment.ToTeamID, agent.IdentityID()).
-		// 	From(&accounting_core.EntryAccountPayload{
-		// 		Key:    accounting_core.ReceivableAccount,
-		// 		TeamID: uint(pay.FromTeamId),
-		// 	}, pay.Amount).
-		// 	To(&accounting_core.EntryAccountPayload{
-		// 		Key:    accounting_core.PendingPaymentReceiveAccount,
-		// 		TeamID: uint(pay.FromTeamId),
-		// 	}, pay.Amount).
-		// 	Transaction(&tran).
-		// 	Commit().
-		// 	Err()
-
-		// if err != nil {
-		// 	return err
-		// }
 
 		result.PaymentId = uint64(payment.ID)
 		result.Status = payment_iface.PaymentStatus_PAYMENT_STATUS_PENDING
@@ -123,3 +70,59 @@ func (p *paymentServiceImpl) PaymentCreate(
 
 	return connect.NewResponse(&result), nil
 }
+
+// CreatePendingPayment simpan payment pending beserta jurnal pending dan event created,
+// kas keluar dari FromBankAccountID. Dipanggil di dalam accounting_core.OpenTransaction
+func CreatePendingPayment(
+	tx *gorm.DB,
+	bookmng accounting_core.BookManage,
+	agent authorization_iface.Identity,
+	payment *accounting_model.Payment,
+	desc string,
+) error {
+	var err error
+
+	bank, err := account.CashBank(tx, payment.FromTeamID, uint64(payment.FromBankAccountID))
+	if err != nil {
+		return err
+	}
+
+	payment.Status = payment_iface.PaymentStatus_PAYMENT_STATUS_PENDING
+	payment.CreatedByID = agent.IdentityID()
+	payment.CreatedAt = time.Now()
+
+	err = tx.Save(payment).Error
+	if err != nil {
+		return err
+	}
+
+	journal := paymentJournal{
+		bookmng: bookmng,
+		agent:   agent,
+		payment: payment,
+	}
+	payment.TxID, err = journal.pending()
+	if err != nil {
+		return err
+	}
+
+	err = tx.Save(payment).Error
+	if err != nil {
+		return err
+	}
+
+	err = syncBanks(tx, bank.ID)
+	if err != nil {
+		return err
+	}
+
+	return addEvent(tx, &accounting_model.PaymentEvent{
+		PaymentID:   payment.ID,
+		TeamID:      payment.FromTeamID,
+		Type:        accounting_model.PaymentEventCreated,
+		Amount:      payment.Amount,
+		TxID:        payment.TxID,
+		Reason:      desc,
+		CreatedByID: agent.IdentityID(),
+	})
+}
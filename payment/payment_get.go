@@ -0,0 +1,215 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"connectrpc.com/connect"
+	"github.com/pdcgo/accounting_service/accounting_core"
+	"github.com/pdcgo/accounting_service/accounting_model"
+	"github.com/pdcgo/schema/services/payment_iface/v1"
+	"github.com/pdcgo/shared/interfaces/authorization_iface"
+	"gorm.io/gorm"
+)
+
+func paymentProto(payment *accounting_model.Payment) *payment_iface.Payment {
+	item := payment_iface.Payment{
+		Id:                uint64(payment.ID),
+		FromTeamId:        uint64(payment.FromTeamID),
+		ToTeamId:          uint64(payment.ToTeamID),
+		Amount:            payment.Amount,
+		PaymentType:       payment.PaymentType,
+		Status:            payment.Status,
+		CreatedAt:         payment.CreatedAt.UnixMicro(),
+		AcceptedAmount:    payment.AcceptedAmount,
+		FromBankAccountId: uint64(payment.FromBankAccountID),
+	}
+	if !payment.AcceptedAt.IsZero() {
+		item.AcceptedAt = payment.AcceptedAt.UnixMicro()
+	}
+
+	return &item
+}
+
+func eventTypeProto(eventType accounting_model.PaymentEventType) payment_iface.PaymentEventType {
+	switch eventType {
+	case accounting_model.PaymentEventCreated:
+		return payment_iface.PaymentEventType_PAYMENT_EVENT_TYPE_CREATED
+	case accounting_model.PaymentEventAccepted:
+		return payment_iface.PaymentEventType_PAYMENT_EVENT_TYPE_ACCEPTED
+	case accounting_model.PaymentEventRejected:
+		return payment_iface.PaymentEventType_PAYMENT_EVENT_TYPE_REJECTED
+	case accounting_model.PaymentEventCancelled:
+		return payment_iface.PaymentEventType_PAYMENT_EVENT_TYPE_CANCELLED
+	default:
+		return payment_iface.PaymentEventType_PAYMENT_EVENT_TYPE_UNSPECIFIED
+	}
+}
+
+// PaymentGet implements payment_ifaceconnect.PaymentServiceHandler.
+func (p *paymentServiceImpl) PaymentGet(
+	ctx context.Context,
+	req *connect.Request[payment_iface.PaymentGetRequest],
+) (*connect.Response[payment_iface.PaymentGetResponse], error) {
+	result := payment_iface.PaymentGetResponse{}
+
+	pay := req.Msg
+	payment, err := p.getPayment(ctx, req.Header(), pay.TeamId, pay.PaymentId)
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	result.Data = paymentProto(payment)
+
+	return connect.NewResponse(&result), nil
+}
+
+// PaymentDetail payment dengan riwayat status dan transaksi ledger yang terhubung
+func (p *paymentServiceImpl) PaymentDetail(
+	ctx context.Context,
+	req *connect.Request[payment_iface.PaymentDetailRequest],
+) (*connect.Response[payment_iface.PaymentDetailResponse], error) {
+	var err error
+	result := payment_iface.PaymentDetailResponse{
+		Events:       []*payment_iface.PaymentEvent{},
+		Transactions: []*payment_iface.PaymentTransaction{},
+	}
+
+	pay := req.Msg
+	payment, err := p.getPayment(ctx, req.Header(), pay.TeamId, pay.PaymentId)
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+	result.Payment = paymentProto(payment)
+
+	db := p.db.WithContext(ctx)
+	events := []*accounting_model.PaymentEvent{}
+	err = db.
+		Model(&accounting_model.PaymentEvent{}).
+		Where("payment_id = ?", pay.PaymentId).
+		Order("created_at asc, id asc").
+		Find(&events).
+		Error
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	for _, event := range events {
+		result.Events = append(result.Events, &payment_iface.PaymentEvent{
+			Id:          uint64(event.ID),
+			PaymentId:   uint64(event.PaymentID),
+			TeamId:      uint64(event.TeamID),
+			Type:        eventTypeProto(event.Type),
+			Amount:      event.Amount,
+			TxId:        uint64(event.TxID),
+			Reason:      event.Reason,
+			CreatedById: uint64(event.CreatedByID),
+			CreatedAt:   event.CreatedAt.UnixMicro(),
+		})
+	}
+
+	// payment lama hanya punya transaksi payment#id saat accept
+	trans := []*accounting_core.Transaction{}
+	err = db.
+		Model(&accounting_core.Transaction{}).
+		Where("ref_id IN ? OR ref_id LIKE ?",
+			[]accounting_core.RefID{
+				accounting_core.NewRefID(&accounting_core.RefData{RefType: accounting_core.PaymentRef, ID: uint(pay.PaymentId)}),
+				accounting_core.NewRefID(&accounting_core.RefData{RefType: accounting_core.PaymentVoidRef, ID: uint(pay.PaymentId)}),
+			},
+			fmt.Sprintf("%s#%d-%%", accounting_core.PaymentAcceptRef, pay.PaymentId),
+		).
+		Order("created asc, id asc").
+		Find(&trans).
+		Error
+	if err != nil || len(trans) == 0 {
+		return connect.NewResponse(&result), err
+	}
+
+	txIDs := make([]uint, len(trans))
+	txMap := map[uint]*payment_iface.PaymentTransaction{}
+	for i, tran := range trans {
+		txIDs[i] = tran.ID
+		item := &payment_iface.PaymentTransaction{
+			Id:      uint64(tran.ID),
+			RefId:   string(tran.RefID),
+			TeamId:  uint64(tran.TeamID),
+			Desc:    tran.Desc,
+			Created: tran.Created.UnixMicro(),
+			Entries: []*payment_iface.PaymentJournalEntry{},
+		}
+		txMap[tran.ID] = item
+		result.Transactions = append(result.Transactions, item)
+	}
+
+	entries := []*accounting_core.JournalEntry{}
+	err = db.
+		Model(&accounting_core.JournalEntry{}).
+		Where("transaction_id IN ?", txIDs).
+		Order("id asc").
+		Find(&entries).
+		Error
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	for _, entry := range entries {
+		item := txMap[entry.TransactionID]
+		item.Entries = append(item.Entries, &payment_iface.PaymentJournalEntry{
+			Id:        uint64(entry.ID),
+			AccountId: uint64(entry.AccountID),
+			TeamId:    uint64(entry.TeamID),
+			EntryTime: entry.EntryTime.UnixMicro(),
+			Debit:     entry.Debit,
+			Credit:    entry.Credit,
+			Desc:      entry.Desc,
+			Rollback:  entry.Rollback,
+		})
+	}
+
+	return connect.NewResponse(&result), nil
+}
+
+// getPayment payment hanya bisa dilihat team pengirim atau penerima
+func (p *paymentServiceImpl) getPayment(
+	ctx context.Context,
+	header http.Header,
+	teamID uint64,
+	paymentID uint64,
+) (*accounting_model.Payment, error) {
+	identity := p.auth.AuthIdentityFromHeader(header)
+	err := identity.
+		HasPermission(authorization_iface.CheckPermissionGroup{
+			&accounting_model.Payment{}: &authorization_iface.CheckPermission{
+				DomainID: uint(teamID),
+				Actions:  []authorization_iface.Action{authorization_iface.Read},
+			},
+		}).
+		Err()
+
+	if err != nil {
+		return nil, err
+	}
+
+	var payment accounting_model.Payment
+	err = p.
+		db.
+		WithContext(ctx).
+		Model(&accounting_model.Payment{}).
+		First(&payment, paymentID).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, connect.NewError(connect.CodeNotFound, fmt.Errorf("payment id %d not found", paymentID))
+		}
+		return nil, err
+	}
+
+	if payment.FromTeamID != uint(teamID) && payment.ToTeamID != uint(teamID) {
+		return nil, connect.NewError(connect.CodePermissionDenied, errors.New("payment not you own"))
+	}
+
+	return &payment, nil
+}
@@ -5,13 +5,13 @@ import (
 	"errors"
 
 	"connectrpc.com/connect"
+	"github.com/pdcgo/accounting_service/accounting_core"
 	"github.com/pdcgo/accounting_service/accounting_model"
 	"github.com/pdcgo/schema/services/common/v1"
 	"github.com/pdcgo/schema/services/payment_iface/v1"
 	"github.com/pdcgo/shared/authorization"
 	"github.com/pdcgo/shared/interfaces/authorization_iface"
 	"gorm.io/gorm"
-	"gorm.io/gorm/clause"
 )
 
 // PaymentReject implements payment_ifaceconnect.PaymentServiceHandler.
@@ -21,7 +21,6 @@ func (p *paymentServiceImpl) PaymentReject(
 ) (*connect.Response[payment_iface.PaymentRejectResponse], error) {
 	var err error
 
-	db := p.db.WithContext(ctx)
 	result := payment_iface.PaymentRejectResponse{}
 
 	pay := req.Msg
@@ -51,50 +50,53 @@ func (p *paymentServiceImpl) PaymentReject(
 		return connect.NewResponse(&result), err
 	}
 
-	// agent := identity.Identity()
-
-	err = db.Transaction(func(tx *gorm.DB) error {
-		var payment accounting_model.Payment
-		err = tx.
-			Clauses(clause.Locking{
-				Strength: "UPDATE",
-			}).
-			Model(&accounting_model.Payment{}).
-			First(&payment, pay.PaymentId).
-			Error
-		if err != nil {
-			return err
-		}
-
+	agent := identity.Identity()
+	withJournal := func(payment *accounting_model.Payment) bool {
+		return payment.TxID != 0
+	}
+	err = p.mutatePayment(ctx, pay.PaymentId, withJournal, func(tx *gorm.DB, bookmng accounting_core.BookManage, payment *accounting_model.Payment) error {
 		if payment.ToTeamID != uint(pay.TeamId) {
 			return errors.New("payment not you own")
 		}
-
 		if payment.Status != payment_iface.PaymentStatus_PAYMENT_STATUS_PENDING {
 			return errors.New("payment not pending")
 		}
 
-		// ref := accounting_core.NewRefID(&accounting_core.RefData{
-		// 	RefType: accounting_core.PaymentRef,
-		// 	ID:      uint(pay.PaymentId),
-		// })
-		// err = accounting_core.
-		// 	NewTransactionMutation(tx).
-		// 	ByRefID(ref, true).
-		// 	RollbackEntry(agent.IdentityID(), fmt.Sprintf("reject %s", ref)).
-		// 	Err()
-
-		// if err != nil {
-		// 	return err
-		// }
+		// sisa yang belum diterima dikembalikan, penerimaan sebagian tetap
+		remaining := payment.Remaining()
+		var txID uint
+		if bookmng != nil {
+			journal := paymentJournal{
+				bookmng: bookmng,
+				agent:   agent,
+				payment: payment,
+			}
+			txID, err = journal.void(remaining, pay.Reason)
+			if err != nil {
+				return err
+			}
+
+			err = syncBanks(tx, payment.FromBankAccountID)
+			if err != nil {
+				return err
+			}
+		}
 
 		payment.Status = payment_iface.PaymentStatus_PAYMENT_STATUS_REJECTED
-		err = tx.Save(&payment).Error
+		err = tx.Save(payment).Error
 		if err != nil {
 			return err
 		}
 
-		return nil
+		return addEvent(tx, &accounting_model.PaymentEvent{
+			PaymentID:   payment.ID,
+			TeamID:      uint(pay.TeamId),
+			Type:        accounting_model.PaymentEventRejected,
+			Amount:      remaining,
+			TxID:        txID,
+			Reason:      pay.Reason,
+			CreatedByID: agent.IdentityID(),
+		})
 	})
 
 	if err != nil {
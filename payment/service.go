@@ -17,14 +17,6 @@ type paymentServiceImpl struct {
 	auth authorization_iface.Authorization
 }
 
-// PaymentGet implements payment_ifaceconnect.PaymentServiceHandler.
-func (p *paymentServiceImpl) PaymentGet(
-	ctx context.Context,
-	req *connect.Request[payment_iface.PaymentGetRequest],
-) (*connect.Response[payment_iface.PaymentGetResponse], error) {
-	panic("unimplemented")
-}
-
 // PaymentList implements payment_ifaceconnect.PaymentServiceHandler.
 func (p *paymentServiceImpl) PaymentList(
 	ctx context.Context,
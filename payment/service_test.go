@@ -0,0 +1,262 @@
+package payment_test
+
+import (
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/pdcgo/accounting_service/accounting_core"
+	"github.com/pdcgo/accounting_service/accounting_mock"
+	"github.com/pdcgo/accounting_service/accounting_model"
+	"github.com/pdcgo/accounting_service/payment"
+	"github.com/pdcgo/schema/services/payment_iface/v1"
+	"github.com/pdcgo/shared/authorization/authorization_mock"
+	"github.com/pdcgo/shared/pkg/moretest"
+	"github.com/pdcgo/shared/pkg/moretest/moretest_mock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestPartialPayment(t *testing.T) {
+	var db gorm.DB
+
+	moretest.Suite(t, "testing payment sebagian dan riwayat",
+		moretest.SetupListFunc{
+			moretest_mock.MockSqliteDatabase(&db),
+			func(t *testing.T) func() error {
+				err := db.AutoMigrate(
+					&accounting_core.Account{},
+					&accounting_core.Transaction{},
+					&accounting_core.JournalEntry{},
+					&accounting_core.JournalEntryBank{},
+					&accounting_model.Payment{},
+					&accounting_model.PaymentEvent{},
+					&accounting_model.BankAccountV2{},
+				)
+				assert.Nil(t, err)
+
+				err = db.Create([]*accounting_model.BankAccountV2{
+					{ID: 1, TeamID: 5, Name: "bca operasional", NumberID: "1001"},
+					{ID: 2, TeamID: 6, Name: "bca gudang", NumberID: "1002"},
+				}).Error
+				assert.Nil(t, err)
+				return nil
+			},
+			accounting_mock.PopulateAccountKey(&db, 5),
+			accounting_mock.PopulateAccountKey(&db, 6),
+		},
+		func(t *testing.T) {
+			service := payment.NewPaymentService(&db, &authorization_mock.EmptyAuthorizationMock{
+				AuthIdentityMock: &authorization_mock.AuthIdentityMock{
+					IdentityMock: &authorization_mock.IdentityMock{
+						ID: 1,
+					},
+				},
+			})
+
+			// saldo akun di buku team, akun milik accTeamID
+			balance := func(t *testing.T, bookTeamID uint, key accounting_core.AccountKey, accTeamID uint) float64 {
+				var value float64
+				err := db.
+					Table("journal_entries je").
+					Joins("join accounts a on a.id = je.account_id").
+					Select("coalesce(sum(je.debit - je.credit), 0)").
+					Where("je.team_id = ?", bookTeamID).
+					Where("a.account_key = ?", key).
+					Where("a.team_id = ?", accTeamID).
+					Scan(&value).
+					Error
+				assert.Nil(t, err)
+				return value
+			}
+
+			bankBalance := func(t *testing.T, id uint) float64 {
+				var bank accounting_model.BankAccountV2
+				err := db.First(&bank, id).Error
+				assert.Nil(t, err)
+				return bank.Balance
+			}
+
+			create := func(t *testing.T, amount float64) uint64 {
+				res, err := service.PaymentCreate(t.Context(), connect.NewRequest(&payment_iface.PaymentCreateRequest{
+					FromTeamId:        5,
+					ToTeamId:          6,
+					Amount:            amount,
+					PaymentType:       payment_iface.PaymentType_PAYMENT_TYPE_OTHER,
+					Description:       "bayar stock",
+					FromBankAccountId: 1,
+				}))
+				assert.Nil(t, err)
+				return res.Msg.PaymentId
+			}
+
+			acceptPartial := func(t *testing.T, paymentID uint64, amount float64) (*payment_iface.Payment, error) {
+				res, err := service.PaymentAcceptPartial(t.Context(), connect.NewRequest(&payment_iface.PaymentAcceptPartialRequest{
+					TeamId:          6,
+					PaymentId:       paymentID,
+					Amount:          amount,
+					ToBankAccountId: 2,
+				}))
+				return res.Msg.Payment, err
+			}
+
+			var paymentID uint64
+			t.Run("create payment masuk akun pending", func(t *testing.T) {
+				paymentID = create(t, 100000)
+
+				assert.Equal(t, 100000.0, balance(t, 5, accounting_core.PendingPaymentPayAccount, 6))
+				assert.Equal(t, 100000.0, balance(t, 6, accounting_core.PendingPaymentReceiveAccount, 5))
+				assert.Equal(t, -100000.0, balance(t, 6, accounting_core.ReceivableAccount, 5))
+				assert.Equal(t, -100000.0, bankBalance(t, 1))
+			})
+
+			t.Run("create dan accept tanpa bank ditolak", func(t *testing.T) {
+				_, err := service.PaymentCreate(t.Context(), connect.NewRequest(&payment_iface.PaymentCreateRequest{
+					FromTeamId:  5,
+					ToTeamId:    6,
+					Amount:      1000,
+					PaymentType: payment_iface.PaymentType_PAYMENT_TYPE_OTHER,
+					Description: "tanpa bank",
+				}))
+				assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+
+				_, err = service.PaymentAccept(t.Context(), connect.NewRequest(&payment_iface.PaymentAcceptRequest{
+					TeamId:          6,
+					PaymentId:       paymentID,
+					ToBankAccountId: 1,
+				}))
+				assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+			})
+
+			t.Run("terima sebagian", func(t *testing.T) {
+				data, err := acceptPartial(t, paymentID, 30000)
+				assert.Nil(t, err)
+				assert.Equal(t, payment_iface.PaymentStatus_PAYMENT_STATUS_PENDING, data.Status)
+				assert.Equal(t, 30000.0, data.AcceptedAmount)
+
+				assert.Equal(t, 70000.0, balance(t, 5, accounting_core.PendingPaymentPayAccount, 6))
+				assert.Equal(t, 70000.0, balance(t, 6, accounting_core.PendingPaymentReceiveAccount, 5))
+				assert.Equal(t, 30000.0, balance(t, 6, accounting_core.CashAccount, 6))
+
+				_, err = acceptPartial(t, paymentID, 80000)
+				assert.NotNil(t, err)
+			})
+
+			t.Run("accept proto menerima sisa", func(t *testing.T) {
+				_, err := service.PaymentAccept(t.Context(), connect.NewRequest(&payment_iface.PaymentAcceptRequest{
+					TeamId:          6,
+					PaymentId:       paymentID,
+					ToBankAccountId: 2,
+				}))
+				assert.Nil(t, err)
+
+				res, err := service.PaymentGet(t.Context(), connect.NewRequest(&payment_iface.PaymentGetRequest{
+					TeamId:    5,
+					PaymentId: paymentID,
+				}))
+				assert.Nil(t, err)
+				assert.Equal(t, payment_iface.PaymentStatus_PAYMENT_STATUS_ACCEPTED, res.Msg.Data.Status)
+				assert.NotZero(t, res.Msg.Data.AcceptedAt)
+
+				assert.Equal(t, 0.0, balance(t, 5, accounting_core.PendingPaymentPayAccount, 6))
+				assert.Equal(t, 0.0, balance(t, 6, accounting_core.PendingPaymentReceiveAccount, 5))
+				assert.Equal(t, 100000.0, balance(t, 6, accounting_core.CashAccount, 6))
+				assert.Equal(t, 100000.0, balance(t, 5, accounting_core.PayableAccount, 6))
+				assert.Equal(t, 100000.0, bankBalance(t, 2))
+
+				_, err = acceptPartial(t, paymentID, 1000)
+				assert.NotNil(t, err)
+			})
+
+			t.Run("detail dengan riwayat dan transaksi", func(t *testing.T) {
+				res, err := service.PaymentDetail(t.Context(), connect.NewRequest(&payment_iface.PaymentDetailRequest{
+					TeamId:    6,
+					PaymentId: paymentID,
+				}))
+				assert.Nil(t, err)
+
+				events := res.Msg.Events
+				assert.Len(t, events, 3)
+				assert.Equal(t, payment_iface.PaymentEventType_PAYMENT_EVENT_TYPE_CREATED, events[0].Type)
+				assert.Equal(t, payment_iface.PaymentEventType_PAYMENT_EVENT_TYPE_ACCEPTED, events[1].Type)
+				assert.Equal(t, 30000.0, events[1].Amount)
+				assert.Equal(t, 70000.0, events[2].Amount)
+
+				assert.Len(t, res.Msg.Transactions, 3)
+				for _, tran := range res.Msg.Transactions {
+					assert.NotEmpty(t, tran.Entries)
+				}
+
+				_, err = service.PaymentDetail(t.Context(), connect.NewRequest(&payment_iface.PaymentDetailRequest{
+					TeamId:    7,
+					PaymentId: paymentID,
+				}))
+				assert.Equal(t, connect.CodePermissionDenied, connect.CodeOf(err))
+			})
+
+			t.Run("tolak setelah diterima sebagian", func(t *testing.T) {
+				rejectID := create(t, 50000)
+				_, err := acceptPartial(t, rejectID, 20000)
+				assert.Nil(t, err)
+
+				_, err = service.PaymentReject(t.Context(), connect.NewRequest(&payment_iface.PaymentRejectRequest{
+					TeamId:    6,
+					PaymentId: rejectID,
+					Reason:    "nominal salah",
+				}))
+				assert.Nil(t, err)
+
+				assert.Equal(t, 0.0, balance(t, 5, accounting_core.PendingPaymentPayAccount, 6))
+				assert.Equal(t, 0.0, balance(t, 6, accounting_core.PendingPaymentReceiveAccount, 5))
+				assert.Equal(t, -120000.0, balance(t, 6, accounting_core.ReceivableAccount, 5))
+				assert.Equal(t, -120000.0, bankBalance(t, 1))
+				assert.Equal(t, 120000.0, bankBalance(t, 2))
+
+				var event accounting_model.PaymentEvent
+				err = db.Where("payment_id = ?", rejectID).Order("id desc").First(&event).Error
+				assert.Nil(t, err)
+				assert.Equal(t, accounting_model.PaymentEventRejected, event.Type)
+				assert.Equal(t, 30000.0, event.Amount)
+				assert.Equal(t, "nominal salah", event.Reason)
+			})
+
+			t.Run("payment lama tanpa jurnal pending", func(t *testing.T) {
+				old := accounting_model.Payment{
+					FromTeamID: 5,
+					ToTeamID:   6,
+					Amount:     10000,
+					Status:     payment_iface.PaymentStatus_PAYMENT_STATUS_PENDING,
+				}
+				err := db.Create(&old).Error
+				assert.Nil(t, err)
+
+				data, err := acceptPartial(t, uint64(old.ID), 0)
+				assert.Nil(t, err)
+				assert.Equal(t, payment_iface.PaymentStatus_PAYMENT_STATUS_ACCEPTED, data.Status)
+				assert.Equal(t, 0.0, balance(t, 6, accounting_core.PendingPaymentReceiveAccount, 5))
+				assert.Equal(t, 130000.0, balance(t, 6, accounting_core.CashAccount, 6))
+				assert.Equal(t, 130000.0, bankBalance(t, 2))
+				assert.Equal(t, -120000.0, bankBalance(t, 1))
+
+				legacy := accounting_model.Payment{
+					FromTeamID: 5,
+					ToTeamID:   6,
+					Amount:     5000,
+					Status:     payment_iface.PaymentStatus_PAYMENT_STATUS_PENDING,
+				}
+				err = db.Create(&legacy).Error
+				assert.Nil(t, err)
+
+				_, err = service.PaymentCancel(t.Context(), connect.NewRequest(&payment_iface.PaymentCancelRequest{
+					TeamId:    5,
+					PaymentId: uint64(legacy.ID),
+					Reason:    "batal",
+				}))
+				assert.Nil(t, err)
+
+				err = db.First(&legacy, legacy.ID).Error
+				assert.Nil(t, err)
+				assert.Equal(t, payment_iface.PaymentStatus_PAYMENT_STATUS_CANCELED, legacy.Status)
+			})
+		},
+	)
+}
@@ -0,0 +1,288 @@
+package payout
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pdcgo/accounting_service/accounting_core"
+	"github.com/pdcgo/accounting_service/query_dialect"
+	"github.com/pdcgo/accounting_service/receivable"
+	"github.com/pdcgo/schema/services/accounting_iface/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/gorm"
+)
+
+type payoutItem struct {
+	Type accounting_iface.PayoutItemType
+	// ref transaksi, untuk fund berisi external order id
+	Ref           string
+	TransactionID uint
+	At            time.Time
+	// positif menambah saldo marketplace, withdrawal positif berarti dana ditarik
+	Amount float64
+}
+
+func (i *payoutItem) proto() *accounting_iface.PayoutItem {
+	return &accounting_iface.PayoutItem{
+		Type:          i.Type,
+		Ref:           i.Ref,
+		TransactionId: uint64(i.TransactionID),
+		At:            timestamppb.New(i.At),
+		Amount:        i.Amount,
+	}
+}
+
+type payoutPeriod struct {
+	// withdrawal sebelumnya, nil kalau tidak ada
+	Start      *time.Time
+	End        time.Time
+	Withdrawal *payoutItem
+	Items      []*payoutItem
+	// fund + adjustment periode
+	Expected   float64
+	Withdrawn  float64
+	Difference float64
+	Reconciled bool
+	// nil kalau periode sudah cocok
+	Suggestion *accounting_iface.PayoutSuggestedEntry
+}
+
+func (p *payoutPeriod) proto() *accounting_iface.PayoutPeriod {
+	period := accounting_iface.PayoutPeriod{
+		End:        timestamppb.New(p.End),
+		Withdrawal: p.Withdrawal.proto(),
+		Items:      make([]*accounting_iface.PayoutItem, 0, len(p.Items)),
+		Expected:   p.Expected,
+		Withdrawn:  p.Withdrawn,
+		Difference: p.Difference,
+		Reconciled: p.Reconciled,
+		Suggestion: p.Suggestion,
+	}
+	if p.Start != nil {
+		period.Start = timestamppb.New(*p.Start)
+	}
+	for _, item := range p.Items {
+		period.Items = append(period.Items, item.proto())
+	}
+	return &period
+}
+
+// reconciler mencocokkan fund, adjustment dan withdrawal satu shop
+type reconciler struct {
+	db        *gorm.DB
+	teamID    uint
+	shopID    uint
+	tolerance float64
+}
+
+func (r *reconciler) withdrawalPrefix() string {
+	return fmt.Sprintf("%s#%d#", accounting_core.WithdrawalRef, r.shopID)
+}
+
+// refTime waktu marketplace dari ref shop date (type#shop#unix)
+func refTime(ref accounting_core.RefID) (time.Time, bool) {
+	parts := strings.Split(string(ref), "#")
+	if len(parts) != 3 {
+		return time.Time{}, false
+	}
+
+	unix, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(unix, 0), true
+}
+
+// receivableItems perubahan selling receivable per transaksi shop dengan prefix ref tertentu
+func (r *reconciler) receivableItems(itype accounting_iface.PayoutItemType, refPrefix string, sign float64) ([]*payoutItem, error) {
+	rows := []*struct {
+		TransactionID uint
+		RefID         accounting_core.RefID
+		EntryTime     int64
+		Amount        float64
+	}{}
+
+	err := r.
+		db.
+		Table("journal_entries je").
+		Joins("join accounts a on a.id = je.account_id").
+		Joins("join transactions t on t.id = je.transaction_id").
+		Joins("join transaction_shops ts on ts.transaction_id = je.transaction_id").
+		Select(strings.Join([]string{
+			"je.transaction_id",
+			"max(t.ref_id) as ref_id",
+			query_dialect.NewDialect(r.db).EpochMicro("min(je.entry_time)") + " as entry_time",
+			"sum(je.debit - je.credit) as amount",
+		}, ", ")).
+		Where("je.team_id = ?", r.teamID).
+		Where("ts.shop_id = ?", r.shopID).
+		Where("a.account_key = ?", accounting_core.SellingReceivableAccount).
+		Where("t.ref_id like ?", refPrefix+"%").
+		Group("je.transaction_id").
+		Find(&rows).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*payoutItem, 0, len(rows))
+	for _, row := range rows {
+		item := payoutItem{
+			Type:          itype,
+			Ref:           string(row.RefID),
+			TransactionID: row.TransactionID,
+			At:            time.UnixMicro(row.EntryTime),
+			Amount:        row.Amount * sign,
+		}
+		if at, ok := refTime(row.RefID); ok {
+			item.At = at
+		}
+		result = append(result, &item)
+	}
+
+	return result, nil
+}
+
+// fundItems order yang dicairkan dari sub ledger piutang
+func (r *reconciler) fundItems() ([]*payoutItem, error) {
+	rows := []*receivable.OrderReceivable{}
+	err := r.
+		db.
+		Model(&receivable.OrderReceivable{}).
+		Where("team_id = ?", r.teamID).
+		Where("shop_id = ?", r.shopID).
+		Where("status in ?", []receivable.ReceivableStatus{receivable.ReceivableFunded, receivable.ReceivableReturned}).
+		Find(&rows).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*payoutItem, 0, len(rows))
+	for _, row := range rows {
+		if row.ClosedAt == nil {
+			continue
+		}
+		result = append(result, &payoutItem{
+			Type:   accounting_iface.PayoutItemType_PAYOUT_ITEM_TYPE_FUND,
+			Ref:    row.ExternalOrderID,
+			At:     *row.ClosedAt,
+			Amount: row.FundAmount,
+		})
+	}
+	return result, nil
+}
+
+func (r *reconciler) suggestion(period *payoutPeriod) *accounting_iface.PayoutSuggestedEntry {
+	desc := fmt.Sprintf("selisih payout shop %d withdrawal %s", r.shopID, period.Withdrawal.Ref)
+	if period.Difference > 0 {
+		return &accounting_iface.PayoutSuggestedEntry{
+			Desc:   desc,
+			Debit:  string(accounting_core.SellingReceivableAccount),
+			Credit: string(accounting_core.OtherRevenueAccount),
+			Amount: period.Difference,
+		}
+	}
+
+	return &accounting_iface.PayoutSuggestedEntry{
+		Desc:   desc,
+		Debit:  string(accounting_core.SellingOtherExpenseAccount),
+		Credit: string(accounting_core.SellingReceivableAccount),
+		Amount: -period.Difference,
+	}
+}
+
+// reconcile membagi item ke periode payout. Periode dimulai setelah withdrawal sebelumnya
+// dan ditutup withdrawal di dalam range, item setelah withdrawal terakhir belum cocok.
+func (r *reconciler) reconcile(start, end time.Time) (periods []*payoutPeriod, pending []*payoutItem, err error) {
+	periods = []*payoutPeriod{}
+	pending = []*payoutItem{}
+
+	withdrawals, err := r.receivableItems(accounting_iface.PayoutItemType_PAYOUT_ITEM_TYPE_WITHDRAWAL, r.withdrawalPrefix(), -1)
+	if err != nil {
+		return periods, pending, err
+	}
+
+	adjustments, err := r.receivableItems(accounting_iface.PayoutItemType_PAYOUT_ITEM_TYPE_ADJUSTMENT, string(accounting_core.AdjustmentRef)+"#", 1)
+	if err != nil {
+		return periods, pending, err
+	}
+
+	funds, err := r.fundItems()
+	if err != nil {
+		return periods, pending, err
+	}
+
+	byTime := func(a, b *payoutItem) int {
+		return a.At.Compare(b.At)
+	}
+	slices.SortFunc(withdrawals, byTime)
+
+	var prev *time.Time
+	for _, wd := range withdrawals {
+		if wd.At.Before(start) {
+			at := wd.At
+			prev = &at
+			continue
+		}
+		if wd.At.After(end) {
+			break
+		}
+
+		periods = append(periods, &payoutPeriod{
+			Start:      prev,
+			End:        wd.At,
+			Withdrawal: wd,
+			Items:      []*payoutItem{},
+			Withdrawn:  wd.Amount,
+		})
+		at := wd.At
+		prev = &at
+	}
+
+	var firstStart *time.Time
+	if len(periods) != 0 {
+		firstStart = periods[0].Start
+	}
+
+	items := append(funds, adjustments...)
+	slices.SortStableFunc(items, byTime)
+	for _, item := range items {
+		if firstStart != nil && !item.At.After(*firstStart) {
+			continue
+		}
+		if item.At.After(end) {
+			continue
+		}
+
+		idx := slices.IndexFunc(periods, func(p *payoutPeriod) bool {
+			return !item.At.After(p.End)
+		})
+		if idx == -1 {
+			// belum ditarik, hanya item setelah start yang ditampilkan
+			if !item.At.Before(start) || len(periods) != 0 {
+				pending = append(pending, item)
+			}
+			continue
+		}
+
+		period := periods[idx]
+		period.Items = append(period.Items, item)
+		period.Expected += item.Amount
+	}
+
+	for _, period := range periods {
+		period.Difference = period.Withdrawn - period.Expected
+		period.Reconciled = accounting_core.CompareFloatSafe(period.Difference, 0, r.tolerance)
+		if !period.Reconciled {
+			period.Suggestion = r.suggestion(period)
+		}
+	}
+
+	return periods, pending, nil
+}
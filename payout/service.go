@@ -0,0 +1,92 @@
+package payout
+
+import (
+	"context"
+	"errors"
+
+	"connectrpc.com/connect"
+	"github.com/pdcgo/accounting_service/accounting_core"
+	"github.com/pdcgo/schema/services/accounting_iface/v1"
+	"github.com/pdcgo/shared/interfaces/authorization_iface"
+	"gorm.io/gorm"
+)
+
+type payoutServiceImpl struct {
+	db   *gorm.DB
+	auth authorization_iface.Authorization
+}
+
+// PayoutReconcile rekonsiliasi withdrawal marketplace dengan fund dan adjustment sejak withdrawal sebelumnya
+func (p *payoutServiceImpl) PayoutReconcile(
+	ctx context.Context,
+	req *connect.Request[accounting_iface.PayoutReconcileRequest],
+) (*connect.Response[accounting_iface.PayoutReconcileResponse], error) {
+	var err error
+	pay := req.Msg
+
+	result := accounting_iface.PayoutReconcileResponse{
+		Periods:   []*accounting_iface.PayoutPeriod{},
+		Unmatched: []*accounting_iface.PayoutItem{},
+	}
+
+	err = p.
+		auth.
+		AuthIdentityFromHeader(req.Header()).
+		Err()
+
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	if pay.TeamId == 0 || pay.ShopId == 0 {
+		return connect.NewResponse(&result), connect.NewError(connect.CodeInvalidArgument, errors.New("team_id and shop_id required"))
+	}
+
+	timeRange := pay.TimeRange
+	if timeRange == nil ||
+		timeRange.StartDate == nil ||
+		timeRange.EndDate == nil ||
+		timeRange.EndDate.AsTime().Before(timeRange.StartDate.AsTime()) {
+		return connect.NewResponse(&result), connect.NewError(connect.CodeInvalidArgument, errors.New("invalid start end"))
+	}
+
+	tolerance := pay.Tolerance
+	if tolerance <= 0 {
+		tolerance = accounting_core.PrecisionEpsilon
+	}
+
+	rec := reconciler{
+		db:        p.db.WithContext(ctx),
+		teamID:    uint(pay.TeamId),
+		shopID:    uint(pay.ShopId),
+		tolerance: tolerance,
+	}
+
+	periods, unmatched, err := rec.reconcile(timeRange.StartDate.AsTime(), timeRange.EndDate.AsTime())
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	for _, period := range periods {
+		result.Periods = append(result.Periods, period.proto())
+		if period.Reconciled {
+			continue
+		}
+		result.UnreconciledCount++
+		result.Difference += period.Difference
+	}
+
+	for _, item := range unmatched {
+		result.Unmatched = append(result.Unmatched, item.proto())
+		result.UnmatchedTotal += item.Amount
+	}
+
+	return connect.NewResponse(&result), nil
+}
+
+func NewPayoutService(db *gorm.DB, auth authorization_iface.Authorization) *payoutServiceImpl {
+	return &payoutServiceImpl{
+		db:   db,
+		auth: auth,
+	}
+}
@@ -0,0 +1,187 @@
+package payout_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/pdcgo/accounting_service/accounting_core"
+	"github.com/pdcgo/accounting_service/accounting_mock"
+	"github.com/pdcgo/accounting_service/payout"
+	"github.com/pdcgo/accounting_service/receivable"
+	"github.com/pdcgo/schema/services/accounting_iface/v1"
+	"github.com/pdcgo/schema/services/common/v1"
+	"github.com/pdcgo/shared/authorization/authorization_mock"
+	"github.com/pdcgo/shared/pkg/moretest"
+	"github.com/pdcgo/shared/pkg/moretest/moretest_mock"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/gorm"
+)
+
+func wdRef(shopID uint, at time.Time) accounting_core.RefID {
+	return accounting_core.RefID(fmt.Sprintf("wd#%d#%d", shopID, at.Unix()))
+}
+
+type shopEntry struct {
+	refID  accounting_core.RefID
+	shopID uint
+	at     time.Time
+	// positif menambah selling receivable
+	amount float64
+}
+
+func TestPayoutReconcile(t *testing.T) {
+	var db gorm.DB
+
+	moretest.Suite(t, "testing rekonsiliasi payout",
+		moretest.SetupListFunc{
+			moretest_mock.MockSqliteDatabase(&db),
+			func(t *testing.T) func() error {
+				err := db.AutoMigrate(
+					&accounting_core.Account{},
+					&accounting_core.Transaction{},
+					&accounting_core.TransactionShop{},
+					&accounting_core.JournalEntry{},
+					&receivable.OrderReceivable{},
+				)
+				assert.Nil(t, err)
+				return nil
+			},
+			accounting_mock.PopulateAccountKey(&db, 1),
+			func(t *testing.T) func() error {
+				var receivableAcc accounting_core.Account
+				err := db.
+					Where("team_id = 1 and account_key = ?", accounting_core.SellingReceivableAccount).
+					Find(&receivableAcc).
+					Error
+				assert.Nil(t, err)
+
+				entries := []*shopEntry{
+					{refID: wdRef(3, accounting_mock.Date(9, 30)), shopID: 3, at: accounting_mock.Date(9, 30), amount: -100},
+					{refID: "common_adjustment#X1", shopID: 3, at: accounting_mock.Date(10, 9), amount: 50},
+					{refID: wdRef(3, accounting_mock.Date(10, 10)), shopID: 3, at: accounting_mock.Date(10, 10), amount: -350},
+					{refID: accounting_core.RefID(fmt.Sprintf("common_adjustment#3#%d", accounting_mock.Date(10, 17).Unix())), shopID: 3, at: accounting_mock.Date(10, 17), amount: -30},
+					{refID: wdRef(3, accounting_mock.Date(10, 20)), shopID: 3, at: accounting_mock.Date(10, 20), amount: -260},
+					// shop lain
+					{refID: wdRef(4, accounting_mock.Date(10, 12)), shopID: 4, at: accounting_mock.Date(10, 12), amount: -999},
+					{refID: wdRef(30, accounting_mock.Date(10, 13)), shopID: 30, at: accounting_mock.Date(10, 13), amount: -999},
+				}
+
+				for _, entry := range entries {
+					tran := accounting_core.Transaction{RefID: entry.refID, TeamID: 1, Created: entry.at}
+					err = db.Create(&tran).Error
+					assert.Nil(t, err)
+
+					err = db.Create(&accounting_core.TransactionShop{
+						TransactionID: tran.ID,
+						ShopID:        entry.shopID,
+					}).Error
+					assert.Nil(t, err)
+
+					journal := accounting_core.JournalEntry{
+						AccountID:     receivableAcc.ID,
+						TeamID:        1,
+						TransactionID: tran.ID,
+						EntryTime:     entry.at,
+					}
+					if entry.amount > 0 {
+						journal.Debit = entry.amount
+					} else {
+						journal.Credit = -entry.amount
+					}
+
+					err = db.Create(&journal).Error
+					assert.Nil(t, err)
+				}
+
+				ledger := receivable.NewOrderLedger(&db)
+				funds := []*receivable.FundPayload{
+					// sebelum withdrawal terakhir sebelum start
+					{TeamID: 1, ShopID: 3, ExternalOrderID: "F", EstAmount: 100, Amount: 100, At: accounting_mock.Date(9, 29)},
+					{TeamID: 1, ShopID: 3, ExternalOrderID: "A", EstAmount: 100, Amount: 100, At: accounting_mock.Date(10, 2)},
+					{TeamID: 1, ShopID: 3, ExternalOrderID: "B", EstAmount: 200, Amount: 200, At: accounting_mock.Date(10, 8)},
+					{TeamID: 1, ShopID: 3, ExternalOrderID: "C", EstAmount: 300, Amount: 300, At: accounting_mock.Date(10, 15)},
+					{TeamID: 1, ShopID: 3, ExternalOrderID: "D", EstAmount: 20, Amount: -20, At: accounting_mock.Date(10, 16)},
+					{TeamID: 1, ShopID: 3, ExternalOrderID: "E", EstAmount: 70, Amount: 70, At: accounting_mock.Date(10, 25)},
+					{TeamID: 1, ShopID: 3, ExternalOrderID: "G", EstAmount: 70, Amount: 70, At: accounting_mock.Date(11, 5)},
+					{TeamID: 1, ShopID: 4, ExternalOrderID: "H", EstAmount: 999, Amount: 999, At: accounting_mock.Date(10, 11)},
+				}
+				for _, fund := range funds {
+					err = ledger.Fund(fund)
+					assert.Nil(t, err)
+				}
+				return nil
+			},
+		},
+		func(t *testing.T) {
+			service := payout.NewPayoutService(&db, &authorization_mock.EmptyAuthorizationMock{})
+
+			t.Run("rekonsiliasi per withdrawal", func(t *testing.T) {
+				res, err := service.PayoutReconcile(t.Context(), connect.NewRequest(&accounting_iface.PayoutReconcileRequest{
+					TeamId: 1,
+					ShopId: 3,
+					TimeRange: &common.TimeFilterRange{
+						StartDate: timestamppb.New(accounting_mock.Date(10, 5)),
+						EndDate:   timestamppb.New(accounting_mock.Date(10, 31)),
+					},
+				}))
+				assert.Nil(t, err)
+				assert.Len(t, res.Msg.Periods, 2)
+
+				period := res.Msg.Periods[0]
+				assert.True(t, period.Start.AsTime().Equal(accounting_mock.Date(9, 30)))
+				assert.True(t, period.End.AsTime().Equal(accounting_mock.Date(10, 10)))
+				assert.Len(t, period.Items, 3)
+				assert.Equal(t, 350.0, period.Expected)
+				assert.Equal(t, 350.0, period.Withdrawn)
+				assert.True(t, period.Reconciled)
+				assert.Nil(t, period.Suggestion)
+
+				period = res.Msg.Periods[1]
+				assert.Len(t, period.Items, 3)
+				assert.Equal(t, 250.0, period.Expected)
+				assert.Equal(t, 10.0, period.Difference)
+				assert.False(t, period.Reconciled)
+				assert.Equal(t, string(accounting_core.SellingReceivableAccount), period.Suggestion.Debit)
+				assert.Equal(t, string(accounting_core.OtherRevenueAccount), period.Suggestion.Credit)
+				assert.Equal(t, 10.0, period.Suggestion.Amount)
+
+				assert.Len(t, res.Msg.Unmatched, 1)
+				assert.Equal(t, "E", res.Msg.Unmatched[0].Ref)
+				assert.Equal(t, accounting_iface.PayoutItemType_PAYOUT_ITEM_TYPE_FUND, res.Msg.Unmatched[0].Type)
+				assert.Equal(t, 70.0, res.Msg.UnmatchedTotal)
+				assert.Equal(t, int64(1), res.Msg.UnreconciledCount)
+				assert.Equal(t, 10.0, res.Msg.Difference)
+			})
+
+			t.Run("selisih dalam toleransi", func(t *testing.T) {
+				res, err := service.PayoutReconcile(t.Context(), connect.NewRequest(&accounting_iface.PayoutReconcileRequest{
+					TeamId: 1,
+					ShopId: 3,
+					TimeRange: &common.TimeFilterRange{
+						StartDate: timestamppb.New(accounting_mock.Date(10, 15)),
+						EndDate:   timestamppb.New(accounting_mock.Date(10, 31)),
+					},
+					Tolerance: 15,
+				}))
+				assert.Nil(t, err)
+				assert.Len(t, res.Msg.Periods, 1)
+				assert.True(t, res.Msg.Periods[0].Reconciled)
+				assert.Equal(t, int64(0), res.Msg.UnreconciledCount)
+			})
+
+			t.Run("shop wajib diisi", func(t *testing.T) {
+				_, err := service.PayoutReconcile(t.Context(), connect.NewRequest(&accounting_iface.PayoutReconcileRequest{
+					TeamId: 1,
+					TimeRange: &common.TimeFilterRange{
+						StartDate: timestamppb.New(accounting_mock.Date(10, 1)),
+						EndDate:   timestamppb.New(accounting_mock.Date(10, 31)),
+					},
+				}))
+				assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+			})
+		},
+	)
+}
@@ -0,0 +1,59 @@
+package query_dialect
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// timezone yang dipakai untuk menentukan hari dan bulan laporan
+const ReportTimezone = "Asia/Jakarta"
+
+// Dialect menyimpan potongan sql yang berbeda antara postgres dan sqlite,
+// supaya query report, ledger dan resync bisa jalan di keduanya.
+type Dialect interface {
+	Name() string
+	// EpochMicro mengubah kolom timestamp jadi unix microsecond (bigint)
+	EpochMicro(col string) string
+	// TruncMonth memotong kolom timestamp ke awal bulan (timezone report)
+	TruncMonth(col string) string
+	// LocalDay mengubah kolom timestamp ke hari laporan, formatnya sama dengan accounting_core.ParseDate
+	LocalDay(col string) string
+	// ILike kondisi where case insensitive, memakai satu placeholder
+	ILike(col string) string
+	// LockTable statement untuk lock table selama resync, kosong kalau tidak didukung
+	LockTable(table string) string
+}
+
+func NewDialect(db *gorm.DB) Dialect {
+	if db.Dialector == nil {
+		return &postgresDialect{}
+	}
+
+	switch db.Dialector.Name() {
+	case "sqlite":
+		return &sqliteDialect{}
+	default:
+		return &postgresDialect{}
+	}
+}
+
+// lokasi report, offset tetap +7 sama dengan sqliteReportOffset
+var reportLocation = time.FixedZone(ReportTimezone, 7*60*60)
+
+// ReportDay mengubah waktu ke hari laporan dengan format yang sama seperti kolom day di daily balance
+func ReportDay(t time.Time) time.Time {
+	y, m, d := t.In(reportLocation).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// ReportDayStart waktu mulai hari laporan, kebalikan dari ReportDay
+func ReportDayStart(day time.Time) time.Time {
+	y, m, d := day.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, reportLocation)
+}
+
+// ReportLocation timezone laporan, dipakai untuk menampilkan waktu di export
+func ReportLocation() *time.Location {
+	return reportLocation
+}
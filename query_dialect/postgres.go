@@ -0,0 +1,40 @@
+package query_dialect
+
+import "fmt"
+
+type postgresDialect struct{}
+
+// Name implements Dialect.
+func (p *postgresDialect) Name() string {
+	return "postgres"
+}
+
+// EpochMicro implements Dialect.
+func (p *postgresDialect) EpochMicro(col string) string {
+	return fmt.Sprintf("(EXTRACT(EPOCH FROM %s) * 1000000)::BIGINT", col)
+}
+
+// TruncMonth implements Dialect.
+func (p *postgresDialect) TruncMonth(col string) string {
+	return fmt.Sprintf("date_trunc('month', %s AT TIME ZONE '%s')", col, ReportTimezone)
+}
+
+// LocalDay implements Dialect.
+func (p *postgresDialect) LocalDay(col string) string {
+	return fmt.Sprintf(
+		"date(%s AT TIME ZONE '%s')::timestamp AT TIME ZONE '%s' + INTERVAL '7 hours'",
+		col,
+		ReportTimezone,
+		ReportTimezone,
+	)
+}
+
+// ILike implements Dialect.
+func (p *postgresDialect) ILike(col string) string {
+	return fmt.Sprintf("%s ilike ?", col)
+}
+
+// LockTable implements Dialect.
+func (p *postgresDialect) LockTable(table string) string {
+	return fmt.Sprintf("lock table %s in ACCESS exclusive mode", table)
+}
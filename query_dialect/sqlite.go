@@ -0,0 +1,41 @@
+package query_dialect
+
+import "fmt"
+
+// sqlite tidak punya timezone database, jadi offset Asia/Jakarta ditulis langsung
+const sqliteReportOffset = "+7 hours"
+
+type sqliteDialect struct{}
+
+// Name implements Dialect.
+func (s *sqliteDialect) Name() string {
+	return "sqlite"
+}
+
+// EpochMicro implements Dialect.
+func (s *sqliteDialect) EpochMicro(col string) string {
+	return fmt.Sprintf("CAST(ROUND((julianday(%s) - 2440587.5) * 86400000000) AS INTEGER)", col)
+}
+
+// TruncMonth implements Dialect.
+func (s *sqliteDialect) TruncMonth(col string) string {
+	return fmt.Sprintf("datetime(%s, '%s', 'start of month')", col, sqliteReportOffset)
+}
+
+// LocalDay implements Dialect.
+//
+// hasilnya harus sama persis dengan format time.Time yang disimpan driver sqlite,
+// kalau tidak upsert on conflict (day, ...) tidak akan ketemu.
+func (s *sqliteDialect) LocalDay(col string) string {
+	return fmt.Sprintf("strftime('%%Y-%%m-%%d 00:00:00+00:00', %s, '%s')", col, sqliteReportOffset)
+}
+
+// ILike implements Dialect.
+func (s *sqliteDialect) ILike(col string) string {
+	return fmt.Sprintf("lower(%s) like lower(?)", col)
+}
+
+// LockTable implements Dialect.
+func (s *sqliteDialect) LockTable(table string) string {
+	return ""
+}
@@ -0,0 +1,130 @@
+package receivable
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/pdcgo/accounting_service/query_dialect"
+	"github.com/pdcgo/schema/services/accounting_iface/v1"
+	"github.com/pdcgo/shared/db_models"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func addAging(a *accounting_iface.ReceivableAgingAmount, other *accounting_iface.ReceivableAgingAmount) {
+	a.Count += other.Count
+	a.Days30 += other.Days30
+	a.Days60 += other.Days60
+	a.Days90 += other.Days90
+	a.Over90 += other.Over90
+	a.Total += other.Total
+}
+
+// ReceivableAging umur piutang order yang belum cair per shop
+func (r *receivableServiceImpl) ReceivableAging(
+	ctx context.Context,
+	req *connect.Request[accounting_iface.ReceivableAgingRequest],
+) (*connect.Response[accounting_iface.ReceivableAgingResponse], error) {
+	var err error
+	pay := req.Msg
+
+	result := accounting_iface.ReceivableAgingResponse{
+		Data:  []*accounting_iface.ShopReceivableAging{},
+		Total: &accounting_iface.ReceivableAgingAmount{},
+	}
+
+	err = r.
+		auth.
+		AuthIdentityFromHeader(req.Header()).
+		Err()
+
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	if pay.TeamId == 0 {
+		return connect.NewResponse(&result), connect.NewError(connect.CodeInvalidArgument, errors.New("team_id required"))
+	}
+
+	asOf := time.Now()
+	if pay.AsOf != nil {
+		asOf = pay.AsOf.AsTime()
+	}
+	asOfDay := query_dialect.ReportDay(asOf)
+	result.AsOf = timestamppb.New(asOfDay)
+
+	// batas bawah tiap bucket dalam waktu order
+	args := map[string]any{
+		"d30": query_dialect.ReportDayStart(asOfDay.AddDate(0, 0, -30)),
+		"d60": query_dialect.ReportDayStart(asOfDay.AddDate(0, 0, -60)),
+		"d90": query_dialect.ReportDayStart(asOfDay.AddDate(0, 0, -90)),
+	}
+	end := query_dialect.ReportDayStart(asOfDay.AddDate(0, 0, 1))
+
+	query := r.
+		db.
+		WithContext(ctx).
+		Table("order_receivables o").
+		Joins("left join marketplaces m on m.id = o.shop_id").
+		Select(strings.Join([]string{
+			"o.shop_id",
+			"max(m.mp_name) as shop_name",
+			"max(m.mp_type) as mp_type",
+			"count(o.id) as count",
+			"sum(case when o.order_at >= @d30 then o.est_amount else 0 end) as b1",
+			"sum(case when o.order_at < @d30 and o.order_at >= @d60 then o.est_amount else 0 end) as b2",
+			"sum(case when o.order_at < @d60 and o.order_at >= @d90 then o.est_amount else 0 end) as b3",
+			"sum(case when o.order_at < @d90 then o.est_amount else 0 end) as b4",
+			"sum(o.est_amount) as total",
+		}, ", "), args).
+		Where("o.team_id = ?", pay.TeamId).
+		Where("o.order_at < ?", end).
+		// piutang yang tertutup setelah as of masih terbuka di tanggal tersebut
+		Where("(o.closed_at is null or o.closed_at >= ?)", end).
+		Group("o.shop_id").
+		Order("o.shop_id asc")
+
+	if pay.ShopId != 0 {
+		query = query.Where("o.shop_id = ?", pay.ShopId)
+	}
+
+	rows := []*struct {
+		ShopID   uint
+		ShopName string
+		MpType   db_models.MarketplaceType
+		Count    int64
+		B1       float64
+		B2       float64
+		B3       float64
+		B4       float64
+		Total    float64
+	}{}
+
+	err = query.Find(&rows).Error
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	for _, row := range rows {
+		item := accounting_iface.ShopReceivableAging{
+			ShopId:   uint64(row.ShopID),
+			ShopName: row.ShopName,
+			MpType:   string(row.MpType),
+			Amount: &accounting_iface.ReceivableAgingAmount{
+				Count:  row.Count,
+				Days30: row.B1,
+				Days60: row.B2,
+				Days90: row.B3,
+				Over90: row.B4,
+				Total:  row.Total,
+			},
+		}
+
+		result.Data = append(result.Data, &item)
+		addAging(result.Total, item.Amount)
+	}
+
+	return connect.NewResponse(&result), nil
+}
@@ -0,0 +1,133 @@
+package receivable
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type BookPayload struct {
+	TeamID          uint
+	ShopID          uint
+	OrderID         uint
+	ExternalOrderID string
+	TransactionID   uint
+	OrderAt         time.Time
+	EstAmount       float64
+}
+
+type FundPayload struct {
+	TeamID          uint
+	ShopID          uint
+	ExternalOrderID string
+	EstAmount       float64
+	Amount          float64
+	At              time.Time
+}
+
+type OrderLedger struct {
+	tx *gorm.DB
+}
+
+func NewOrderLedger(tx *gorm.DB) *OrderLedger {
+	return &OrderLedger{
+		tx: tx,
+	}
+}
+
+// Book mencatat piutang order baru, order yang sama tidak dicatat dua kali
+func (o *OrderLedger) Book(pay *BookPayload) error {
+	var item OrderReceivable
+	err := o.
+		tx.
+		Where("team_id = ?", pay.TeamID).
+		Where("order_id = ?", pay.OrderID).
+		Find(&item).
+		Error
+
+	if err != nil {
+		return err
+	}
+
+	if item.ID != 0 {
+		return nil
+	}
+
+	item = OrderReceivable{
+		TeamID:          pay.TeamID,
+		ShopID:          pay.ShopID,
+		OrderID:         pay.OrderID,
+		ExternalOrderID: pay.ExternalOrderID,
+		TransactionID:   pay.TransactionID,
+		Status:          ReceivableOpen,
+		OrderAt:         pay.OrderAt,
+		EstAmount:       pay.EstAmount,
+		Updated:         time.Now(),
+	}
+
+	return o.tx.Create(&item).Error
+}
+
+// Fund menutup piutang dari event pencairan marketplace.
+// Kalau order tidak ditemukan di sub ledger, dicatat baru dengan estimasi dari event.
+func (o *OrderLedger) Fund(pay *FundPayload) error {
+	var item OrderReceivable
+	err := o.
+		tx.
+		Where("team_id = ?", pay.TeamID).
+		Where("shop_id = ?", pay.ShopID).
+		Where("external_order_id = ?", pay.ExternalOrderID).
+		Where("status <> ?", ReceivableCancelled).
+		Order("id desc").
+		Find(&item).
+		Error
+
+	if err != nil {
+		return err
+	}
+
+	at := pay.At
+	if at.IsZero() {
+		at = time.Now()
+	}
+
+	if item.ID == 0 {
+		item = OrderReceivable{
+			TeamID:          pay.TeamID,
+			ShopID:          pay.ShopID,
+			ExternalOrderID: pay.ExternalOrderID,
+			OrderAt:         at,
+			EstAmount:       pay.EstAmount,
+		}
+	}
+
+	// event ulang tidak menggeser tanggal cair
+	if item.ClosedAt == nil {
+		item.ClosedAt = &at
+	}
+
+	item.FundAmount = pay.Amount
+	item.Status = ReceivableFunded
+	if pay.Amount < 0 {
+		item.Status = ReceivableReturned
+	}
+	item.Updated = time.Now()
+
+	return o.tx.Save(&item).Error
+}
+
+// Cancel menutup piutang order yang dibatalkan
+func (o *OrderLedger) Cancel(teamID, orderID uint, at time.Time) error {
+	return o.
+		tx.
+		Model(&OrderReceivable{}).
+		Where("team_id = ?", teamID).
+		Where("order_id = ?", orderID).
+		Where("status = ?", ReceivableOpen).
+		Updates(map[string]any{
+			"status":    ReceivableCancelled,
+			"closed_at": at,
+			"updated":   time.Now(),
+		}).
+		Error
+}
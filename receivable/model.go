@@ -0,0 +1,89 @@
+package receivable
+
+import (
+	"time"
+
+	"github.com/pdcgo/schema/services/accounting_iface/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type ReceivableStatus string
+
+const (
+	ReceivableOpen      ReceivableStatus = "open"
+	ReceivableFunded    ReceivableStatus = "funded"
+	ReceivableReturned  ReceivableStatus = "returned"
+	ReceivableCancelled ReceivableStatus = "cancelled"
+)
+
+// OrderReceivable sub ledger piutang selling per order.
+// Order lama yang dicairkan sebelum sub ledger ada tercatat dengan OrderID 0.
+type OrderReceivable struct {
+	ID              uint             `json:"id" gorm:"primarykey"`
+	TeamID          uint             `json:"team_id" gorm:"index:order_receivable_order;index:order_receivable_external"`
+	ShopID          uint             `json:"shop_id" gorm:"index:order_receivable_external"`
+	OrderID         uint             `json:"order_id" gorm:"index:order_receivable_order"`
+	ExternalOrderID string           `json:"external_order_id" gorm:"index:order_receivable_external"`
+	TransactionID   uint             `json:"transaction_id"`
+	Status          ReceivableStatus `json:"status" gorm:"index"`
+	OrderAt         time.Time        `json:"order_at" gorm:"index"`
+	// estimasi saat order dibuat
+	EstAmount float64 `json:"est_amount"`
+	// nominal yang dicairkan marketplace, negatif kalau order diretur
+	FundAmount float64 `json:"fund_amount"`
+	// waktu piutang tertutup karena cair, retur atau batal
+	ClosedAt *time.Time `json:"closed_at" gorm:"index"`
+	Updated  time.Time  `json:"updated"`
+}
+
+func statusProto(status ReceivableStatus) accounting_iface.ReceivableStatus {
+	switch status {
+	case ReceivableOpen:
+		return accounting_iface.ReceivableStatus_RECEIVABLE_STATUS_OPEN
+	case ReceivableFunded:
+		return accounting_iface.ReceivableStatus_RECEIVABLE_STATUS_FUNDED
+	case ReceivableReturned:
+		return accounting_iface.ReceivableStatus_RECEIVABLE_STATUS_RETURNED
+	case ReceivableCancelled:
+		return accounting_iface.ReceivableStatus_RECEIVABLE_STATUS_CANCELLED
+	default:
+		return accounting_iface.ReceivableStatus_RECEIVABLE_STATUS_UNSPECIFIED
+	}
+}
+
+func statusFromProto(status accounting_iface.ReceivableStatus) ReceivableStatus {
+	switch status {
+	case accounting_iface.ReceivableStatus_RECEIVABLE_STATUS_OPEN:
+		return ReceivableOpen
+	case accounting_iface.ReceivableStatus_RECEIVABLE_STATUS_FUNDED:
+		return ReceivableFunded
+	case accounting_iface.ReceivableStatus_RECEIVABLE_STATUS_RETURNED:
+		return ReceivableReturned
+	case accounting_iface.ReceivableStatus_RECEIVABLE_STATUS_CANCELLED:
+		return ReceivableCancelled
+	default:
+		return ""
+	}
+}
+
+func (o *OrderReceivable) proto() *accounting_iface.OrderReceivable {
+	item := accounting_iface.OrderReceivable{
+		Id:              uint64(o.ID),
+		TeamId:          uint64(o.TeamID),
+		ShopId:          uint64(o.ShopID),
+		OrderId:         uint64(o.OrderID),
+		ExternalOrderId: o.ExternalOrderID,
+		TransactionId:   uint64(o.TransactionID),
+		Status:          statusProto(o.Status),
+		OrderAt:         timestamppb.New(o.OrderAt),
+		EstAmount:       o.EstAmount,
+		FundAmount:      o.FundAmount,
+		Updated:         timestamppb.New(o.Updated),
+	}
+
+	if o.ClosedAt != nil {
+		item.ClosedAt = timestamppb.New(*o.ClosedAt)
+	}
+
+	return &item
+}
@@ -0,0 +1,115 @@
+package receivable
+
+import (
+	"context"
+	"errors"
+
+	"connectrpc.com/connect"
+	"github.com/pdcgo/schema/services/accounting_iface/v1"
+	"github.com/pdcgo/schema/services/common/v1"
+	"github.com/pdcgo/shared/interfaces/authorization_iface"
+	"gorm.io/gorm"
+)
+
+// limit default list sub ledger
+const defaultListLimit = 100
+
+type receivableServiceImpl struct {
+	db   *gorm.DB
+	auth authorization_iface.Authorization
+}
+
+// ReceivableList sub ledger piutang per order
+func (r *receivableServiceImpl) ReceivableList(
+	ctx context.Context,
+	req *connect.Request[accounting_iface.ReceivableListRequest],
+) (*connect.Response[accounting_iface.ReceivableListResponse], error) {
+	var err error
+	pay := req.Msg
+
+	result := accounting_iface.ReceivableListResponse{
+		Data:     []*accounting_iface.OrderReceivable{},
+		PageInfo: &common.PageInfo{},
+	}
+
+	err = r.
+		auth.
+		AuthIdentityFromHeader(req.Header()).
+		Err()
+
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	if pay.TeamId == 0 {
+		return connect.NewResponse(&result), connect.NewError(connect.CodeInvalidArgument, errors.New("team_id required"))
+	}
+
+	query := r.
+		db.
+		WithContext(ctx).
+		Model(&OrderReceivable{}).
+		Where("team_id = ?", pay.TeamId)
+
+	if pay.ShopId != 0 {
+		query = query.Where("shop_id = ?", pay.ShopId)
+	}
+	if pay.OrderId != 0 {
+		query = query.Where("order_id = ?", pay.OrderId)
+	}
+	if pay.ExternalOrderId != "" {
+		query = query.Where("external_order_id = ?", pay.ExternalOrderId)
+	}
+	if pay.Status != accounting_iface.ReceivableStatus_RECEIVABLE_STATUS_UNSPECIFIED {
+		query = query.Where("status = ?", statusFromProto(pay.Status))
+	}
+
+	var total int64
+	err = query.
+		Session(&gorm.Session{}).
+		Count(&total).
+		Error
+
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	var page, limit int64 = 1, defaultListLimit
+	if pay.Page != nil {
+		page = max(pay.Page.Page, 1)
+		if pay.Page.Limit > 0 {
+			limit = pay.Page.Limit
+		}
+	}
+
+	result.PageInfo = &common.PageInfo{
+		CurrentPage: page,
+		TotalPage:   max((total+limit-1)/limit, 1),
+		TotalItems:  total,
+	}
+
+	items := []*OrderReceivable{}
+	err = query.
+		Order("order_at asc, id asc").
+		Offset(int((page - 1) * limit)).
+		Limit(int(limit)).
+		Find(&items).
+		Error
+
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	for _, item := range items {
+		result.Data = append(result.Data, item.proto())
+	}
+
+	return connect.NewResponse(&result), nil
+}
+
+func NewReceivableService(db *gorm.DB, auth authorization_iface.Authorization) *receivableServiceImpl {
+	return &receivableServiceImpl{
+		db:   db,
+		auth: auth,
+	}
+}
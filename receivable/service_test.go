@@ -0,0 +1,162 @@
+package receivable_test
+
+import (
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/pdcgo/accounting_service/accounting_mock"
+	"github.com/pdcgo/accounting_service/receivable"
+	"github.com/pdcgo/schema/services/accounting_iface/v1"
+	"github.com/pdcgo/schema/services/common/v1"
+	"github.com/pdcgo/shared/authorization/authorization_mock"
+	"github.com/pdcgo/shared/db_models"
+	"github.com/pdcgo/shared/pkg/moretest"
+	"github.com/pdcgo/shared/pkg/moretest/moretest_mock"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/gorm"
+)
+
+func TestReceivable(t *testing.T) {
+	var db gorm.DB
+
+	moretest.Suite(t, "testing order receivable",
+		moretest.SetupListFunc{
+			moretest_mock.MockSqliteDatabase(&db),
+			func(t *testing.T) func() error {
+				err := db.AutoMigrate(
+					&db_models.Marketplace{},
+					&receivable.OrderReceivable{},
+				)
+				assert.Nil(t, err)
+
+				err = db.Create([]*db_models.Marketplace{
+					{ID: 3, TeamID: 1, MpUsername: "shop3", MpName: "shop 3", MpType: db_models.MpShopee},
+					{ID: 4, TeamID: 1, MpUsername: "shop4", MpName: "shop 4", MpType: db_models.MpTiktok},
+				}).Error
+				assert.Nil(t, err)
+				return nil
+			},
+		},
+		func(t *testing.T) {
+			ledger := receivable.NewOrderLedger(&db)
+
+			t.Run("catat sub ledger", func(t *testing.T) {
+				books := []*receivable.BookPayload{
+					{TeamID: 1, ShopID: 3, OrderID: 1, ExternalOrderID: "A", OrderAt: accounting_mock.Date(10, 1), EstAmount: 100},
+					{TeamID: 1, ShopID: 3, OrderID: 2, ExternalOrderID: "B", OrderAt: accounting_mock.Date(8, 15), EstAmount: 200},
+					{TeamID: 1, ShopID: 4, OrderID: 3, ExternalOrderID: "C", OrderAt: accounting_mock.Date(10, 20), EstAmount: 300},
+					{TeamID: 1, ShopID: 4, OrderID: 4, ExternalOrderID: "D", OrderAt: accounting_mock.Date(7, 1), EstAmount: 50},
+					{TeamID: 1, ShopID: 3, OrderID: 5, ExternalOrderID: "F", OrderAt: accounting_mock.Date(9, 1), EstAmount: 150},
+					{TeamID: 1, ShopID: 4, OrderID: 6, ExternalOrderID: "G", OrderAt: accounting_mock.Date(10, 2), EstAmount: 120},
+					// order yang sama tidak dicatat ulang
+					{TeamID: 1, ShopID: 3, OrderID: 1, ExternalOrderID: "A", OrderAt: accounting_mock.Date(10, 2), EstAmount: 999},
+				}
+				for _, book := range books {
+					err := ledger.Book(book)
+					assert.Nil(t, err)
+				}
+
+				err := ledger.Cancel(1, 4, accounting_mock.Date(7, 2))
+				assert.Nil(t, err)
+
+				funds := []*receivable.FundPayload{
+					{TeamID: 1, ShopID: 3, ExternalOrderID: "A", EstAmount: 100, Amount: 90, At: accounting_mock.Date(10, 10)},
+					{TeamID: 1, ShopID: 4, ExternalOrderID: "E", EstAmount: 80, Amount: 80, At: accounting_mock.Date(10, 12)},
+					{TeamID: 1, ShopID: 3, ExternalOrderID: "F", EstAmount: 150, Amount: 150, At: accounting_mock.Date(11, 5)},
+					{TeamID: 1, ShopID: 4, ExternalOrderID: "G", EstAmount: 120, Amount: -120, At: accounting_mock.Date(10, 15)},
+					// event ulang
+					{TeamID: 1, ShopID: 3, ExternalOrderID: "A", EstAmount: 100, Amount: 90, At: accounting_mock.Date(10, 25)},
+				}
+				for _, fund := range funds {
+					err := ledger.Fund(fund)
+					assert.Nil(t, err)
+				}
+			})
+
+			service := receivable.NewReceivableService(&db, &authorization_mock.EmptyAuthorizationMock{})
+
+			t.Run("list sub ledger", func(t *testing.T) {
+				res, err := service.ReceivableList(t.Context(), connect.NewRequest(&accounting_iface.ReceivableListRequest{
+					TeamId:          1,
+					ExternalOrderId: "A",
+				}))
+				assert.Nil(t, err)
+				assert.Equal(t, int64(1), res.Msg.PageInfo.TotalItems)
+				assert.Equal(t, accounting_iface.ReceivableStatus_RECEIVABLE_STATUS_FUNDED, res.Msg.Data[0].Status)
+				assert.Equal(t, 100.0, res.Msg.Data[0].EstAmount)
+				assert.True(t, res.Msg.Data[0].ClosedAt.AsTime().Equal(accounting_mock.Date(10, 10)))
+
+				res, err = service.ReceivableList(t.Context(), connect.NewRequest(&accounting_iface.ReceivableListRequest{
+					TeamId: 1,
+					Status: accounting_iface.ReceivableStatus_RECEIVABLE_STATUS_OPEN,
+					Page:   &common.PageFilter{Page: 1, Limit: 1},
+				}))
+				assert.Nil(t, err)
+				assert.Equal(t, int64(2), res.Msg.PageInfo.TotalItems)
+				assert.Equal(t, int64(2), res.Msg.PageInfo.TotalPage)
+				assert.Len(t, res.Msg.Data, 1)
+			})
+
+			t.Run("list tanpa team", func(t *testing.T) {
+				_, err := service.ReceivableList(t.Context(), connect.NewRequest(&accounting_iface.ReceivableListRequest{}))
+				assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+			})
+
+			t.Run("aging piutang", func(t *testing.T) {
+				res, err := service.ReceivableAging(t.Context(), connect.NewRequest(&accounting_iface.ReceivableAgingRequest{
+					TeamId: 1,
+					AsOf:   timestamppb.New(accounting_mock.Date(10, 31)),
+				}))
+				assert.Nil(t, err)
+				assert.Len(t, res.Msg.Data, 2)
+
+				shop := res.Msg.Data[0]
+				assert.Equal(t, uint64(3), shop.ShopId)
+				assert.Equal(t, int64(2), shop.Amount.Count)
+				assert.Equal(t, 150.0, shop.Amount.Days60)
+				assert.Equal(t, 200.0, shop.Amount.Days90)
+				assert.Equal(t, 350.0, shop.Amount.Total)
+
+				shop = res.Msg.Data[1]
+				assert.Equal(t, string(db_models.MpTiktok), shop.MpType)
+				assert.Equal(t, 300.0, shop.Amount.Days30)
+
+				assert.Equal(t, 650.0, res.Msg.Total.Total)
+			})
+
+			t.Run("variance estimasi dan pencairan", func(t *testing.T) {
+				res, err := service.ReceivableVariance(t.Context(), connect.NewRequest(&accounting_iface.ReceivableVarianceRequest{
+					TeamId: 1,
+					TimeRange: &common.TimeFilterRange{
+						StartDate: timestamppb.New(accounting_mock.Date(10, 1)),
+						EndDate:   timestamppb.New(accounting_mock.Date(10, 31)),
+					},
+				}))
+				assert.Nil(t, err)
+				assert.Len(t, res.Msg.Shops, 2)
+
+				shop := res.Msg.Shops[0]
+				assert.Equal(t, int64(1), shop.Amount.Count)
+				assert.Equal(t, -10.0, shop.Amount.Variance)
+				assert.InDelta(t, -10.0, shop.Amount.GetVariancePercent(), 0.001)
+
+				shop = res.Msg.Shops[1]
+				assert.Equal(t, int64(2), shop.Amount.Count)
+				assert.Equal(t, int64(1), shop.Amount.DeviatedCount)
+				assert.Equal(t, -240.0, shop.Amount.Variance)
+
+				assert.Len(t, res.Msg.Marketplaces, 2)
+				assert.Equal(t, string(db_models.MpShopee), res.Msg.Marketplaces[0].MpType)
+				assert.Equal(t, -250.0, res.Msg.Total.Variance)
+			})
+
+			t.Run("variance tanpa periode", func(t *testing.T) {
+				_, err := service.ReceivableVariance(t.Context(), connect.NewRequest(&accounting_iface.ReceivableVarianceRequest{
+					TeamId: 1,
+				}))
+				assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+			})
+		},
+	)
+}
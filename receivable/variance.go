@@ -0,0 +1,154 @@
+package receivable
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	"connectrpc.com/connect"
+	"github.com/pdcgo/accounting_service/accounting_core"
+	"github.com/pdcgo/accounting_service/query_dialect"
+	"github.com/pdcgo/schema/services/accounting_iface/v1"
+	"github.com/pdcgo/schema/services/common/v1"
+	"github.com/pdcgo/shared/db_models"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func addVariance(v *accounting_iface.ReceivableVarianceAmount, other *accounting_iface.ReceivableVarianceAmount) {
+	v.Count += other.Count
+	v.DeviatedCount += other.DeviatedCount
+	v.EstAmount += other.EstAmount
+	v.FundAmount += other.FundAmount
+	v.Variance += other.Variance
+}
+
+// finishVariance mengisi persen variance, kosong kalau estimasi 0
+func finishVariance(v *accounting_iface.ReceivableVarianceAmount) {
+	if accounting_core.CompareFloatSafe(v.EstAmount, 0, accounting_core.PrecisionEpsilon) {
+		return
+	}
+	percent := v.Variance / v.EstAmount * 100
+	v.VariancePercent = &percent
+}
+
+// ReceivableVariance selisih estimasi dan pencairan marketplace per shop dan marketplace
+func (r *receivableServiceImpl) ReceivableVariance(
+	ctx context.Context,
+	req *connect.Request[accounting_iface.ReceivableVarianceRequest],
+) (*connect.Response[accounting_iface.ReceivableVarianceResponse], error) {
+	var err error
+	pay := req.Msg
+
+	result := accounting_iface.ReceivableVarianceResponse{
+		Shops:        []*accounting_iface.ShopReceivableVariance{},
+		Marketplaces: []*accounting_iface.MarketplaceReceivableVariance{},
+		Total:        &accounting_iface.ReceivableVarianceAmount{},
+	}
+
+	err = r.
+		auth.
+		AuthIdentityFromHeader(req.Header()).
+		Err()
+
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	if pay.TeamId == 0 {
+		return connect.NewResponse(&result), connect.NewError(connect.CodeInvalidArgument, errors.New("team_id required"))
+	}
+
+	timeRange := pay.TimeRange
+	if timeRange == nil ||
+		timeRange.StartDate == nil ||
+		timeRange.EndDate == nil ||
+		timeRange.EndDate.AsTime().Before(timeRange.StartDate.AsTime()) {
+		return connect.NewResponse(&result), connect.NewError(connect.CodeInvalidArgument, errors.New("invalid start end"))
+	}
+
+	start := query_dialect.ReportDay(timeRange.StartDate.AsTime())
+	end := query_dialect.ReportDay(timeRange.EndDate.AsTime())
+	result.TimeRange = &common.TimeFilterRange{
+		StartDate: timestamppb.New(start),
+		EndDate:   timestamppb.New(end),
+	}
+
+	query := r.
+		db.
+		WithContext(ctx).
+		Table("order_receivables o").
+		Joins("left join marketplaces m on m.id = o.shop_id").
+		Select(strings.Join([]string{
+			"o.shop_id",
+			"max(m.mp_name) as shop_name",
+			"max(m.mp_type) as mp_type",
+			"count(o.id) as count",
+			"sum(case when abs(o.fund_amount - o.est_amount) > @epsilon then 1 else 0 end) as deviated_count",
+			"sum(o.est_amount) as est_amount",
+			"sum(o.fund_amount) as fund_amount",
+		}, ", "), map[string]any{"epsilon": accounting_core.PrecisionEpsilon}).
+		Where("o.team_id = ?", pay.TeamId).
+		Where("o.status in ?", []ReceivableStatus{ReceivableFunded, ReceivableReturned}).
+		Where("o.closed_at >= ?", query_dialect.ReportDayStart(start)).
+		Where("o.closed_at < ?", query_dialect.ReportDayStart(end.AddDate(0, 0, 1))).
+		Group("o.shop_id").
+		Order("o.shop_id asc")
+
+	if pay.ShopId != 0 {
+		query = query.Where("o.shop_id = ?", pay.ShopId)
+	}
+
+	rows := []*struct {
+		ShopID        uint
+		ShopName      string
+		MpType        db_models.MarketplaceType
+		Count         int64
+		DeviatedCount int64
+		EstAmount     float64
+		FundAmount    float64
+	}{}
+
+	err = query.Find(&rows).Error
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	mps := map[string]*accounting_iface.MarketplaceReceivableVariance{}
+	for _, row := range rows {
+		item := accounting_iface.ShopReceivableVariance{
+			ShopId:   uint64(row.ShopID),
+			ShopName: row.ShopName,
+			MpType:   string(row.MpType),
+			Amount: &accounting_iface.ReceivableVarianceAmount{
+				Count:         row.Count,
+				DeviatedCount: row.DeviatedCount,
+				EstAmount:     row.EstAmount,
+				FundAmount:    row.FundAmount,
+				Variance:      row.FundAmount - row.EstAmount,
+			},
+		}
+		finishVariance(item.Amount)
+		result.Shops = append(result.Shops, &item)
+
+		if mps[item.MpType] == nil {
+			mps[item.MpType] = &accounting_iface.MarketplaceReceivableVariance{
+				MpType: item.MpType,
+				Amount: &accounting_iface.ReceivableVarianceAmount{},
+			}
+		}
+		addVariance(mps[item.MpType].Amount, item.Amount)
+		addVariance(result.Total, item.Amount)
+	}
+
+	for _, mp := range mps {
+		finishVariance(mp.Amount)
+		result.Marketplaces = append(result.Marketplaces, mp)
+	}
+	slices.SortFunc(result.Marketplaces, func(a, b *accounting_iface.MarketplaceReceivableVariance) int {
+		return strings.Compare(a.MpType, b.MpType)
+	})
+
+	finishVariance(result.Total)
+	return connect.NewResponse(&result), nil
+}
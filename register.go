@@ -7,17 +7,26 @@ import (
 	"github.com/pdcgo/accounting_service/account"
 	"github.com/pdcgo/accounting_service/adjustment"
 	"github.com/pdcgo/accounting_service/ads_expense"
+	"github.com/pdcgo/accounting_service/bank_statement"
+	"github.com/pdcgo/accounting_service/budget"
 	"github.com/pdcgo/accounting_service/core"
+	"github.com/pdcgo/accounting_service/customer_service"
 	"github.com/pdcgo/accounting_service/expense"
+	"github.com/pdcgo/accounting_service/hledger"
 	"github.com/pdcgo/accounting_service/ledger"
+	"github.com/pdcgo/accounting_service/netting"
 	"github.com/pdcgo/accounting_service/payment"
+	"github.com/pdcgo/accounting_service/payout"
+	"github.com/pdcgo/accounting_service/receivable"
 	"github.com/pdcgo/accounting_service/report"
 	"github.com/pdcgo/accounting_service/report/report_balance"
 	"github.com/pdcgo/accounting_service/revenue"
 	"github.com/pdcgo/accounting_service/setup"
 	"github.com/pdcgo/accounting_service/statement"
 	"github.com/pdcgo/accounting_service/stock"
+	"github.com/pdcgo/accounting_service/supplier"
 	"github.com/pdcgo/accounting_service/tag"
+	"github.com/pdcgo/accounting_service/task_queue"
 	"github.com/pdcgo/accounting_service/transfer"
 	"github.com/pdcgo/schema/services/accounting_iface/v1/accounting_ifaceconnect"
 	"github.com/pdcgo/schema/services/payment_iface/v1/payment_ifaceconnect"
@@ -43,6 +52,7 @@ func NewRegister(
 	defaultInterceptor custom_connect.DefaultInterceptor,
 	cache ware_cache.Cache,
 	dispather report.ReportDispatcher,
+	queue *task_queue.TaskQueue,
 ) RegisterHandler {
 
 	return func() ServiceReflectNames {
@@ -135,13 +145,89 @@ func NewRegister(
 		mux.Handle(path, handler)
 		grpcReflect = append(grpcReflect, accounting_ifaceconnect.TagServiceName)
 
-		path, handler = accounting_ifaceconnect.NewTransferServiceHandler(transfer.NewTransferService(db, auth), defaultInterceptor)
+		path, handler = accounting_ifaceconnect.NewTransferServiceHandler(
+			transfer.NewTransferService(db, auth),
+			defaultInterceptor,
+			sourceInterceptor,
+		)
 		mux.Handle(path, handler)
 		grpcReflect = append(grpcReflect, accounting_ifaceconnect.TransferServiceName)
 
-		var ledgerClient accounting_ifaceconnect.LedgerServiceClient
+		path, handler = accounting_ifaceconnect.NewBudgetServiceHandler(
+			budget.NewBudgetService(db, auth),
+			defaultInterceptor,
+			sourceInterceptor,
+		)
+		mux.Handle(path, handler)
+		grpcReflect = append(grpcReflect, accounting_ifaceconnect.BudgetServiceName)
+
+		path, handler = accounting_ifaceconnect.NewCustomerServiceReportServiceHandler(
+			customer_service.NewCsService(db, auth),
+			defaultInterceptor,
+			sourceInterceptor,
+		)
+		mux.Handle(path, handler)
+		grpcReflect = append(grpcReflect, accounting_ifaceconnect.CustomerServiceReportServiceName)
+
+		path, handler = accounting_ifaceconnect.NewSupplierReportServiceHandler(
+			supplier.NewSupplierService(db, auth),
+			defaultInterceptor,
+			sourceInterceptor,
+		)
+		mux.Handle(path, handler)
+		grpcReflect = append(grpcReflect, accounting_ifaceconnect.SupplierReportServiceName)
+
+		path, handler = accounting_ifaceconnect.NewReceivableServiceHandler(
+			receivable.NewReceivableService(db, auth),
+			defaultInterceptor,
+			sourceInterceptor,
+		)
+		mux.Handle(path, handler)
+		grpcReflect = append(grpcReflect, accounting_ifaceconnect.ReceivableServiceName)
+
+		path, handler = accounting_ifaceconnect.NewPayoutServiceHandler(
+			payout.NewPayoutService(db, auth),
+			defaultInterceptor,
+			sourceInterceptor,
+		)
+		mux.Handle(path, handler)
+		grpcReflect = append(grpcReflect, accounting_ifaceconnect.PayoutServiceName)
+
+		path, handler = accounting_ifaceconnect.NewNettingServiceHandler(
+			netting.NewNettingService(db, auth),
+			defaultInterceptor,
+			sourceInterceptor,
+		)
+		mux.Handle(path, handler)
+		grpcReflect = append(grpcReflect, accounting_ifaceconnect.NettingServiceName)
+
+		path, handler = accounting_ifaceconnect.NewHledgerServiceHandler(
+			hledger.NewHledgerService(db, auth),
+			defaultInterceptor,
+			sourceInterceptor,
+		)
+		mux.Handle(path, handler)
+		grpcReflect = append(grpcReflect, accounting_ifaceconnect.HledgerServiceName)
+
+		path, handler = accounting_ifaceconnect.NewBankStatementServiceHandler(
+			bank_statement.NewBankStatementService(db, auth),
+			defaultInterceptor,
+			sourceInterceptor,
+		)
+		mux.Handle(path, handler)
+		grpcReflect = append(grpcReflect, accounting_ifaceconnect.BankStatementServiceName)
+
+		path, handler = accounting_ifaceconnect.NewTaskQueueServiceHandler(
+			task_queue.NewTaskQueueAdminService(queue, auth),
+			defaultInterceptor,
+			sourceInterceptor,
+		)
+		mux.Handle(path, handler)
+		grpcReflect = append(grpcReflect, accounting_ifaceconnect.TaskQueueServiceName)
+
+		statementService := statement.NewStatementService(db, auth)
 		path, handler = accounting_ifaceconnect.NewStatementServiceHandler(
-			statement.NewStatementService(ledgerClient),
+			statementService,
 			defaultInterceptor,
 			sourceInterceptor,
 		)
@@ -50,11 +50,20 @@ func (b *balanceViewImpl) Iterate(handle func(d *report_iface.AccountBalanceItem
 	lbQuery := b.lastBalanceQuery()
 	sbQuery := b.startBalanceQuery()
 
+	// lb dan start diambil dari baris yang juga masuk ke base,
+	// jadi cukup left join (sqlite lama tidak punya full outer join)
 	query := b.
 		db.
 		Table("(?) as base", baseQuery).
-		Joins("full outer join (?) as bal on bal.account_key = base.account_key", lbQuery).
-		Joins("full outer join (?) as start on start.account_key = base.account_key", sbQuery)
+		Select([]string{
+			"base.account_key",
+			"base.debit",
+			"base.credit",
+			"coalesce(bal.balance, 0) as balance",
+			"coalesce(start.start_balance, 0) as start_balance",
+		}).
+		Joins("left join (?) as bal on bal.account_key = base.account_key", lbQuery).
+		Joins("left join (?) as start on start.account_key = base.account_key", sbQuery)
 
 	if b.pay.Sort != nil {
 		psort := b.pay.Sort
@@ -80,6 +89,7 @@ func (b *balanceViewImpl) Iterate(handle func(d *report_iface.AccountBalanceItem
 	if err != nil {
 		return err
 	}
+	defer rows.Close()
 
 	for rows.Next() {
 		var d report_iface.AccountBalanceItem
@@ -99,79 +109,88 @@ func (b *balanceViewImpl) Iterate(handle func(d *report_iface.AccountBalanceItem
 
 func (b *balanceViewImpl) lastBalanceQuery() *gorm.DB {
 	query := b.
+		filterQuery(
+			b.
+				db.
+				Table("account_key_daily_balances adb").
+				Select([]string{
+					"adb.account_key",
+					"adb.balance",
+					"row_number() over (partition by adb.account_key order by adb.day desc) as rn",
+				}),
+			false,
+		)
+
+	return b.
 		db.
-		Table("account_key_daily_balances adb").
+		Table("(?) as lb", query).
 		Select([]string{
-			"distinct on (adb.account_key) adb.account_key",
-			"adb.balance",
-		})
+			"lb.account_key",
+			"lb.balance",
+		}).
+		Where("lb.rn = 1")
+}
 
-	pay := b.pay
-	trange := b.pay.TimeRange
-	if trange.EndDate.IsValid() {
-		query = query.Where("adb.day <= ?",
-			trange.EndDate.AsTime(),
+func (b *balanceViewImpl) startBalanceQuery() *gorm.DB {
+	query := b.
+		filterQuery(
+			b.
+				db.
+				Table("account_key_daily_balances adb").
+				Select([]string{
+					"adb.account_key",
+					"adb.start_balance",
+					"row_number() over (partition by adb.account_key order by adb.day asc) as rn",
+				}),
+			true,
 		)
-	}
 
-	if trange.StartDate.IsValid() {
-		query = query.Where("adb.day > ?",
-			trange.StartDate.AsTime(),
-		)
-	}
+	return b.
+		db.
+		Table("(?) as sb", query).
+		Select([]string{
+			"sb.account_key",
+			"sb.start_balance",
+		}).
+		Where("sb.rn = 1")
+}
+
+// filterQuery filter yang sama untuk semua subquery balance,
+// excludeEnd dipakai start balance yang tidak mengambil hari terakhir
+func (b *balanceViewImpl) filterQuery(query *gorm.DB, excludeEnd bool) *gorm.DB {
+	pay := b.pay
 
 	if pay.TeamId != 0 {
 		query = query.
 			Where("adb.journal_team_id = ?", pay.TeamId)
 	}
 
-	if len(pay.AccountKeys) != 0 {
-		query = query.
-			Where("adb.account_key in ?", pay.AccountKeys)
-	}
-
-	query = query.
-		Order("adb.account_key, adb.day desc")
-
-	return query
-}
-
-func (b *balanceViewImpl) startBalanceQuery() *gorm.DB {
-	query := b.
-		db.
-		Table("account_key_daily_balances adb").
-		Select([]string{
-			"distinct on (adb.account_key) adb.account_key",
-			"adb.start_balance",
-		})
-
-	pay := b.pay
-	trange := b.pay.TimeRange
+	trange := pay.TimeRange
 	if trange.EndDate.IsValid() {
-		query = query.Where("adb.day < ?",
-			trange.EndDate.AsTime(),
-		)
+		end := accounting_core.ParseDate(trange.EndDate.AsTime())
+		if excludeEnd {
+			query = query.Where("adb.day < ?",
+				end,
+			)
+		} else {
+			query = query.Where("adb.day <= ?",
+				end,
+			)
+		}
 	}
 
 	if trange.StartDate.IsValid() {
+		start := accounting_core.ParseDate(trange.StartDate.AsTime())
 		query = query.Where("adb.day > ?",
-			trange.StartDate.AsTime(),
+			start,
 		)
 	}
 
-	if pay.TeamId != 0 {
-		query = query.
-			Where("adb.journal_team_id = ?", pay.TeamId)
-	}
-
 	if len(pay.AccountKeys) != 0 {
 		query = query.
 			Where("adb.account_key in ?", pay.AccountKeys)
 	}
 
-	query = query.
-		Order("adb.account_key, adb.day asc")
-
 	return query
 }
 
@@ -184,39 +203,10 @@ func (b *balanceViewImpl) dcQuery() *gorm.DB {
 			"adb.account_key",
 			"sum(adb.debit) as debit",
 			"sum(adb.credit) as credit",
-		})
-
-	pay := b.pay
-
-	query = query.
+		}).
 		Group("adb.account_key")
 
-	if pay.TeamId != 0 {
-		query = query.
-			Where("adb.journal_team_id = ?", pay.TeamId)
-	}
-
-	trange := pay.TimeRange
-	if trange.EndDate.IsValid() {
-		end := accounting_core.ParseDate(trange.EndDate.AsTime())
-		query = query.Where("adb.day <= ?",
-			end,
-		)
-	}
-
-	if trange.StartDate.IsValid() {
-		start := accounting_core.ParseDate(trange.StartDate.AsTime())
-		query = query.Where("adb.day > ?",
-			start,
-		)
-	}
-
-	if len(pay.AccountKeys) != 0 {
-		query = query.
-			Where("adb.account_key in ?", pay.AccountKeys)
-	}
-
-	return query
+	return b.filterQuery(query, false)
 }
 
 func NewBalanceView(db *gorm.DB, pay *report_iface.BalanceRequest) BalanceView {
@@ -72,7 +72,13 @@ func (b *balanceDetailViewImpl) Iterate(handle func(d *report_iface.BalanceDetai
 	query := b.
 		db.
 		Table("(?) as base", baseQuery).
-		Joins("full outer join (?) as bal on bal.label_id = base.label_id", lbQuery).
+		Joins("left join (?) as bal on bal.label_id = base.label_id", lbQuery).
+		Select([]string{
+			"base.label_id",
+			"base.debit",
+			"base.credit",
+			"coalesce(bal.balance, 0) as balance",
+		}).
 		Offset(int(offset)).
 		Limit(int(b.pay.Page.Limit))
 
@@ -98,6 +104,7 @@ func (b *balanceDetailViewImpl) Iterate(handle func(d *report_iface.BalanceDetai
 	if err != nil {
 		return &page, err
 	}
+	defer rows.Close()
 
 	for rows.Next() {
 		var d report_iface.BalanceDetailItem
@@ -272,17 +279,25 @@ func (b *balanceDetailViewImpl) lastBalanceQuery() *gorm.DB {
 		)
 	}
 
-	query = query.
-		Order("label_id, adb.day desc")
-
+	// ambil balance hari terakhir per label
 	query = b.
 		db.
 		Table("(?) as daygroup", query).
 		Select([]string{
-			"distinct on (daygroup.label_id) daygroup.label_id",
+			"daygroup.label_id",
 			"daygroup.balance",
+			"row_number() over (partition by daygroup.label_id order by daygroup.day desc) as rn",
 		})
 
+	query = b.
+		db.
+		Table("(?) as lb", query).
+		Select([]string{
+			"lb.label_id",
+			"lb.balance",
+		}).
+		Where("lb.rn = 1")
+
 	return query
 }
 
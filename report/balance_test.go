@@ -0,0 +1,210 @@
+package report
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/cloudtasks/apiv2/cloudtaskspb"
+	"connectrpc.com/connect"
+	"github.com/googleapis/gax-go/v2"
+	"github.com/pdcgo/accounting_service/accounting_core"
+	"github.com/pdcgo/accounting_service/accounting_mock"
+	"github.com/pdcgo/schema/services/common/v1"
+	"github.com/pdcgo/schema/services/report_iface/v1"
+	"github.com/pdcgo/shared/authorization/authorization_mock"
+	"github.com/pdcgo/shared/configs"
+	"github.com/pdcgo/shared/pkg/moretest"
+	"github.com/pdcgo/shared/pkg/moretest/moretest_mock"
+	"github.com/pdcgo/shared/pkg/ware_cache"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/gorm"
+)
+
+func TestReportQuerySqlite(t *testing.T) {
+	var db gorm.DB
+	var cashAcc accounting_core.Account
+
+	var migrate moretest.SetupFunc = func(t *testing.T) func() error {
+		err := db.AutoMigrate(
+			&accounting_core.Account{},
+			&accounting_core.AccountKeyDailyBalance{},
+			&accounting_core.AccountDailyBalance{},
+			&accounting_core.CsDailyBalance{},
+			&accounting_core.ShopDailyBalance{},
+			&accounting_core.SupplierDailyBalance{},
+			&accounting_core.CustomLabelDailyBalance{},
+			&accounting_core.DailyAppliedEntry{},
+			&accounting_core.ProjectionAppliedEntry{},
+		)
+
+		assert.Nil(t, err)
+		return nil
+	}
+
+	jkt := time.FixedZone("WIB", 7*3600)
+	timeRange := &common.TimeFilterRange{
+		StartDate: timestamppb.New(time.Date(2025, 9, 1, 0, 0, 0, 0, jkt)),
+		EndDate:   timestamppb.New(time.Date(2025, 12, 1, 0, 0, 0, 0, jkt)),
+	}
+
+	moretest.Suite(t, "testing report query di sqlite",
+		moretest.SetupListFunc{
+			moretest_mock.MockSqliteDatabase(&db),
+			migrate,
+			accounting_mock.PopulateAccountKey(&db, 1),
+			loadAccount(&db, 1, accounting_core.CashAccount, &cashAcc),
+		},
+		func(t *testing.T) {
+			reportService := NewAccountReportService(
+				&configs.DispatcherConfig{},
+				&configs.AccountingService{},
+				&db,
+				&authorization_mock.EmptyAuthorizationMock{},
+				ware_cache.NewLocalCache(),
+				func(ctx context.Context, req *cloudtaskspb.CreateTaskRequest, opts ...gax.CallOption) error {
+					return nil
+				},
+			)
+
+			entries := []*report_iface.EntryPayload{
+				{
+					AccountId:     uint64(cashAcc.ID),
+					TeamId:        1,
+					TransactionId: 1,
+					EntryTime:     timestamppb.New(time.Date(2025, 10, 1, 10, 0, 0, 0, jkt)),
+					Debit:         1000,
+				},
+				{
+					AccountId:     uint64(cashAcc.ID),
+					TeamId:        1,
+					TransactionId: 2,
+					EntryTime:     timestamppb.New(time.Date(2025, 10, 2, 10, 0, 0, 0, jkt)),
+					Debit:         500,
+				},
+				{
+					AccountId:     uint64(cashAcc.ID),
+					TeamId:        1,
+					TransactionId: 3,
+					EntryTime:     timestamppb.New(time.Date(2025, 10, 2, 11, 0, 0, 0, jkt)),
+					Credit:        200,
+				},
+				{
+					AccountId:     uint64(cashAcc.ID),
+					TeamId:        1,
+					TransactionId: 4,
+					EntryTime:     timestamppb.New(time.Date(2025, 11, 3, 10, 0, 0, 0, jkt)),
+					Credit:        100,
+				},
+			}
+
+			for _, entry := range entries {
+				_, err := reportService.DailyUpdateBalance(t.Context(), &connect.Request[report_iface.DailyUpdateBalanceRequest]{
+					Msg: &report_iface.DailyUpdateBalanceRequest{
+						LabelExtra: &report_iface.TxLabelExtra{},
+						Entries:    []*report_iface.EntryPayload{entry},
+					},
+				})
+				assert.Nil(t, err)
+			}
+
+			t.Run("testing balance", func(t *testing.T) {
+				res, err := reportService.Balance(t.Context(), &connect.Request[report_iface.BalanceRequest]{
+					Msg: &report_iface.BalanceRequest{
+						TeamId:      1,
+						AccountKeys: []string{string(accounting_core.CashAccount)},
+						TimeRange:   timeRange,
+					},
+				})
+				assert.Nil(t, err)
+				assert.Len(t, res.Msg.Data, 1)
+
+				item := res.Msg.Data[0]
+				assert.Equal(t, 1500.0, item.Debit)
+				assert.Equal(t, 300.0, item.Credit)
+				assert.Equal(t, 1200.0, item.Balance)
+			})
+
+			t.Run("testing daily balance", func(t *testing.T) {
+				res, err := reportService.DailyBalance(t.Context(), &connect.Request[report_iface.DailyBalanceRequest]{
+					Msg: &report_iface.DailyBalanceRequest{
+						TeamId:     1,
+						AccountKey: string(accounting_core.CashAccount),
+						TimeRange:  timeRange,
+						Page: &common.PageFilter{
+							Page:  1,
+							Limit: 10,
+						},
+					},
+				})
+				assert.Nil(t, err)
+				assert.Len(t, res.Msg.Data, 3)
+
+				days := map[int64]*report_iface.DailyAccountBalanceItem{}
+				for _, item := range res.Msg.Data {
+					days[item.Day] = item
+				}
+
+				day := time.Date(2025, 10, 2, 0, 0, 0, 0, time.UTC).UnixMicro()
+				assert.NotNil(t, days[day])
+				if days[day] != nil {
+					assert.Equal(t, 500.0, days[day].Debit)
+					assert.Equal(t, 200.0, days[day].Credit)
+					assert.Equal(t, 1300.0, days[day].Balance)
+				}
+			})
+
+			t.Run("testing monthly balance", func(t *testing.T) {
+				res, err := reportService.MonthlyBalance(t.Context(), &connect.Request[report_iface.MonthlyBalanceRequest]{
+					Msg: &report_iface.MonthlyBalanceRequest{
+						TeamId:     1,
+						AccountKey: string(accounting_core.CashAccount),
+						TimeRange:  timeRange,
+						Page: &common.PageFilter{
+							Page:  1,
+							Limit: 10,
+						},
+					},
+				})
+				assert.Nil(t, err)
+				assert.Len(t, res.Msg.Data, 2)
+
+				if len(res.Msg.Data) == 2 {
+					nov := res.Msg.Data[0]
+					oct := res.Msg.Data[1]
+					assert.Greater(t, nov.Month, oct.Month)
+
+					assert.Equal(t, 1500.0, oct.Debit)
+					assert.Equal(t, 200.0, oct.Credit)
+					assert.Equal(t, 1300.0, oct.Balance)
+
+					assert.Equal(t, 100.0, nov.Credit)
+					assert.Equal(t, 1200.0, nov.Balance)
+				}
+			})
+
+			t.Run("testing balance detail", func(t *testing.T) {
+				res, err := reportService.BalanceDetail(t.Context(), &connect.Request[report_iface.BalanceDetailRequest]{
+					Msg: &report_iface.BalanceDetailRequest{
+						TeamId:          1,
+						AccountKey:      string(accounting_core.CashAccount),
+						LabelFilterType: report_iface.LabelFilterType_LABEL_FILTER_TYPE_TEAM,
+						TimeRange:       timeRange,
+						Page: &common.PageFilter{
+							Page:  1,
+							Limit: 10,
+						},
+					},
+				})
+				assert.Nil(t, err)
+				assert.Len(t, res.Msg.Data, 1)
+
+				if len(res.Msg.Data) == 1 {
+					assert.Equal(t, "1", res.Msg.Data[0].LabelId)
+					assert.Equal(t, 1200.0, res.Msg.Data[0].Balance)
+				}
+			})
+		},
+	)
+}
@@ -7,6 +7,7 @@ import (
 
 	"connectrpc.com/connect"
 	"github.com/pdcgo/accounting_service/accounting_core"
+	"github.com/pdcgo/accounting_service/query_dialect"
 	"github.com/pdcgo/schema/services/common/v1"
 	"github.com/pdcgo/schema/services/report_iface/v1"
 	"gorm.io/gorm"
@@ -70,10 +71,11 @@ func (a *accountReportImpl) DailyBalance(
 }
 
 func createDailyReportQ(db *gorm.DB, pay *report_iface.DailyBalanceRequest) *gorm.DB {
+	dialect := query_dialect.NewDialect(db)
 	query := db.
 		Table("account_key_daily_balances adb").
 		Select([]string{
-			"(EXTRACT(EPOCH FROM adb.day) * 1000000)::BIGINT as day",
+			dialect.EpochMicro("adb.day") + " as day",
 			"sum(adb.debit) as debit",
 			"sum(adb.credit) as credit",
 			"sum(adb.balance) as balance",
@@ -6,6 +6,7 @@ import (
 	"math"
 
 	"connectrpc.com/connect"
+	"github.com/pdcgo/accounting_service/query_dialect"
 	"github.com/pdcgo/schema/services/common/v1"
 	"github.com/pdcgo/schema/services/report_iface/v1"
 	"gorm.io/gorm"
@@ -81,6 +82,7 @@ func (a *accountReportImpl) DailyBalanceDetail(
 
 func dailyBalanceDetailQ(db *gorm.DB, pay *report_iface.DailyBalanceDetailRequest) *gorm.DB {
 	var query *gorm.DB
+	dayField := query_dialect.NewDialect(db).EpochMicro("adb.day") + " as day"
 
 	switch pay.LabelFilterType {
 	case report_iface.LabelFilterType_LABEL_FILTER_TYPE_TEAM:
@@ -89,7 +91,7 @@ func dailyBalanceDetailQ(db *gorm.DB, pay *report_iface.DailyBalanceDetailReques
 			Joins("join accounts a on a.id = adb.account_id").
 			Select([]string{
 				"adb.account_id as label_id",
-				"(EXTRACT(EPOCH FROM adb.day) * 1000000)::BIGINT as day",
+				dayField,
 				"adb.debit as debit",
 				"adb.credit as credit",
 				"adb.balance as balance",
@@ -102,7 +104,7 @@ func dailyBalanceDetailQ(db *gorm.DB, pay *report_iface.DailyBalanceDetailReques
 			Joins("join accounts a on a.id = adb.account_id").
 			Select([]string{
 				"adb.shop_id as label_id",
-				"(EXTRACT(EPOCH FROM adb.day) * 1000000)::BIGINT as day",
+				dayField,
 				"adb.debit as debit",
 				"adb.credit as credit",
 				"adb.balance as balance",
@@ -115,7 +117,7 @@ func dailyBalanceDetailQ(db *gorm.DB, pay *report_iface.DailyBalanceDetailReques
 			Joins("join accounts a on a.id = adb.account_id").
 			Select([]string{
 				"adb.supplier_id as label_id",
-				"(EXTRACT(EPOCH FROM adb.day) * 1000000)::BIGINT as day",
+				dayField,
 				"adb.debit as debit",
 				"adb.credit as credit",
 				"adb.balance as balance",
@@ -128,7 +130,7 @@ func dailyBalanceDetailQ(db *gorm.DB, pay *report_iface.DailyBalanceDetailReques
 			Joins("join accounts a on a.id = adb.account_id").
 			Select([]string{
 				"adb.custom_id as label_id",
-				"(EXTRACT(EPOCH FROM adb.day) * 1000000)::BIGINT as day",
+				dayField,
 				"adb.debit as debit",
 				"adb.credit as credit",
 				"adb.balance as balance",
@@ -141,7 +143,7 @@ func dailyBalanceDetailQ(db *gorm.DB, pay *report_iface.DailyBalanceDetailReques
 			Joins("join accounts a on a.id = adb.account_id").
 			Select([]string{
 				"adb.cs_id as label_id",
-				"(EXTRACT(EPOCH FROM adb.day) * 1000000)::BIGINT as day",
+				dayField,
 				"adb.debit as debit",
 				"adb.credit as credit",
 				"adb.balance as balance",
@@ -12,6 +12,7 @@ import (
 	"github.com/pdcgo/schema/services/report_iface/v1"
 	"github.com/pdcgo/shared/pkg/ware_cache"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 func (a *accountReportImpl) getTypeLabel(ctx context.Context, label *accounting_iface.TypeLabel) (*accounting_core.TypeLabel, error) {
@@ -109,228 +110,374 @@ func (a *accountReportImpl) DailyUpdateBalance(
 
 	pay := req.Msg
 	labels := pay.LabelExtra
-	for _, entry := range pay.Entries {
-		day := accounting_core.ParseDate(entry.EntryTime.AsTime())
-		var balance, debit, credit float64
-		account, err := a.getAccount(ctx, uint(entry.AccountId))
-		if err != nil {
-			return &connect.Response[report_iface.DailyUpdateBalanceResponse]{}, err
-		}
 
-		// debugtool.LogJson(account)
+	var appliedCount, skipped int
+	// semua increment dan catatan entry yang sudah diapply masuk satu transaction,
+	// jadi redelivery task tidak menghitung ulang balance
+	err = a.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		appliedCount, skipped, err = a.applyEntries(ctx, tx, labels, pay.Entries)
+		return err
+	})
 
-		if !entry.Rollback {
-			debit = entry.Debit
-			credit = entry.Credit
-		} else {
-			debit = entry.Credit * -1
-			credit = entry.Debit * -1
-		}
+	result := report_iface.DailyUpdateBalanceResponse{}
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
 
-		switch account.BalanceType {
-		case accounting_core.DebitBalance:
-			balance = entry.Debit - entry.Credit
-		case accounting_core.CreditBalance:
-			balance = entry.Credit - entry.Debit
-		default:
-			return &connect.Response[report_iface.DailyUpdateBalanceResponse]{}, errors.New("account not credit or debit")
-		}
+	result.AppliedCount = int64(appliedCount)
+	result.SkippedCount = int64(skipped)
+	return connect.NewResponse(&result), nil
+}
 
-		keyDailyBalance := &accounting_core.AccountKeyDailyBalance{
-			Day:           day,
-			JournalTeamID: uint(entry.TeamId),
-			AccountKey:    account.AccountKey,
-			Debit:         debit,
-			Credit:        credit,
-			Balance:       balance,
-		}
+// dailyEntry journal entry yang sudah dihitung debit credit dan balancenya
+type dailyEntry struct {
+	entry   *report_iface.EntryPayload
+	labels  *report_iface.TxLabelExtra
+	day     time.Time
+	debit   float64
+	credit  float64
+	balance float64
+	account *accounting_core.Account
+}
 
-		err = a.updateDailyBalance(
-			a.
-				db.
-				Model(&accounting_core.AccountKeyDailyBalance{}).
-				Where("day = ?", keyDailyBalance.Day).
-				Where("account_key = ?", keyDailyBalance.AccountKey).
-				Where("journal_team_id = ?", keyDailyBalance.JournalTeamID),
-			keyDailyBalance,
-		)
+// dailyTable satu tabel daily balance, name dipakai sebagai nama projection dan catatan entry yang sudah diapply
+type dailyTable struct {
+	name  string
+	apply func(ctx context.Context, tx *gorm.DB, row *dailyEntry) error
+}
 
+// dailyTables semua tabel daily balance yang diisi dari journal entry
+func (a *accountReportImpl) dailyTables() []*dailyTable {
+	return []*dailyTable{
+		{name: "account_key_daily_balances", apply: a.applyAccountKeyDaily},
+		{name: "account_daily_balances", apply: a.applyAccountDaily},
+		{name: "cs_daily_balances", apply: a.applyCsDaily},
+		{name: "shop_daily_balances", apply: a.applyShopDaily},
+		{name: "supplier_daily_balances", apply: a.applySupplierDaily},
+		{name: "custom_label_daily_balances", apply: a.applyCustomLabelDaily},
+		{name: "type_label_daily_balances", apply: a.applyTypeLabelDaily},
+	}
+}
+
+// applyEntries menambahkan entries ke tabel daily balance memakai tx yang diberikan, tables kosong berarti semua.
+// dipakai DailyUpdateBalance (push) dan BalanceProjector (pull). entry dihitung applied kalau masuk ke minimal satu tabel
+func (a *accountReportImpl) applyEntries(
+	ctx context.Context,
+	tx *gorm.DB,
+	labels *report_iface.TxLabelExtra,
+	entries []*report_iface.EntryPayload,
+	tables ...*dailyTable,
+) (appliedCount int, skipped int, err error) {
+	if labels == nil {
+		labels = &report_iface.TxLabelExtra{}
+	}
+	if len(tables) == 0 {
+		tables = a.dailyTables()
+	}
+
+	for _, entry := range entries {
+		legacy, err := a.legacyApplied(tx, entry)
 		if err != nil {
-			return &connect.Response[report_iface.DailyUpdateBalanceResponse]{}, err
+			return appliedCount, skipped, err
 		}
-
-		dayBalance := &accounting_core.AccountDailyBalance{
-			Day:           day,
-			AccountID:     uint(entry.AccountId),
-			JournalTeamID: uint(entry.TeamId),
-			Debit:         debit,
-			Credit:        credit,
-			Balance:       balance,
+		if legacy {
+			skipped += 1
+			continue
 		}
 
-		err = a.updateDailyBalance(
-			a.
-				db.
-				Model(&accounting_core.AccountDailyBalance{}).
-				Where("day = ?", dayBalance.Day).
-				Where("account_id = ?", dayBalance.AccountID).
-				Where("journal_team_id = ?", dayBalance.JournalTeamID),
-			dayBalance,
-		)
-
+		row, err := a.dailyEntry(ctx, labels, entry)
 		if err != nil {
-			return &connect.Response[report_iface.DailyUpdateBalanceResponse]{}, err
+			return appliedCount, skipped, err
 		}
 
-		if labels.CsId != 0 {
-			csDayBalance := &accounting_core.CsDailyBalance{
-				Day:           day,
-				CsID:          uint(labels.CsId),
-				AccountID:     uint(entry.AccountId),
-				JournalTeamID: uint(entry.TeamId),
-				Debit:         debit,
-				Credit:        credit,
-				Balance:       balance,
+		applied := false
+		for _, table := range tables {
+			ok, err := a.markEntryApplied(tx, table.name, entry)
+			if err != nil {
+				return appliedCount, skipped, err
+			}
+			if !ok {
+				continue
 			}
 
-			err = a.updateDailyBalance(
-				a.
-					db.
-					Model(&accounting_core.CsDailyBalance{}).
-					Where("cs_id = ?", csDayBalance.CsID).
-					Where("day = ?", csDayBalance.Day).
-					Where("account_id = ?", csDayBalance.AccountID).
-					Where("journal_team_id = ?", csDayBalance.JournalTeamID),
-				csDayBalance,
-			)
+			applied = true
+			err = table.apply(ctx, tx, row)
 			if err != nil {
-				return &connect.Response[report_iface.DailyUpdateBalanceResponse]{}, err
+				return appliedCount, skipped, err
 			}
 		}
 
-		if labels.ShopId != 0 {
-			shopDayBalance := &accounting_core.ShopDailyBalance{
-				Day:           day,
-				ShopID:        uint(labels.ShopId),
-				AccountID:     uint(entry.AccountId),
-				JournalTeamID: uint(entry.TeamId),
-				Debit:         debit,
-				Credit:        credit,
-				Balance:       balance,
-			}
+		if applied {
+			appliedCount += 1
+		} else {
+			skipped += 1
+		}
+	}
 
-			err = a.updateDailyBalance(
-				a.
-					db.
-					Model(&accounting_core.ShopDailyBalance{}).
-					Where("shop_id = ?", shopDayBalance.ShopID).
-					Where("day = ?", shopDayBalance.Day).
-					Where("account_id = ?", shopDayBalance.AccountID).
-					Where("journal_team_id = ?", shopDayBalance.JournalTeamID),
-				shopDayBalance,
-			)
-			if err != nil {
-				return &connect.Response[report_iface.DailyUpdateBalanceResponse]{}, err
-			}
+	return appliedCount, skipped, nil
+}
+
+func (a *accountReportImpl) dailyEntry(ctx context.Context, labels *report_iface.TxLabelExtra, entry *report_iface.EntryPayload) (*dailyEntry, error) {
+	account, err := a.getAccount(ctx, uint(entry.AccountId))
+	if err != nil {
+		return nil, err
+	}
+
+	row := dailyEntry{
+		entry:   entry,
+		labels:  labels,
+		day:     accounting_core.ParseDate(entry.EntryTime.AsTime()),
+		account: account,
+	}
+
+	if !entry.Rollback {
+		row.debit = entry.Debit
+		row.credit = entry.Credit
+	} else {
+		row.debit = entry.Credit * -1
+		row.credit = entry.Debit * -1
+	}
+
+	switch account.BalanceType {
+	case accounting_core.DebitBalance:
+		row.balance = entry.Debit - entry.Credit
+	case accounting_core.CreditBalance:
+		row.balance = entry.Credit - entry.Debit
+	default:
+		return nil, errors.New("account not credit or debit")
+	}
+
+	return &row, nil
+}
+
+func (a *accountReportImpl) applyAccountKeyDaily(ctx context.Context, tx *gorm.DB, row *dailyEntry) error {
+	keyDailyBalance := &accounting_core.AccountKeyDailyBalance{
+		Day:           row.day,
+		JournalTeamID: uint(row.entry.TeamId),
+		AccountKey:    row.account.AccountKey,
+		Debit:         row.debit,
+		Credit:        row.credit,
+		Balance:       row.balance,
+	}
+
+	return a.updateDailyBalance(
+		tx,
+		tx.
+			Model(&accounting_core.AccountKeyDailyBalance{}).
+			Where("day = ?", keyDailyBalance.Day).
+			Where("account_key = ?", keyDailyBalance.AccountKey).
+			Where("journal_team_id = ?", keyDailyBalance.JournalTeamID),
+		keyDailyBalance,
+	)
+}
+
+func (a *accountReportImpl) applyAccountDaily(ctx context.Context, tx *gorm.DB, row *dailyEntry) error {
+	dayBalance := &accounting_core.AccountDailyBalance{
+		Day:           row.day,
+		AccountID:     uint(row.entry.AccountId),
+		JournalTeamID: uint(row.entry.TeamId),
+		Debit:         row.debit,
+		Credit:        row.credit,
+		Balance:       row.balance,
+	}
 
+	return a.updateDailyBalance(
+		tx,
+		tx.
+			Model(&accounting_core.AccountDailyBalance{}).
+			Where("day = ?", dayBalance.Day).
+			Where("account_id = ?", dayBalance.AccountID).
+			Where("journal_team_id = ?", dayBalance.JournalTeamID),
+		dayBalance,
+	)
+}
+
+func (a *accountReportImpl) applyCsDaily(ctx context.Context, tx *gorm.DB, row *dailyEntry) error {
+	if row.labels.CsId == 0 {
+		return nil
+	}
+
+	csDayBalance := &accounting_core.CsDailyBalance{
+		Day:           row.day,
+		CsID:          uint(row.labels.CsId),
+		AccountID:     uint(row.entry.AccountId),
+		JournalTeamID: uint(row.entry.TeamId),
+		Debit:         row.debit,
+		Credit:        row.credit,
+		Balance:       row.balance,
+	}
+
+	return a.updateDailyBalance(
+		tx,
+		tx.
+			Model(&accounting_core.CsDailyBalance{}).
+			Where("cs_id = ?", csDayBalance.CsID).
+			Where("day = ?", csDayBalance.Day).
+			Where("account_id = ?", csDayBalance.AccountID).
+			Where("journal_team_id = ?", csDayBalance.JournalTeamID),
+		csDayBalance,
+	)
+}
+
+func (a *accountReportImpl) applyShopDaily(ctx context.Context, tx *gorm.DB, row *dailyEntry) error {
+	if row.labels.ShopId == 0 {
+		return nil
+	}
+
+	shopDayBalance := &accounting_core.ShopDailyBalance{
+		Day:           row.day,
+		ShopID:        uint(row.labels.ShopId),
+		AccountID:     uint(row.entry.AccountId),
+		JournalTeamID: uint(row.entry.TeamId),
+		Debit:         row.debit,
+		Credit:        row.credit,
+		Balance:       row.balance,
+	}
+
+	return a.updateDailyBalance(
+		tx,
+		tx.
+			Model(&accounting_core.ShopDailyBalance{}).
+			Where("shop_id = ?", shopDayBalance.ShopID).
+			Where("day = ?", shopDayBalance.Day).
+			Where("account_id = ?", shopDayBalance.AccountID).
+			Where("journal_team_id = ?", shopDayBalance.JournalTeamID),
+		shopDayBalance,
+	)
+}
+
+func (a *accountReportImpl) applySupplierDaily(ctx context.Context, tx *gorm.DB, row *dailyEntry) error {
+	if row.labels.SupplierId == 0 {
+		return nil
+	}
+
+	supplierDayBalance := &accounting_core.SupplierDailyBalance{
+		Day:           row.day,
+		SupplierID:    uint(row.labels.SupplierId),
+		AccountID:     uint(row.entry.AccountId),
+		JournalTeamID: uint(row.entry.TeamId),
+		Debit:         row.debit,
+		Credit:        row.credit,
+		Balance:       row.balance,
+	}
+
+	return a.updateDailyBalance(
+		tx,
+		tx.
+			Model(&accounting_core.SupplierDailyBalance{}).
+			Where("supplier_id = ?", supplierDayBalance.SupplierID).
+			Where("day = ?", supplierDayBalance.Day).
+			Where("account_id = ?", supplierDayBalance.AccountID).
+			Where("journal_team_id = ?", supplierDayBalance.JournalTeamID),
+		supplierDayBalance,
+	)
+}
+
+func (a *accountReportImpl) applyCustomLabelDaily(ctx context.Context, tx *gorm.DB, row *dailyEntry) error {
+	for _, tagID := range row.labels.TagIds {
+		customDayBalance := &accounting_core.CustomLabelDailyBalance{
+			Day:           row.day,
+			CustomID:      uint(tagID),
+			AccountID:     uint(row.entry.AccountId),
+			JournalTeamID: uint(row.entry.TeamId),
+			Debit:         row.debit,
+			Credit:        row.credit,
+			Balance:       row.balance,
 		}
 
-		if labels.SupplierId != 0 {
-			supplierDayBalance := &accounting_core.SupplierDailyBalance{
-				Day:           day,
-				SupplierID:    uint(labels.SupplierId),
-				AccountID:     uint(entry.AccountId),
-				JournalTeamID: uint(entry.TeamId),
-				Debit:         debit,
-				Credit:        credit,
-				Balance:       balance,
-			}
+		err := a.updateDailyBalance(
+			tx,
+			tx.
+				Model(&accounting_core.CustomLabelDailyBalance{}).
+				Where("custom_id = ?", customDayBalance.CustomID).
+				Where("day = ?", customDayBalance.Day).
+				Where("account_id = ?", customDayBalance.AccountID).
+				Where("journal_team_id = ?", customDayBalance.JournalTeamID),
+			customDayBalance,
+		)
+		if err != nil {
+			return err
+		}
+	}
 
-			err = a.updateDailyBalance(
-				a.
-					db.
-					Model(&accounting_core.SupplierDailyBalance{}).
-					Where("supplier_id = ?", supplierDayBalance.SupplierID).
-					Where("day = ?", supplierDayBalance.Day).
-					Where("account_id = ?", supplierDayBalance.AccountID).
-					Where("journal_team_id = ?", supplierDayBalance.JournalTeamID),
-				supplierDayBalance,
-			)
+	return nil
+}
 
-			if err != nil {
-				return &connect.Response[report_iface.DailyUpdateBalanceResponse]{}, err
-			}
+func (a *accountReportImpl) applyTypeLabelDaily(ctx context.Context, tx *gorm.DB, row *dailyEntry) error {
+	for _, label := range row.labels.TypeLabels {
+		dlabel, err := a.getTypeLabel(ctx, label)
+		if err != nil {
+			return err
 		}
 
-		if labels.TagIds != nil {
-			for _, tagID := range labels.TagIds {
-
-				customDayBalance := &accounting_core.CustomLabelDailyBalance{
-					Day:           day,
-					CustomID:      uint(tagID),
-					AccountID:     uint(entry.AccountId),
-					JournalTeamID: uint(entry.TeamId),
-					Debit:         debit,
-					Credit:        credit,
-					Balance:       balance,
-				}
-
-				err = a.updateDailyBalance(
-					a.
-						db.
-						Model(&accounting_core.CustomLabelDailyBalance{}).
-						Where("custom_id = ?", customDayBalance.CustomID).
-						Where("day = ?", customDayBalance.Day).
-						Where("account_id = ?", customDayBalance.AccountID).
-						Where("journal_team_id = ?", customDayBalance.JournalTeamID),
-					customDayBalance,
-				)
-
-				if err != nil {
-					return &connect.Response[report_iface.DailyUpdateBalanceResponse]{}, err
-				}
-			}
+		typeDayBalance := &accounting_core.TypeLabelDailyBalance{
+			Day:           row.day,
+			LabelID:       dlabel.ID,
+			AccountID:     uint(row.entry.AccountId),
+			JournalTeamID: uint(row.entry.TeamId),
+			Debit:         row.debit,
+			Credit:        row.credit,
+			Balance:       row.balance,
 		}
 
-		if labels.TypeLabels != nil {
-			for _, label := range labels.TypeLabels {
-				var dlabel *accounting_core.TypeLabel
-				dlabel, err = a.getTypeLabel(ctx, label)
-				if err != nil {
-					return &connect.Response[report_iface.DailyUpdateBalanceResponse]{}, err
-				}
-				typeDayBalance := &accounting_core.TypeLabelDailyBalance{
-					Day:           day,
-					LabelID:       dlabel.ID,
-					AccountID:     uint(entry.AccountId),
-					JournalTeamID: uint(entry.TeamId),
-					Debit:         debit,
-					Credit:        credit,
-					Balance:       balance,
-				}
-
-				err = a.updateDailyBalance(
-					a.
-						db.
-						Model(&accounting_core.TypeLabelDailyBalance{}).
-						Where("label_id = ?", typeDayBalance.LabelID).
-						Where("day = ?", typeDayBalance.Day).
-						Where("account_id = ?", typeDayBalance.AccountID).
-						Where("journal_team_id = ?", typeDayBalance.JournalTeamID),
-					typeDayBalance,
-				)
-
-				if err != nil {
-					return &connect.Response[report_iface.DailyUpdateBalanceResponse]{}, err
-				}
-			}
+		err = a.updateDailyBalance(
+			tx,
+			tx.
+				Model(&accounting_core.TypeLabelDailyBalance{}).
+				Where("label_id = ?", typeDayBalance.LabelID).
+				Where("day = ?", typeDayBalance.Day).
+				Where("account_id = ?", typeDayBalance.AccountID).
+				Where("journal_team_id = ?", typeDayBalance.JournalTeamID),
+			typeDayBalance,
+		)
+		if err != nil {
+			return err
 		}
 	}
 
-	return &connect.Response[report_iface.DailyUpdateBalanceResponse]{}, err
+	return nil
+}
+
+// legacyApplied entry yang sudah tercatat di DailyAppliedEntry sudah masuk ke semua tabel
+func (a *accountReportImpl) legacyApplied(tx *gorm.DB, entry *report_iface.EntryPayload) (bool, error) {
+	if entry.Id == 0 {
+		return false, nil
+	}
+
+	var count int64
+	err := tx.
+		Model(&accounting_core.DailyAppliedEntry{}).
+		Where("entry_id = ?", entry.Id).
+		Count(&count).
+		Error
+
+	return count > 0, err
+}
+
+// markEntryApplied mencatat entry id untuk satu tabel, false kalau entry sudah pernah diapply ke tabel itu.
+// entry tanpa id tidak bisa dicek jadi selalu diapply.
+func (a *accountReportImpl) markEntryApplied(tx *gorm.DB, projection string, entry *report_iface.EntryPayload) (bool, error) {
+	if entry.Id == 0 {
+		return true, nil
+	}
+
+	res := tx.
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&accounting_core.ProjectionAppliedEntry{
+			Projection: projection,
+			EntryID:    uint(entry.Id),
+			TeamID:     uint(entry.TeamId),
+			Applied:    time.Now(),
+		})
+
+	if res.Error != nil {
+		return false, res.Error
+	}
+
+	return res.RowsAffected > 0, nil
 }
 
-func (a *accountReportImpl) updateDailyBalance(query *gorm.DB, daily accounting_core.DailyBalance) error {
+func (a *accountReportImpl) updateDailyBalance(tx *gorm.DB, query *gorm.DB, daily accounting_core.DailyBalance) error {
 	var err error
 	var incBalance float64
 
@@ -352,33 +499,25 @@ func (a *accountReportImpl) updateDailyBalance(query *gorm.DB, daily accounting_
 
 		var beforeBalance float64
 
-		err = a.db.Transaction(func(tx *gorm.DB) error {
-			err = daily.
-				Before(tx, true).
-				Select([]string{
-					"balance",
-				}).
-				Order("day desc").
-				Limit(1).
-				Find(&beforeBalance).
-				Error
-
-			if err != nil {
-				return err
-			}
-
-			daily.AddBalance(beforeBalance)
-			daily.AddStartBalance(beforeBalance)
-			err = tx.
-				Save(daily).
-				Error
+		err = daily.
+			Before(tx, true).
+			Select([]string{
+				"balance",
+			}).
+			Order("day desc").
+			Limit(1).
+			Find(&beforeBalance).
+			Error
 
-			if err != nil {
-				return err
-			}
+		if err != nil {
+			return err
+		}
 
-			return nil
-		})
+		daily.AddBalance(beforeBalance)
+		daily.AddStartBalance(beforeBalance)
+		err = tx.
+			Save(daily).
+			Error
 
 		if err != nil {
 			return err
@@ -387,15 +526,15 @@ func (a *accountReportImpl) updateDailyBalance(query *gorm.DB, daily accounting_
 		incBalance += beforeBalance
 	}
 
-	err = a.updateAfterIncrement(daily, incBalance)
+	err = a.updateAfterIncrement(tx, daily, incBalance)
 
 	return err
 
 }
 
-func (a *accountReportImpl) updateAfterIncrement(daily accounting_core.DailyBalance, incAmount float64) error {
+func (a *accountReportImpl) updateAfterIncrement(tx *gorm.DB, daily accounting_core.DailyBalance, incAmount float64) error {
 	err := daily.
-		After(a.db, false).
+		After(tx, false).
 		Updates(map[string]interface{}{
 			"balance": gorm.Expr("balance + ?", incAmount),
 		}).
@@ -51,6 +51,8 @@ func TestAccountKeyDailyBalance(t *testing.T) {
 			&accounting_core.ShopDailyBalance{},
 			&accounting_core.SupplierDailyBalance{},
 			&accounting_core.CustomLabelDailyBalance{},
+			&accounting_core.DailyAppliedEntry{},
+			&accounting_core.ProjectionAppliedEntry{},
 			&accounting_core.TypeLabelDailyBalance{},
 			&accounting_core.TypeLabel{},
 			&accounting_core.TransactionTypeLabel{},
@@ -121,7 +123,7 @@ func TestAccountKeyDailyBalance(t *testing.T) {
 								Credit:        0,
 							},
 							{
-								Id:            1,
+								Id:            2,
 								AccountId:     uint64(hutangAcc2.ID),
 								TeamId:        1,
 								EntryTime:     timestamppb.Now(),
@@ -131,7 +133,7 @@ func TestAccountKeyDailyBalance(t *testing.T) {
 								Credit:        12000,
 							},
 							{
-								Id:            1,
+								Id:            3,
 								AccountId:     uint64(hutangAcc2.ID),
 								TeamId:        1,
 								EntryTime:     timestamppb.New(time.Now().AddDate(0, 0, -1)),
@@ -163,6 +165,54 @@ func TestAccountKeyDailyBalance(t *testing.T) {
 				})
 			})
 
+			t.Run("testing redelivery entry", func(t *testing.T) {
+				var before float64
+				err := db.
+					Model(&accounting_core.AccountKeyDailyBalance{}).
+					Select("sum(debit)").
+					Find(&before).
+					Error
+				assert.Nil(t, err)
+
+				res, err := reportService.DailyUpdateBalance(t.Context(), &connect.Request[report_iface.DailyUpdateBalanceRequest]{
+					Msg: &report_iface.DailyUpdateBalanceRequest{
+						LabelExtra: &report_iface.TxLabelExtra{},
+						Entries: []*report_iface.EntryPayload{
+							{
+								Id:            1,
+								AccountId:     uint64(hutangAcc2.ID),
+								TeamId:        1,
+								EntryTime:     timestamppb.Now(),
+								Debit:         12000,
+								Desc:          "today",
+								TransactionId: 1,
+							},
+							{
+								Id:            4,
+								AccountId:     uint64(hutangAcc2.ID),
+								TeamId:        1,
+								EntryTime:     timestamppb.Now(),
+								Debit:         1000,
+								Desc:          "today",
+								TransactionId: 2,
+							},
+						},
+					},
+				})
+				assert.Nil(t, err)
+				assert.Equal(t, int64(1), res.Msg.AppliedCount)
+				assert.Equal(t, int64(1), res.Msg.SkippedCount)
+
+				var after float64
+				err = db.
+					Model(&accounting_core.AccountKeyDailyBalance{}).
+					Select("sum(debit)").
+					Find(&after).
+					Error
+				assert.Nil(t, err)
+				assert.Equal(t, before+1000, after)
+			})
+
 		},
 	)
 
@@ -180,6 +230,8 @@ func TestDailyUpdateBalance(t *testing.T) {
 			&accounting_core.ShopDailyBalance{},
 			&accounting_core.SupplierDailyBalance{},
 			&accounting_core.CustomLabelDailyBalance{},
+			&accounting_core.DailyAppliedEntry{},
+			&accounting_core.ProjectionAppliedEntry{},
 		)
 
 		assert.Nil(t, err)
@@ -286,6 +338,8 @@ func TestDailyBalance(t *testing.T) {
 			&accounting_core.AccountKeyDailyBalance{},
 			&accounting_core.AccountDailyBalance{},
 			&accounting_core.ShopDailyBalance{},
+			&accounting_core.DailyAppliedEntry{},
+			&accounting_core.ProjectionAppliedEntry{},
 		)
 
 		assert.Nil(t, err)
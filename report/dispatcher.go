@@ -25,10 +25,18 @@ func NewLocalReportDispatcher() ReportDispatcher {
 
 		htreq, err := http.NewRequest(http.MethodPost, httpreq.GetUrl(), bytes.NewBuffer(httpreq.Body))
 		if err != nil {
-			return nil
+			return err
 		}
-		_, err = http.DefaultClient.Do(htreq)
 
-		return err
+		for k, v := range httpreq.Headers {
+			htreq.Header.Set(k, v)
+		}
+
+		res, err := http.DefaultClient.Do(htreq)
+		if err != nil {
+			return err
+		}
+
+		return res.Body.Close()
 	}
 }
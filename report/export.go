@@ -0,0 +1,183 @@
+package report
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/pdcgo/accounting_service/export"
+	"github.com/pdcgo/schema/services/accounting_iface/v1"
+	"github.com/pdcgo/schema/services/common/v1"
+	"github.com/pdcgo/schema/services/report_iface/v1"
+)
+
+// exportRange range export, kosong berarti semua periode
+func exportRange(trange *common.TimeFilterRange) *common.TimeFilterRange {
+	if trange == nil {
+		return &common.TimeFilterRange{}
+	}
+	return trange
+}
+
+// exportPeriod periode header dokumen export, kosong kalau range tidak diisi
+func exportPeriod(trange *common.TimeFilterRange) (start, end time.Time) {
+	if trange.StartDate.IsValid() {
+		start = trange.StartDate.AsTime()
+	}
+	if trange.EndDate.IsValid() {
+		end = trange.EndDate.AsTime()
+	}
+	return start, end
+}
+
+// BalanceExport export saldo per account key
+func (a *accountReportImpl) BalanceExport(
+	ctx context.Context,
+	req *connect.Request[report_iface.BalanceExportRequest],
+	stream *connect.ServerStream[accounting_iface.ExportChunk],
+) error {
+	var err error
+	pay := req.Msg
+
+	err = a.
+		auth.
+		AuthIdentityFromHeader(req.Header()).
+		Err()
+
+	if err != nil {
+		return err
+	}
+
+	if pay.TeamId == 0 {
+		return connect.NewError(connect.CodeInvalidArgument, errors.New("team_id required"))
+	}
+
+	db := a.db.WithContext(ctx)
+	trange := exportRange(pay.TimeRange)
+
+	items := []*report_iface.AccountBalanceItem{}
+	view := NewBalanceView(db, &report_iface.BalanceRequest{
+		TeamId:      pay.TeamId,
+		AccountKeys: pay.AccountKeys,
+		TimeRange:   trange,
+		Sort: &report_iface.BalanceListSort{
+			Field: report_iface.BalanceFieldSort_BALANCE_FIELD_SORT_ACCOUNT,
+			Type:  common.SortType_SORT_TYPE_ASC,
+		},
+	})
+	err = view.Iterate(func(d *report_iface.AccountBalanceItem) error {
+		items = append(items, d)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	start, end := exportPeriod(trange)
+	doc := export.Document{
+		Title: "Balance",
+		Start: start,
+		End:   end,
+		Columns: []*export.Column{
+			{Header: "account", Kind: export.TextColumn, Width: 30},
+			{Header: "start_balance", Kind: export.NumberColumn, Width: 18},
+			{Header: "debit", Kind: export.NumberColumn, Width: 18},
+			{Header: "credit", Kind: export.NumberColumn, Width: 18},
+			{Header: "balance", Kind: export.NumberColumn, Width: 18},
+		},
+	}
+
+	doc.TeamName, err = export.TeamName(db, pay.TeamId)
+	if err != nil {
+		return err
+	}
+
+	return export.Stream(stream, pay.Format, &doc, int64(len(items)), func(w export.Writer) error {
+		for _, item := range items {
+			err := w.WriteRow(item.AccountKey, item.StartBalance, item.Debit, item.Credit, item.Balance)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// MonthlyBalanceExport export saldo bulanan satu account key
+func (a *accountReportImpl) MonthlyBalanceExport(
+	ctx context.Context,
+	req *connect.Request[report_iface.MonthlyBalanceExportRequest],
+	stream *connect.ServerStream[accounting_iface.ExportChunk],
+) error {
+	var err error
+	pay := req.Msg
+
+	err = a.
+		auth.
+		AuthIdentityFromHeader(req.Header()).
+		Err()
+
+	if err != nil {
+		return err
+	}
+
+	if pay.TeamId == 0 || pay.AccountKey == "" {
+		return connect.NewError(connect.CodeInvalidArgument, errors.New("team_id and account_key required"))
+	}
+
+	db := a.db.WithContext(ctx)
+	trange := exportRange(pay.TimeRange)
+
+	view := monthlyViewImpl{
+		db: db,
+		pay: &report_iface.MonthlyBalanceRequest{
+			TeamId:     pay.TeamId,
+			AccountKey: pay.AccountKey,
+			TimeRange:  trange,
+			Sort: &report_iface.MonthlyListSort{
+				Type: common.SortType_SORT_TYPE_ASC,
+			},
+		},
+	}
+
+	items := []*report_iface.MonthlyAccountBalanceItem{}
+	err = view.
+		baseQ().
+		Find(&items).
+		Error
+	if err != nil {
+		return err
+	}
+
+	start, end := exportPeriod(trange)
+	doc := export.Document{
+		Title: "Monthly Balance " + pay.AccountKey,
+		Start: start,
+		End:   end,
+		Columns: []*export.Column{
+			{Header: "month", Kind: export.TextColumn, Width: 12},
+			{Header: "start_balance", Kind: export.NumberColumn, Width: 18},
+			{Header: "debit", Kind: export.NumberColumn, Width: 18},
+			{Header: "credit", Kind: export.NumberColumn, Width: 18},
+			{Header: "balance", Kind: export.NumberColumn, Width: 18},
+		},
+	}
+
+	doc.TeamName, err = export.TeamName(db, pay.TeamId)
+	if err != nil {
+		return err
+	}
+
+	return export.Stream(stream, pay.Format, &doc, int64(len(items)), func(w export.Writer) error {
+		for _, item := range items {
+			// month sudah awal bulan timezone laporan, disimpan sebagai utc
+			month := time.UnixMicro(item.Month).UTC().Format("2006-01")
+			err := w.WriteRow(month, item.StartBalance, item.Debit, item.Credit, item.Balance)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
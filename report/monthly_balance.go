@@ -7,6 +7,7 @@ import (
 
 	"connectrpc.com/connect"
 	"github.com/pdcgo/accounting_service/accounting_core"
+	"github.com/pdcgo/accounting_service/query_dialect"
 	"github.com/pdcgo/schema/services/common/v1"
 	"github.com/pdcgo/schema/services/report_iface/v1"
 	"gorm.io/gorm"
@@ -81,19 +82,20 @@ type monthlyViewImpl struct {
 
 func (m *monthlyViewImpl) balanceQ() *gorm.DB {
 	pay := m.pay
+	dialect := query_dialect.NewDialect(m.db)
+	month := dialect.TruncMonth("db.day")
+
+	// balance terakhir tiap bulan
 	keyBalanceQ := m.
 		db.
 		Table("account_key_daily_balances db").
-		Select(`
-		distinct on (
-			db.account_key,
-			date_trunc('month', db.day AT TIME ZONE 'Asia/Jakarta')
-		)
-		db.account_key,
-		date_trunc('month', db.day AT TIME ZONE 'Asia/Jakarta') as month,
-		db.balance,
-		db.start_balance
-	`).
+		Select([]string{
+			"db.account_key",
+			month + " as month",
+			"db.balance",
+			"db.start_balance",
+			fmt.Sprintf("row_number() over (partition by db.account_key, %s order by db.day desc) as rn", month),
+		}).
 		Where("db.journal_team_id = ?", pay.TeamId).
 		Where("db.account_key = ?", pay.AccountKey)
 
@@ -112,22 +114,15 @@ func (m *monthlyViewImpl) balanceQ() *gorm.DB {
 		)
 	}
 
-	keyBalanceQ = keyBalanceQ.
-		Order(`
-		db.account_key,
-		month,
-		db.day desc
-	`)
-
 	bquery := m.
 		db.
 		Table("(?) as bal", keyBalanceQ).
 		Select([]string{
-			"(EXTRACT(EPOCH FROM bal.month) * 1000000)::BIGINT as month",
-			// "bal.month",
+			dialect.EpochMicro("bal.month") + " as month",
 			"sum(bal.balance) as balance",
 			"sum(bal.start_balance) as start_balance",
 		}).
+		Where("bal.rn = 1").
 		Group("bal.month")
 
 	return bquery
@@ -135,12 +130,13 @@ func (m *monthlyViewImpl) balanceQ() *gorm.DB {
 
 func (m *monthlyViewImpl) debitCreditQ() *gorm.DB {
 	pay := m.pay
+	dialect := query_dialect.NewDialect(m.db)
 
 	query := m.
 		db.
 		Table("account_key_daily_balances adb").
 		Select([]string{
-			"(EXTRACT(EPOCH FROM date_trunc('month', adb.day)) * 1000000)::BIGINT as month",
+			dialect.EpochMicro(dialect.TruncMonth("adb.day")) + " as month",
 			"sum(adb.debit) as debit",
 			"sum(adb.credit) as credit",
 		}).
@@ -181,6 +177,7 @@ func (m *monthlyViewImpl) baseQ() *gorm.DB {
 	debitCredit := m.debitCreditQ()
 	balance := m.balanceQ()
 
+	// bulan di bal selalu ada di dc, karena diambil dari baris yang sama
 	query := m.
 		db.
 		Table("(?) as dc", debitCredit).
@@ -188,10 +185,10 @@ func (m *monthlyViewImpl) baseQ() *gorm.DB {
 			"dc.month",
 			"dc.debit",
 			"dc.credit",
-			"bal.balance",
-			"bal.start_balance",
+			"coalesce(bal.balance, 0) as balance",
+			"coalesce(bal.start_balance, 0) as start_balance",
 		}).
-		Joins("full outer join (?) as bal on bal.month = dc.month", balance)
+		Joins("left join (?) as bal on bal.month = dc.month", balance)
 
 	if pay.Sort != nil {
 		var sorttype string
@@ -205,11 +202,11 @@ func (m *monthlyViewImpl) baseQ() *gorm.DB {
 		}
 
 		query = query.
-			Order(fmt.Sprintf("bal.month, dc.month %s", sorttype))
+			Order(fmt.Sprintf("dc.month %s", sorttype))
 
 	} else {
 		query = query.
-			Order("bal.month, dc.month desc")
+			Order("dc.month desc")
 	}
 
 	return query
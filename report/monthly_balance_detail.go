@@ -6,6 +6,7 @@ import (
 	"math"
 
 	"connectrpc.com/connect"
+	"github.com/pdcgo/accounting_service/query_dialect"
 	"github.com/pdcgo/schema/services/common/v1"
 	"github.com/pdcgo/schema/services/report_iface/v1"
 	"gorm.io/gorm"
@@ -82,6 +83,8 @@ func (a *accountReportImpl) MonthlyBalanceDetail(
 
 func monthlyBalanceDetailQ(db *gorm.DB, pay *report_iface.MonthlyBalanceDetailRequest) *gorm.DB {
 	var query *gorm.DB
+	dialect := query_dialect.NewDialect(db)
+	monthField := dialect.EpochMicro(dialect.TruncMonth("adb.day")) + " as month"
 
 	switch pay.LabelFilterType {
 	case report_iface.LabelFilterType_LABEL_FILTER_TYPE_TEAM:
@@ -90,7 +93,7 @@ func monthlyBalanceDetailQ(db *gorm.DB, pay *report_iface.MonthlyBalanceDetailRe
 			Joins("join accounts a on a.id = adb.account_id").
 			Select([]string{
 				"adb.account_id as label_id",
-				"(EXTRACT(EPOCH FROM date_trunc('month', adb.day)) * 1000000)::BIGINT as month",
+				monthField,
 				"sum(adb.debit) as debit",
 				"sum(adb.credit) as credit",
 				"sum(adb.balance) as balance",
@@ -103,7 +106,7 @@ func monthlyBalanceDetailQ(db *gorm.DB, pay *report_iface.MonthlyBalanceDetailRe
 			Joins("join accounts a on a.id = adb.account_id").
 			Select([]string{
 				"adb.shop_id as label_id",
-				"(EXTRACT(EPOCH FROM date_trunc('month', adb.day)) * 1000000)::BIGINT as month",
+				monthField,
 				"sum(adb.debit) as debit",
 				"sum(adb.credit) as credit",
 				"sum(adb.balance) as balance",
@@ -116,7 +119,7 @@ func monthlyBalanceDetailQ(db *gorm.DB, pay *report_iface.MonthlyBalanceDetailRe
 			Joins("join accounts a on a.id = adb.account_id").
 			Select([]string{
 				"adb.supplier_id as label_id",
-				"(EXTRACT(EPOCH FROM date_trunc('month', adb.day)) * 1000000)::BIGINT as month",
+				monthField,
 				"sum(adb.debit) as debit",
 				"sum(adb.credit) as credit",
 				"sum(adb.balance) as balance",
@@ -129,7 +132,7 @@ func monthlyBalanceDetailQ(db *gorm.DB, pay *report_iface.MonthlyBalanceDetailRe
 			Joins("join accounts a on a.id = adb.account_id").
 			Select([]string{
 				"adb.custom_id as label_id",
-				"(EXTRACT(EPOCH FROM date_trunc('month', adb.day)) * 1000000)::BIGINT as month",
+				monthField,
 				"sum(adb.debit) as debit",
 				"sum(adb.credit) as credit",
 				"sum(adb.balance) as balance",
@@ -142,7 +145,7 @@ func monthlyBalanceDetailQ(db *gorm.DB, pay *report_iface.MonthlyBalanceDetailRe
 			Joins("join accounts a on a.id = adb.account_id").
 			Select([]string{
 				"adb.cs_id as label_id",
-				"(EXTRACT(EPOCH FROM date_trunc('month', adb.day)) * 1000000)::BIGINT as month",
+				monthField,
 				"sum(adb.debit) as debit",
 				"sum(adb.credit) as credit",
 				"sum(adb.balance) as balance",
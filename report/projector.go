@@ -0,0 +1,425 @@
+package report
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/pdcgo/accounting_service/accounting_core"
+	"github.com/pdcgo/schema/services/accounting_iface/v1"
+	"github.com/pdcgo/schema/services/report_iface/v1"
+	"github.com/pdcgo/shared/pkg/ware_cache"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const DailyBalanceProjection = "daily_balance"
+
+// DefaultProjectorGrace waktu tunggu sebelum id journal entry dianggap sudah pasti commit.
+//
+// id journal entry diambil dari sequence saat insert, bukan saat commit. transaksi yang
+// lama commit bisa punya id lebih kecil dari entry yang sudah dibaca projector. karena itu
+// entry di atas SafeEntryID selalu dibaca ulang (yang sudah diapply dilewati), dan
+// SafeEntryID baru naik ke id terbesar yang dilihat grace period sebelumnya.
+// asumsinya tidak ada transaksi yang commit lebih lama dari grace period
+const DefaultProjectorGrace = 5 * time.Minute
+
+type ProjectorLag struct {
+	Projection  string    `json:"projection"`
+	LastEntryID uint      `json:"last_entry_id"`
+	SafeEntryID uint      `json:"safe_entry_id"`
+	MaxEntryID  uint      `json:"max_entry_id"`
+	Pending     int64     `json:"pending"`
+	Updated     time.Time `json:"updated"`
+}
+
+// BalanceProjector membaca journal_entries berdasarkan id dan mengisi tabel daily balance,
+// pengganti push DailyUpdateBalance lewat cloud task. tiap tabel punya checkpoint sendiri
+type BalanceProjector struct {
+	db        *gorm.DB
+	report    *accountReportImpl
+	name      string
+	batchSize int
+
+	Grace time.Duration
+}
+
+func NewBalanceProjector(db *gorm.DB, cache ware_cache.Cache) *BalanceProjector {
+	return &BalanceProjector{
+		db: db,
+		report: &accountReportImpl{
+			db:    db,
+			cache: cache,
+		},
+		name:      DailyBalanceProjection,
+		batchSize: 1000,
+		Grace:     DefaultProjectorGrace,
+	}
+}
+
+func (p *BalanceProjector) checkpointName(table *dailyTable) string {
+	return p.name + "/" + table.name
+}
+
+// checkpoint mengambil checkpoint projector untuk satu tabel. kalau belum ada dimulai dari entry
+// terakhir yang sudah diapply push, entry setelahnya di backfill oleh batch berikutnya.
+// kalau belum ada entry yang diapply sama sekali semua entry di backfill dari awal
+func (p *BalanceProjector) checkpoint(tx *gorm.DB, table *dailyTable) (*accounting_core.ProjectionCheckpoint, error) {
+	var err error
+	var cp accounting_core.ProjectionCheckpoint
+
+	err = tx.
+		Clauses(clause.Locking{
+			Strength: "UPDATE",
+		}).
+		Model(&accounting_core.ProjectionCheckpoint{}).
+		Where("name = ?", p.checkpointName(table)).
+		Find(&cp).
+		Error
+
+	if err != nil {
+		return &cp, err
+	}
+
+	if cp.Name != "" {
+		return &cp, nil
+	}
+
+	var legacyApplied, lastApplied uint
+	err = tx.
+		Model(&accounting_core.DailyAppliedEntry{}).
+		Select("coalesce(max(entry_id), 0)").
+		Find(&legacyApplied).
+		Error
+
+	if err != nil {
+		return &cp, err
+	}
+
+	err = tx.
+		Model(&accounting_core.ProjectionAppliedEntry{}).
+		Select("coalesce(max(entry_id), 0)").
+		Where("projection = ?", table.name).
+		Find(&lastApplied).
+		Error
+
+	if err != nil {
+		return &cp, err
+	}
+
+	lastApplied = max(lastApplied, legacyApplied)
+	maxID, err := p.maxEntryID(tx)
+	if err != nil {
+		return &cp, err
+	}
+
+	now := time.Now()
+	cp = accounting_core.ProjectionCheckpoint{
+		Name:        p.checkpointName(table),
+		LastEntryID: lastApplied,
+		SafeEntryID: lastApplied,
+		WatermarkID: maxID,
+		WatermarkAt: now,
+		Updated:     now,
+	}
+
+	err = tx.Save(&cp).Error
+	return &cp, err
+}
+
+func (p *BalanceProjector) maxEntryID(tx *gorm.DB) (uint, error) {
+	var maxID uint
+	err := tx.
+		Model(&accounting_core.JournalEntry{}).
+		Select("coalesce(max(id), 0)").
+		Find(&maxID).
+		Error
+
+	return maxID, err
+}
+
+// unapplied journal entry di atas SafeEntryID yang belum masuk ke tabel
+func (p *BalanceProjector) unapplied(tx *gorm.DB, table *dailyTable, cp *accounting_core.ProjectionCheckpoint) *gorm.DB {
+	return tx.
+		Model(&accounting_core.JournalEntry{}).
+		Where("id > ?", cp.SafeEntryID).
+		Where("id not in (?)",
+			tx.
+				Model(&accounting_core.ProjectionAppliedEntry{}).
+				Select("entry_id").
+				Where("projection = ?", table.name).
+				Where("entry_id > ?", cp.SafeEntryID),
+		).
+		Where("id not in (?)",
+			tx.
+				Model(&accounting_core.DailyAppliedEntry{}).
+				Select("entry_id").
+				Where("entry_id > ?", cp.SafeEntryID),
+		)
+}
+
+// RunOnce memproses satu batch untuk tiap tabel, return jumlah entry terbanyak yang dibaca satu tabel,
+// 0 kalau sudah tidak ada yang tertinggal. tabel yang error tidak menahan tabel lain
+func (p *BalanceProjector) RunOnce(ctx context.Context) (int, error) {
+	var count int
+	var errs []error
+
+	for _, table := range p.report.dailyTables() {
+		tcount, err := p.runTable(ctx, table)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", table.name, err))
+			continue
+		}
+
+		count = max(count, tcount)
+	}
+
+	return count, errors.Join(errs...)
+}
+
+func (p *BalanceProjector) runTable(ctx context.Context, table *dailyTable) (int, error) {
+	var count int
+
+	err := p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		cp, err := p.checkpoint(tx, table)
+		if err != nil {
+			return err
+		}
+
+		entries := accounting_core.JournalEntriesList{}
+		err = p.
+			unapplied(tx, table, cp).
+			Order("id asc").
+			Limit(p.batchSize).
+			Find(&entries).
+			Error
+
+		if err != nil {
+			return err
+		}
+
+		txIDs := []uint{}
+		groups := map[uint][]*report_iface.EntryPayload{}
+		count = len(entries)
+		for _, entry := range entries {
+			if groups[entry.TransactionID] == nil {
+				txIDs = append(txIDs, entry.TransactionID)
+			}
+
+			groups[entry.TransactionID] = append(groups[entry.TransactionID], &report_iface.EntryPayload{
+				Id:            uint64(entry.ID),
+				TransactionId: uint64(entry.TransactionID),
+				Desc:          entry.Desc,
+				AccountId:     uint64(entry.AccountID),
+				TeamId:        uint64(entry.TeamID),
+				Debit:         entry.Debit,
+				Credit:        entry.Credit,
+				EntryTime:     timestamppb.New(entry.EntryTime),
+				Rollback:      entry.Rollback,
+			})
+		}
+
+		if len(txIDs) > 0 {
+			labels, err := p.loadLabels(tx, txIDs)
+			if err != nil {
+				return err
+			}
+
+			for _, txID := range txIDs {
+				_, _, err = p.report.applyEntries(ctx, tx, labels[txID], groups[txID], table)
+				if err != nil {
+					return err
+				}
+			}
+
+			cp.LastEntryID = max(cp.LastEntryID, entries[len(entries)-1].ID)
+		}
+
+		now := time.Now()
+		// SafeEntryID hanya naik kalau semua entry yang terlihat sudah habis dibaca
+		if count < p.batchSize && now.Sub(cp.WatermarkAt) >= p.Grace {
+			maxID, err := p.maxEntryID(tx)
+			if err != nil {
+				return err
+			}
+
+			cp.SafeEntryID = max(cp.SafeEntryID, cp.WatermarkID)
+			cp.WatermarkID = maxID
+			cp.WatermarkAt = now
+		}
+		cp.Updated = now
+
+		return tx.Save(cp).Error
+	})
+
+	return count, err
+}
+
+// loadLabels membuat label extra per transaction, sama dengan yang dikirim BookManage.DailyUpdateData
+func (p *BalanceProjector) loadLabels(tx *gorm.DB, txIDs []uint) (map[uint]*report_iface.TxLabelExtra, error) {
+	var err error
+
+	result := map[uint]*report_iface.TxLabelExtra{}
+	for _, txID := range txIDs {
+		result[txID] = &report_iface.TxLabelExtra{
+			TagIds: []uint64{},
+		}
+	}
+
+	shops := []*accounting_core.TransactionShop{}
+	err = tx.Where("transaction_id in ?", txIDs).Find(&shops).Error
+	if err != nil {
+		return result, err
+	}
+	for _, item := range shops {
+		result[item.TransactionID].ShopId = uint64(item.ShopID)
+	}
+
+	css := []*accounting_core.TransactionCustomerService{}
+	err = tx.Where("transaction_id in ?", txIDs).Find(&css).Error
+	if err != nil {
+		return result, err
+	}
+	for _, item := range css {
+		result[item.TransactionID].CsId = uint64(item.CustomerServiceID)
+	}
+
+	suppliers := []*accounting_core.TransactionSupplier{}
+	err = tx.Where("transaction_id in ?", txIDs).Find(&suppliers).Error
+	if err != nil {
+		return result, err
+	}
+	for _, item := range suppliers {
+		result[item.TransactionID].SupplierId = uint64(item.SupplierID)
+	}
+
+	tags := []*accounting_core.TransactionTag{}
+	err = tx.Where("transaction_id in ?", txIDs).Find(&tags).Error
+	if err != nil {
+		return result, err
+	}
+	for _, item := range tags {
+		label := result[item.TransactionID]
+		label.TagIds = append(label.TagIds, uint64(item.TagID))
+	}
+
+	typeLabels := []*struct {
+		TransactionID uint
+		Key           accounting_iface.LabelKey
+		Label         string
+	}{}
+	err = tx.
+		Model(&accounting_core.TransactionTypeLabel{}).
+		Joins("join type_labels tl on tl.id = transaction_type_labels.type_label_id").
+		Select("transaction_type_labels.transaction_id, tl.key, tl.label").
+		Where("transaction_type_labels.transaction_id in ?", txIDs).
+		Find(&typeLabels).
+		Error
+	if err != nil {
+		return result, err
+	}
+	for _, item := range typeLabels {
+		label := result[item.TransactionID]
+		label.TypeLabels = append(label.TypeLabels, &accounting_iface.TypeLabel{
+			Key:   item.Key,
+			Label: item.Label,
+		})
+	}
+
+	return result, nil
+}
+
+// Lag jarak antara checkpoint tiap tabel dengan journal entry terakhir. Pending menghitung
+// entry di atas SafeEntryID yang belum diapply, termasuk yang akan dibaca ulang
+func (p *BalanceProjector) Lag(ctx context.Context) ([]*ProjectorLag, error) {
+	db := p.db.WithContext(ctx)
+	lags := []*ProjectorLag{}
+
+	maxID, err := p.maxEntryID(db)
+	if err != nil {
+		return lags, err
+	}
+
+	for _, table := range p.report.dailyTables() {
+		lag := ProjectorLag{
+			Projection: p.checkpointName(table),
+			MaxEntryID: maxID,
+		}
+
+		var cp accounting_core.ProjectionCheckpoint
+		err = db.
+			Model(&accounting_core.ProjectionCheckpoint{}).
+			Where("name = ?", lag.Projection).
+			Find(&cp).
+			Error
+
+		if err != nil {
+			return lags, err
+		}
+
+		lag.LastEntryID = cp.LastEntryID
+		lag.SafeEntryID = cp.SafeEntryID
+		lag.Updated = cp.Updated
+
+		err = p.
+			unapplied(db, table, &cp).
+			Count(&lag.Pending).
+			Error
+
+		if err != nil {
+			return lags, err
+		}
+
+		lags = append(lags, &lag)
+	}
+
+	return lags, nil
+}
+
+// Run menjalankan projector sampai context selesai
+func (p *BalanceProjector) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			count, err := p.RunOnce(ctx)
+			if err != nil {
+				slog.Error("balance projector", slog.Any("error", err))
+				break
+			}
+
+			if count == 0 {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.Canceled) {
+				return nil
+			}
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// LagHandler http handler untuk monitoring lag projector
+func (p *BalanceProjector) LagHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lags, err := p.Lag(r.Context())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(lags)
+	})
+}
@@ -0,0 +1,298 @@
+package report
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/cloudtasks/apiv2/cloudtaskspb"
+	"connectrpc.com/connect"
+	"github.com/googleapis/gax-go/v2"
+	"github.com/pdcgo/accounting_service/accounting_core"
+	"github.com/pdcgo/accounting_service/accounting_mock"
+	"github.com/pdcgo/schema/services/accounting_iface/v1"
+	"github.com/pdcgo/schema/services/report_iface/v1"
+	"github.com/pdcgo/shared/authorization/authorization_mock"
+	"github.com/pdcgo/shared/configs"
+	"github.com/pdcgo/shared/pkg/moretest"
+	"github.com/pdcgo/shared/pkg/moretest/moretest_mock"
+	"github.com/pdcgo/shared/pkg/ware_cache"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/gorm"
+)
+
+func TestBalanceProjector(t *testing.T) {
+	var db gorm.DB
+	var cashAcc accounting_core.Account
+
+	var migrate moretest.SetupFunc = func(t *testing.T) func() error {
+		err := db.AutoMigrate(
+			&accounting_core.JournalEntry{},
+			&accounting_core.Account{},
+			&accounting_core.TransactionShop{},
+			&accounting_core.TransactionSupplier{},
+			&accounting_core.TransactionCustomerService{},
+			&accounting_core.TransactionTag{},
+			&accounting_core.TypeLabel{},
+			&accounting_core.TransactionTypeLabel{},
+			&accounting_core.AccountKeyDailyBalance{},
+			&accounting_core.AccountDailyBalance{},
+			&accounting_core.CsDailyBalance{},
+			&accounting_core.ShopDailyBalance{},
+			&accounting_core.SupplierDailyBalance{},
+			&accounting_core.CustomLabelDailyBalance{},
+			&accounting_core.TypeLabelDailyBalance{},
+			&accounting_core.DailyAppliedEntry{},
+			&accounting_core.ProjectionAppliedEntry{},
+			&accounting_core.ProjectionCheckpoint{},
+		)
+
+		assert.Nil(t, err)
+		return nil
+	}
+
+	moretest.Suite(t, "testing balance projector",
+		moretest.SetupListFunc{
+			moretest_mock.MockSqliteDatabase(&db),
+			migrate,
+			accounting_mock.PopulateAccountKey(&db, 1),
+			loadAccount(&db, 1, accounting_core.CashAccount, &cashAcc),
+		},
+		func(t *testing.T) {
+			cache := ware_cache.NewLocalCache()
+			projector := NewBalanceProjector(&db, cache)
+
+			accountLag := func(t *testing.T, projector *BalanceProjector) *ProjectorLag {
+				lags, err := projector.Lag(t.Context())
+				assert.Nil(t, err)
+				assert.Len(t, lags, len(projector.report.dailyTables()))
+
+				for _, lag := range lags {
+					if lag.Projection == DailyBalanceProjection+"/account_daily_balances" {
+						return lag
+					}
+				}
+
+				t.Fatal("lag account_daily_balances not found")
+				return nil
+			}
+
+			count, err := projector.RunOnce(t.Context())
+			assert.Nil(t, err)
+			assert.Equal(t, 0, count)
+
+			entries := accounting_core.JournalEntriesList{
+				{
+					AccountID:     cashAcc.ID,
+					TeamID:        1,
+					TransactionID: 1,
+					EntryTime:     time.Now(),
+					Debit:         1000,
+				},
+				{
+					AccountID:     cashAcc.ID,
+					TeamID:        1,
+					TransactionID: 2,
+					EntryTime:     time.Now(),
+					Credit:        400,
+				},
+			}
+			err = db.Save(&entries).Error
+			assert.Nil(t, err)
+
+			err = db.Save(&accounting_core.TransactionShop{TransactionID: 1, ShopID: 3}).Error
+			assert.Nil(t, err)
+
+			typeLabel := accounting_core.TypeLabel{
+				Key:   accounting_iface.LabelKey_LABEL_KEY_MARKETPLACE,
+				Label: "shopee",
+			}
+			err = db.Save(&typeLabel).Error
+			assert.Nil(t, err)
+			err = db.Save(&accounting_core.TransactionTypeLabel{TransactionID: 2, TypeLabelID: typeLabel.ID}).Error
+			assert.Nil(t, err)
+
+			t.Run("pull entry baru", func(t *testing.T) {
+				lag := accountLag(t, projector)
+				assert.Equal(t, int64(2), lag.Pending)
+
+				count, err := projector.RunOnce(t.Context())
+				assert.Nil(t, err)
+				assert.Equal(t, 2, count)
+
+				var daily accounting_core.AccountDailyBalance
+				err = db.Model(&accounting_core.AccountDailyBalance{}).Where("account_id = ?", cashAcc.ID).First(&daily).Error
+				assert.Nil(t, err)
+				assert.Equal(t, 600.0, daily.Balance)
+
+				var shopDaily accounting_core.ShopDailyBalance
+				err = db.Model(&accounting_core.ShopDailyBalance{}).Where("shop_id = ?", 3).First(&shopDaily).Error
+				assert.Nil(t, err)
+				assert.Equal(t, 1000.0, shopDaily.Balance)
+
+				var typeDaily accounting_core.TypeLabelDailyBalance
+				err = db.Model(&accounting_core.TypeLabelDailyBalance{}).Where("label_id = ?", typeLabel.ID).First(&typeDaily).Error
+				assert.Nil(t, err)
+				assert.Equal(t, -400.0, typeDaily.Balance)
+
+				lag = accountLag(t, projector)
+				assert.Equal(t, int64(0), lag.Pending)
+				assert.Equal(t, entries[1].ID, lag.LastEntryID)
+			})
+
+			t.Run("entry yang sudah di push tidak dihitung ulang", func(t *testing.T) {
+				entry := accounting_core.JournalEntry{
+					AccountID:     cashAcc.ID,
+					TeamID:        1,
+					TransactionID: 3,
+					EntryTime:     time.Now(),
+					Debit:         50,
+				}
+				err := db.Save(&entry).Error
+				assert.Nil(t, err)
+
+				reportService := NewAccountReportService(
+					&configs.DispatcherConfig{},
+					&configs.AccountingService{},
+					&db,
+					&authorization_mock.EmptyAuthorizationMock{},
+					cache,
+					func(ctx context.Context, req *cloudtaskspb.CreateTaskRequest, opts ...gax.CallOption) error {
+						return nil
+					},
+				)
+
+				_, err = reportService.DailyUpdateBalance(t.Context(), &connect.Request[report_iface.DailyUpdateBalanceRequest]{
+					Msg: &report_iface.DailyUpdateBalanceRequest{
+						LabelExtra: &report_iface.TxLabelExtra{},
+						Entries: []*report_iface.EntryPayload{
+							{
+								Id:            uint64(entry.ID),
+								AccountId:     uint64(entry.AccountID),
+								TeamId:        1,
+								TransactionId: 3,
+								EntryTime:     timestamppb.New(entry.EntryTime),
+								Debit:         50,
+							},
+						},
+					},
+				})
+				assert.Nil(t, err)
+
+				// sudah masuk semua tabel lewat push, tidak dibaca lagi
+				count, err := projector.RunOnce(t.Context())
+				assert.Nil(t, err)
+				assert.Equal(t, 0, count)
+
+				var daily accounting_core.AccountDailyBalance
+				err = db.Model(&accounting_core.AccountDailyBalance{}).Where("account_id = ?", cashAcc.ID).First(&daily).Error
+				assert.Nil(t, err)
+				assert.Equal(t, 650.0, daily.Balance)
+			})
+
+			balance := func(t *testing.T) float64 {
+				var daily accounting_core.AccountDailyBalance
+				err := db.Model(&accounting_core.AccountDailyBalance{}).Where("account_id = ?", cashAcc.ID).First(&daily).Error
+				assert.Nil(t, err)
+				return daily.Balance
+			}
+
+			t.Run("checkpoint baru mulai dari entry terakhir yang diapply", func(t *testing.T) {
+				entry := accounting_core.JournalEntry{
+					AccountID:     cashAcc.ID,
+					TeamID:        1,
+					TransactionID: 4,
+					EntryTime:     time.Now(),
+					Debit:         25,
+				}
+				err := db.Save(&entry).Error
+				assert.Nil(t, err)
+
+				fresh := NewBalanceProjector(&db, cache)
+				fresh.name = "fresh"
+
+				count, err := fresh.RunOnce(t.Context())
+				assert.Nil(t, err)
+				assert.Equal(t, 1, count)
+				assert.Equal(t, 675.0, balance(t))
+
+				// sudah diapply projector lain ke tabel yang sama
+				lag := accountLag(t, projector)
+				assert.Equal(t, int64(0), lag.Pending)
+
+				count, err = projector.RunOnce(t.Context())
+				assert.Nil(t, err)
+				assert.Equal(t, 0, count)
+				assert.Equal(t, 675.0, balance(t))
+			})
+
+			t.Run("entry commit telat di bawah checkpoint", func(t *testing.T) {
+				err := db.Save(&accounting_core.JournalEntry{
+					ID:            20,
+					AccountID:     cashAcc.ID,
+					TeamID:        1,
+					TransactionID: 5,
+					EntryTime:     time.Now(),
+					Debit:         5,
+				}).Error
+				assert.Nil(t, err)
+
+				count, err := projector.RunOnce(t.Context())
+				assert.Nil(t, err)
+				assert.Equal(t, 1, count)
+
+				err = db.Save(&accounting_core.JournalEntry{
+					ID:            15,
+					AccountID:     cashAcc.ID,
+					TeamID:        1,
+					TransactionID: 6,
+					EntryTime:     time.Now(),
+					Debit:         3,
+				}).Error
+				assert.Nil(t, err)
+
+				count, err = projector.RunOnce(t.Context())
+				assert.Nil(t, err)
+				assert.Equal(t, 1, count)
+				assert.Equal(t, 683.0, balance(t))
+
+				lag := accountLag(t, projector)
+				assert.Equal(t, uint(20), lag.LastEntryID)
+				assert.Equal(t, int64(0), lag.Pending)
+			})
+
+			t.Run("safe entry naik setelah grace period", func(t *testing.T) {
+				projector.Grace = 0
+
+				// watermark lama dipakai dulu, baru batch berikutnya sampai id terakhir
+				_, err := projector.RunOnce(t.Context())
+				assert.Nil(t, err)
+				_, err = projector.RunOnce(t.Context())
+				assert.Nil(t, err)
+
+				lag := accountLag(t, projector)
+				assert.Equal(t, uint(20), lag.SafeEntryID)
+				assert.Equal(t, int64(0), lag.Pending)
+
+				err = db.Save(&accounting_core.JournalEntry{
+					ID:            21,
+					AccountID:     cashAcc.ID,
+					TeamID:        1,
+					TransactionID: 7,
+					EntryTime:     time.Now(),
+					Debit:         7,
+				}).Error
+				assert.Nil(t, err)
+
+				lag = accountLag(t, projector)
+				assert.Equal(t, int64(1), lag.Pending)
+
+				count, err := projector.RunOnce(t.Context())
+				assert.Nil(t, err)
+				assert.Equal(t, 1, count)
+				assert.Equal(t, 690.0, balance(t))
+			})
+		},
+	)
+}
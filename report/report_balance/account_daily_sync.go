@@ -1,14 +1,28 @@
 package report_balance
 
-func accountDailySync() map[string]string {
-	return map[string]string{
-		"locking account daily":   "lock table account_daily_balances in ACCESS exclusive mode",
-		"locking journal entries": "lock table journal_entries in ACCESS exclusive mode",
+import (
+	"fmt"
+
+	"github.com/pdcgo/accounting_service/query_dialect"
+)
+
+func accountDailySync(dialect query_dialect.Dialect) []*syncStatement {
+	return []*syncStatement{
+		{
+			msg:  "locking account daily",
+			stmt: dialect.LockTable("account_daily_balances"),
+		},
+		{
+			msg:  "locking journal entries",
+			stmt: dialect.LockTable("journal_entries"),
+		},
 		// upsert account daily
-		"upsert account daily": `
+		{
+			msg: "upsert account daily",
+			stmt: fmt.Sprintf(`
 			with entries as (
 				select
-					date(je.entry_time AT TIME ZONE 'Asia/Jakarta')::timestamp AT TIME ZONE 'Asia/Jakarta' + INTERVAL '7 hours' as day,
+					%s as day,
 					je.account_id,
 					je.team_id,
 					case 
@@ -67,6 +81,7 @@ func accountDailySync() map[string]string {
 
 			insert into account_daily_balances (day, account_id, journal_team_id, debit, credit, balance)
 			select day, account_id, team_id, debit, credit, balance from statdata
+			where true
 			on conflict (day, account_id, journal_team_id)
 			do update
 			set
@@ -74,9 +89,12 @@ func accountDailySync() map[string]string {
 				credit=excluded.credit, 
 				balance=excluded.balance
 
-		`,
+		`, dialect.LocalDay("je.entry_time")),
+		},
 		// updating start balance
-		"updating start balance": `
+		{
+			msg: "updating start balance",
+			stmt: `
 			with stb as (
 				select 
 					akdb.id,
@@ -98,5 +116,6 @@ func accountDailySync() map[string]string {
 			) as datalb
 			where u.id = datalb.id
 		`,
+		},
 	}
 }
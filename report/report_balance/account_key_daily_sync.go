@@ -1,14 +1,28 @@
 package report_balance
 
-func accountKeyDailySync() map[string]string {
-	return map[string]string{
-		"locking account_key":     "lock table account_key_daily_balances in ACCESS exclusive mode",
-		"locking journal_entries": "lock table journal_entries in ACCESS exclusive mode",
+import (
+	"fmt"
+
+	"github.com/pdcgo/accounting_service/query_dialect"
+)
+
+func accountKeyDailySync(dialect query_dialect.Dialect) []*syncStatement {
+	return []*syncStatement{
+		{
+			msg:  "locking account_key",
+			stmt: dialect.LockTable("account_key_daily_balances"),
+		},
+		{
+			msg:  "locking journal_entries",
+			stmt: dialect.LockTable("journal_entries"),
+		},
 		// upsert account key daily balance
-		"upsert account key daily balance": `
+		{
+			msg: "upsert account key daily balance",
+			stmt: fmt.Sprintf(`
 				with entries as (
 					select
-						date(je.entry_time AT TIME ZONE 'Asia/Jakarta')::timestamp AT TIME ZONE 'Asia/Jakarta' + INTERVAL '7 hours' as day,
+						%s as day,
 						a.account_key,
 						je.team_id,
 						case 
@@ -62,14 +76,18 @@ func accountKeyDailySync() map[string]string {
 
 				insert into account_key_daily_balances (day, account_key, journal_team_id, debit, credit, balance)
 				select day, account_key, team_id, debit, credit, balance from statdata
+				where true
 				on conflict (day, account_key, journal_team_id)
 				do update
 				set debit=excluded.debit, 
 					credit=excluded.credit, 
 					balance=excluded.balance
-			`,
+			`, dialect.LocalDay("je.entry_time")),
+		},
 		// updating start balance
-		"updating start balance": `
+		{
+			msg: "updating start balance",
+			stmt: `
 				with stb as (
 					select 
 						akdb.id,
@@ -91,5 +109,6 @@ func accountKeyDailySync() map[string]string {
 				) as datalb
 				where u.id = datalb.id
 			`,
+		},
 	}
 }
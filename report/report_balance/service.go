@@ -6,6 +6,7 @@ import (
 	"fmt"
 
 	"connectrpc.com/connect"
+	"github.com/pdcgo/accounting_service/query_dialect"
 	"github.com/pdcgo/schema/services/report_iface/v1"
 	"github.com/pdcgo/shared/interfaces/authorization_iface"
 	"gorm.io/gorm"
@@ -44,28 +45,37 @@ func (b *balanceImpl) BalanceResync(
 	}
 
 	streamlog("syncing daily account key")
-	err = db.Transaction(func(tx *gorm.DB) error {
-		statements := accountKeyDailySync()
-
-		for msg, stmt := range statements {
-			streamlog(msg)
-			err = tx.Exec(stmt).Error
-			if err != nil {
-				return err
-			}
-		}
+	dialect := query_dialect.NewDialect(db)
+	err = runSync(db, accountKeyDailySync(dialect), streamlog)
+	if err != nil {
+		return err
+	}
 
-		return nil
-	})
+	err = runSync(db, accountDailySync(dialect), streamlog)
 	if err != nil {
 		return err
 	}
 
-	err = db.Transaction(func(tx *gorm.DB) error {
-		statements := accountDailySync()
-		for msg, stmt := range statements {
-			streamlog(msg)
-			err = tx.Exec(stmt).Error
+	streamlog("complete sync daily account key")
+	return nil
+
+}
+
+type syncStatement struct {
+	msg  string
+	stmt string
+}
+
+// runSync menjalankan statement sesuai urutan dalam satu transaction
+func runSync(db *gorm.DB, statements []*syncStatement, streamlog func(format string, a ...any)) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, item := range statements {
+			if item.stmt == "" {
+				continue
+			}
+
+			streamlog("%s", item.msg)
+			err := tx.Exec(item.stmt).Error
 			if err != nil {
 				return err
 			}
@@ -73,14 +83,6 @@ func (b *balanceImpl) BalanceResync(
 
 		return nil
 	})
-
-	if err != nil {
-		return err
-	}
-
-	streamlog("complete sync daily account key")
-	return nil
-
 }
 
 func NewBalanceService(
@@ -0,0 +1,132 @@
+package report_balance
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pdcgo/accounting_service/accounting_core"
+	"github.com/pdcgo/accounting_service/accounting_mock"
+	"github.com/pdcgo/accounting_service/query_dialect"
+	"github.com/pdcgo/shared/pkg/moretest"
+	"github.com/pdcgo/shared/pkg/moretest/moretest_mock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestResyncSqlite(t *testing.T) {
+	var db gorm.DB
+	var cashAcc accounting_core.Account
+
+	var migrate moretest.SetupFunc = func(t *testing.T) func() error {
+		err := db.AutoMigrate(
+			&accounting_core.JournalEntry{},
+			&accounting_core.Account{},
+			&accounting_core.AccountKeyDailyBalance{},
+			&accounting_core.AccountDailyBalance{},
+		)
+
+		assert.Nil(t, err)
+		return nil
+	}
+
+	jkt := time.FixedZone("WIB", 7*3600)
+	var seed moretest.SetupFunc = func(t *testing.T) func() error {
+		err := db.
+			Model(&accounting_core.Account{}).
+			Where("team_id = ?", 1).
+			Where("account_key = ?", accounting_core.CashAccount).
+			First(&cashAcc).
+			Error
+		assert.Nil(t, err)
+
+		entries := accounting_core.JournalEntriesList{
+			{
+				AccountID: cashAcc.ID,
+				TeamID:    1,
+				EntryTime: time.Date(2025, 10, 1, 10, 0, 0, 0, jkt),
+				Debit:     1000,
+			},
+			{
+				// masih tanggal 2 kalau pakai jam jakarta
+				AccountID: cashAcc.ID,
+				TeamID:    1,
+				EntryTime: time.Date(2025, 10, 2, 1, 0, 0, 0, jkt),
+				Credit:    300,
+			},
+		}
+		err = db.Save(&entries).Error
+		assert.Nil(t, err)
+
+		// data lama yang harus ketimpa
+		old := accounting_core.AccountKeyDailyBalance{
+			Day:           accounting_core.ParseDate(time.Date(2025, 10, 1, 10, 0, 0, 0, jkt)),
+			JournalTeamID: 1,
+			AccountKey:    accounting_core.CashAccount,
+			Debit:         99,
+			Balance:       99,
+		}
+		err = db.Save(&old).Error
+		assert.Nil(t, err)
+
+		return nil
+	}
+
+	moretest.Suite(t, "testing resync di sqlite",
+		moretest.SetupListFunc{
+			moretest_mock.MockSqliteDatabase(&db),
+			migrate,
+			accounting_mock.PopulateAccountKey(&db, 1),
+			seed,
+		},
+		func(t *testing.T) {
+			msgs := []string{}
+			streamlog := func(format string, a ...any) {
+				msgs = append(msgs, format)
+			}
+
+			dialect := query_dialect.NewDialect(&db)
+			err := runSync(&db, accountKeyDailySync(dialect), streamlog)
+			assert.Nil(t, err)
+			err = runSync(&db, accountDailySync(dialect), streamlog)
+			assert.Nil(t, err)
+
+			assert.NotEmpty(t, msgs)
+
+			t.Run("account key daily", func(t *testing.T) {
+				dailys := []*accounting_core.AccountKeyDailyBalance{}
+				err := db.
+					Model(&accounting_core.AccountKeyDailyBalance{}).
+					Where("account_key = ?", accounting_core.CashAccount).
+					Order("day asc").
+					Find(&dailys).
+					Error
+				assert.Nil(t, err)
+				assert.Len(t, dailys, 2)
+
+				if len(dailys) == 2 {
+					assert.Equal(t, 1000.0, dailys[0].Debit)
+					assert.Equal(t, 1000.0, dailys[0].Balance)
+					assert.Equal(t, 300.0, dailys[1].Credit)
+					assert.Equal(t, 700.0, dailys[1].Balance)
+					assert.Equal(t, 1000.0, dailys[1].StartBalance)
+				}
+			})
+
+			t.Run("account daily", func(t *testing.T) {
+				dailys := []*accounting_core.AccountDailyBalance{}
+				err := db.
+					Model(&accounting_core.AccountDailyBalance{}).
+					Where("account_id = ?", cashAcc.ID).
+					Order("day asc").
+					Find(&dailys).
+					Error
+				assert.Nil(t, err)
+				assert.Len(t, dailys, 2)
+
+				if len(dailys) == 2 {
+					assert.Equal(t, 700.0, dailys[1].Balance)
+				}
+			})
+		},
+	)
+}
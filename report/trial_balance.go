@@ -0,0 +1,168 @@
+package report
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/pdcgo/accounting_service/accounting_core"
+	"github.com/pdcgo/accounting_service/query_dialect"
+	"github.com/pdcgo/schema/services/accounting_iface/v1"
+	"github.com/pdcgo/schema/services/common/v1"
+	"github.com/pdcgo/schema/services/report_iface/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func addTrialBalanceAmount(total, other *report_iface.TrialBalanceAmount) {
+	total.OpeningDebit += other.OpeningDebit
+	total.OpeningCredit += other.OpeningCredit
+	total.Debit += other.Debit
+	total.Credit += other.Credit
+	total.ClosingDebit += other.ClosingDebit
+	total.ClosingCredit += other.ClosingCredit
+}
+
+func balanceTypeProto(balanceType accounting_core.BalanceType) accounting_iface.BalanceType {
+	switch balanceType {
+	case accounting_core.DebitBalance:
+		return accounting_iface.BalanceType_BALANCE_TYPE_DEBIT
+	case accounting_core.CreditBalance:
+		return accounting_iface.BalanceType_BALANCE_TYPE_CREDIT
+	default:
+		return accounting_iface.BalanceType_BALANCE_TYPE_UNSPECIFIED
+	}
+}
+
+// TrialBalance neraca saldo per account dari account daily balance
+func (a *accountReportImpl) TrialBalance(
+	ctx context.Context,
+	req *connect.Request[report_iface.TrialBalanceRequest],
+) (*connect.Response[report_iface.TrialBalanceResponse], error) {
+	var err error
+	pay := req.Msg
+
+	result := report_iface.TrialBalanceResponse{
+		TimeRange: pay.TimeRange,
+		Rows:      []*report_iface.TrialBalanceRow{},
+		Total:     &report_iface.TrialBalanceAmount{},
+	}
+
+	err = a.
+		auth.
+		AuthIdentityFromHeader(req.Header()).
+		Err()
+
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	timeRange := pay.TimeRange
+	if timeRange.StartDate == nil || timeRange.EndDate == nil || timeRange.EndDate.AsTime().Before(timeRange.StartDate.AsTime()) {
+		return connect.NewResponse(&result), connect.NewError(connect.CodeInvalidArgument, errors.New("invalid start end"))
+	}
+
+	start := query_dialect.ReportDay(timeRange.StartDate.AsTime())
+	end := query_dialect.ReportDay(timeRange.EndDate.AsTime())
+
+	rows := []*struct {
+		AccountID     uint
+		AccountTeamID uint
+		AccountKey    accounting_core.AccountKey
+		Name          string
+		Coa           accounting_core.CoaCode
+		BalanceType   accounting_core.BalanceType
+		Opening       float64
+		Debit         float64
+		Credit        float64
+	}{}
+
+	err = a.
+		db.
+		WithContext(ctx).
+		Table("account_daily_balances adb").
+		Joins("join accounts a on a.id = adb.account_id").
+		Select(strings.Join([]string{
+			"a.id as account_id",
+			"a.team_id as account_team_id",
+			"a.account_key",
+			"a.name",
+			"a.coa",
+			"a.balance_type",
+			"sum(case when adb.day < @start then adb.debit - adb.credit else 0 end) as opening",
+			"sum(case when adb.day >= @start then adb.debit else 0 end) as debit",
+			"sum(case when adb.day >= @start then adb.credit else 0 end) as credit",
+		}, ", "), map[string]any{"start": start}).
+		Where("adb.journal_team_id = ?", pay.TeamId).
+		Where("adb.day <= ?", end).
+		Group("a.id, a.team_id, a.account_key, a.name, a.coa, a.balance_type").
+		Order("a.coa asc, a.account_key asc, a.team_id asc").
+		Find(&rows).
+		Error
+
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	// EntryList memakai entry_time > start dan entry_time <= end
+	entryStart := query_dialect.ReportDayStart(start).Add(-time.Microsecond)
+	entryEnd := query_dialect.ReportDayStart(end.AddDate(0, 0, 1)).Add(-time.Microsecond)
+
+	for _, item := range rows {
+		closing := item.Opening + item.Debit - item.Credit
+
+		amount := report_iface.TrialBalanceAmount{
+			Debit:  item.Debit,
+			Credit: item.Credit,
+		}
+
+		row := report_iface.TrialBalanceRow{
+			AccountId:      uint64(item.AccountID),
+			AccountTeamId:  uint64(item.AccountTeamID),
+			AccountKey:     string(item.AccountKey),
+			Name:           item.Name,
+			Coa:            accounting_iface.CoaCode(item.Coa),
+			BalanceType:    balanceTypeProto(item.BalanceType),
+			OpeningBalance: item.Opening,
+			ClosingBalance: closing,
+			Amount:         &amount,
+			EntryList: &accounting_iface.EntryListRequest{
+				TeamId:        pay.TeamId,
+				AccountTeamId: uint64(item.AccountTeamID),
+				AccountKey:    string(item.AccountKey),
+				TimeRange: &common.TimeFilterRange{
+					StartDate: timestamppb.New(entryStart),
+					EndDate:   timestamppb.New(entryEnd),
+				},
+			},
+		}
+
+		if item.BalanceType == accounting_core.CreditBalance {
+			row.OpeningBalance = -item.Opening
+			row.ClosingBalance = -closing
+		}
+
+		if item.Opening >= 0 {
+			amount.OpeningDebit = item.Opening
+		} else {
+			amount.OpeningCredit = -item.Opening
+		}
+
+		if closing >= 0 {
+			amount.ClosingDebit = closing
+		} else {
+			amount.ClosingCredit = -closing
+		}
+
+		result.Rows = append(result.Rows, &row)
+		addTrialBalanceAmount(result.Total, &amount)
+	}
+
+	total := result.Total
+	result.Balanced = accounting_core.CompareFloatSafe(total.OpeningDebit, total.OpeningCredit, accounting_core.PrecisionEpsilon) &&
+		accounting_core.CompareFloatSafe(total.Debit, total.Credit, accounting_core.PrecisionEpsilon) &&
+		accounting_core.CompareFloatSafe(total.ClosingDebit, total.ClosingCredit, accounting_core.PrecisionEpsilon)
+
+	return connect.NewResponse(&result), nil
+}
@@ -0,0 +1,140 @@
+package report
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/cloudtasks/apiv2/cloudtaskspb"
+	"connectrpc.com/connect"
+	"github.com/googleapis/gax-go/v2"
+	"github.com/pdcgo/accounting_service/accounting_core"
+	"github.com/pdcgo/accounting_service/accounting_mock"
+	"github.com/pdcgo/schema/services/accounting_iface/v1"
+	"github.com/pdcgo/schema/services/common/v1"
+	"github.com/pdcgo/schema/services/report_iface/v1"
+	"github.com/pdcgo/shared/authorization/authorization_mock"
+	"github.com/pdcgo/shared/configs"
+	"github.com/pdcgo/shared/pkg/moretest"
+	"github.com/pdcgo/shared/pkg/moretest/moretest_mock"
+	"github.com/pdcgo/shared/pkg/ware_cache"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/gorm"
+)
+
+func TestTrialBalance(t *testing.T) {
+	var db gorm.DB
+	var cashAcc, revenueAcc, adsAcc accounting_core.Account
+
+	var migrate moretest.SetupFunc = func(t *testing.T) func() error {
+		err := db.AutoMigrate(
+			&accounting_core.Account{},
+			&accounting_core.AccountKeyDailyBalance{},
+			&accounting_core.AccountDailyBalance{},
+			&accounting_core.CsDailyBalance{},
+			&accounting_core.ShopDailyBalance{},
+			&accounting_core.SupplierDailyBalance{},
+			&accounting_core.CustomLabelDailyBalance{},
+			&accounting_core.DailyAppliedEntry{},
+			&accounting_core.ProjectionAppliedEntry{},
+		)
+
+		assert.Nil(t, err)
+		return nil
+	}
+
+	jkt := time.FixedZone("WIB", 7*3600)
+
+	moretest.Suite(t, "testing trial balance",
+		moretest.SetupListFunc{
+			moretest_mock.MockSqliteDatabase(&db),
+			migrate,
+			accounting_mock.PopulateAccountKey(&db, 1),
+			loadAccount(&db, 1, accounting_core.CashAccount, &cashAcc),
+			loadAccount(&db, 1, accounting_core.SalesRevenueAccount, &revenueAcc),
+			loadAccount(&db, 1, accounting_core.AdsExpenseAccount, &adsAcc),
+		},
+		func(t *testing.T) {
+			reportService := NewAccountReportService(
+				&configs.DispatcherConfig{},
+				&configs.AccountingService{},
+				&db,
+				&authorization_mock.EmptyAuthorizationMock{},
+				ware_cache.NewLocalCache(),
+				func(ctx context.Context, req *cloudtaskspb.CreateTaskRequest, opts ...gax.CallOption) error {
+					return nil
+				},
+			)
+
+			entry := func(acc *accounting_core.Account, day time.Time, debit, credit float64) *report_iface.EntryPayload {
+				return &report_iface.EntryPayload{
+					AccountId: uint64(acc.ID),
+					TeamId:    1,
+					EntryTime: timestamppb.New(day),
+					Debit:     debit,
+					Credit:    credit,
+				}
+			}
+
+			sep := time.Date(2025, 9, 20, 10, 0, 0, 0, jkt)
+			oct := time.Date(2025, 10, 3, 10, 0, 0, 0, jkt)
+
+			_, err := reportService.DailyUpdateBalance(t.Context(), &connect.Request[report_iface.DailyUpdateBalanceRequest]{
+				Msg: &report_iface.DailyUpdateBalanceRequest{
+					LabelExtra: &report_iface.TxLabelExtra{},
+					Entries: []*report_iface.EntryPayload{
+						entry(&cashAcc, sep, 5000, 0),
+						entry(&revenueAcc, sep, 0, 5000),
+						entry(&cashAcc, oct, 2000, 0),
+						entry(&revenueAcc, oct, 0, 2000),
+						entry(&adsAcc, oct, 700, 0),
+						entry(&cashAcc, oct, 0, 700),
+					},
+				},
+			})
+			assert.Nil(t, err)
+
+			res, err := reportService.TrialBalance(t.Context(), connect.NewRequest(&report_iface.TrialBalanceRequest{
+				TeamId: 1,
+				TimeRange: &common.TimeFilterRange{
+					StartDate: timestamppb.New(time.Date(2025, 10, 1, 0, 0, 0, 0, jkt)),
+					EndDate:   timestamppb.New(time.Date(2025, 10, 31, 0, 0, 0, 0, jkt)),
+				},
+			}))
+			assert.Nil(t, err)
+
+			result := res.Msg
+			assert.True(t, result.Balanced)
+			assert.Len(t, result.Rows, 3)
+			assert.Equal(t, 5000.0, result.Total.OpeningDebit)
+			assert.Equal(t, 5000.0, result.Total.OpeningCredit)
+			assert.Equal(t, 2700.0, result.Total.Debit)
+			assert.Equal(t, 2700.0, result.Total.Credit)
+			assert.Equal(t, 7000.0, result.Total.ClosingDebit)
+			assert.Equal(t, 7000.0, result.Total.ClosingCredit)
+
+			rows := map[accounting_core.AccountKey]*report_iface.TrialBalanceRow{}
+			for _, row := range result.Rows {
+				rows[accounting_core.AccountKey(row.AccountKey)] = row
+			}
+
+			cash := rows[accounting_core.CashAccount]
+			assert.Equal(t, 5000.0, cash.OpeningBalance)
+			assert.Equal(t, 6300.0, cash.ClosingBalance)
+			assert.Equal(t, accounting_iface.BalanceType_BALANCE_TYPE_DEBIT, cash.BalanceType)
+
+			revenue := rows[accounting_core.SalesRevenueAccount]
+			assert.Equal(t, 5000.0, revenue.OpeningBalance)
+			assert.Equal(t, 7000.0, revenue.ClosingBalance)
+			assert.Equal(t, 7000.0, revenue.Amount.ClosingCredit)
+
+			drill := cash.EntryList
+			assert.Equal(t, uint64(1), drill.TeamId)
+			assert.Equal(t, string(accounting_core.CashAccount), drill.AccountKey)
+			assert.True(t, drill.TimeRange.StartDate.AsTime().Before(time.Date(2025, 10, 1, 0, 0, 0, 0, jkt)))
+			assert.True(t, drill.TimeRange.EndDate.AsTime().Before(time.Date(2025, 11, 1, 0, 0, 0, 0, jkt)))
+			assert.True(t, drill.TimeRange.EndDate.AsTime().After(time.Date(2025, 10, 31, 23, 59, 0, 0, jkt)))
+		},
+	)
+}
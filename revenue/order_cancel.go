@@ -3,9 +3,11 @@ package revenue
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"connectrpc.com/connect"
 	"github.com/pdcgo/accounting_service/accounting_core"
+	"github.com/pdcgo/accounting_service/receivable"
 	"github.com/pdcgo/schema/services/revenue_iface/v1"
 	"github.com/pdcgo/shared/db_models"
 	"github.com/pdcgo/shared/interfaces/authorization_iface"
@@ -43,11 +45,19 @@ func (r *revenueServiceImpl) OrderCancel(
 			RefType: accounting_core.OrderRef,
 			ID:      uint(pay.OrderId),
 		})
-		return accounting_core.
+		err := accounting_core.
 			NewTransactionMutation(ctx, tx).
 			ByRefID(accounting_core.RefID(ref), true).
 			RollbackEntry(agent.GetUserID(), fmt.Sprintf("cancelling order %s", ref)).
 			Err()
+
+		if err != nil {
+			return err
+		}
+
+		return receivable.
+			NewOrderLedger(tx).
+			Cancel(uint(pay.TeamId), uint(pay.OrderId), time.Now())
 	})
 
 	return res, err
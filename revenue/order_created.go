@@ -8,6 +8,7 @@ import (
 
 	"connectrpc.com/connect"
 	"github.com/pdcgo/accounting_service/accounting_core"
+	"github.com/pdcgo/accounting_service/receivable"
 	"github.com/pdcgo/schema/services/common/v1"
 	"github.com/pdcgo/schema/services/revenue_iface/v1"
 	"github.com/pdcgo/shared/interfaces/authorization_iface"
@@ -123,6 +124,24 @@ func (r *revenueServiceImpl) OnOrder(
 				return err
 			}
 
+			// sub ledger piutang per order
+			if !pay.IsCustomOrder {
+				err = receivable.
+					NewOrderLedger(tx).
+					Book(&receivable.BookPayload{
+						TeamID:          uint(pay.TeamId),
+						ShopID:          uint(labelInfo.ShopId),
+						OrderID:         txOrderID,
+						ExternalOrderID: ordInfo.ExternalOrderId,
+						TransactionID:   tran.ID,
+						OrderAt:         tran.Created,
+						EstAmount:       pay.OrderAmount,
+					})
+				if err != nil {
+					return err
+				}
+			}
+
 			// additional cost
 			err = r.additionalAmount(bookmng, agent, pay, &tran)
 			if err != nil {
@@ -9,6 +9,7 @@ import (
 	"github.com/googleapis/gax-go/v2"
 	"github.com/pdcgo/accounting_service/accounting_core"
 	"github.com/pdcgo/accounting_service/accounting_mock"
+	"github.com/pdcgo/accounting_service/receivable"
 	"github.com/pdcgo/accounting_service/revenue"
 	"github.com/pdcgo/schema/services/common/v1"
 	"github.com/pdcgo/schema/services/revenue_iface/v1"
@@ -70,6 +71,7 @@ func TestOnOrder(t *testing.T) {
 			accounting_core.TypeLabel{},
 			accounting_core.TransactionTypeLabel{},
 			db_models.Marketplace{},
+			receivable.OrderReceivable{},
 		)
 		assert.Nil(t, err)
 		return nil
@@ -158,6 +160,17 @@ func TestOnOrder(t *testing.T) {
 					_, err := service.OnOrder(t.Context(), req)
 					assert.Nil(t, err)
 				})
+
+				t.Run("checking sub ledger piutang", func(t *testing.T) {
+					items := []*receivable.OrderReceivable{}
+					err := db.Model(&receivable.OrderReceivable{}).Find(&items).Error
+					assert.NoError(t, err)
+					assert.Len(t, items, 1)
+					assert.Equal(t, "ASDASD", items[0].ExternalOrderID)
+					assert.Equal(t, uint(3), items[0].ShopID)
+					assert.Equal(t, 100.0, items[0].EstAmount)
+					assert.Equal(t, receivable.ReceivableOpen, items[0].Status)
+				})
 			})
 
 		},
@@ -180,6 +193,7 @@ func TestOnOrderCustom(t *testing.T) {
 			accounting_core.TypeLabel{},
 			accounting_core.TransactionTypeLabel{},
 			db_models.Marketplace{},
+			receivable.OrderReceivable{},
 		)
 		assert.Nil(t, err)
 		return nil
@@ -9,6 +9,7 @@ import (
 
 	"connectrpc.com/connect"
 	"github.com/pdcgo/accounting_service/accounting_core"
+	"github.com/pdcgo/accounting_service/receivable"
 	"github.com/pdcgo/schema/services/accounting_iface/v1"
 	"github.com/pdcgo/schema/services/revenue_iface/v1"
 	"gorm.io/gorm"
@@ -113,15 +114,34 @@ func (r *revenueProcessor) checkTxExist(refID accounting_core.RefID) (bool, erro
 func (r *revenueProcessor) fund(fund *revenue_iface.RevenueStreamEventFund) error {
 	var err error
 
-	if accounting_core.CompareFloatSafe(fund.EstAmount, fund.Amount, accounting_core.PrecisionEpsilon) {
-		return nil
-	}
-
 	init := r.init
 	var shopID uint = uint(init.ShopId)
 	var teamID uint = uint(init.TeamId)
 	var userID uint = uint(init.UserId)
 
+	var fundAt time.Time
+	if fund.At != nil {
+		fundAt = fund.At.AsTime()
+	}
+
+	fundLedger := func(tx *gorm.DB) error {
+		return receivable.
+			NewOrderLedger(tx).
+			Fund(&receivable.FundPayload{
+				TeamID:          teamID,
+				ShopID:          shopID,
+				ExternalOrderID: fund.OrderId,
+				EstAmount:       fund.EstAmount,
+				Amount:          fund.Amount,
+				At:              fundAt,
+			})
+	}
+
+	// sub ledger tetap dicatat walaupun nominal sama dengan estimasi
+	if accounting_core.CompareFloatSafe(fund.EstAmount, fund.Amount, accounting_core.PrecisionEpsilon) {
+		return fundLedger(r.db)
+	}
+
 	refID := accounting_core.NewStringRefID(&accounting_core.StringRefData{
 		RefType: accounting_core.OrderFundRef,
 		ID:      fund.OrderId,
@@ -133,7 +153,7 @@ func (r *revenueProcessor) fund(fund *revenue_iface.RevenueStreamEventFund) erro
 	}
 
 	if exist {
-		return nil
+		return fundLedger(r.db)
 	}
 
 	err = accounting_core.OpenTransaction(r.ctx, r.db, func(tx *gorm.DB, bookmng accounting_core.BookManage) error {
@@ -142,6 +162,11 @@ func (r *revenueProcessor) fund(fund *revenue_iface.RevenueStreamEventFund) erro
 			ID:      fund.OrderId,
 		})
 
+		err = fundLedger(tx)
+		if err != nil {
+			return err
+		}
+
 		tran := accounting_core.Transaction{
 			RefID:       refID,
 			Desc:        fmt.Sprintf("%s %s", refID, fund.Desc),
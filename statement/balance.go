@@ -0,0 +1,408 @@
+package statement
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/pdcgo/accounting_service/accounting_core"
+	"github.com/pdcgo/accounting_service/query_dialect"
+	"github.com/pdcgo/schema/services/accounting_iface/v1"
+	"github.com/pdcgo/schema/services/common/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/gorm"
+)
+
+// item laba di section equity
+const (
+	RetainedEarningsKey = "retained_earnings"
+	CurrentEarningsKey  = "current_earnings"
+)
+
+type BalanceSheetRequest struct {
+	TeamID uint64    `json:"team_id"`
+	AsOf   time.Time `json:"as_of"`
+	// awal periode berjalan, laba sebelum tanggal ini masuk retained earnings
+	PeriodStart *time.Time `json:"period_start"`
+}
+
+type BalanceSheetItem struct {
+	AccountKey string  `json:"account_key"`
+	Balance    float64 `json:"balance"`
+}
+
+type BalanceSheetSection struct {
+	Items []*BalanceSheetItem `json:"items"`
+	Total float64             `json:"total"`
+}
+
+type BalanceSheetIssue struct {
+	AccountKey    string  `json:"account_key"`
+	TransactionID uint64  `json:"transaction_id,omitempty"`
+	Coa           string  `json:"coa"`
+	Balance       float64 `json:"balance"`
+	Recomputed    float64 `json:"recomputed"`
+	Reason        string  `json:"reason"`
+}
+
+type BalanceSheetResponse struct {
+	AsOf        time.Time            `json:"as_of"`
+	Assets      *BalanceSheetSection `json:"assets,omitempty"`
+	Liabilities *BalanceSheetSection `json:"liabilities,omitempty"`
+	Equity      *BalanceSheetSection `json:"equity,omitempty"`
+	Balanced    bool                 `json:"balanced"`
+	Difference  float64              `json:"difference"`
+	Issues      []*BalanceSheetIssue `json:"issues"`
+}
+
+// StatementBalance implements accounting_ifaceconnect.StatementServiceHandler.
+func (s *statementImpl) StatementBalance(
+	ctx context.Context,
+	req *connect.Request[accounting_iface.StatementBalanceRequest],
+	stream *connect.ServerStream[accounting_iface.StatementBalanceResponse]) error {
+
+	var err error
+	pay := req.Msg
+
+	err = s.
+		auth.
+		AuthIdentityFromHeader(req.Header()).
+		Err()
+
+	if err != nil {
+		return err
+	}
+
+	if pay.TeamId == 0 {
+		return connect.NewError(connect.CodeInvalidArgument, errors.New("team_id required"))
+	}
+
+	result := BalanceSheetResponse{
+		Issues: []*BalanceSheetIssue{},
+	}
+	err = s.balanceSheet(ctx, sheetRequest(pay.TeamId, pay.AsOf, pay.PeriodStart), &result)
+	if err != nil {
+		return err
+	}
+
+	if result.Balanced {
+		sections := []*accounting_iface.StatementBalanceResponse{
+			{Data: &accounting_iface.StatementBalanceResponse_Assets{Assets: result.Assets.proto()}},
+			{Data: &accounting_iface.StatementBalanceResponse_Liabilities{Liabilities: result.Liabilities.proto()}},
+			{Data: &accounting_iface.StatementBalanceResponse_Equity{Equity: result.Equity.proto()}},
+		}
+		for _, section := range sections {
+			err = stream.Send(section)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	check := accounting_iface.BalanceCheck{
+		Balanced:   result.Balanced,
+		Difference: result.Difference,
+		Issues:     []*accounting_iface.BalanceIssue{},
+	}
+	for _, issue := range result.Issues {
+		check.Issues = append(check.Issues, &accounting_iface.BalanceIssue{
+			AccountKey:    issue.AccountKey,
+			TransactionId: issue.TransactionID,
+			Coa:           issue.Coa,
+			Balance:       issue.Balance,
+			Recomputed:    issue.Recomputed,
+			Reason:        issue.Reason,
+		})
+	}
+
+	return stream.Send(&accounting_iface.StatementBalanceResponse{
+		Data: &accounting_iface.StatementBalanceResponse_Check{Check: &check},
+	})
+}
+
+func sheetRequest(teamID uint64, asOf, periodStart *timestamppb.Timestamp) *BalanceSheetRequest {
+	sheetReq := BalanceSheetRequest{
+		TeamID: teamID,
+	}
+	if asOf.IsValid() {
+		sheetReq.AsOf = asOf.AsTime()
+	}
+	if periodStart.IsValid() {
+		start := periodStart.AsTime()
+		sheetReq.PeriodStart = &start
+	}
+	return &sheetReq
+}
+
+// balanceSheet menyusun neraca ke result, section kosong kalau neraca tidak balance
+func (s *statementImpl) balanceSheet(ctx context.Context, pay *BalanceSheetRequest, result *BalanceSheetResponse) error {
+	var err error
+
+	asOf := pay.AsOf
+	if asOf.IsZero() {
+		asOf = time.Now()
+	}
+	result.AsOf = asOf
+
+	db := s.db.WithContext(ctx)
+	trange := &common.TimeFilterRange{
+		EndDate: timestamppb.New(asOf),
+	}
+	query := newDailyQuery(db, pay.TeamID, trange, &statementFilter{})
+
+	infos, err := query.keyInfos()
+	if err != nil {
+		return err
+	}
+
+	balances, err := query.keyBalances()
+	if err != nil {
+		return err
+	}
+
+	// laba sebelum periode berjalan
+	retainedBalances := map[accounting_core.AccountKey]*keyBalance{}
+	if pay.PeriodStart != nil {
+		query.end = query_dialect.ReportDay(*pay.PeriodStart).AddDate(0, 0, -1)
+		retainedBalances, err = query.keyBalances()
+		if err != nil {
+			return err
+		}
+	}
+
+	assets := &BalanceSheetSection{Items: []*BalanceSheetItem{}}
+	liabilities := &BalanceSheetSection{Items: []*BalanceSheetItem{}}
+	equity := &BalanceSheetSection{Items: []*BalanceSheetItem{}}
+	var earnings, retained float64
+
+	keys := []accounting_core.AccountKey{}
+	for key := range balances {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		bal := balances[key]
+		info := infos[key]
+
+		if len(info) != 1 {
+			reason := "account key tidak punya coa"
+			if len(info) > 1 {
+				reason = "account key punya lebih dari satu coa"
+			}
+			result.Issues = append(result.Issues, &BalanceSheetIssue{
+				AccountKey: string(key),
+				Balance:    bal.Balance,
+				Reason:     reason,
+			})
+			continue
+		}
+
+		coa := info[0].Coa
+		recomputed := bal.Debit - bal.Credit
+		if info[0].BalanceType == accounting_core.CreditBalance {
+			recomputed = bal.Credit - bal.Debit
+		}
+
+		if !accounting_core.CompareFloatSafe(recomputed, bal.Balance, accounting_core.PrecisionEpsilon) {
+			result.Issues = append(result.Issues, &BalanceSheetIssue{
+				AccountKey: string(key),
+				Coa:        coa.String(),
+				Balance:    bal.Balance,
+				Recomputed: recomputed,
+				Reason:     "running balance tidak sama dengan total debit credit",
+			})
+		}
+
+		switch coa {
+		case accounting_core.ASSET:
+			assets.add(key, bal.Debit-bal.Credit)
+		case accounting_core.LIABILITY:
+			liabilities.add(key, bal.Credit-bal.Debit)
+		case accounting_core.EQUITY:
+			equity.add(key, bal.Credit-bal.Debit)
+		case accounting_core.REVENUE, accounting_core.EXPENSE:
+			earnings += bal.Credit - bal.Debit
+			if old := retainedBalances[key]; old != nil {
+				retained += old.Credit - old.Debit
+			}
+		default:
+			result.Issues = append(result.Issues, &BalanceSheetIssue{
+				AccountKey: string(key),
+				Coa:        coa.String(),
+				Balance:    bal.Balance,
+				Reason:     "coa tidak dikenal",
+			})
+		}
+	}
+
+	if pay.PeriodStart != nil {
+		equity.add(RetainedEarningsKey, retained)
+	}
+	equity.add(CurrentEarningsKey, earnings-retained)
+
+	result.Difference = assets.Total - (liabilities.Total + equity.Total)
+	result.Balanced = accounting_core.CompareFloatSafe(result.Difference, 0, accounting_core.PrecisionEpsilon) &&
+		len(result.Issues) == 0
+
+	if !result.Balanced {
+		if len(result.Issues) != 0 {
+			return nil
+		}
+
+		// selisih tanpa masalah di daily balance, cari sumbernya di journal entry
+		end := query_dialect.ReportDayStart(query_dialect.ReportDay(asOf).AddDate(0, 0, 1))
+		err = s.ledgerIssues(db, pay.TeamID, end, balances, infos, result)
+		if err != nil {
+			return err
+		}
+
+		err = s.unbalancedTxIssues(db, pay.TeamID, end, infos, result)
+		return err
+	}
+
+	result.Assets = assets
+	result.Liabilities = liabilities
+	result.Equity = equity
+
+	return nil
+}
+
+func (b *BalanceSheetSection) add(key accounting_core.AccountKey, amount float64) {
+	b.Items = append(b.Items, &BalanceSheetItem{
+		AccountKey: string(key),
+		Balance:    amount,
+	})
+	b.Total += amount
+}
+
+type ledgerSum struct {
+	TransactionID uint64
+	AccountKey    accounting_core.AccountKey
+	Debit         float64
+	Credit        float64
+}
+
+func (l *ledgerSum) issue(infos map[accounting_core.AccountKey][]*keyInfo, reason string) *BalanceSheetIssue {
+	issue := BalanceSheetIssue{
+		AccountKey:    string(l.AccountKey),
+		TransactionID: l.TransactionID,
+		Balance:       l.Debit - l.Credit,
+		Reason:        reason,
+	}
+	if info := infos[l.AccountKey]; len(info) == 1 {
+		issue.Coa = info[0].Coa.String()
+	}
+	return &issue
+}
+
+// ledgerIssues account key yang total daily balance nya beda dengan journal entry,
+// entry rollback dibalik seperti di tabel daily
+func (s *statementImpl) ledgerIssues(
+	db *gorm.DB,
+	teamID uint64,
+	end time.Time,
+	balances map[accounting_core.AccountKey]*keyBalance,
+	infos map[accounting_core.AccountKey][]*keyInfo,
+	result *BalanceSheetResponse,
+) error {
+	sums := []*ledgerSum{}
+	err := db.
+		Table("journal_entries je").
+		Joins("join accounts a on a.id = je.account_id").
+		Select([]string{
+			"a.account_key",
+			"sum(case when je.rollback then je.credit * -1 else je.debit end) as debit",
+			"sum(case when je.rollback then je.debit * -1 else je.credit end) as credit",
+		}).
+		Where("je.team_id = ?", teamID).
+		Where("je.entry_time < ?", end).
+		Group("a.account_key").
+		Order("a.account_key").
+		Find(&sums).
+		Error
+
+	if err != nil {
+		return err
+	}
+
+	for _, sum := range sums {
+		bal := balances[sum.AccountKey]
+		if bal == nil {
+			bal = &keyBalance{}
+		}
+
+		if accounting_core.CompareFloatSafe(bal.Debit, sum.Debit, accounting_core.PrecisionEpsilon) &&
+			accounting_core.CompareFloatSafe(bal.Credit, sum.Credit, accounting_core.PrecisionEpsilon) {
+			continue
+		}
+
+		issue := sum.issue(infos, "daily balance tidak sama dengan journal entry")
+		issue.Recomputed = issue.Balance
+		issue.Balance = bal.Debit - bal.Credit
+		result.Issues = append(result.Issues, issue)
+	}
+
+	return nil
+}
+
+// unbalancedTxIssues account key di transaksi yang debit dan credit nya tidak sama
+func (s *statementImpl) unbalancedTxIssues(
+	db *gorm.DB,
+	teamID uint64,
+	end time.Time,
+	infos map[accounting_core.AccountKey][]*keyInfo,
+	result *BalanceSheetResponse,
+) error {
+	txQ := db.
+		Table("journal_entries je").
+		Select("je.transaction_id").
+		Where("je.team_id = ?", teamID).
+		Where("je.entry_time < ?", end).
+		Group("je.transaction_id").
+		Having("abs(sum(je.debit) - sum(je.credit)) > ?", accounting_core.PrecisionEpsilon)
+
+	sums := []*ledgerSum{}
+	err := db.
+		Table("journal_entries je").
+		Joins("join accounts a on a.id = je.account_id").
+		Select([]string{
+			"je.transaction_id",
+			"a.account_key",
+			"sum(je.debit) as debit",
+			"sum(je.credit) as credit",
+		}).
+		Where("je.team_id = ?", teamID).
+		Where("je.entry_time < ?", end).
+		Where("je.transaction_id in (?)", txQ).
+		Group("je.transaction_id, a.account_key").
+		Order("je.transaction_id, a.account_key").
+		Find(&sums).
+		Error
+
+	if err != nil {
+		return err
+	}
+
+	for _, sum := range sums {
+		result.Issues = append(result.Issues, sum.issue(infos, "transaksi tidak balance"))
+	}
+
+	return nil
+}
+
+func (b *BalanceSheetSection) proto() *accounting_iface.BalanceSection {
+	result := accounting_iface.BalanceSection{
+		Data:  []*accounting_iface.ItemDetail{},
+		Total: b.Total,
+	}
+	for _, item := range b.Items {
+		result.Data = append(result.Data, &accounting_iface.ItemDetail{
+			AccountKey: item.AccountKey,
+			Balance:    item.Balance,
+		})
+	}
+	return &result
+}
@@ -0,0 +1,220 @@
+package statement_test
+
+import (
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/pdcgo/accounting_service/accounting_core"
+	"github.com/pdcgo/accounting_service/accounting_mock"
+	"github.com/pdcgo/accounting_service/statement"
+	"github.com/pdcgo/schema/services/accounting_iface/v1"
+	"github.com/pdcgo/schema/services/accounting_iface/v1/accounting_ifaceconnect"
+	"github.com/pdcgo/shared/pkg/moretest"
+	"github.com/pdcgo/shared/pkg/moretest/moretest_mock"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/gorm"
+)
+
+type balanceResult struct {
+	sections map[string]*accounting_iface.BalanceSection
+	check    *accounting_iface.BalanceCheck
+}
+
+func statementBalance(t *testing.T, client accounting_ifaceconnect.StatementServiceClient, pay *accounting_iface.StatementBalanceRequest) *balanceResult {
+	stream, err := client.StatementBalance(t.Context(), connect.NewRequest(pay))
+	assert.Nil(t, err)
+
+	result := balanceResult{
+		sections: map[string]*accounting_iface.BalanceSection{},
+	}
+	for stream.Receive() {
+		switch data := stream.Msg().Data.(type) {
+		case *accounting_iface.StatementBalanceResponse_Assets:
+			result.sections["assets"] = data.Assets
+		case *accounting_iface.StatementBalanceResponse_Liabilities:
+			result.sections["liabilities"] = data.Liabilities
+		case *accounting_iface.StatementBalanceResponse_Equity:
+			result.sections["equity"] = data.Equity
+		case *accounting_iface.StatementBalanceResponse_Check:
+			result.check = data.Check
+		}
+	}
+	assert.Nil(t, stream.Err())
+	return &result
+}
+
+func TestStatementBalanceSheet(t *testing.T) {
+	var db gorm.DB
+
+	moretest.Suite(t, "testing balance sheet",
+		moretest.SetupListFunc{
+			moretest_mock.MockSqliteDatabase(&db),
+			migrateStatement(&db),
+			accounting_mock.PopulateAccountKey(&db, 1),
+			seedStatementEntries(&db, 1, []*statementEntry{
+				{key: accounting_core.CashAccount, day: time.Date(2025, 9, 10, 10, 0, 0, 0, jkt), debit: 4000},
+				{key: accounting_core.SalesRevenueAccount, day: time.Date(2025, 9, 10, 10, 0, 0, 0, jkt), credit: 4000},
+				{key: accounting_core.CashAccount, day: time.Date(2025, 10, 1, 10, 0, 0, 0, jkt), debit: 10000},
+				{key: accounting_core.SalesRevenueAccount, day: time.Date(2025, 10, 1, 10, 0, 0, 0, jkt), credit: 10000},
+				{key: accounting_core.AdsExpenseAccount, day: time.Date(2025, 10, 2, 10, 0, 0, 0, jkt), debit: 1000},
+				{key: accounting_core.CashAccount, day: time.Date(2025, 10, 2, 10, 0, 0, 0, jkt), credit: 1000},
+			}),
+		},
+		func(t *testing.T) {
+			client := newStatementClient(t, &db)
+
+			items := func(section *accounting_iface.BalanceSection) map[string]float64 {
+				result := map[string]float64{}
+				for _, item := range section.Data {
+					result[item.AccountKey] = item.Balance
+				}
+				return result
+			}
+
+			t.Run("neraca balance", func(t *testing.T) {
+				result := statementBalance(t, client, &accounting_iface.StatementBalanceRequest{
+					TeamId:      1,
+					AsOf:        timestamppb.New(time.Date(2025, 10, 31, 0, 0, 0, 0, jkt)),
+					PeriodStart: timestamppb.New(time.Date(2025, 10, 1, 0, 0, 0, 0, jkt)),
+				})
+
+				assert.True(t, result.check.Balanced)
+				assert.Empty(t, result.check.Issues)
+				assert.Equal(t, 13000.0, result.sections["assets"].Total)
+				assert.Equal(t, 13000.0, result.sections["equity"].Total)
+
+				equity := items(result.sections["equity"])
+				assert.Equal(t, 4000.0, equity[statement.RetainedEarningsKey])
+				assert.Equal(t, 9000.0, equity[statement.CurrentEarningsKey])
+			})
+
+			t.Run("neraca sebelum transaksi oktober", func(t *testing.T) {
+				result := statementBalance(t, client, &accounting_iface.StatementBalanceRequest{
+					TeamId: 1,
+					AsOf:   timestamppb.New(time.Date(2025, 9, 30, 0, 0, 0, 0, jkt)),
+				})
+
+				assert.True(t, result.check.Balanced)
+				assert.Equal(t, 4000.0, result.sections["assets"].Total)
+			})
+
+			t.Run("neraca tidak balance", func(t *testing.T) {
+				err := db.
+					Model(&accounting_core.AccountKeyDailyBalance{}).
+					Where("account_key = ?", accounting_core.CashAccount).
+					Update("debit", gorm.Expr("debit + 500")).
+					Error
+				assert.Nil(t, err)
+
+				result := statementBalance(t, client, &accounting_iface.StatementBalanceRequest{
+					TeamId: 1,
+					AsOf:   timestamppb.New(time.Date(2025, 10, 31, 0, 0, 0, 0, jkt)),
+				})
+
+				assert.False(t, result.check.Balanced)
+				assert.Empty(t, result.sections)
+				assert.NotEmpty(t, result.check.Issues)
+				assert.Equal(t, string(accounting_core.CashAccount), result.check.Issues[0].AccountKey)
+			})
+		},
+	)
+}
+
+func TestStatementBalanceSheetLedgerIssue(t *testing.T) {
+	var db gorm.DB
+
+	moretest.Suite(t, "testing balance sheet tidak balance dari journal entry",
+		moretest.SetupListFunc{
+			moretest_mock.MockSqliteDatabase(&db),
+			migrateStatement(&db),
+			accounting_mock.PopulateAccountKey(&db, 1),
+			seedStatementEntries(&db, 1, []*statementEntry{
+				{key: accounting_core.CashAccount, day: time.Date(2025, 10, 1, 10, 0, 0, 0, jkt), debit: 10000, txID: 1},
+				{key: accounting_core.SalesRevenueAccount, day: time.Date(2025, 10, 1, 10, 0, 0, 0, jkt), credit: 10000, txID: 1},
+				{key: accounting_core.CashAccount, day: time.Date(2025, 10, 2, 10, 0, 0, 0, jkt), debit: 700, txID: 2},
+			}),
+		},
+		func(t *testing.T) {
+			client := newStatementClient(t, &db)
+
+			sheet := func(t *testing.T) *accounting_iface.BalanceCheck {
+				result := statementBalance(t, client, &accounting_iface.StatementBalanceRequest{
+					TeamId: 1,
+					AsOf:   timestamppb.New(time.Date(2025, 10, 31, 0, 0, 0, 0, jkt)),
+				})
+				return result.check
+			}
+
+			t.Run("transaksi tidak balance", func(t *testing.T) {
+				result := sheet(t)
+				assert.False(t, result.Balanced)
+				assert.Equal(t, 700.0, result.Difference)
+				assert.Len(t, result.Issues, 1)
+
+				issue := result.Issues[0]
+				assert.Equal(t, string(accounting_core.CashAccount), issue.AccountKey)
+				assert.Equal(t, uint64(2), issue.TransactionId)
+				assert.Equal(t, 700.0, issue.Balance)
+			})
+
+			t.Run("daily balance beda dengan journal entry", func(t *testing.T) {
+				err := db.
+					Model(&accounting_core.JournalEntry{}).
+					Where("transaction_id = ?", 2).
+					Delete(&accounting_core.JournalEntry{}).
+					Error
+				assert.Nil(t, err)
+
+				result := sheet(t)
+				assert.False(t, result.Balanced)
+				assert.Len(t, result.Issues, 1)
+
+				issue := result.Issues[0]
+				assert.Equal(t, string(accounting_core.CashAccount), issue.AccountKey)
+				assert.Equal(t, uint64(0), issue.TransactionId)
+				assert.Equal(t, 10700.0, issue.Balance)
+				assert.Equal(t, 10000.0, issue.Recomputed)
+			})
+		},
+	)
+}
+
+func TestStatementBalanceSheetRollback(t *testing.T) {
+	var db gorm.DB
+
+	moretest.Suite(t, "testing balance sheet dengan transaksi rollback",
+		moretest.SetupListFunc{
+			moretest_mock.MockSqliteDatabase(&db),
+			migrateStatement(&db),
+			accounting_mock.PopulateAccountKey(&db, 1),
+			seedStatementEntries(&db, 1, []*statementEntry{
+				{key: accounting_core.CashAccount, day: time.Date(2025, 10, 1, 10, 0, 0, 0, jkt), debit: 10000, txID: 1},
+				{key: accounting_core.SalesRevenueAccount, day: time.Date(2025, 10, 1, 10, 0, 0, 0, jkt), credit: 10000, txID: 1},
+				{key: accounting_core.CashAccount, day: time.Date(2025, 10, 2, 10, 0, 0, 0, jkt), debit: 2000, txID: 2},
+				{key: accounting_core.SalesRevenueAccount, day: time.Date(2025, 10, 2, 10, 0, 0, 0, jkt), credit: 2000, txID: 2},
+				{key: accounting_core.CashAccount, day: time.Date(2025, 10, 3, 10, 0, 0, 0, jkt), credit: 2000, txID: 2, rollback: true},
+				{key: accounting_core.SalesRevenueAccount, day: time.Date(2025, 10, 3, 10, 0, 0, 0, jkt), debit: 2000, txID: 2, rollback: true},
+				{key: accounting_core.CashAccount, day: time.Date(2025, 10, 4, 10, 0, 0, 0, jkt), debit: 700, txID: 3},
+			}),
+		},
+		func(t *testing.T) {
+			client := newStatementClient(t, &db)
+
+			result := statementBalance(t, client, &accounting_iface.StatementBalanceRequest{
+				TeamId: 1,
+				AsOf:   timestamppb.New(time.Date(2025, 10, 31, 0, 0, 0, 0, jkt)),
+			})
+
+			// entry rollback tidak boleh terbaca sebagai selisih daily balance
+			assert.False(t, result.check.Balanced)
+			assert.Len(t, result.check.Issues, 1)
+
+			issue := result.check.Issues[0]
+			assert.Equal(t, string(accounting_core.CashAccount), issue.AccountKey)
+			assert.Equal(t, uint64(3), issue.TransactionId)
+			assert.Equal(t, 700.0, issue.Balance)
+		},
+	)
+}
@@ -0,0 +1,245 @@
+package statement
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/pdcgo/accounting_service/accounting_core"
+	"github.com/pdcgo/accounting_service/query_dialect"
+	"github.com/pdcgo/schema/services/accounting_iface/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// item laba bersih di section operating
+const CashFlowNetIncomeKey = "net_income"
+
+const defaultCashFlowTransactionLimit = 20
+
+type CashFlowSectionType string
+
+const (
+	CashFlowOperating CashFlowSectionType = "operating"
+	CashFlowInvesting CashFlowSectionType = "investing"
+	CashFlowFinancing CashFlowSectionType = "financing"
+)
+
+// account key yang tidak mengikuti klasifikasi default dari coa,
+// default nya asset dan liability masuk operating, equity masuk financing.
+// Pinjaman ke team lain masuk investing, pinjaman dari team lain masuk financing.
+var cashFlowSectionKeys = map[accounting_core.AccountKey]CashFlowSectionType{
+	accounting_core.LoanReceivableAccount: CashFlowInvesting,
+	accounting_core.LoanPayableAccount:    CashFlowFinancing,
+	accounting_core.DebtAccount:           CashFlowFinancing,
+	accounting_core.CapitalStartAccount:   CashFlowFinancing,
+}
+
+// StatementCashFlow arus kas metode tidak langsung, dimulai dari laba bersih
+// lalu disesuaikan dengan perubahan account selain cash
+func (s *statementImpl) StatementCashFlow(
+	ctx context.Context,
+	req *connect.Request[accounting_iface.StatementCashFlowRequest],
+) (*connect.Response[accounting_iface.StatementCashFlowResponse], error) {
+	var err error
+	pay := req.Msg
+
+	result := accounting_iface.StatementCashFlowResponse{
+		Operating: &accounting_iface.CashFlowSection{Items: []*accounting_iface.CashFlowItem{}},
+		Investing: &accounting_iface.CashFlowSection{Items: []*accounting_iface.CashFlowItem{}},
+		Financing: &accounting_iface.CashFlowSection{Items: []*accounting_iface.CashFlowItem{}},
+	}
+
+	err = s.
+		auth.
+		AuthIdentityFromHeader(req.Header()).
+		Err()
+
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	if pay.TeamId == 0 {
+		return connect.NewResponse(&result), connect.NewError(connect.CodeInvalidArgument, errors.New("team_id required"))
+	}
+
+	trange := pay.GetTimeRange()
+	if !trange.GetStartDate().IsValid() ||
+		!trange.GetEndDate().IsValid() ||
+		trange.EndDate.AsTime().Before(trange.StartDate.AsTime()) {
+		return connect.NewResponse(&result), connect.NewError(connect.CodeInvalidArgument, errors.New("invalid start end"))
+	}
+
+	limit := int(pay.TransactionLimit)
+	if limit <= 0 {
+		limit = defaultCashFlowTransactionLimit
+	}
+
+	db := s.db.WithContext(ctx)
+	query := newDailyQuery(db, pay.TeamId, trange, &statementFilter{})
+
+	infos, err := query.keyInfos()
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	balances, err := query.keyBalances()
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	// saldo cash sebelum periode
+	opening := *query
+	opening.start = time.Time{}
+	opening.end = query.start.AddDate(0, 0, -1)
+	openingBalances, err := opening.keyBalances()
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	if cash := openingBalances[accounting_core.CashAccount]; cash != nil {
+		result.OpeningCash = cash.Debit - cash.Credit
+	}
+	if cash := balances[accounting_core.CashAccount]; cash != nil {
+		result.ActualChange = cash.Debit - cash.Credit
+	}
+	result.ClosingCash = result.OpeningCash + result.ActualChange
+
+	keys := []accounting_core.AccountKey{}
+	for key := range balances {
+		if key == accounting_core.CashAccount {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	netIncome := &accounting_iface.CashFlowItem{
+		AccountKey:   CashFlowNetIncomeKey,
+		Transactions: []*accounting_iface.CashFlowTransaction{},
+	}
+	addCashFlowItem(result.Operating, netIncome)
+	incomeKeys := []accounting_core.AccountKey{}
+
+	for _, key := range keys {
+		bal := balances[key]
+		// efek ke cash: debit di account lain berarti cash keluar, credit berarti cash masuk
+		amount := bal.Credit - bal.Debit
+
+		var coa accounting_core.CoaCode
+		if info := infos[key]; len(info) > 0 {
+			coa = info[0].Coa
+		}
+
+		if coa == accounting_core.REVENUE || coa == accounting_core.EXPENSE {
+			netIncome.Amount += amount
+			result.Operating.Total += amount
+			incomeKeys = append(incomeKeys, key)
+			continue
+		}
+
+		if accounting_core.CompareFloatSafe(amount, 0, accounting_core.PrecisionEpsilon) {
+			continue
+		}
+
+		txs, err := query.cashFlowTransactions([]accounting_core.AccountKey{key}, limit)
+		if err != nil {
+			return connect.NewResponse(&result), err
+		}
+
+		item := &accounting_iface.CashFlowItem{
+			AccountKey:   string(key),
+			Amount:       amount,
+			Transactions: txs,
+		}
+
+		switch cashFlowSection(key, coa) {
+		case CashFlowInvesting:
+			addCashFlowItem(result.Investing, item)
+		case CashFlowFinancing:
+			addCashFlowItem(result.Financing, item)
+		default:
+			addCashFlowItem(result.Operating, item)
+		}
+	}
+
+	if len(incomeKeys) != 0 {
+		netIncome.Transactions, err = query.cashFlowTransactions(incomeKeys, limit)
+		if err != nil {
+			return connect.NewResponse(&result), err
+		}
+	}
+
+	result.NetIncome = netIncome.Amount
+	result.NetChange = result.Operating.Total + result.Investing.Total + result.Financing.Total
+	result.Difference = result.NetChange - result.ActualChange
+	result.Reconciled = accounting_core.CompareFloatSafe(result.Difference, 0, accounting_core.PrecisionEpsilon)
+
+	return connect.NewResponse(&result), nil
+}
+
+func cashFlowSection(key accounting_core.AccountKey, coa accounting_core.CoaCode) CashFlowSectionType {
+	if section, ok := cashFlowSectionKeys[key]; ok {
+		return section
+	}
+
+	if coa == accounting_core.EQUITY {
+		return CashFlowFinancing
+	}
+
+	return CashFlowOperating
+}
+
+func addCashFlowItem(section *accounting_iface.CashFlowSection, item *accounting_iface.CashFlowItem) {
+	section.Items = append(section.Items, item)
+	section.Total += item.Amount
+}
+
+// cashFlowTransactions transaksi terbesar di account key selama periode, amount dalam efek ke cash
+func (d *dailyQuery) cashFlowTransactions(keys []accounting_core.AccountKey, limit int) ([]*accounting_iface.CashFlowTransaction, error) {
+	query := d.
+		db.
+		Table("journal_entries je").
+		Joins("join accounts a on a.id = je.account_id").
+		Joins("left join transactions t on t.id = je.transaction_id").
+		Select([]string{
+			"je.transaction_id",
+			`max(t."desc") as "desc"`,
+			query_dialect.NewDialect(d.db).EpochMicro("min(je.entry_time)") + " as entry_time",
+			"sum(je.credit - je.debit) as amount",
+		}).
+		Where("je.team_id = ?", d.teamID).
+		Where("a.account_key in ?", keys).
+		Group("je.transaction_id").
+		Order("abs(sum(je.credit - je.debit)) desc").
+		Limit(limit)
+
+	if !d.start.IsZero() {
+		query = query.Where("je.entry_time >= ?", query_dialect.ReportDayStart(d.start))
+	}
+
+	if !d.end.IsZero() {
+		query = query.Where("je.entry_time < ?", query_dialect.ReportDayStart(d.end.AddDate(0, 0, 1)))
+	}
+
+	rows := []*struct {
+		TransactionID uint
+		Desc          string
+		EntryTime     int64
+		Amount        float64
+	}{}
+	err := query.Find(&rows).Error
+
+	result := []*accounting_iface.CashFlowTransaction{}
+	for _, row := range rows {
+		result = append(result, &accounting_iface.CashFlowTransaction{
+			TransactionId: uint64(row.TransactionID),
+			Desc:          row.Desc,
+			EntryTime:     timestamppb.New(time.UnixMicro(row.EntryTime)),
+			Amount:        row.Amount,
+		})
+	}
+
+	return result, err
+}
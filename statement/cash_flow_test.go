@@ -0,0 +1,123 @@
+package statement_test
+
+import (
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/pdcgo/accounting_service/accounting_core"
+	"github.com/pdcgo/accounting_service/accounting_mock"
+	"github.com/pdcgo/accounting_service/statement"
+	"github.com/pdcgo/schema/services/accounting_iface/v1"
+	"github.com/pdcgo/schema/services/common/v1"
+	"github.com/pdcgo/shared/pkg/moretest"
+	"github.com/pdcgo/shared/pkg/moretest/moretest_mock"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/gorm"
+)
+
+func TestStatementCashFlow(t *testing.T) {
+	var db gorm.DB
+
+	sep := time.Date(2025, 9, 10, 10, 0, 0, 0, jkt)
+	oct := time.Date(2025, 10, 5, 10, 0, 0, 0, jkt)
+
+	moretest.Suite(t, "testing cash flow",
+		moretest.SetupListFunc{
+			moretest_mock.MockSqliteDatabase(&db),
+			migrateStatement(&db),
+			accounting_mock.PopulateAccountKey(&db, 1),
+			func(t *testing.T) func() error {
+				create := accounting_core.NewCreateAccount(&db)
+				err := create.Create(accounting_core.CreditBalance, accounting_core.EQUITY, 1, accounting_core.OwnerCapitalAccount, "owner capital")
+				assert.Nil(t, err)
+				err = create.Create(accounting_core.DebitBalance, accounting_core.EQUITY, 1, accounting_core.Prive, "prive")
+				assert.Nil(t, err)
+				return nil
+			},
+			seedStatementEntries(&db, 1, []*statementEntry{
+				// modal awal bulan sebelumnya
+				{key: accounting_core.CashAccount, day: sep, debit: 50000, txID: 1},
+				{key: accounting_core.OwnerCapitalAccount, day: sep, credit: 50000, txID: 1},
+				// penjualan, sebagian belum cair
+				{key: accounting_core.SellingReceivableAccount, day: oct, debit: 10000, txID: 2},
+				{key: accounting_core.SalesRevenueAccount, day: oct, credit: 10000, txID: 2},
+				{key: accounting_core.CashAccount, day: oct, debit: 6000, txID: 3},
+				{key: accounting_core.SellingReceivableAccount, day: oct, credit: 6000, txID: 3},
+				// beli stock
+				{key: accounting_core.StockReadyAccount, day: oct, debit: 3000, txID: 4},
+				{key: accounting_core.CashAccount, day: oct, credit: 3000, txID: 4},
+				// prive
+				{key: accounting_core.Prive, day: oct, debit: 1000, txID: 5},
+				{key: accounting_core.CashAccount, day: oct, credit: 1000, txID: 5},
+				// piutang ke pihak lain
+				{key: accounting_core.ReceivableAccount, day: oct, debit: 500, txID: 6},
+				{key: accounting_core.CashAccount, day: oct, credit: 500, txID: 6},
+				// pinjaman ke team lain
+				{key: accounting_core.LoanReceivableAccount, day: oct, debit: 2000, txID: 7},
+				{key: accounting_core.CashAccount, day: oct, credit: 2000, txID: 7},
+				// pinjaman dari team lain
+				{key: accounting_core.CashAccount, day: oct, debit: 1500, txID: 8},
+				{key: accounting_core.LoanPayableAccount, day: oct, credit: 1500, txID: 8},
+			}),
+		},
+		func(t *testing.T) {
+			client := newStatementClient(t, &db)
+
+			res, err := client.StatementCashFlow(t.Context(), connect.NewRequest(&accounting_iface.StatementCashFlowRequest{
+				TeamId: 1,
+				TimeRange: &common.TimeFilterRange{
+					StartDate: timestamppb.New(time.Date(2025, 10, 1, 0, 0, 0, 0, jkt)),
+					EndDate:   timestamppb.New(time.Date(2025, 10, 31, 0, 0, 0, 0, jkt)),
+				},
+			}))
+			assert.Nil(t, err)
+
+			result := res.Msg
+			assert.Equal(t, 10000.0, result.NetIncome)
+			assert.Equal(t, 50000.0, result.OpeningCash)
+			assert.Equal(t, 51000.0, result.ClosingCash)
+			assert.Equal(t, 1000.0, result.ActualChange)
+			assert.Equal(t, 1000.0, result.NetChange)
+			assert.True(t, result.Reconciled)
+
+			for name, section := range map[string]*accounting_iface.CashFlowSection{
+				"operating": result.Operating,
+				"investing": result.Investing,
+				"financing": result.Financing,
+			} {
+				assert.NotEmpty(t, section.Items, name)
+			}
+
+			operating := map[string]*accounting_iface.CashFlowItem{}
+			for _, item := range result.Operating.Items {
+				operating[item.AccountKey] = item
+			}
+			assert.Equal(t, 2500.0, result.Operating.Total)
+			assert.Equal(t, -4000.0, operating[string(accounting_core.SellingReceivableAccount)].Amount)
+			assert.Equal(t, -3000.0, operating[string(accounting_core.StockReadyAccount)].Amount)
+			assert.Len(t, operating[string(accounting_core.SellingReceivableAccount)].Transactions, 2)
+			assert.Equal(t, -500.0, operating[string(accounting_core.ReceivableAccount)].Amount)
+
+			netIncome := operating[statement.CashFlowNetIncomeKey]
+			assert.Equal(t, 10000.0, netIncome.Amount)
+			assert.Len(t, netIncome.Transactions, 1)
+			assert.Equal(t, uint64(2), netIncome.Transactions[0].TransactionId)
+
+			assert.Equal(t, -2000.0, result.Investing.Total)
+			assert.Len(t, result.Investing.Items, 1)
+			assert.Equal(t, string(accounting_core.LoanReceivableAccount), result.Investing.Items[0].AccountKey)
+			assert.Equal(t, uint64(7), result.Investing.Items[0].Transactions[0].TransactionId)
+
+			financing := map[string]*accounting_iface.CashFlowItem{}
+			for _, item := range result.Financing.Items {
+				financing[item.AccountKey] = item
+			}
+			assert.Equal(t, 500.0, result.Financing.Total)
+			assert.Len(t, result.Financing.Items, 2)
+			assert.Equal(t, uint64(5), financing[string(accounting_core.Prive)].Transactions[0].TransactionId)
+			assert.Equal(t, 1500.0, financing[string(accounting_core.LoanPayableAccount)].Amount)
+		},
+	)
+}
@@ -0,0 +1,262 @@
+package statement
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/pdcgo/accounting_service/accounting_core"
+	"github.com/pdcgo/accounting_service/query_dialect"
+	"github.com/pdcgo/schema/services/accounting_iface/v1"
+	"github.com/pdcgo/schema/services/common/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// item total di section expense
+const CompareExpenseTotalKey = "total_expense"
+
+// comparePeriod menghitung periode pembanding dari preset, semua dalam hari laporan
+func comparePeriod(curStart, curEnd time.Time, pay *accounting_iface.StatementCompareRequest) (start, end time.Time, err error) {
+	switch pay.Preset {
+	case accounting_iface.ComparePreset_COMPARE_PRESET_PREVIOUS_PERIOD, accounting_iface.ComparePreset_COMPARE_PRESET_UNSPECIFIED:
+		// periode bulan penuh digeser per bulan, selain itu digeser sejumlah hari
+		nextDay := curEnd.AddDate(0, 0, 1)
+		if curStart.Day() == 1 && nextDay.Day() == 1 {
+			months := (nextDay.Year()-curStart.Year())*12 + int(nextDay.Month()-curStart.Month())
+			return curStart.AddDate(0, -months, 0), curStart.AddDate(0, 0, -1), nil
+		}
+
+		days := int(curEnd.Sub(curStart).Hours()/24) + 1
+		return curStart.AddDate(0, 0, -days), curStart.AddDate(0, 0, -1), nil
+
+	case accounting_iface.ComparePreset_COMPARE_PRESET_LAST_YEAR:
+		end = curEnd.AddDate(-1, 0, 0)
+		// akhir bulan tetap akhir bulan, misal 29 feb
+		if curEnd.AddDate(0, 0, 1).Day() == 1 {
+			end = time.Date(curEnd.Year()-1, curEnd.Month()+1, 0, 0, 0, 0, 0, time.UTC)
+		}
+		return curStart.AddDate(-1, 0, 0), end, nil
+
+	case accounting_iface.ComparePreset_COMPARE_PRESET_CUSTOM:
+		compareRange := pay.CompareRange
+		if !validRange(compareRange) {
+			return start, end, errors.New("invalid compare_range")
+		}
+		return query_dialect.ReportDay(compareRange.StartDate.AsTime()), query_dialect.ReportDay(compareRange.EndDate.AsTime()), nil
+	}
+
+	return start, end, errors.New("unknown preset " + pay.Preset.String())
+}
+
+func validRange(timeRange *common.TimeFilterRange) bool {
+	return timeRange != nil &&
+		timeRange.StartDate != nil &&
+		timeRange.EndDate != nil &&
+		!timeRange.EndDate.AsTime().Before(timeRange.StartDate.AsTime())
+}
+
+func reportRange(start, end time.Time) *common.TimeFilterRange {
+	return &common.TimeFilterRange{
+		StartDate: timestamppb.New(start),
+		EndDate:   timestamppb.New(end),
+	}
+}
+
+type compareSummary struct {
+	balance    map[string]float64
+	profitLoss map[string]float64
+	expense    map[string]float64
+}
+
+// summary saldo neraca sampai end dan laba rugi selama start sampai end
+func compareSummaryFor(
+	query *dailyQuery,
+	infos map[accounting_core.AccountKey][]*keyInfo,
+) (*compareSummary, error) {
+	summary := compareSummary{
+		balance:    map[string]float64{},
+		profitLoss: map[string]float64{},
+		expense:    map[string]float64{},
+	}
+
+	closing := *query
+	closing.start = time.Time{}
+	balances, err := closing.keyBalances()
+	if err != nil {
+		return &summary, err
+	}
+
+	for key, bal := range balances {
+		info := infos[key]
+		if len(info) == 0 {
+			continue
+		}
+
+		switch info[0].Coa {
+		case accounting_core.ASSET:
+			summary.balance[string(key)] = bal.Debit - bal.Credit
+		case accounting_core.LIABILITY, accounting_core.EQUITY:
+			summary.balance[string(key)] = bal.Credit - bal.Debit
+		}
+	}
+
+	period, err := query.keyBalances()
+	if err != nil {
+		return &summary, err
+	}
+
+	var revenue, cogs, opex float64
+	for key, bal := range period {
+		info := infos[key]
+		if len(info) == 0 {
+			continue
+		}
+
+		switch info[0].Coa {
+		case accounting_core.REVENUE:
+			revenue += bal.Credit - bal.Debit
+		case accounting_core.EXPENSE:
+			amount := bal.Debit - bal.Credit
+			summary.expense[string(key)] = amount
+			summary.expense[CompareExpenseTotalKey] += amount
+			if slices.Contains(cogsAccounts, key) {
+				cogs += amount
+			} else {
+				opex += amount
+			}
+		}
+	}
+
+	summary.profitLoss[IncomeRevenueKey] = revenue
+	summary.profitLoss[IncomeCogsKey] = cogs
+	summary.profitLoss[IncomeGrossProfitKey] = revenue - cogs
+	summary.profitLoss[IncomeOperatingExpenseKey] = opex
+	summary.profitLoss[IncomeNetProfitKey] = revenue - cogs - opex
+
+	return &summary, nil
+}
+
+func compareItems(keys []string, current, compare map[string]float64) []*accounting_iface.CompareItem {
+	result := []*accounting_iface.CompareItem{}
+	for _, key := range keys {
+		item := accounting_iface.CompareItem{
+			Key:     key,
+			Current: current[key],
+			Compare: compare[key],
+		}
+		item.Change = item.Current - item.Compare
+
+		if !accounting_core.CompareFloatSafe(item.Compare, 0, accounting_core.PrecisionEpsilon) {
+			percent := item.Change / abs(item.Compare) * 100
+			item.ChangePercent = &percent
+		}
+
+		result = append(result, &item)
+	}
+	return result
+}
+
+// sortedKeys gabungan key dari dua periode
+func sortedKeys(current, compare map[string]float64) []string {
+	keys := []string{}
+	for key := range current {
+		keys = append(keys, key)
+	}
+	for key := range compare {
+		if _, ok := current[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func abs(v float64) float64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// StatementCompare membandingkan neraca, laba rugi dan beban dengan periode pembanding
+func (s *statementImpl) StatementCompare(
+	ctx context.Context,
+	req *connect.Request[accounting_iface.StatementCompareRequest],
+) (*connect.Response[accounting_iface.StatementCompareResponse], error) {
+	var err error
+	pay := req.Msg
+
+	result := accounting_iface.StatementCompareResponse{
+		Balance:    []*accounting_iface.CompareItem{},
+		ProfitLoss: []*accounting_iface.CompareItem{},
+		Expense:    []*accounting_iface.CompareItem{},
+	}
+
+	err = s.
+		auth.
+		AuthIdentityFromHeader(req.Header()).
+		Err()
+
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	if !validRange(pay.TimeRange) {
+		return connect.NewResponse(&result), connect.NewError(connect.CodeInvalidArgument, errors.New("invalid start end"))
+	}
+
+	start := query_dialect.ReportDay(pay.TimeRange.StartDate.AsTime())
+	end := query_dialect.ReportDay(pay.TimeRange.EndDate.AsTime())
+	compareStart, compareEnd, err := comparePeriod(start, end, pay)
+	if err != nil {
+		return connect.NewResponse(&result), connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	db := s.db.WithContext(ctx)
+	current := &dailyQuery{
+		db:     db,
+		teamID: uint(pay.TeamId),
+		filter: &statementFilter{},
+		start:  start,
+		end:    end,
+	}
+	compare := &dailyQuery{
+		db:     db,
+		teamID: uint(pay.TeamId),
+		filter: &statementFilter{},
+		start:  compareStart,
+		end:    compareEnd,
+	}
+
+	result.Current = reportRange(current.start, current.end)
+	result.Comparison = reportRange(compare.start, compare.end)
+
+	infos, err := current.keyInfos()
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	cur, err := compareSummaryFor(current, infos)
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	cmp, err := compareSummaryFor(compare, infos)
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	result.Balance = compareItems(sortedKeys(cur.balance, cmp.balance), cur.balance, cmp.balance)
+	result.ProfitLoss = compareItems([]string{
+		IncomeRevenueKey,
+		IncomeCogsKey,
+		IncomeGrossProfitKey,
+		IncomeOperatingExpenseKey,
+		IncomeNetProfitKey,
+	}, cur.profitLoss, cmp.profitLoss)
+	result.Expense = compareItems(sortedKeys(cur.expense, cmp.expense), cur.expense, cmp.expense)
+
+	return connect.NewResponse(&result), nil
+}
@@ -0,0 +1,99 @@
+package statement_test
+
+import (
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/pdcgo/accounting_service/accounting_core"
+	"github.com/pdcgo/accounting_service/accounting_mock"
+	"github.com/pdcgo/accounting_service/statement"
+	"github.com/pdcgo/schema/services/accounting_iface/v1"
+	"github.com/pdcgo/schema/services/common/v1"
+	"github.com/pdcgo/shared/authorization/authorization_mock"
+	"github.com/pdcgo/shared/pkg/moretest"
+	"github.com/pdcgo/shared/pkg/moretest/moretest_mock"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/gorm"
+)
+
+func TestStatementCompare(t *testing.T) {
+	var db gorm.DB
+
+	moretest.Suite(t, "testing comparative statement",
+		moretest.SetupListFunc{
+			moretest_mock.MockSqliteDatabase(&db),
+			migrateStatement(&db),
+			accounting_mock.PopulateAccountKey(&db, 1),
+			seedStatementEntries(&db, 1, []*statementEntry{
+				{key: accounting_core.CashAccount, day: time.Date(2024, 10, 3, 10, 0, 0, 0, jkt), debit: 2000},
+				{key: accounting_core.SalesRevenueAccount, day: time.Date(2024, 10, 3, 10, 0, 0, 0, jkt), credit: 2000},
+				{key: accounting_core.CashAccount, day: time.Date(2025, 9, 3, 10, 0, 0, 0, jkt), debit: 4000},
+				{key: accounting_core.SalesRevenueAccount, day: time.Date(2025, 9, 3, 10, 0, 0, 0, jkt), credit: 4000},
+				{key: accounting_core.AdsExpenseAccount, day: time.Date(2025, 9, 4, 10, 0, 0, 0, jkt), debit: 1000},
+				{key: accounting_core.CashAccount, day: time.Date(2025, 9, 4, 10, 0, 0, 0, jkt), credit: 1000},
+				{key: accounting_core.CashAccount, day: time.Date(2025, 10, 3, 10, 0, 0, 0, jkt), debit: 5000},
+				{key: accounting_core.SalesRevenueAccount, day: time.Date(2025, 10, 3, 10, 0, 0, 0, jkt), credit: 5000},
+				{key: accounting_core.AdsExpenseAccount, day: time.Date(2025, 10, 4, 10, 0, 0, 0, jkt), debit: 1500},
+				{key: accounting_core.CashAccount, day: time.Date(2025, 10, 4, 10, 0, 0, 0, jkt), credit: 1500},
+			}),
+		},
+		func(t *testing.T) {
+			service := statement.NewStatementService(&db, &authorization_mock.EmptyAuthorizationMock{})
+
+			compare := func(t *testing.T, preset accounting_iface.ComparePreset) *accounting_iface.StatementCompareResponse {
+				res, err := service.StatementCompare(t.Context(), connect.NewRequest(&accounting_iface.StatementCompareRequest{
+					TeamId: 1,
+					TimeRange: &common.TimeFilterRange{
+						StartDate: timestamppb.New(time.Date(2025, 10, 1, 0, 0, 0, 0, jkt)),
+						EndDate:   timestamppb.New(time.Date(2025, 10, 31, 0, 0, 0, 0, jkt)),
+					},
+					Preset: preset,
+				}))
+				assert.Nil(t, err)
+				return res.Msg
+			}
+
+			items := func(data []*accounting_iface.CompareItem) map[string]*accounting_iface.CompareItem {
+				result := map[string]*accounting_iface.CompareItem{}
+				for _, item := range data {
+					result[item.Key] = item
+				}
+				return result
+			}
+
+			t.Run("bulan sebelumnya", func(t *testing.T) {
+				result := compare(t, accounting_iface.ComparePreset_COMPARE_PRESET_PREVIOUS_PERIOD)
+				assert.Equal(t, time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC), result.Comparison.StartDate.AsTime())
+				assert.Equal(t, time.Date(2025, 9, 30, 0, 0, 0, 0, time.UTC), result.Comparison.EndDate.AsTime())
+
+				pl := items(result.ProfitLoss)
+				net := pl[statement.IncomeNetProfitKey]
+				assert.Equal(t, 3500.0, net.Current)
+				assert.Equal(t, 3000.0, net.Compare)
+				assert.Equal(t, 500.0, net.Change)
+				assert.InDelta(t, 16.666, *net.ChangePercent, 0.01)
+
+				expense := items(result.Expense)
+				assert.Equal(t, 500.0, expense[string(accounting_core.AdsExpenseAccount)].Change)
+
+				balance := items(result.Balance)
+				cash := balance[string(accounting_core.CashAccount)]
+				assert.Equal(t, 8500.0, cash.Current)
+				assert.Equal(t, 5000.0, cash.Compare)
+			})
+
+			t.Run("tahun sebelumnya", func(t *testing.T) {
+				result := compare(t, accounting_iface.ComparePreset_COMPARE_PRESET_LAST_YEAR)
+				assert.Equal(t, time.Date(2024, 10, 31, 0, 0, 0, 0, time.UTC), result.Comparison.EndDate.AsTime())
+
+				pl := items(result.ProfitLoss)
+				assert.Equal(t, 2000.0, pl[statement.IncomeRevenueKey].Compare)
+
+				expense := items(result.Expense)
+				assert.Nil(t, expense[string(accounting_core.AdsExpenseAccount)].ChangePercent)
+			})
+		},
+	)
+}
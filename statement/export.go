@@ -0,0 +1,183 @@
+package statement
+
+import (
+	"context"
+	"errors"
+
+	"connectrpc.com/connect"
+	"github.com/pdcgo/accounting_service/export"
+	"github.com/pdcgo/accounting_service/query_dialect"
+	"github.com/pdcgo/schema/services/accounting_iface/v1"
+	"github.com/pdcgo/schema/services/common/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var statementColumns = []*export.Column{
+	{Header: "period", Kind: export.TextColumn, Width: 12},
+	{Header: "section", Kind: export.TextColumn, Width: 18},
+	{Header: "account", Kind: export.TextColumn, Width: 30},
+	{Header: "amount", Kind: export.NumberColumn, Width: 20},
+}
+
+type statementRow struct {
+	period  string
+	section string
+	account string
+	amount  float64
+}
+
+func streamStatement(stream *connect.ServerStream[accounting_iface.ExportChunk], format export.Format, doc *export.Document, rows []*statementRow) error {
+	return export.Stream(stream, format, doc, int64(len(rows)), func(w export.Writer) error {
+		for _, row := range rows {
+			err := w.WriteRow(row.period, row.section, row.account, row.amount)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// StatementIncomeExport export laba rugi, filter shop marketplace tag sama dengan StatementIncome
+func (s *statementImpl) StatementIncomeExport(
+	ctx context.Context,
+	req *connect.Request[accounting_iface.StatementIncomeExportRequest],
+	stream *connect.ServerStream[accounting_iface.ExportChunk],
+) error {
+	var err error
+	pay := req.Msg
+
+	err = s.
+		auth.
+		AuthIdentityFromHeader(req.Header()).
+		Err()
+
+	if err != nil {
+		return err
+	}
+
+	trange := pay.TimeRange.GetRange()
+	if pay.TeamId == 0 || !trange.GetStartDate().IsValid() || !trange.GetEndDate().IsValid() {
+		return connect.NewError(connect.CodeInvalidArgument, errors.New("team_id, start and end required"))
+	}
+
+	periodLayout := "2006-01-02"
+	switch pay.TimeRange.Type {
+	case common.TimeType_TIME_TYPE_MONTHLY:
+		periodLayout = "2006-01"
+	case common.TimeType_TIME_TYPE_YEARLY:
+		periodLayout = "2006"
+	}
+
+	rows := []*statementRow{}
+	addDetails := func(section string, t *timestamppb.Timestamp, details []*accounting_iface.ItemDetail) {
+		for _, detail := range details {
+			rows = append(rows, &statementRow{
+				period:  t.AsTime().UTC().Format(periodLayout),
+				section: section,
+				account: detail.AccountKey,
+				amount:  detail.Balance,
+			})
+		}
+	}
+
+	incomeReq := accounting_iface.StatementIncomeRequest{
+		TeamId:      pay.TeamId,
+		TimeRange:   pay.TimeRange,
+		ShopId:      pay.ShopId,
+		Marketplace: pay.Marketplace,
+		TagId:       pay.TagId,
+	}
+
+	err = s.income(ctx, &incomeReq, func(res *accounting_iface.StatementIncomeResponse) error {
+		switch data := res.Data.(type) {
+		case *accounting_iface.StatementIncomeResponse_Revenue:
+			addDetails("revenue", data.Revenue.T, data.Revenue.Data)
+		case *accounting_iface.StatementIncomeResponse_Expense:
+			addDetails("expense", data.Expense.T, data.Expense.Data)
+		case *accounting_iface.StatementIncomeResponse_NetIncome:
+			addDetails("net_income", data.NetIncome.T, data.NetIncome.Data)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	doc := export.Document{
+		Title:   "Profit and Loss",
+		Start:   trange.StartDate.AsTime(),
+		End:     trange.EndDate.AsTime(),
+		Columns: statementColumns,
+	}
+
+	doc.TeamName, err = export.TeamName(s.db.WithContext(ctx), pay.TeamId)
+	if err != nil {
+		return err
+	}
+
+	return streamStatement(stream, pay.Format, &doc, rows)
+}
+
+// StatementBalanceSheetExport export neraca, neraca yang tidak balance diexport beserta issue nya
+func (s *statementImpl) StatementBalanceSheetExport(
+	ctx context.Context,
+	req *connect.Request[accounting_iface.StatementBalanceSheetExportRequest],
+	stream *connect.ServerStream[accounting_iface.ExportChunk],
+) error {
+	var err error
+	pay := req.Msg
+
+	err = s.
+		auth.
+		AuthIdentityFromHeader(req.Header()).
+		Err()
+
+	if err != nil {
+		return err
+	}
+
+	if pay.TeamId == 0 {
+		return connect.NewError(connect.CodeInvalidArgument, errors.New("team_id required"))
+	}
+
+	result := BalanceSheetResponse{
+		Issues: []*BalanceSheetIssue{},
+	}
+	err = s.balanceSheet(ctx, sheetRequest(pay.TeamId, pay.AsOf, pay.PeriodStart), &result)
+	if err != nil {
+		return err
+	}
+
+	period := query_dialect.ReportDay(result.AsOf).Format("2006-01-02")
+	rows := []*statementRow{}
+	addSection := func(name string, section *BalanceSheetSection) {
+		if section == nil {
+			return
+		}
+		for _, item := range section.Items {
+			rows = append(rows, &statementRow{period, name, item.AccountKey, item.Balance})
+		}
+		rows = append(rows, &statementRow{period, name, "total", section.Total})
+	}
+
+	addSection("assets", result.Assets)
+	addSection("liabilities", result.Liabilities)
+	addSection("equity", result.Equity)
+	for _, issue := range result.Issues {
+		rows = append(rows, &statementRow{period, "issue", issue.AccountKey + " " + issue.Reason, issue.Balance})
+	}
+
+	doc := export.Document{
+		Title:   "Balance Sheet",
+		End:     result.AsOf,
+		Columns: statementColumns,
+	}
+
+	doc.TeamName, err = export.TeamName(s.db.WithContext(ctx), pay.TeamId)
+	if err != nil {
+		return err
+	}
+
+	return streamStatement(stream, pay.Format, &doc, rows)
+}
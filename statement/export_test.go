@@ -0,0 +1,121 @@
+package statement_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/pdcgo/accounting_service/accounting_core"
+	"github.com/pdcgo/accounting_service/accounting_mock"
+	"github.com/pdcgo/accounting_service/export"
+	"github.com/pdcgo/schema/services/accounting_iface/v1"
+	"github.com/pdcgo/schema/services/common/v1"
+	"github.com/pdcgo/shared/db_models"
+	"github.com/pdcgo/shared/pkg/moretest"
+	"github.com/pdcgo/shared/pkg/moretest/moretest_mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/xuri/excelize/v2"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/gorm"
+)
+
+func TestStatementExport(t *testing.T) {
+	var db gorm.DB
+
+	moretest.Suite(t, "testing export statement",
+		moretest.SetupListFunc{
+			moretest_mock.MockSqliteDatabase(&db),
+			migrateStatement(&db),
+			func(t *testing.T) func() error {
+				err := db.AutoMigrate(&db_models.Team{})
+				assert.Nil(t, err)
+				err = db.Create(&db_models.Team{ID: 1, Name: "team satu", TeamCode: "T1"}).Error
+				assert.Nil(t, err)
+				return nil
+			},
+			accounting_mock.PopulateAccountKey(&db, 1),
+			seedStatementEntries(&db, 1, []*statementEntry{
+				{key: accounting_core.CashAccount, day: time.Date(2025, 10, 1, 10, 0, 0, 0, jkt), debit: 10000},
+				{key: accounting_core.SalesRevenueAccount, day: time.Date(2025, 10, 1, 10, 0, 0, 0, jkt), credit: 10000},
+				{key: accounting_core.AdsExpenseAccount, day: time.Date(2025, 10, 2, 10, 0, 0, 0, jkt), debit: 1000},
+				{key: accounting_core.CashAccount, day: time.Date(2025, 10, 2, 10, 0, 0, 0, jkt), credit: 1000},
+			}),
+		},
+		func(t *testing.T) {
+			client := newStatementClient(t, &db)
+
+			download := func(t *testing.T, stream *connect.ServerStreamForClient[accounting_iface.ExportChunk]) (*accounting_iface.ExportChunk, []byte) {
+				var info *accounting_iface.ExportChunk
+				data := bytes.NewBuffer(nil)
+				defer stream.Close()
+
+				for stream.Receive() {
+					chunk := stream.Msg()
+					if info == nil {
+						info = chunk
+					}
+					data.Write(chunk.Data)
+				}
+				assert.Nil(t, stream.Err())
+				return info, data.Bytes()
+			}
+
+			t.Run("neraca xlsx", func(t *testing.T) {
+				stream, err := client.StatementBalanceSheetExport(t.Context(), connect.NewRequest(&accounting_iface.StatementBalanceSheetExportRequest{
+					Format: export.FormatXlsx,
+					TeamId: 1,
+					AsOf:   timestamppb.New(time.Date(2025, 10, 31, 0, 0, 0, 0, jkt)),
+				}))
+				assert.Nil(t, err)
+
+				info, raw := download(t, stream)
+				assert.Equal(t, "balance_sheet_20251031.xlsx", info.Filename)
+				assert.Equal(t, int64(5), info.Total)
+
+				file, err := excelize.OpenReader(bytes.NewReader(raw))
+				assert.Nil(t, err)
+				defer file.Close()
+
+				sheet := file.GetSheetName(0)
+				team, err := file.GetCellValue(sheet, "A2")
+				assert.Nil(t, err)
+				assert.Equal(t, "team satu", team)
+
+				rows, err := file.GetRows(sheet, excelize.Options{RawCellValue: true})
+				assert.Nil(t, err)
+				assert.Equal(t, []string{"2025-10-31", "assets", "cash", "9000"}, rows[5])
+			})
+
+			t.Run("laba rugi pdf", func(t *testing.T) {
+				stream, err := client.StatementIncomeExport(t.Context(), connect.NewRequest(&accounting_iface.StatementIncomeExportRequest{
+					Format: export.FormatPdf,
+					TeamId: 1,
+					TimeRange: &common.TimeFilterRangeType{
+						Range: &common.TimeFilterRange{
+							StartDate: timestamppb.New(time.Date(2025, 10, 1, 0, 0, 0, 0, jkt)),
+							EndDate:   timestamppb.New(time.Date(2025, 10, 31, 0, 0, 0, 0, jkt)),
+						},
+					},
+				}))
+				assert.Nil(t, err)
+
+				info, raw := download(t, stream)
+				assert.Equal(t, "application/pdf", info.ContentType)
+				assert.True(t, bytes.HasPrefix(raw, []byte("%PDF")))
+			})
+
+			t.Run("laba rugi tanpa periode", func(t *testing.T) {
+				stream, err := client.StatementIncomeExport(t.Context(), connect.NewRequest(&accounting_iface.StatementIncomeExportRequest{
+					TeamId:    1,
+					TimeRange: &common.TimeFilterRangeType{},
+				}))
+				assert.Nil(t, err)
+				defer stream.Close()
+
+				assert.False(t, stream.Receive())
+				assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(stream.Err()))
+			})
+		},
+	)
+}
@@ -0,0 +1,261 @@
+package statement
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/pdcgo/accounting_service/accounting_core"
+	"github.com/pdcgo/schema/services/accounting_iface/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// item ringkasan di section net income
+const (
+	IncomeRevenueKey          = "revenue"
+	IncomeCogsKey             = "cogs"
+	IncomeGrossProfitKey      = "gross_profit"
+	IncomeOperatingExpenseKey = "operating_expense"
+	IncomeNetProfitKey        = "net_profit"
+)
+
+// account beban yang dihitung sebagai harga pokok penjualan
+var cogsAccounts = []accounting_core.AccountKey{
+	accounting_core.StockCostAccount,
+	accounting_core.StockBrokenCostAccount,
+	accounting_core.StockLostCostAccount,
+	accounting_core.StockBorrowCostAccount,
+	accounting_core.StockToBorrowCostAccount,
+}
+
+type incomePeriod struct {
+	t     time.Time
+	items map[accounting_core.AccountKey]float64
+}
+
+type incomeSection struct {
+	periods []*incomePeriod
+	index   map[time.Time]*incomePeriod
+}
+
+func (i *incomeSection) add(t time.Time, key accounting_core.AccountKey, amount float64) {
+	period := i.index[t]
+	if period == nil {
+		period = &incomePeriod{
+			t:     t,
+			items: map[accounting_core.AccountKey]float64{},
+		}
+		i.index[t] = period
+		i.periods = append(i.periods, period)
+	}
+
+	period.items[key] += amount
+}
+
+func (i *incomeSection) total(t time.Time, filter func(key accounting_core.AccountKey) bool) float64 {
+	var total float64
+	period := i.index[t]
+	if period == nil {
+		return total
+	}
+
+	for key, amount := range period.items {
+		if filter(key) {
+			total += amount
+		}
+	}
+	return total
+}
+
+// details item per account key, cogs didahulukan
+func (p *incomePeriod) details() []*accounting_iface.ItemDetail {
+	keys := []accounting_core.AccountKey{}
+	for key := range p.items {
+		keys = append(keys, key)
+	}
+
+	slices.SortFunc(keys, func(a, b accounting_core.AccountKey) int {
+		ca := slices.Contains(cogsAccounts, a)
+		cb := slices.Contains(cogsAccounts, b)
+		switch {
+		case ca && !cb:
+			return -1
+		case !ca && cb:
+			return 1
+		case a < b:
+			return -1
+		case a > b:
+			return 1
+		}
+		return 0
+	})
+
+	result := []*accounting_iface.ItemDetail{}
+	for _, key := range keys {
+		result = append(result, &accounting_iface.ItemDetail{
+			AccountKey: string(key),
+			Balance:    p.items[key],
+		})
+	}
+
+	return result
+}
+
+// StatementIncome implements accounting_ifaceconnect.StatementServiceHandler.
+func (s *statementImpl) StatementIncome(
+	ctx context.Context,
+	req *connect.Request[accounting_iface.StatementIncomeRequest],
+	stream *connect.ServerStream[accounting_iface.StatementIncomeResponse]) error {
+
+	var err error
+	pay := req.Msg
+
+	err = s.
+		auth.
+		AuthIdentityFromHeader(req.Header()).
+		Err()
+
+	if err != nil {
+		return err
+	}
+
+	return s.income(ctx, pay, stream.Send)
+}
+
+// income menghitung laba rugi, hasil per section dikirim lewat send
+func (s *statementImpl) income(
+	ctx context.Context,
+	pay *accounting_iface.StatementIncomeRequest,
+	send func(*accounting_iface.StatementIncomeResponse) error,
+) error {
+	var err error
+
+	db := s.db.WithContext(ctx)
+	filter, err := newStatementFilter(db, pay.ShopId, pay.Marketplace, pay.TagId)
+	if errors.Is(err, ErrUnknownMarketplace) {
+		return connect.NewError(connect.CodeInvalidArgument, err)
+	}
+	if err != nil {
+		return err
+	}
+
+	trange := pay.TimeRange.GetRange()
+	ttype := pay.TimeRange.GetType()
+	query := newDailyQuery(db, pay.TeamId, trange, filter)
+
+	loadSection := func(coa accounting_core.CoaCode) (*incomeSection, error) {
+		section := incomeSection{
+			periods: []*incomePeriod{},
+			index:   map[time.Time]*incomePeriod{},
+		}
+
+		keys, err := query.accountKeys(coa)
+		if err != nil {
+			return &section, err
+		}
+
+		rows, err := query.rows(keys)
+		if err != nil {
+			return &section, err
+		}
+
+		for _, row := range rows {
+			t := bucketTime(row.Day, ttype, query.start)
+			switch coa {
+			case accounting_core.REVENUE:
+				section.add(t, row.AccountKey, row.Credit-row.Debit)
+			default:
+				section.add(t, row.AccountKey, row.Debit-row.Credit)
+			}
+		}
+
+		return &section, nil
+	}
+
+	// revenue
+	revenue, err := loadSection(accounting_core.REVENUE)
+	if err != nil {
+		return err
+	}
+
+	for _, period := range revenue.periods {
+		err = send(&accounting_iface.StatementIncomeResponse{
+			Data: &accounting_iface.StatementIncomeResponse_Revenue{
+				Revenue: &accounting_iface.Revenue{
+					T:    timestamppb.New(period.t),
+					Data: period.details(),
+				},
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// expense, cogs dan beban operasional
+	expense, err := loadSection(accounting_core.EXPENSE)
+	if err != nil {
+		return err
+	}
+
+	for _, period := range expense.periods {
+		err = send(&accounting_iface.StatementIncomeResponse{
+			Data: &accounting_iface.StatementIncomeResponse_Expense{
+				Expense: &accounting_iface.Expense{
+					T:    timestamppb.New(period.t),
+					Data: period.details(),
+				},
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// net income per periode
+	times := []time.Time{}
+	for _, period := range revenue.periods {
+		times = append(times, period.t)
+	}
+	for _, period := range expense.periods {
+		if revenue.index[period.t] == nil {
+			times = append(times, period.t)
+		}
+	}
+	slices.SortFunc(times, func(a, b time.Time) int {
+		return a.Compare(b)
+	})
+
+	all := func(key accounting_core.AccountKey) bool { return true }
+	isCogs := func(key accounting_core.AccountKey) bool { return slices.Contains(cogsAccounts, key) }
+	isOpex := func(key accounting_core.AccountKey) bool { return !isCogs(key) }
+
+	for _, t := range times {
+		rev := revenue.total(t, all)
+		cogs := expense.total(t, isCogs)
+		opex := expense.total(t, isOpex)
+		gross := rev - cogs
+
+		err = send(&accounting_iface.StatementIncomeResponse{
+			Data: &accounting_iface.StatementIncomeResponse_NetIncome{
+				NetIncome: &accounting_iface.NetIncome{
+					T: timestamppb.New(t),
+					Data: []*accounting_iface.ItemDetail{
+						{AccountKey: IncomeRevenueKey, Balance: rev},
+						{AccountKey: IncomeCogsKey, Balance: cogs},
+						{AccountKey: IncomeGrossProfitKey, Balance: gross},
+						{AccountKey: IncomeOperatingExpenseKey, Balance: opex},
+						{AccountKey: IncomeNetProfitKey, Balance: gross - opex},
+					},
+				},
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,367 @@
+package statement_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/cloudtasks/apiv2/cloudtaskspb"
+	"connectrpc.com/connect"
+	"github.com/googleapis/gax-go/v2"
+	"github.com/pdcgo/accounting_service/accounting_core"
+	"github.com/pdcgo/accounting_service/accounting_mock"
+	"github.com/pdcgo/accounting_service/report"
+	"github.com/pdcgo/accounting_service/statement"
+	"github.com/pdcgo/schema/services/accounting_iface/v1"
+	"github.com/pdcgo/schema/services/accounting_iface/v1/accounting_ifaceconnect"
+	"github.com/pdcgo/schema/services/common/v1"
+	"github.com/pdcgo/schema/services/report_iface/v1"
+	"github.com/pdcgo/shared/authorization/authorization_mock"
+	"github.com/pdcgo/shared/configs"
+	"github.com/pdcgo/shared/db_models"
+	"github.com/pdcgo/shared/pkg/moretest"
+	"github.com/pdcgo/shared/pkg/moretest/moretest_mock"
+	"github.com/pdcgo/shared/pkg/ware_cache"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/gorm"
+)
+
+var jkt = time.FixedZone("WIB", 7*3600)
+
+type statementEntry struct {
+	key    accounting_core.AccountKey
+	day    time.Time
+	debit  float64
+	credit float64
+	shopID uint64
+	// entry pembalik dari transaksi yang di rollback
+	rollback bool
+	// kalau diisi, entry juga dibuat di journal_entries dengan transaksi ini
+	txID uint
+}
+
+// seedStatementEntries mengisi tabel daily balance lewat DailyUpdateBalance
+func seedStatementEntries(db *gorm.DB, teamID uint, entries []*statementEntry) moretest.SetupFunc {
+	return func(t *testing.T) func() error {
+		reportService := report.NewAccountReportService(
+			&configs.DispatcherConfig{},
+			&configs.AccountingService{},
+			db,
+			&authorization_mock.EmptyAuthorizationMock{},
+			ware_cache.NewLocalCache(),
+			func(ctx context.Context, req *cloudtaskspb.CreateTaskRequest, opts ...gax.CallOption) error {
+				return nil
+			},
+		)
+
+		for _, entry := range entries {
+			var acc accounting_core.Account
+			err := db.
+				Model(&accounting_core.Account{}).
+				Where("team_id = ? and account_key = ?", teamID, entry.key).
+				First(&acc).
+				Error
+			assert.Nil(t, err)
+
+			var entryID uint64
+			if entry.txID != 0 {
+				err = db.
+					Where("id = ?", entry.txID).
+					FirstOrCreate(&accounting_core.Transaction{
+						ID:      entry.txID,
+						RefID:   accounting_core.RefID(fmt.Sprintf("statement-%d", entry.txID)),
+						TeamID:  teamID,
+						Desc:    fmt.Sprintf("transaction %d", entry.txID),
+						Created: entry.day,
+					}).
+					Error
+				assert.Nil(t, err)
+
+				journal := accounting_core.JournalEntry{
+					AccountID:     acc.ID,
+					TeamID:        teamID,
+					TransactionID: entry.txID,
+					EntryTime:     entry.day,
+					Debit:         entry.debit,
+					Credit:        entry.credit,
+					Rollback:      entry.rollback,
+				}
+				err = db.Create(&journal).Error
+				assert.Nil(t, err)
+				entryID = uint64(journal.ID)
+
+				if entry.shopID != 0 {
+					err = db.Save(&accounting_core.TransactionShop{
+						TransactionID: entry.txID,
+						ShopID:        uint(entry.shopID),
+					}).Error
+					assert.Nil(t, err)
+				}
+			}
+
+			_, err = reportService.DailyUpdateBalance(t.Context(), &connect.Request[report_iface.DailyUpdateBalanceRequest]{
+				Msg: &report_iface.DailyUpdateBalanceRequest{
+					LabelExtra: &report_iface.TxLabelExtra{
+						ShopId: entry.shopID,
+					},
+					Entries: []*report_iface.EntryPayload{
+						{
+							Id:        entryID,
+							AccountId: uint64(acc.ID),
+							TeamId:    uint64(teamID),
+							EntryTime: timestamppb.New(entry.day),
+							Debit:     entry.debit,
+							Credit:    entry.credit,
+							Rollback:  entry.rollback,
+						},
+					},
+				},
+			})
+			assert.Nil(t, err)
+		}
+
+		return nil
+	}
+}
+
+func migrateStatement(db *gorm.DB) moretest.SetupFunc {
+	return func(t *testing.T) func() error {
+		err := db.AutoMigrate(
+			&accounting_core.JournalEntry{},
+			&accounting_core.Transaction{},
+			&accounting_core.TransactionShop{},
+			&accounting_core.Account{},
+			&accounting_core.AccountKeyDailyBalance{},
+			&accounting_core.AccountDailyBalance{},
+			&accounting_core.CsDailyBalance{},
+			&accounting_core.ShopDailyBalance{},
+			&accounting_core.SupplierDailyBalance{},
+			&accounting_core.CustomLabelDailyBalance{},
+			&accounting_core.TypeLabelDailyBalance{},
+			&accounting_core.TypeLabel{},
+			&accounting_core.TransactionTag{},
+			&accounting_core.TransactionTypeLabel{},
+			&accounting_core.DailyAppliedEntry{},
+			&accounting_core.ProjectionAppliedEntry{},
+			&db_models.Marketplace{},
+		)
+		assert.Nil(t, err)
+		return nil
+	}
+}
+
+func newStatementClient(t *testing.T, db *gorm.DB) accounting_ifaceconnect.StatementServiceClient {
+	mux := http.NewServeMux()
+	mux.Handle(accounting_ifaceconnect.NewStatementServiceHandler(
+		statement.NewStatementService(db, &authorization_mock.EmptyAuthorizationMock{}),
+	))
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return accounting_ifaceconnect.NewStatementServiceClient(server.Client(), server.URL)
+}
+
+func TestStatementIncome(t *testing.T) {
+	var db gorm.DB
+
+	moretest.Suite(t, "testing income statement",
+		moretest.SetupListFunc{
+			moretest_mock.MockSqliteDatabase(&db),
+			migrateStatement(&db),
+			accounting_mock.PopulateAccountKey(&db, 1),
+			seedStatementEntries(&db, 1, []*statementEntry{
+				{key: accounting_core.SalesRevenueAccount, day: time.Date(2025, 10, 1, 10, 0, 0, 0, jkt), credit: 10000, shopID: 3},
+				{key: accounting_core.StockCostAccount, day: time.Date(2025, 10, 1, 10, 0, 0, 0, jkt), debit: 6000, shopID: 3},
+				{key: accounting_core.AdsExpenseAccount, day: time.Date(2025, 10, 2, 10, 0, 0, 0, jkt), debit: 1000},
+				{key: accounting_core.SalesRevenueAccount, day: time.Date(2025, 11, 5, 10, 0, 0, 0, jkt), credit: 5000},
+			}),
+		},
+		func(t *testing.T) {
+			client := newStatementClient(t, &db)
+
+			incomeRequest := func(shopID uint64, tagID uint64) *connect.Request[accounting_iface.StatementIncomeRequest] {
+				return connect.NewRequest(&accounting_iface.StatementIncomeRequest{
+					TeamId: 1,
+					TimeRange: &common.TimeFilterRangeType{
+						Type: common.TimeType_TIME_TYPE_MONTHLY,
+						Range: &common.TimeFilterRange{
+							StartDate: timestamppb.New(time.Date(2025, 10, 1, 0, 0, 0, 0, jkt)),
+							EndDate:   timestamppb.New(time.Date(2025, 11, 30, 0, 0, 0, 0, jkt)),
+						},
+					},
+					ShopId: shopID,
+					TagId:  tagID,
+				})
+			}
+
+			income := func(t *testing.T, shopID uint64) map[string][]*accounting_iface.StatementIncomeResponse {
+				stream, err := client.StatementIncome(t.Context(), incomeRequest(shopID, 0))
+				assert.Nil(t, err)
+
+				result := map[string][]*accounting_iface.StatementIncomeResponse{}
+				for stream.Receive() {
+					msg := stream.Msg()
+					switch msg.Data.(type) {
+					case *accounting_iface.StatementIncomeResponse_Revenue:
+						result["revenue"] = append(result["revenue"], msg)
+					case *accounting_iface.StatementIncomeResponse_Expense:
+						result["expense"] = append(result["expense"], msg)
+					case *accounting_iface.StatementIncomeResponse_NetIncome:
+						result["net"] = append(result["net"], msg)
+					}
+				}
+				assert.Nil(t, stream.Err())
+				return result
+			}
+
+			summary := func(msg *accounting_iface.StatementIncomeResponse) map[string]float64 {
+				result := map[string]float64{}
+				for _, item := range msg.GetNetIncome().Data {
+					result[item.AccountKey] = item.Balance
+				}
+				return result
+			}
+
+			t.Run("income bulanan", func(t *testing.T) {
+				result := income(t, 0)
+				assert.Len(t, result["revenue"], 2)
+				assert.Len(t, result["expense"], 1)
+				assert.Len(t, result["net"], 2)
+
+				expense := result["expense"][0].GetExpense().Data
+				assert.Equal(t, string(accounting_core.StockCostAccount), expense[0].AccountKey)
+
+				oct := summary(result["net"][0])
+				assert.Equal(t, 10000.0, oct[statement.IncomeRevenueKey])
+				assert.Equal(t, 6000.0, oct[statement.IncomeCogsKey])
+				assert.Equal(t, 4000.0, oct[statement.IncomeGrossProfitKey])
+				assert.Equal(t, 1000.0, oct[statement.IncomeOperatingExpenseKey])
+				assert.Equal(t, 3000.0, oct[statement.IncomeNetProfitKey])
+
+				nov := summary(result["net"][1])
+				assert.Equal(t, 5000.0, nov[statement.IncomeNetProfitKey])
+			})
+
+			t.Run("income filter shop", func(t *testing.T) {
+				result := income(t, 3)
+				assert.Len(t, result["net"], 1)
+
+				oct := summary(result["net"][0])
+				assert.Equal(t, 4000.0, oct[statement.IncomeNetProfitKey])
+			})
+
+			t.Run("marketplace tidak dikenal", func(t *testing.T) {
+				req := incomeRequest(0, 0)
+				req.Msg.Marketplace = "tidak_ada"
+				stream, err := client.StatementIncome(t.Context(), req)
+				assert.Nil(t, err)
+				for stream.Receive() {
+				}
+				assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(stream.Err()))
+			})
+		},
+	)
+}
+
+func TestStatementIncomeCombinedFilter(t *testing.T) {
+	var db gorm.DB
+	oct := time.Date(2025, 10, 3, 10, 0, 0, 0, jkt)
+
+	moretest.Suite(t, "testing income statement dengan beberapa filter",
+		moretest.SetupListFunc{
+			moretest_mock.MockSqliteDatabase(&db),
+			migrateStatement(&db),
+			accounting_mock.PopulateAccountKey(&db, 1),
+			seedStatementEntries(&db, 1, []*statementEntry{
+				{key: accounting_core.SalesRevenueAccount, day: oct, credit: 10000, shopID: 3, txID: 1},
+				{key: accounting_core.SalesRevenueAccount, day: oct, credit: 4000, shopID: 3, txID: 2},
+				{key: accounting_core.SalesRevenueAccount, day: oct, credit: 2000, shopID: 4, txID: 3},
+				{key: accounting_core.SalesRevenueAccount, day: oct, credit: 500, shopID: 3, txID: 4},
+			}),
+			func(t *testing.T) func() error {
+				label := accounting_core.TypeLabel{
+					Key:   accounting_iface.LabelKey_LABEL_KEY_MARKETPLACE,
+					Label: "shopee",
+				}
+				err := db.Create(&label).Error
+				assert.Nil(t, err)
+
+				err = db.Create([]*accounting_core.TransactionTag{
+					{TransactionID: 1, TagID: 7},
+					{TransactionID: 3, TagID: 7},
+					{TransactionID: 4, TagID: 7},
+				}).Error
+				assert.Nil(t, err)
+
+				err = db.Create([]*accounting_core.TransactionTypeLabel{
+					{TransactionID: 1, TypeLabelID: label.ID},
+					{TransactionID: 2, TypeLabelID: label.ID},
+				}).Error
+				assert.Nil(t, err)
+
+				// transaksi 4 dibatalkan
+				var entry accounting_core.JournalEntry
+				err = db.Where("transaction_id = ?", 4).First(&entry).Error
+				assert.Nil(t, err)
+				err = db.Create(&accounting_core.JournalEntry{
+					AccountID:     entry.AccountID,
+					TeamID:        1,
+					TransactionID: 4,
+					EntryTime:     oct,
+					Debit:         500,
+					Rollback:      true,
+				}).Error
+				assert.Nil(t, err)
+				return nil
+			},
+		},
+		func(t *testing.T) {
+			client := newStatementClient(t, &db)
+
+			revenue := func(t *testing.T, shopID uint64, marketplace string, tagID uint64) float64 {
+				stream, err := client.StatementIncome(t.Context(), connect.NewRequest(&accounting_iface.StatementIncomeRequest{
+					TeamId: 1,
+					TimeRange: &common.TimeFilterRangeType{
+						Range: &common.TimeFilterRange{
+							StartDate: timestamppb.New(time.Date(2025, 10, 1, 0, 0, 0, 0, jkt)),
+							EndDate:   timestamppb.New(time.Date(2025, 10, 31, 0, 0, 0, 0, jkt)),
+						},
+					},
+					ShopId:      shopID,
+					Marketplace: marketplace,
+					TagId:       tagID,
+				}))
+				assert.Nil(t, err)
+
+				var total float64
+				for stream.Receive() {
+					for _, item := range stream.Msg().GetRevenue().GetData() {
+						total += item.Balance
+					}
+				}
+				assert.Nil(t, stream.Err())
+				return total
+			}
+
+			t.Run("filter tunggal tetap dari tabel daily", func(t *testing.T) {
+				assert.Equal(t, 14500.0, revenue(t, 3, "", 0))
+			})
+
+			t.Run("shop dan tag dipakai bersamaan", func(t *testing.T) {
+				assert.Equal(t, 10000.0, revenue(t, 3, "", 7))
+				assert.Equal(t, 2000.0, revenue(t, 4, "", 7))
+			})
+
+			t.Run("shop, marketplace dan tag", func(t *testing.T) {
+				assert.Equal(t, 10000.0, revenue(t, 3, "shopee", 7))
+				assert.Equal(t, 14000.0, revenue(t, 3, "shopee", 0))
+				assert.Equal(t, 0.0, revenue(t, 4, "shopee", 7))
+			})
+		},
+	)
+}
@@ -0,0 +1,366 @@
+package statement
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/pdcgo/accounting_service/accounting_core"
+	"github.com/pdcgo/accounting_service/query_dialect"
+	"github.com/pdcgo/schema/services/accounting_iface/v1"
+	"github.com/pdcgo/schema/services/common/v1"
+	"gorm.io/gorm"
+)
+
+var ErrUnknownMarketplace = errors.New("marketplace label tidak ditemukan")
+
+// statementFilter filter tambahan statement, semua filter yang diisi dipakai bersamaan
+type statementFilter struct {
+	ShopID      uint
+	Marketplace string
+	TagID       uint
+	// id type label marketplace, diisi newStatementFilter
+	LabelID uint
+}
+
+func newStatementFilter(db *gorm.DB, shopID uint64, marketplace string, tagID uint64) (*statementFilter, error) {
+	filter := statementFilter{
+		ShopID:      uint(shopID),
+		Marketplace: marketplace,
+		TagID:       uint(tagID),
+	}
+
+	if filter.Marketplace == "" {
+		return &filter, nil
+	}
+
+	var label accounting_core.TypeLabel
+	err := db.
+		Model(&accounting_core.TypeLabel{}).
+		Where("key = ? and label = ?", accounting_iface.LabelKey_LABEL_KEY_MARKETPLACE, filter.Marketplace).
+		Find(&label).
+		Error
+
+	if err != nil {
+		return &filter, err
+	}
+
+	if label.ID == 0 {
+		return &filter, fmt.Errorf("%w: %s", ErrUnknownMarketplace, filter.Marketplace)
+	}
+
+	filter.LabelID = label.ID
+	return &filter, nil
+}
+
+func (f *statementFilter) count() int {
+	count := 0
+	if f.ShopID != 0 {
+		count += 1
+	}
+	if f.LabelID != 0 {
+		count += 1
+	}
+	if f.TagID != 0 {
+		count += 1
+	}
+	return count
+}
+
+type dailyRow struct {
+	Day        time.Time
+	AccountKey accounting_core.AccountKey
+	Debit      float64
+	Credit     float64
+}
+
+type dailyQuery struct {
+	db     *gorm.DB
+	teamID uint
+	filter *statementFilter
+	start  time.Time
+	end    time.Time
+}
+
+// accountKeys account key yang punya coa tertentu
+func (d *dailyQuery) accountKeys(coa ...accounting_core.CoaCode) ([]accounting_core.AccountKey, error) {
+	keys := []accounting_core.AccountKey{}
+	err := d.
+		db.
+		Model(&accounting_core.Account{}).
+		Where("coa in ?", coa).
+		Distinct("account_key").
+		Order("account_key").
+		Find(&keys).
+		Error
+
+	return keys, err
+}
+
+// rows debit credit per hari dan account key, memakai tabel daily sesuai filter.
+// kalau filter lebih dari satu dihitung dari journal entry karena tabel daily hanya punya satu dimensi
+func (d *dailyQuery) rows(keys []accounting_core.AccountKey) ([]*dailyRow, error) {
+	var err error
+	if d.filter.count() > 1 {
+		return d.journalRows(keys)
+	}
+
+	query := d.db
+	keyField := "a.account_key"
+
+	switch {
+	case d.filter.ShopID != 0:
+		query = query.
+			Table("shop_daily_balances adb").
+			Joins("join accounts a on a.id = adb.account_id").
+			Where("adb.shop_id = ?", d.filter.ShopID)
+
+	case d.filter.TagID != 0:
+		query = query.
+			Table("custom_label_daily_balances adb").
+			Joins("join accounts a on a.id = adb.account_id").
+			Where("adb.custom_id = ?", d.filter.TagID)
+
+	case d.filter.LabelID != 0:
+		query = query.
+			Table("type_label_daily_balances adb").
+			Joins("join accounts a on a.id = adb.account_id").
+			Where("adb.label_id = ?", d.filter.LabelID)
+
+	default:
+		query = query.
+			Table("account_key_daily_balances adb")
+		keyField = "adb.account_key"
+	}
+
+	query = query.
+		Select([]string{
+			"adb.day",
+			keyField + " as account_key",
+			"sum(adb.debit) as debit",
+			"sum(adb.credit) as credit",
+		}).
+		Where("adb.journal_team_id = ?", d.teamID).
+		Where(keyField+" in ?", keys).
+		Group("adb.day, " + keyField).
+		Order("adb.day asc")
+
+	if !d.start.IsZero() {
+		query = query.Where("adb.day >= ?", d.start)
+	}
+
+	if !d.end.IsZero() {
+		query = query.Where("adb.day <= ?", d.end)
+	}
+
+	result := []*dailyRow{}
+	err = query.Find(&result).Error
+	return result, err
+}
+
+// journalRows sama dengan rows tapi langsung dari journal entry dengan semua filter,
+// entry rollback dibalik seperti di tabel daily
+func (d *dailyQuery) journalRows(keys []accounting_core.AccountKey) ([]*dailyRow, error) {
+	query := d.
+		db.
+		Table("journal_entries je").
+		Joins("join accounts a on a.id = je.account_id").
+		Select([]string{
+			query_dialect.NewDialect(d.db).EpochMicro("je.entry_time") + " as entry_time",
+			"a.account_key",
+			"case when je.rollback then je.credit * -1 else je.debit end as debit",
+			"case when je.rollback then je.debit * -1 else je.credit end as credit",
+		}).
+		Where("je.team_id = ?", d.teamID).
+		Where("a.account_key in ?", keys).
+		Order("je.entry_time asc")
+
+	if d.filter.ShopID != 0 {
+		query = query.
+			Joins("join transaction_shops ts on ts.transaction_id = je.transaction_id and ts.shop_id = ?", d.filter.ShopID)
+	}
+	if d.filter.TagID != 0 {
+		query = query.
+			Joins("join transaction_tags tt on tt.transaction_id = je.transaction_id and tt.tag_id = ?", d.filter.TagID)
+	}
+	if d.filter.LabelID != 0 {
+		query = query.
+			Joins("join transaction_type_labels ttl on ttl.transaction_id = je.transaction_id and ttl.type_label_id = ?", d.filter.LabelID)
+	}
+
+	if !d.start.IsZero() {
+		query = query.Where("je.entry_time >= ?", query_dialect.ReportDayStart(d.start))
+	}
+	if !d.end.IsZero() {
+		query = query.Where("je.entry_time < ?", query_dialect.ReportDayStart(d.end.AddDate(0, 0, 1)))
+	}
+
+	entries := []*struct {
+		EntryTime  int64
+		AccountKey accounting_core.AccountKey
+		Debit      float64
+		Credit     float64
+	}{}
+	err := query.Find(&entries).Error
+	if err != nil {
+		return nil, err
+	}
+
+	type rowKey struct {
+		day time.Time
+		key accounting_core.AccountKey
+	}
+
+	result := []*dailyRow{}
+	index := map[rowKey]*dailyRow{}
+	for _, entry := range entries {
+		rkey := rowKey{
+			day: query_dialect.ReportDay(time.UnixMicro(entry.EntryTime)),
+			key: entry.AccountKey,
+		}
+		row := index[rkey]
+		if row == nil {
+			row = &dailyRow{
+				Day:        rkey.day,
+				AccountKey: rkey.key,
+			}
+			index[rkey] = row
+			result = append(result, row)
+		}
+		row.Debit += entry.Debit
+		row.Credit += entry.Credit
+	}
+
+	return result, nil
+}
+
+func newDailyQuery(db *gorm.DB, teamID uint64, trange *common.TimeFilterRange, filter *statementFilter) *dailyQuery {
+	query := dailyQuery{
+		db:     db,
+		teamID: uint(teamID),
+		filter: filter,
+	}
+
+	if trange.GetStartDate().IsValid() {
+		query.start = query_dialect.ReportDay(trange.GetStartDate().AsTime())
+	}
+
+	if trange.GetEndDate().IsValid() {
+		query.end = query_dialect.ReportDay(trange.GetEndDate().AsTime())
+	}
+
+	return &query
+}
+
+// bucketTime awal periode sesuai tipe waktu, tanpa tipe semua masuk satu periode
+func bucketTime(day time.Time, ttype common.TimeType, start time.Time) time.Time {
+	switch ttype {
+	case common.TimeType_TIME_TYPE_DAILY:
+		return day
+	case common.TimeType_TIME_TYPE_MONTHLY:
+		return time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.UTC)
+	case common.TimeType_TIME_TYPE_YEARLY:
+		return time.Date(day.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return start
+	}
+}
+
+type keyInfo struct {
+	AccountKey  accounting_core.AccountKey
+	Coa         accounting_core.CoaCode
+	BalanceType accounting_core.BalanceType
+}
+
+// keyInfos coa dan balance type per account key, account key dengan lebih dari satu coa ikut dikembalikan
+func (d *dailyQuery) keyInfos() (map[accounting_core.AccountKey][]*keyInfo, error) {
+	infos := []*keyInfo{}
+	err := d.
+		db.
+		Model(&accounting_core.Account{}).
+		Distinct("account_key", "coa", "balance_type").
+		Find(&infos).
+		Error
+
+	result := map[accounting_core.AccountKey][]*keyInfo{}
+	for _, info := range infos {
+		result[info.AccountKey] = append(result[info.AccountKey], info)
+	}
+
+	return result, err
+}
+
+type keyBalance struct {
+	AccountKey accounting_core.AccountKey
+	Debit      float64
+	Credit     float64
+	Balance    float64
+}
+
+// keyBalances total debit credit dan running balance terakhir per account key sampai end
+func (d *dailyQuery) keyBalances() (map[accounting_core.AccountKey]*keyBalance, error) {
+	var err error
+
+	filterQ := func(query *gorm.DB) *gorm.DB {
+		query = query.Where("adb.journal_team_id = ?", d.teamID)
+		if !d.start.IsZero() {
+			query = query.Where("adb.day >= ?", d.start)
+		}
+		if !d.end.IsZero() {
+			query = query.Where("adb.day <= ?", d.end)
+		}
+		return query
+	}
+
+	sums := []*keyBalance{}
+	err = filterQ(d.db.Table("account_key_daily_balances adb")).
+		Select([]string{
+			"adb.account_key",
+			"sum(adb.debit) as debit",
+			"sum(adb.credit) as credit",
+		}).
+		Group("adb.account_key").
+		Find(&sums).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	lastQ := filterQ(d.db.Table("account_key_daily_balances adb")).
+		Select([]string{
+			"adb.account_key",
+			"adb.balance",
+			"row_number() over (partition by adb.account_key order by adb.day desc) as rn",
+		})
+
+	lasts := []*keyBalance{}
+	err = d.
+		db.
+		Table("(?) as lb", lastQ).
+		Select([]string{
+			"lb.account_key",
+			"lb.balance",
+		}).
+		Where("lb.rn = 1").
+		Find(&lasts).
+		Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[accounting_core.AccountKey]*keyBalance{}
+	for _, item := range sums {
+		result[item.AccountKey] = item
+	}
+
+	for _, item := range lasts {
+		if result[item.AccountKey] == nil {
+			result[item.AccountKey] = &keyBalance{AccountKey: item.AccountKey}
+		}
+		result[item.AccountKey].Balance = item.Balance
+	}
+
+	return result, nil
+}
@@ -0,0 +1,407 @@
+package statement
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/pdcgo/accounting_service/accounting_core"
+	"github.com/pdcgo/accounting_service/query_dialect"
+	"github.com/pdcgo/schema/services/accounting_iface/v1"
+	"github.com/pdcgo/schema/services/common/v1"
+	"github.com/pdcgo/shared/db_models"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/gorm"
+)
+
+// komponen laba rugi shop
+const (
+	ShopProfitSalesRevenue          = "sales_revenue"
+	ShopProfitReturns               = "returns"
+	ShopProfitStockCost             = "stock_cost"
+	ShopProfitWarehouseFee          = "warehouse_fee"
+	ShopProfitAdsExpense            = "ads_expense"
+	ShopProfitMarketplaceAdjustment = "marketplace_adjustment"
+	ShopProfitOther                 = "other"
+	ShopProfitNetProfit             = "net_profit"
+)
+
+var shopProfitKeys = map[accounting_core.AccountKey]string{
+	accounting_core.SalesRevenueAccount:           ShopProfitSalesRevenue,
+	accounting_core.SalesReturnRevenueAccount:     ShopProfitReturns,
+	accounting_core.SellingReturnExpenseAccount:   ShopProfitReturns,
+	accounting_core.WarehouseCostAccount:          ShopProfitWarehouseFee,
+	accounting_core.PackingExpenseAccount:         ShopProfitWarehouseFee,
+	accounting_core.AdsExpenseAccount:             ShopProfitAdsExpense,
+	accounting_core.SalesRevenueAdjustmentAccount: ShopProfitMarketplaceAdjustment,
+	accounting_core.SellingOtherExpenseAccount:    ShopProfitMarketplaceAdjustment,
+	accounting_core.CodCostAccount:                ShopProfitMarketplaceAdjustment,
+}
+
+func shopProfitComponent(key accounting_core.AccountKey) string {
+	if slices.Contains(cogsAccounts, key) {
+		return ShopProfitStockCost
+	}
+
+	if component, ok := shopProfitKeys[key]; ok {
+		return component
+	}
+
+	return ShopProfitOther
+}
+
+func addShopProfit(s *accounting_iface.ShopProfitAmount, component string, amount float64) {
+	switch component {
+	case ShopProfitSalesRevenue:
+		s.SalesRevenue += amount
+	case ShopProfitReturns:
+		s.Returns += amount
+	case ShopProfitStockCost:
+		s.StockCost += amount
+	case ShopProfitWarehouseFee:
+		s.WarehouseFee += amount
+	case ShopProfitAdsExpense:
+		s.AdsExpense += amount
+	case ShopProfitMarketplaceAdjustment:
+		s.MarketplaceAdjustment += amount
+	default:
+		s.Other += amount
+	}
+	s.NetProfit += amount
+}
+
+func shopProfitValue(s *accounting_iface.ShopProfitAmount, component string) float64 {
+	switch component {
+	case ShopProfitSalesRevenue:
+		return s.SalesRevenue
+	case ShopProfitReturns:
+		return s.Returns
+	case ShopProfitStockCost:
+		return s.StockCost
+	case ShopProfitWarehouseFee:
+		return s.WarehouseFee
+	case ShopProfitAdsExpense:
+		return s.AdsExpense
+	case ShopProfitMarketplaceAdjustment:
+		return s.MarketplaceAdjustment
+	case ShopProfitOther:
+		return s.Other
+	}
+	return s.NetProfit
+}
+
+// finishShopProfit mengisi margin, net profit dibagi sales revenue
+func finishShopProfit(s *accounting_iface.ShopProfitAmount) {
+	if accounting_core.CompareFloatSafe(s.SalesRevenue, 0, accounting_core.PrecisionEpsilon) {
+		return
+	}
+
+	margin := s.NetProfit / s.SalesRevenue * 100
+	s.MarginPercent = &margin
+}
+
+type shopProfitRow struct {
+	ShopID     uint
+	ShopName   string
+	MpType     db_models.MarketplaceType
+	Day        time.Time
+	AccountKey accounting_core.AccountKey
+	Amount     float64
+}
+
+// StatementShopProfit laba rugi per shop dan marketplace dari shop daily balance
+func (s *statementImpl) StatementShopProfit(
+	ctx context.Context,
+	req *connect.Request[accounting_iface.StatementShopProfitRequest],
+) (*connect.Response[accounting_iface.StatementShopProfitResponse], error) {
+	var err error
+	pay := req.Msg
+
+	result := accounting_iface.StatementShopProfitResponse{
+		Shops:        []*accounting_iface.ShopProfit{},
+		Marketplaces: []*accounting_iface.MarketplaceProfit{},
+		Total:        &accounting_iface.ShopProfitAmount{},
+	}
+
+	err = s.
+		auth.
+		AuthIdentityFromHeader(req.Header()).
+		Err()
+
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	if pay.TeamId == 0 {
+		return connect.NewResponse(&result), connect.NewError(connect.CodeInvalidArgument, errors.New("team_id required"))
+	}
+
+	if !validRange(pay.TimeRange) {
+		return connect.NewResponse(&result), connect.NewError(connect.CodeInvalidArgument, errors.New("invalid start end"))
+	}
+
+	start := query_dialect.ReportDay(pay.TimeRange.StartDate.AsTime())
+	end := query_dialect.ReportDay(pay.TimeRange.EndDate.AsTime())
+
+	query := s.
+		db.
+		WithContext(ctx).
+		Table("shop_daily_balances adb").
+		Joins("join accounts a on a.id = adb.account_id").
+		Joins("left join marketplaces m on m.id = adb.shop_id").
+		Select([]string{
+			"adb.shop_id",
+			"max(m.mp_name) as shop_name",
+			"max(m.mp_type) as mp_type",
+			"adb.day",
+			"a.account_key",
+			"sum(adb.credit - adb.debit) as amount",
+		}).
+		Where("adb.journal_team_id = ?", pay.TeamId).
+		Where("a.coa in ?", []accounting_core.CoaCode{accounting_core.REVENUE, accounting_core.EXPENSE}).
+		Where("adb.day >= ?", start).
+		Where("adb.day <= ?", end).
+		Group("adb.shop_id, adb.day, a.account_key").
+		Order("adb.day asc")
+
+	if pay.MpType != "" {
+		query = query.Where("m.mp_type = ?", pay.MpType)
+	}
+
+	if pay.ShopId != 0 {
+		query = query.Where("adb.shop_id = ?", pay.ShopId)
+	}
+
+	rows := []*shopProfitRow{}
+	err = query.Find(&rows).Error
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	shops := map[uint]*accounting_iface.ShopProfit{}
+	series := map[uint]map[time.Time]*accounting_iface.ShopProfitPeriod{}
+	marketplaces := map[db_models.MarketplaceType]*accounting_iface.MarketplaceProfit{}
+	for _, row := range rows {
+		component := shopProfitComponent(row.AccountKey)
+
+		shop := shops[row.ShopID]
+		if shop == nil {
+			shop = &accounting_iface.ShopProfit{
+				ShopId:   uint64(row.ShopID),
+				ShopName: row.ShopName,
+				MpType:   string(row.MpType),
+				Amount:   &accounting_iface.ShopProfitAmount{},
+				Series:   []*accounting_iface.ShopProfitPeriod{},
+			}
+			shops[row.ShopID] = shop
+			series[row.ShopID] = map[time.Time]*accounting_iface.ShopProfitPeriod{}
+			result.Shops = append(result.Shops, shop)
+		}
+		addShopProfit(shop.Amount, component, row.Amount)
+
+		if pay.TimeType != common.TimeType_TIME_TYPE_UNSPECIFIED {
+			t := bucketTime(row.Day, pay.TimeType, start)
+			period := series[row.ShopID][t]
+			if period == nil {
+				period = &accounting_iface.ShopProfitPeriod{
+					T:      timestamppb.New(t),
+					Amount: &accounting_iface.ShopProfitAmount{},
+				}
+				series[row.ShopID][t] = period
+				shop.Series = append(shop.Series, period)
+			}
+			addShopProfit(period.Amount, component, row.Amount)
+		}
+
+		mp := marketplaces[row.MpType]
+		if mp == nil {
+			mp = &accounting_iface.MarketplaceProfit{
+				MpType: string(row.MpType),
+				Amount: &accounting_iface.ShopProfitAmount{},
+			}
+			marketplaces[row.MpType] = mp
+			result.Marketplaces = append(result.Marketplaces, mp)
+		}
+		addShopProfit(mp.Amount, component, row.Amount)
+
+		addShopProfit(result.Total, component, row.Amount)
+	}
+
+	sortBy := pay.SortBy
+	if sortBy == "" {
+		sortBy = ShopProfitNetProfit
+	}
+
+	slices.SortStableFunc(result.Shops, func(a, b *accounting_iface.ShopProfit) int {
+		va, vb := shopProfitValue(a.Amount, sortBy), shopProfitValue(b.Amount, sortBy)
+		if pay.Asc {
+			va, vb = vb, va
+		}
+		switch {
+		case va > vb:
+			return -1
+		case va < vb:
+			return 1
+		}
+		return int(a.ShopId) - int(b.ShopId)
+	})
+
+	for i, shop := range result.Shops {
+		shop.Rank = int64(i + 1)
+		finishShopProfit(shop.Amount)
+		for _, period := range shop.Series {
+			finishShopProfit(period.Amount)
+		}
+	}
+
+	if pay.Limit > 0 && int64(len(result.Shops)) > pay.Limit {
+		result.Shops = result.Shops[:pay.Limit]
+	}
+
+	slices.SortFunc(result.Marketplaces, func(a, b *accounting_iface.MarketplaceProfit) int {
+		switch {
+		case a.Amount.NetProfit > b.Amount.NetProfit:
+			return -1
+		case a.Amount.NetProfit < b.Amount.NetProfit:
+			return 1
+		}
+		return 0
+	})
+
+	for _, mp := range result.Marketplaces {
+		finishShopProfit(mp.Amount)
+	}
+	finishShopProfit(result.Total)
+
+	return connect.NewResponse(&result), nil
+}
+
+// StatementShopProfitTransaction transaksi di balik angka laba rugi shop, lewat transaction_shops
+func (s *statementImpl) StatementShopProfitTransaction(
+	ctx context.Context,
+	req *connect.Request[accounting_iface.StatementShopProfitTransactionRequest],
+) (*connect.Response[accounting_iface.StatementShopProfitTransactionResponse], error) {
+	var err error
+	pay := req.Msg
+
+	result := accounting_iface.StatementShopProfitTransactionResponse{
+		Data:     []*accounting_iface.ShopProfitTransaction{},
+		PageInfo: &common.PageInfo{},
+	}
+
+	err = s.
+		auth.
+		AuthIdentityFromHeader(req.Header()).
+		Err()
+
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	if pay.TeamId == 0 || pay.ShopId == 0 {
+		return connect.NewResponse(&result), connect.NewError(connect.CodeInvalidArgument, errors.New("team_id and shop_id required"))
+	}
+
+	if !validRange(pay.TimeRange) {
+		return connect.NewResponse(&result), connect.NewError(connect.CodeInvalidArgument, errors.New("invalid start end"))
+	}
+
+	var page, limit int64 = 1, 20
+	if pay.Page != nil {
+		page = max(pay.Page.Page, 1)
+		if pay.Page.Limit > 0 {
+			limit = pay.Page.Limit
+		}
+	}
+
+	start := query_dialect.ReportDayStart(query_dialect.ReportDay(pay.TimeRange.StartDate.AsTime()))
+	end := query_dialect.ReportDayStart(query_dialect.ReportDay(pay.TimeRange.EndDate.AsTime()).AddDate(0, 0, 1))
+
+	query := s.
+		db.
+		WithContext(ctx).
+		Table("journal_entries je").
+		Joins("join transaction_shops ts on ts.transaction_id = je.transaction_id").
+		Joins("join accounts a on a.id = je.account_id").
+		Joins("left join transactions t on t.id = je.transaction_id").
+		Where("je.team_id = ?", pay.TeamId).
+		Where("ts.shop_id = ?", pay.ShopId).
+		Where("a.coa in ?", []accounting_core.CoaCode{accounting_core.REVENUE, accounting_core.EXPENSE}).
+		Where("je.entry_time >= ?", start).
+		Where("je.entry_time < ?", end)
+
+	if pay.Component != "" {
+		keys := []accounting_core.AccountKey{}
+		err = s.
+			db.
+			WithContext(ctx).
+			Model(&accounting_core.Account{}).
+			Where("coa in ?", []accounting_core.CoaCode{accounting_core.REVENUE, accounting_core.EXPENSE}).
+			Distinct("account_key").
+			Find(&keys).
+			Error
+		if err != nil {
+			return connect.NewResponse(&result), err
+		}
+
+		keys = slices.DeleteFunc(keys, func(key accounting_core.AccountKey) bool {
+			return shopProfitComponent(key) != pay.Component
+		})
+		query = query.Where("a.account_key in ?", keys)
+	}
+
+	// session supaya query bisa dipakai untuk count dan list
+	query = query.Session(&gorm.Session{})
+
+	var total int64
+	err = query.
+		Select("count(*)").
+		Scan(&total).
+		Error
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	result.PageInfo = &common.PageInfo{
+		CurrentPage: page,
+		TotalPage:   max((total+limit-1)/limit, 1),
+		TotalItems:  total,
+	}
+
+	rows := []*struct {
+		TransactionID uint
+		Desc          string
+		EntryTime     int64
+		AccountKey    accounting_core.AccountKey
+		Amount        float64
+	}{}
+
+	err = query.
+		Select([]string{
+			"je.transaction_id",
+			`t."desc" as "desc"`,
+			query_dialect.NewDialect(s.db).EpochMicro("je.entry_time") + " as entry_time",
+			"a.account_key",
+			"je.credit - je.debit as amount",
+		}).
+		Order("je.entry_time desc, je.id desc").
+		Offset(int((page - 1) * limit)).
+		Limit(int(limit)).
+		Find(&rows).
+		Error
+
+	for _, row := range rows {
+		result.Data = append(result.Data, &accounting_iface.ShopProfitTransaction{
+			TransactionId: uint64(row.TransactionID),
+			Desc:          row.Desc,
+			EntryTime:     timestamppb.New(time.UnixMicro(row.EntryTime)),
+			AccountKey:    string(row.AccountKey),
+			Component:     shopProfitComponent(row.AccountKey),
+			Amount:        row.Amount,
+		})
+	}
+
+	return connect.NewResponse(&result), err
+}
@@ -0,0 +1,115 @@
+package statement_test
+
+import (
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/pdcgo/accounting_service/accounting_core"
+	"github.com/pdcgo/accounting_service/accounting_mock"
+	"github.com/pdcgo/accounting_service/statement"
+	"github.com/pdcgo/schema/services/accounting_iface/v1"
+	"github.com/pdcgo/schema/services/common/v1"
+	"github.com/pdcgo/shared/authorization/authorization_mock"
+	"github.com/pdcgo/shared/db_models"
+	"github.com/pdcgo/shared/pkg/moretest"
+	"github.com/pdcgo/shared/pkg/moretest/moretest_mock"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/gorm"
+)
+
+func TestStatementShopProfit(t *testing.T) {
+	var db gorm.DB
+
+	oct1 := time.Date(2025, 10, 1, 10, 0, 0, 0, jkt)
+	oct2 := time.Date(2025, 10, 2, 10, 0, 0, 0, jkt)
+
+	moretest.Suite(t, "testing shop profit",
+		moretest.SetupListFunc{
+			moretest_mock.MockSqliteDatabase(&db),
+			migrateStatement(&db),
+			accounting_mock.PopulateAccountKey(&db, 1),
+			func(t *testing.T) func() error {
+				err := db.Create([]*db_models.Marketplace{
+					{ID: 3, TeamID: 1, MpUsername: "shop3", MpName: "shop 3", MpType: db_models.MpShopee},
+					{ID: 4, TeamID: 1, MpUsername: "shop4", MpName: "shop 4", MpType: db_models.MpTiktok},
+				}).Error
+				assert.Nil(t, err)
+				return nil
+			},
+			seedStatementEntries(&db, 1, []*statementEntry{
+				{key: accounting_core.SalesRevenueAccount, day: oct1, credit: 10000, shopID: 3, txID: 1},
+				{key: accounting_core.StockCostAccount, day: oct1, debit: 6000, shopID: 3, txID: 1},
+				{key: accounting_core.AdsExpenseAccount, day: oct2, debit: 1000, shopID: 3, txID: 2},
+				{key: accounting_core.SalesRevenueAccount, day: oct2, credit: 5000, shopID: 4, txID: 3},
+				{key: accounting_core.StockCostAccount, day: oct2, debit: 2000, shopID: 4, txID: 3},
+				{key: accounting_core.SellingReturnExpenseAccount, day: oct2, debit: 500, shopID: 4, txID: 4},
+			}),
+		},
+		func(t *testing.T) {
+			service := statement.NewStatementService(&db, &authorization_mock.EmptyAuthorizationMock{})
+			timeRange := &common.TimeFilterRange{
+				StartDate: timestamppb.New(time.Date(2025, 10, 1, 0, 0, 0, 0, jkt)),
+				EndDate:   timestamppb.New(time.Date(2025, 10, 31, 0, 0, 0, 0, jkt)),
+			}
+
+			t.Run("ranking shop", func(t *testing.T) {
+				res, err := service.StatementShopProfit(t.Context(), connect.NewRequest(&accounting_iface.StatementShopProfitRequest{
+					TeamId:    1,
+					TimeRange: timeRange,
+					TimeType:  common.TimeType_TIME_TYPE_DAILY,
+				}))
+				assert.Nil(t, err)
+
+				result := res.Msg
+				assert.Len(t, result.Shops, 2)
+
+				first := result.Shops[0]
+				assert.Equal(t, uint64(3), first.ShopId)
+				assert.Equal(t, int64(1), first.Rank)
+				assert.Equal(t, 3000.0, first.Amount.NetProfit)
+				assert.Equal(t, -6000.0, first.Amount.StockCost)
+				assert.Equal(t, -1000.0, first.Amount.AdsExpense)
+				assert.InDelta(t, 30.0, first.Amount.GetMarginPercent(), 0.001)
+				assert.Len(t, first.Series, 2)
+				assert.Equal(t, 4000.0, first.Series[0].Amount.NetProfit)
+
+				second := result.Shops[1]
+				assert.Equal(t, uint64(4), second.ShopId)
+				assert.Equal(t, 2500.0, second.Amount.NetProfit)
+				assert.Equal(t, -500.0, second.Amount.Returns)
+				assert.Equal(t, string(db_models.MpTiktok), second.MpType)
+				assert.InDelta(t, 50.0, second.Amount.GetMarginPercent(), 0.001)
+
+				assert.Len(t, result.Marketplaces, 2)
+				assert.Equal(t, 5500.0, result.Total.NetProfit)
+			})
+
+			t.Run("ranking by sales revenue", func(t *testing.T) {
+				res, err := service.StatementShopProfit(t.Context(), connect.NewRequest(&accounting_iface.StatementShopProfitRequest{
+					TeamId:    1,
+					TimeRange: timeRange,
+					SortBy:    statement.ShopProfitSalesRevenue,
+					Limit:     1,
+				}))
+				assert.Nil(t, err)
+				assert.Len(t, res.Msg.Shops, 1)
+				assert.Equal(t, uint64(3), res.Msg.Shops[0].ShopId)
+			})
+
+			t.Run("drill down transaksi", func(t *testing.T) {
+				res, err := service.StatementShopProfitTransaction(t.Context(), connect.NewRequest(&accounting_iface.StatementShopProfitTransactionRequest{
+					TeamId:    1,
+					ShopId:    3,
+					TimeRange: timeRange,
+					Component: statement.ShopProfitStockCost,
+				}))
+				assert.Nil(t, err)
+				assert.Equal(t, int64(1), res.Msg.PageInfo.TotalItems)
+				assert.Equal(t, uint64(1), res.Msg.Data[0].TransactionId)
+				assert.Equal(t, -6000.0, res.Msg.Data[0].Amount)
+			})
+		},
+	)
+}
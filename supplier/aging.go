@@ -0,0 +1,218 @@
+package supplier
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/pdcgo/accounting_service/accounting_core"
+	"github.com/pdcgo/accounting_service/query_dialect"
+	"github.com/pdcgo/schema/services/accounting_iface/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/gorm"
+)
+
+func addAge(a *accounting_iface.SupplierAgingAmount, days int, amount float64) {
+	switch {
+	case days <= 30:
+		a.Days30 += amount
+	case days <= 60:
+		a.Days60 += amount
+	case days <= 90:
+		a.Days90 += amount
+	default:
+		a.Over90 += amount
+	}
+	a.Total += amount
+}
+
+func addAging(a *accounting_iface.SupplierAgingAmount, other *accounting_iface.SupplierAgingAmount) {
+	a.Days30 += other.Days30
+	a.Days60 += other.Days60
+	a.Days90 += other.Days90
+	a.Over90 += other.Over90
+	a.Total += other.Total
+	a.Advance += other.Advance
+}
+
+// acceptTimes waktu stock diterima per ref restock, dari transaksi stock_accept dengan id yang sama
+func acceptTimes(db *gorm.DB, refs []accounting_core.RefID) (map[accounting_core.RefID]time.Time, error) {
+	result := map[accounting_core.RefID]time.Time{}
+	acceptRefs := []accounting_core.RefID{}
+	restockRefs := map[accounting_core.RefID]accounting_core.RefID{}
+	for _, ref := range refs {
+		refType, id, _ := strings.Cut(string(ref), "#")
+		if accounting_core.RefType(refType) != accounting_core.RestockRef {
+			continue
+		}
+
+		accept := accounting_core.RefID(string(accounting_core.StockAcceptRef) + "#" + id)
+		acceptRefs = append(acceptRefs, accept)
+		restockRefs[accept] = ref
+	}
+
+	if len(acceptRefs) == 0 {
+		return result, nil
+	}
+
+	rows := []*struct {
+		RefID     accounting_core.RefID
+		EntryTime int64
+	}{}
+
+	err := db.
+		Table("transactions t").
+		Joins("join journal_entries je on je.transaction_id = t.id").
+		Select([]string{
+			"t.ref_id",
+			query_dialect.NewDialect(db).EpochMicro("min(je.entry_time)") + " as entry_time",
+		}).
+		Where("t.ref_id in ?", acceptRefs).
+		Group("t.ref_id").
+		Find(&rows).
+		Error
+
+	if err != nil {
+		return result, err
+	}
+
+	for _, row := range rows {
+		result[restockRefs[row.RefID]] = time.UnixMicro(row.EntryTime)
+	}
+
+	return result, nil
+}
+
+type agingLot struct {
+	accepted time.Time
+	amount   float64
+}
+
+// SupplierPayableAging umur hutang supplier yang belum dibayar.
+// Pembayaran dan retur dialokasikan ke pembelian tertua (FIFO).
+func (s *supplierServiceImpl) SupplierPayableAging(
+	ctx context.Context,
+	req *connect.Request[accounting_iface.SupplierPayableAgingRequest],
+) (*connect.Response[accounting_iface.SupplierPayableAgingResponse], error) {
+	var err error
+	pay := req.Msg
+
+	result := accounting_iface.SupplierPayableAgingResponse{
+		Data:  []*accounting_iface.SupplierAging{},
+		Total: &accounting_iface.SupplierAgingAmount{},
+	}
+
+	err = s.
+		auth.
+		AuthIdentityFromHeader(req.Header()).
+		Err()
+
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	if pay.TeamId == 0 {
+		return connect.NewResponse(&result), connect.NewError(connect.CodeInvalidArgument, errors.New("team_id required"))
+	}
+
+	asOf := time.Now()
+	if pay.AsOf != nil {
+		asOf = pay.AsOf.AsTime()
+	}
+	asOfDay := query_dialect.ReportDay(asOf)
+	result.AsOf = timestamppb.New(asOfDay)
+
+	db := s.db.WithContext(ctx)
+	query := movementQuery{
+		db:         db,
+		teamID:     uint(pay.TeamId),
+		supplierID: uint(pay.SupplierId),
+		end:        query_dialect.ReportDayStart(asOfDay.AddDate(0, 0, 1)),
+	}
+
+	movements, err := query.movements()
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	refs := make([]accounting_core.RefID, 0, len(movements))
+	for _, item := range movements {
+		refs = append(refs, item.RefID)
+	}
+
+	accepted, err := acceptTimes(db, refs)
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	lots := map[uint][]*agingLot{}
+	paid := map[uint]float64{}
+	suppliers := []uint{}
+	for _, item := range movements {
+		if _, ok := paid[item.SupplierID]; !ok {
+			paid[item.SupplierID] = 0
+			suppliers = append(suppliers, item.SupplierID)
+		}
+
+		if item.Amount < 0 {
+			paid[item.SupplierID] -= item.Amount
+			continue
+		}
+
+		lot := agingLot{
+			accepted: item.EntryTime,
+			amount:   item.Amount,
+		}
+		if at, ok := accepted[item.RefID]; ok {
+			lot.accepted = at
+		}
+		lots[item.SupplierID] = append(lots[item.SupplierID], &lot)
+	}
+
+	slices.Sort(suppliers)
+	for _, supplierID := range suppliers {
+		supLots := lots[supplierID]
+		slices.SortStableFunc(supLots, func(a, b *agingLot) int {
+			return a.accepted.Compare(b.accepted)
+		})
+
+		aging := accounting_iface.SupplierAging{
+			SupplierId: uint64(supplierID),
+			Amount:     &accounting_iface.SupplierAgingAmount{},
+		}
+
+		remainingPaid := paid[supplierID]
+		for _, lot := range supLots {
+			used := min(lot.amount, remainingPaid)
+			remainingPaid -= used
+			unpaid := lot.amount - used
+			if unpaid <= 0 || accounting_core.CompareFloatSafe(unpaid, 0, accounting_core.PrecisionEpsilon) {
+				continue
+			}
+
+			acceptedDay := query_dialect.ReportDay(lot.accepted)
+			if aging.OldestUnpaid == nil {
+				aging.OldestUnpaid = timestamppb.New(acceptedDay)
+			}
+
+			days := int(asOfDay.Sub(acceptedDay).Hours() / 24)
+			addAge(aging.Amount, days, unpaid)
+		}
+
+		if remainingPaid > 0 && !accounting_core.CompareFloatSafe(remainingPaid, 0, accounting_core.PrecisionEpsilon) {
+			aging.Amount.Advance = remainingPaid
+		}
+
+		if aging.Amount.Total == 0 && aging.Amount.Advance == 0 {
+			continue
+		}
+
+		result.Data = append(result.Data, &aging)
+		addAging(result.Total, aging.Amount)
+	}
+
+	return connect.NewResponse(&result), nil
+}
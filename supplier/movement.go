@@ -0,0 +1,115 @@
+package supplier
+
+import (
+	"strings"
+	"time"
+
+	"github.com/pdcgo/accounting_service/accounting_core"
+	"github.com/pdcgo/accounting_service/query_dialect"
+	"github.com/pdcgo/schema/services/accounting_iface/v1"
+	"gorm.io/gorm"
+)
+
+var movementRefs = map[accounting_core.RefType]accounting_iface.SupplierMovementType{
+	accounting_core.StockAcceptRef:       accounting_iface.SupplierMovementType_SUPPLIER_MOVEMENT_TYPE_PURCHASE,
+	accounting_core.RestockRef:           accounting_iface.SupplierMovementType_SUPPLIER_MOVEMENT_TYPE_PURCHASE,
+	accounting_core.PaymentRef:           accounting_iface.SupplierMovementType_SUPPLIER_MOVEMENT_TYPE_PAYMENT,
+	accounting_core.PaymentAcceptRef:     accounting_iface.SupplierMovementType_SUPPLIER_MOVEMENT_TYPE_PAYMENT,
+	accounting_core.StockReturnRef:       accounting_iface.SupplierMovementType_SUPPLIER_MOVEMENT_TYPE_RETURN,
+	accounting_core.StockReturnAcceptRef: accounting_iface.SupplierMovementType_SUPPLIER_MOVEMENT_TYPE_RETURN,
+}
+
+// movementType jenis transaksi dari ref id, ref lain dianggap adjustment
+func movementType(ref accounting_core.RefID) accounting_iface.SupplierMovementType {
+	refType, _, _ := strings.Cut(string(ref), "#")
+	if mtype, ok := movementRefs[accounting_core.RefType(refType)]; ok {
+		return mtype
+	}
+	return accounting_iface.SupplierMovementType_SUPPLIER_MOVEMENT_TYPE_ADJUSTMENT
+}
+
+// payableMovement perubahan hutang supplier per transaksi
+type payableMovement struct {
+	SupplierID    uint
+	TransactionID uint
+	RefID         accounting_core.RefID
+	Desc          string
+	EntryTime     time.Time
+	// positif menambah hutang
+	Amount float64
+}
+
+type movementQuery struct {
+	db         *gorm.DB
+	teamID     uint
+	supplierID uint
+	// waktu entry, zero berarti tidak dibatasi
+	start time.Time
+	end   time.Time
+}
+
+// movements perubahan supplier payable dari journal entries transaksi yang ditandai AddSupplierID
+func (q *movementQuery) movements() ([]*payableMovement, error) {
+	query := q.
+		db.
+		Table("journal_entries je").
+		Joins("join accounts a on a.id = je.account_id").
+		Joins("join transaction_suppliers ts on ts.transaction_id = je.transaction_id").
+		Joins("join transactions t on t.id = je.transaction_id").
+		Select([]string{
+			"ts.supplier_id",
+			"je.transaction_id",
+			"max(t.ref_id) as ref_id",
+			`max(t."desc") as "desc"`,
+			query_dialect.NewDialect(q.db).EpochMicro("min(je.entry_time)") + " as entry_time",
+			"sum(je.credit - je.debit) as amount",
+		}).
+		Where("je.team_id = ?", q.teamID).
+		Where("a.account_key = ?", accounting_core.SupplierPayableAccount).
+		Group("ts.supplier_id, je.transaction_id").
+		Order("min(je.entry_time) asc, je.transaction_id asc")
+
+	if q.supplierID != 0 {
+		query = query.Where("ts.supplier_id = ?", q.supplierID)
+	}
+
+	if !q.start.IsZero() {
+		query = query.Where("je.entry_time >= ?", q.start)
+	}
+
+	if !q.end.IsZero() {
+		query = query.Where("je.entry_time < ?", q.end)
+	}
+
+	rows := []*struct {
+		SupplierID    uint
+		TransactionID uint
+		RefID         accounting_core.RefID
+		Desc          string
+		EntryTime     int64
+		Amount        float64
+	}{}
+
+	err := query.Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*payableMovement, 0, len(rows))
+	for _, row := range rows {
+		if accounting_core.CompareFloatSafe(row.Amount, 0, accounting_core.PrecisionEpsilon) {
+			continue
+		}
+
+		result = append(result, &payableMovement{
+			SupplierID:    row.SupplierID,
+			TransactionID: row.TransactionID,
+			RefID:         row.RefID,
+			Desc:          row.Desc,
+			EntryTime:     time.UnixMicro(row.EntryTime),
+			Amount:        row.Amount,
+		})
+	}
+
+	return result, nil
+}
@@ -0,0 +1,18 @@
+package supplier
+
+import (
+	"github.com/pdcgo/shared/interfaces/authorization_iface"
+	"gorm.io/gorm"
+)
+
+type supplierServiceImpl struct {
+	db   *gorm.DB
+	auth authorization_iface.Authorization
+}
+
+func NewSupplierService(db *gorm.DB, auth authorization_iface.Authorization) *supplierServiceImpl {
+	return &supplierServiceImpl{
+		db:   db,
+		auth: auth,
+	}
+}
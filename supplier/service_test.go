@@ -0,0 +1,188 @@
+package supplier_test
+
+import (
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/pdcgo/accounting_service/accounting_core"
+	"github.com/pdcgo/accounting_service/accounting_mock"
+	"github.com/pdcgo/accounting_service/supplier"
+	"github.com/pdcgo/schema/services/accounting_iface/v1"
+	"github.com/pdcgo/schema/services/common/v1"
+	"github.com/pdcgo/shared/authorization/authorization_mock"
+	"github.com/pdcgo/shared/pkg/moretest"
+	"github.com/pdcgo/shared/pkg/moretest/moretest_mock"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/gorm"
+)
+
+type supplierEntry struct {
+	refID      accounting_core.RefID
+	supplierID uint
+	key        accounting_core.AccountKey
+	at         time.Time
+	// positif menambah hutang
+	amount float64
+}
+
+func TestSupplierReport(t *testing.T) {
+	var db gorm.DB
+
+	moretest.Suite(t, "testing supplier report",
+		moretest.SetupListFunc{
+			moretest_mock.MockSqliteDatabase(&db),
+			func(t *testing.T) func() error {
+				err := db.AutoMigrate(
+					&accounting_core.Account{},
+					&accounting_core.Transaction{},
+					&accounting_core.TransactionSupplier{},
+					&accounting_core.JournalEntry{},
+					&accounting_core.SupplierDailyBalance{},
+				)
+				assert.Nil(t, err)
+				return nil
+			},
+			accounting_mock.PopulateAccountKey(&db, 1),
+			func(t *testing.T) func() error {
+				err := accounting_core.
+					NewCreateAccount(&db).
+					Create(
+						accounting_core.CreditBalance,
+						accounting_core.LIABILITY,
+						1,
+						accounting_core.SupplierPayableAccount,
+						"supplier payable",
+					)
+				assert.Nil(t, err)
+
+				accounts := map[accounting_core.AccountKey]uint{}
+				accs := []*accounting_core.Account{}
+				err = db.Where("team_id = 1").Find(&accs).Error
+				assert.Nil(t, err)
+				for _, acc := range accs {
+					accounts[acc.AccountKey] = acc.ID
+				}
+
+				payable := accounting_core.SupplierPayableAccount
+				entries := []*supplierEntry{
+					{refID: "restock#10", supplierID: 5, key: payable, at: accounting_mock.Date(7, 20), amount: 1000},
+					// diterima gudang, tidak ditandai supplier
+					{refID: "stock_accept#10", key: accounting_core.StockReadyAccount, at: accounting_mock.Date(8, 5), amount: 1000},
+					{refID: "stock_accept#11", supplierID: 5, key: payable, at: accounting_mock.Date(9, 1), amount: 2000},
+					{refID: "payment#1", supplierID: 5, key: payable, at: accounting_mock.Date(10, 5), amount: -300},
+					{refID: "stock_return#3", supplierID: 5, key: payable, at: accounting_mock.Date(10, 10), amount: -200},
+					{refID: "restock#12", supplierID: 5, key: payable, at: accounting_mock.Date(10, 20), amount: 800},
+					{refID: "payment#2", supplierID: 6, key: payable, at: accounting_mock.Date(10, 3), amount: -300},
+					// setelah periode
+					{refID: "payment#3", supplierID: 5, key: payable, at: accounting_mock.Date(11, 3), amount: -800},
+				}
+
+				for _, entry := range entries {
+					tran := accounting_core.Transaction{RefID: entry.refID, TeamID: 1, Created: entry.at}
+					err = db.Create(&tran).Error
+					assert.Nil(t, err)
+
+					if entry.supplierID != 0 {
+						err = db.Create(&accounting_core.TransactionSupplier{
+							TransactionID: tran.ID,
+							SupplierID:    entry.supplierID,
+						}).Error
+						assert.Nil(t, err)
+					}
+
+					journal := accounting_core.JournalEntry{
+						AccountID:     accounts[entry.key],
+						TeamID:        1,
+						TransactionID: tran.ID,
+						EntryTime:     entry.at,
+					}
+					if entry.amount > 0 {
+						journal.Credit = entry.amount
+					} else {
+						journal.Debit = -entry.amount
+					}
+
+					err = db.Create(&journal).Error
+					assert.Nil(t, err)
+				}
+
+				err = db.Create(&accounting_core.SupplierDailyBalance{
+					Day:           time.Date(2025, 9, 15, 0, 0, 0, 0, time.UTC),
+					SupplierID:    5,
+					AccountID:     accounts[payable],
+					JournalTeamID: 1,
+					Credit:        500,
+				}).Error
+				assert.Nil(t, err)
+				return nil
+			},
+		},
+		func(t *testing.T) {
+			service := supplier.NewSupplierService(&db, &authorization_mock.EmptyAuthorizationMock{})
+			october := &common.TimeFilterRange{
+				StartDate: timestamppb.New(accounting_mock.Date(10, 1)),
+				EndDate:   timestamppb.New(accounting_mock.Date(10, 31)),
+			}
+
+			t.Run("rekening koran supplier", func(t *testing.T) {
+				statement, err := service.SupplierStatement(t.Context(), connect.NewRequest(&accounting_iface.SupplierStatementRequest{
+					TeamId:     1,
+					SupplierId: 5,
+					TimeRange:  october,
+				}))
+				assert.Nil(t, err)
+
+				res := statement.Msg
+				assert.Equal(t, 500.0, res.OpeningBalance)
+				assert.Len(t, res.Lines, 3)
+				assert.Equal(t, accounting_iface.SupplierMovementType_SUPPLIER_MOVEMENT_TYPE_PAYMENT, res.Lines[0].Type)
+				assert.Equal(t, 200.0, res.Lines[0].Balance)
+				assert.Equal(t, accounting_iface.SupplierMovementType_SUPPLIER_MOVEMENT_TYPE_RETURN, res.Lines[1].Type)
+				assert.Equal(t, 0.0, res.Lines[1].Balance)
+				assert.Equal(t, accounting_iface.SupplierMovementType_SUPPLIER_MOVEMENT_TYPE_PURCHASE, res.Lines[2].Type)
+				assert.Equal(t, 800.0, res.ClosingBalance)
+
+				totals := map[accounting_iface.SupplierMovementType]float64{}
+				for _, total := range res.Totals {
+					totals[total.Type] = total.Amount
+				}
+				assert.Equal(t, 800.0, totals[accounting_iface.SupplierMovementType_SUPPLIER_MOVEMENT_TYPE_PURCHASE])
+				assert.Equal(t, -300.0, totals[accounting_iface.SupplierMovementType_SUPPLIER_MOVEMENT_TYPE_PAYMENT])
+			})
+
+			t.Run("supplier wajib diisi", func(t *testing.T) {
+				_, err := service.SupplierStatement(t.Context(), connect.NewRequest(&accounting_iface.SupplierStatementRequest{
+					TeamId:    1,
+					TimeRange: october,
+				}))
+				assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+			})
+
+			t.Run("aging hutang supplier", func(t *testing.T) {
+				aging, err := service.SupplierPayableAging(t.Context(), connect.NewRequest(&accounting_iface.SupplierPayableAgingRequest{
+					TeamId: 1,
+					AsOf:   timestamppb.New(accounting_mock.Date(10, 31)),
+				}))
+				assert.Nil(t, err)
+
+				res := aging.Msg
+				assert.Len(t, res.Data, 2)
+
+				sup := res.Data[0]
+				assert.Equal(t, uint64(5), sup.SupplierId)
+				assert.Equal(t, 800.0, sup.Amount.Days30)
+				assert.Equal(t, 2000.0, sup.Amount.Days60)
+				// umur dari tanggal diterima 5 agustus, bukan tanggal restock
+				assert.Equal(t, 500.0, sup.Amount.Days90)
+				assert.Equal(t, 0.0, sup.Amount.Over90)
+				assert.Equal(t, 3300.0, sup.Amount.Total)
+				assert.Equal(t, time.Date(2025, 8, 5, 0, 0, 0, 0, time.UTC), sup.OldestUnpaid.AsTime())
+
+				assert.Equal(t, 300.0, res.Data[1].Amount.Advance)
+				assert.Equal(t, 3300.0, res.Total.Total)
+			})
+		},
+	)
+}
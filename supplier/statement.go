@@ -0,0 +1,132 @@
+package supplier
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/pdcgo/accounting_service/accounting_core"
+	"github.com/pdcgo/accounting_service/query_dialect"
+	"github.com/pdcgo/schema/services/accounting_iface/v1"
+	"github.com/pdcgo/schema/services/common/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// openingBalance saldo hutang supplier sebelum hari start dari supplier daily balance
+func (s *supplierServiceImpl) openingBalance(ctx context.Context, teamID, supplierID uint, start time.Time) (float64, error) {
+	var balance float64
+	err := s.
+		db.
+		WithContext(ctx).
+		Table("supplier_daily_balances adb").
+		Joins("join accounts a on a.id = adb.account_id").
+		Select("coalesce(sum(adb.credit - adb.debit), 0)").
+		Where("adb.journal_team_id = ?", teamID).
+		Where("adb.supplier_id = ?", supplierID).
+		Where("a.account_key = ?", accounting_core.SupplierPayableAccount).
+		Where("adb.day < ?", start).
+		Scan(&balance).
+		Error
+
+	return balance, err
+}
+
+// SupplierStatement rekening koran supplier, pembelian, pembayaran dan retur dengan saldo berjalan
+func (s *supplierServiceImpl) SupplierStatement(
+	ctx context.Context,
+	req *connect.Request[accounting_iface.SupplierStatementRequest],
+) (*connect.Response[accounting_iface.SupplierStatementResponse], error) {
+	var err error
+	pay := req.Msg
+
+	result := accounting_iface.SupplierStatementResponse{
+		SupplierId: pay.SupplierId,
+		Lines:      []*accounting_iface.SupplierStatementLine{},
+		Totals:     []*accounting_iface.SupplierMovementTotal{},
+	}
+
+	err = s.
+		auth.
+		AuthIdentityFromHeader(req.Header()).
+		Err()
+
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	timeRange := pay.TimeRange
+	switch {
+	case pay.TeamId == 0:
+		err = errors.New("team_id required")
+	case pay.SupplierId == 0:
+		err = errors.New("supplier_id required")
+	case timeRange == nil ||
+		timeRange.StartDate == nil ||
+		timeRange.EndDate == nil ||
+		timeRange.EndDate.AsTime().Before(timeRange.StartDate.AsTime()):
+		err = errors.New("invalid start end")
+	}
+
+	if err != nil {
+		return connect.NewResponse(&result), connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	start := query_dialect.ReportDay(timeRange.StartDate.AsTime())
+	end := query_dialect.ReportDay(timeRange.EndDate.AsTime())
+	result.TimeRange = &common.TimeFilterRange{
+		StartDate: timestamppb.New(start),
+		EndDate:   timestamppb.New(end),
+	}
+
+	result.OpeningBalance, err = s.openingBalance(ctx, uint(pay.TeamId), uint(pay.SupplierId), start)
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	query := movementQuery{
+		db:         s.db.WithContext(ctx),
+		teamID:     uint(pay.TeamId),
+		supplierID: uint(pay.SupplierId),
+		start:      query_dialect.ReportDayStart(start),
+		end:        query_dialect.ReportDayStart(end.AddDate(0, 0, 1)),
+	}
+
+	movements, err := query.movements()
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	totals := map[accounting_iface.SupplierMovementType]*accounting_iface.SupplierMovementTotal{}
+	balance := result.OpeningBalance
+	for _, item := range movements {
+		balance += item.Amount
+		line := accounting_iface.SupplierStatementLine{
+			TransactionId: uint64(item.TransactionID),
+			RefId:         string(item.RefID),
+			Type:          movementType(item.RefID),
+			Desc:          item.Desc,
+			EntryTime:     timestamppb.New(item.EntryTime),
+			Amount:        item.Amount,
+			Balance:       balance,
+		}
+
+		total := totals[line.Type]
+		if total == nil {
+			total = &accounting_iface.SupplierMovementTotal{Type: line.Type}
+			totals[line.Type] = total
+			result.Totals = append(result.Totals, total)
+		}
+		total.Amount += line.Amount
+
+		result.Lines = append(result.Lines, &line)
+	}
+
+	slices.SortFunc(result.Totals, func(a, b *accounting_iface.SupplierMovementTotal) int {
+		return int(a.Type) - int(b.Type)
+	})
+
+	result.ClosingBalance = balance
+	return connect.NewResponse(&result), nil
+}
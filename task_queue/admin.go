@@ -0,0 +1,194 @@
+package task_queue
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"connectrpc.com/connect"
+	"github.com/pdcgo/schema/services/accounting_iface/v1"
+	"github.com/pdcgo/schema/services/common/v1"
+	"github.com/pdcgo/shared/interfaces/authorization_iface"
+)
+
+type taskQueueAdminImpl struct {
+	auth  authorization_iface.Authorization
+	queue *TaskQueue
+}
+
+func (t *taskQueueAdminImpl) checkSuperUser(header http.Header) error {
+	identity := t.auth.AuthIdentityFromHeader(header)
+	err := identity.Err()
+	if err != nil {
+		return err
+	}
+
+	if !identity.Identity().IsSuperUser() {
+		return connect.NewError(connect.CodePermissionDenied, errors.New("bukan superuser"))
+	}
+
+	return nil
+}
+
+// TaskList implements accounting_ifaceconnect.TaskQueueServiceHandler.
+// list task di antrian atau di dead letter
+func (t *taskQueueAdminImpl) TaskList(
+	ctx context.Context,
+	req *connect.Request[accounting_iface.TaskListRequest],
+) (*connect.Response[accounting_iface.TaskListResponse], error) {
+	result := accounting_iface.TaskListResponse{
+		Data:     []*accounting_iface.TaskItem{},
+		PageInfo: &common.PageInfo{},
+	}
+
+	err := t.checkSuperUser(req.Header())
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	pay := req.Msg
+	if pay.Page == nil {
+		pay.Page = &common.PageFilter{}
+	}
+	if pay.Page.Limit <= 0 {
+		pay.Page.Limit = 20
+	}
+	if pay.Page.Page <= 0 {
+		pay.Page.Page = 1
+	}
+
+	var total int64
+	result.Data, total, err = t.queue.List(ctx, pay.Dead, pay.Queue, int(pay.Page.Page), int(pay.Page.Limit))
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	result.PageInfo = &common.PageInfo{
+		CurrentPage: pay.Page.Page,
+		TotalPage:   max((total+pay.Page.Limit-1)/pay.Page.Limit, 1),
+		TotalItems:  total,
+	}
+	return connect.NewResponse(&result), nil
+}
+
+// TaskRetry implements accounting_ifaceconnect.TaskQueueServiceHandler.
+// memasukan kembali dead task ke antrian
+func (t *taskQueueAdminImpl) TaskRetry(
+	ctx context.Context,
+	req *connect.Request[accounting_iface.TaskRetryRequest],
+) (*connect.Response[accounting_iface.TaskRetryResponse], error) {
+	result := accounting_iface.TaskRetryResponse{}
+
+	err := t.checkSuperUser(req.Header())
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	count, err := t.queue.Retry(ctx, taskIDs(req.Msg.Ids))
+	result.Count = int64(count)
+	return connect.NewResponse(&result), err
+}
+
+// TaskPurge implements accounting_ifaceconnect.TaskQueueServiceHandler.
+// menghapus task
+func (t *taskQueueAdminImpl) TaskPurge(
+	ctx context.Context,
+	req *connect.Request[accounting_iface.TaskPurgeRequest],
+) (*connect.Response[accounting_iface.TaskPurgeResponse], error) {
+	result := accounting_iface.TaskPurgeResponse{}
+
+	err := t.checkSuperUser(req.Header())
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	pay := req.Msg
+	result.Count, err = t.queue.Purge(ctx, pay.Dead, pay.Queue, taskIDs(pay.Ids))
+	if errors.Is(err, ErrPurgeScope) {
+		return connect.NewResponse(&result), connect.NewError(connect.CodeInvalidArgument, err)
+	}
+	return connect.NewResponse(&result), err
+}
+
+func taskIDs(ids []uint64) []uint {
+	result := make([]uint, len(ids))
+	for i, id := range ids {
+		result[i] = uint(id)
+	}
+	return result
+}
+
+// List task di antrian, atau dead letter kalau dead
+func (q *TaskQueue) List(ctx context.Context, dead bool, queue string, page, limit int) ([]*accounting_iface.TaskItem, int64, error) {
+	var err error
+	var total int64
+	items := []*accounting_iface.TaskItem{}
+
+	query := q.db.WithContext(ctx)
+	if dead {
+		query = query.Model(&QueueDeadTask{})
+	} else {
+		query = query.Model(&QueueTask{})
+	}
+
+	if queue != "" {
+		query = query.Where("queue = ?", queue)
+	}
+
+	err = query.Count(&total).Error
+	if err != nil {
+		return items, total, err
+	}
+
+	query = query.
+		Order("id desc").
+		Offset((page - 1) * limit).
+		Limit(limit)
+
+	if dead {
+		deads := []*QueueDeadTask{}
+		err = query.Find(&deads).Error
+		for _, dead := range deads {
+			items = append(items, &accounting_iface.TaskItem{
+				Id:          uint64(dead.ID),
+				Queue:       dead.Queue,
+				Url:         dead.Url,
+				Attempts:    int64(dead.Attempts),
+				MaxAttempts: int64(dead.MaxAttempts),
+				LastError:   dead.LastError,
+				Failed:      dead.Failed.UnixMicro(),
+				Created:     dead.Created.UnixMicro(),
+			})
+		}
+		return items, total, err
+	}
+
+	tasks := []*QueueTask{}
+	err = query.Find(&tasks).Error
+	for _, task := range tasks {
+		items = append(items, &accounting_iface.TaskItem{
+			Id:          uint64(task.ID),
+			Queue:       task.Queue,
+			Url:         task.Url,
+			Attempts:    int64(task.Attempts),
+			MaxAttempts: int64(task.MaxAttempts),
+			LastError:   task.LastError,
+			NextRun:     task.NextRun.UnixMicro(),
+			Created:     task.Created.UnixMicro(),
+		})
+	}
+
+	return items, total, err
+}
+
+// NewTaskQueueAdminService service admin task queue.
+// queue harus yang sama dengan worker supaya retry langsung membangunkan worker
+func NewTaskQueueAdminService(
+	queue *TaskQueue,
+	auth authorization_iface.Authorization,
+) *taskQueueAdminImpl {
+	return &taskQueueAdminImpl{
+		auth:  auth,
+		queue: queue,
+	}
+}
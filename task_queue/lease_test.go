@@ -0,0 +1,83 @@
+package task_queue
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pdcgo/shared/pkg/moretest"
+	"github.com/pdcgo/shared/pkg/moretest/moretest_mock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestTaskLease(t *testing.T) {
+	var db gorm.DB
+
+	moretest.Suite(t, "testing lease task queue",
+		moretest.SetupListFunc{
+			moretest_mock.MockSqliteDatabase(&db),
+			func(t *testing.T) func() error {
+				err := db.AutoMigrate(&QueueTask{}, &QueueDeadTask{})
+				assert.Nil(t, err)
+				return nil
+			},
+		},
+		func(t *testing.T) {
+			queue := NewTaskQueue(&db)
+			// lease langsung habis, seolah worker mati
+			queue.Lease = -time.Second
+
+			enqueue := func(t *testing.T, maxAttempts int) {
+				task := QueueTask{
+					Queue:       "slow",
+					Url:         "http://localhost",
+					Method:      "POST",
+					MaxAttempts: maxAttempts,
+					NextRun:     time.Now().UTC(),
+				}
+				err := db.Save(&task).Error
+				assert.Nil(t, err)
+			}
+
+			t.Run("worker lama tidak bisa complete setelah diambil worker lain", func(t *testing.T) {
+				enqueue(t, 3)
+
+				first, err := queue.claim(t.Context())
+				assert.Nil(t, err)
+				second, err := queue.claim(t.Context())
+				assert.Nil(t, err)
+				assert.Equal(t, first.ID, second.ID)
+				assert.Equal(t, 2, second.Attempts)
+
+				err = queue.complete(t.Context(), first)
+				assert.True(t, errors.Is(err, ErrTaskLeaseLost))
+				err = queue.fail(t.Context(), first, errors.New("timeout"))
+				assert.True(t, errors.Is(err, ErrTaskLeaseLost))
+
+				err = queue.complete(t.Context(), second)
+				assert.Nil(t, err)
+			})
+
+			t.Run("lease habis di attempt terakhir masuk dead letter", func(t *testing.T) {
+				enqueue(t, 1)
+
+				task, err := queue.claim(t.Context())
+				assert.Nil(t, err)
+				assert.Equal(t, 1, task.Attempts)
+
+				again, err := queue.claim(t.Context())
+				assert.Nil(t, err)
+				assert.Nil(t, again)
+
+				var dead QueueDeadTask
+				err = db.Where("task_id = ?", task.ID).First(&dead).Error
+				assert.Nil(t, err)
+				assert.Equal(t, 1, dead.Attempts)
+
+				err = queue.complete(t.Context(), task)
+				assert.True(t, errors.Is(err, ErrTaskLeaseLost))
+			})
+		},
+	)
+}
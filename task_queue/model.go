@@ -0,0 +1,34 @@
+package task_queue
+
+import "time"
+
+// QueueTask task http yang menunggu dijalankan worker
+type QueueTask struct {
+	ID          uint              `json:"id" gorm:"primarykey"`
+	Queue       string            `json:"queue" gorm:"index"`
+	Url         string            `json:"url"`
+	Method      string            `json:"method"`
+	Headers     map[string]string `json:"headers" gorm:"serializer:json"`
+	Body        []byte            `json:"body"`
+	Attempts    int               `json:"attempts"`
+	MaxAttempts int               `json:"max_attempts"`
+	NextRun     time.Time         `json:"next_run" gorm:"index"`
+	LastError   string            `json:"last_error"`
+	Created     time.Time         `json:"created"`
+}
+
+// QueueDeadTask task yang gagal sampai max attempts
+type QueueDeadTask struct {
+	ID          uint              `json:"id" gorm:"primarykey"`
+	TaskID      uint              `json:"task_id"`
+	Queue       string            `json:"queue" gorm:"index"`
+	Url         string            `json:"url"`
+	Method      string            `json:"method"`
+	Headers     map[string]string `json:"headers" gorm:"serializer:json"`
+	Body        []byte            `json:"body"`
+	Attempts    int               `json:"attempts"`
+	MaxAttempts int               `json:"max_attempts"`
+	LastError   string            `json:"last_error"`
+	Created     time.Time         `json:"created"`
+	Failed      time.Time         `json:"failed"`
+}
@@ -0,0 +1,403 @@
+package task_queue
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/cloudtasks/apiv2/cloudtaskspb"
+	"github.com/googleapis/gax-go/v2"
+	"github.com/pdcgo/accounting_service/report"
+	"gorm.io/gorm"
+)
+
+var ErrTaskEmpty = errors.New("task http request empty")
+var ErrTaskLeaseLost = errors.New("task lease lost")
+var ErrPurgeScope = errors.New("purge need queue or ids")
+
+// TaskQueue antrian task di database, pengganti cloud task untuk self hosting
+type TaskQueue struct {
+	db     *gorm.DB
+	client *http.Client
+	notify chan struct{}
+
+	Concurrency  int
+	MaxAttempts  int
+	BaseBackoff  time.Duration
+	MaxBackoff   time.Duration
+	Lease        time.Duration
+	PollInterval time.Duration
+}
+
+func NewTaskQueue(db *gorm.DB) *TaskQueue {
+	return &TaskQueue{
+		db:     db,
+		client: &http.Client{Timeout: time.Minute},
+		notify: make(chan struct{}, 1),
+
+		Concurrency:  4,
+		MaxAttempts:  8,
+		BaseBackoff:  time.Second * 5,
+		MaxBackoff:   time.Hour,
+		Lease:        time.Minute * 5,
+		PollInterval: time.Second * 2,
+	}
+}
+
+// Dispatcher ReportDispatcher yang menyimpan task ke database
+func (q *TaskQueue) Dispatcher() report.ReportDispatcher {
+	return func(ctx context.Context, req *cloudtaskspb.CreateTaskRequest, opts ...gax.CallOption) error {
+		return q.Enqueue(ctx, req)
+	}
+}
+
+func (q *TaskQueue) Enqueue(ctx context.Context, req *cloudtaskspb.CreateTaskRequest) error {
+	httpreq := req.GetTask().GetHttpRequest()
+	if httpreq == nil {
+		return ErrTaskEmpty
+	}
+
+	method := http.MethodPost
+	if httpreq.HttpMethod != cloudtaskspb.HttpMethod_HTTP_METHOD_UNSPECIFIED {
+		method = httpreq.HttpMethod.String()
+	}
+
+	// parent formatnya projects/x/locations/y/queues/nama
+	queue := req.Parent
+	if idx := strings.LastIndex(queue, "/"); idx != -1 {
+		queue = queue[idx+1:]
+	}
+
+	now := time.Now().UTC()
+	// task terjadwal baru diambil worker setelah schedule_time
+	nextRun := now
+	if schedule := req.GetTask().GetScheduleTime(); schedule.IsValid() && schedule.AsTime().After(now) {
+		nextRun = schedule.AsTime().UTC()
+	}
+
+	task := QueueTask{
+		Queue:       queue,
+		Url:         httpreq.Url,
+		Method:      method,
+		Headers:     httpreq.Headers,
+		Body:        httpreq.Body,
+		MaxAttempts: q.MaxAttempts,
+		NextRun:     nextRun,
+		Created:     now,
+	}
+
+	err := q.db.WithContext(ctx).Save(&task).Error
+	if err != nil {
+		return err
+	}
+
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// claim mengambil satu task yang sudah waktunya jalan.
+// attempts dipakai sebagai optimistic lock sekaligus token lease, supaya satu task
+// tidak diambil dua worker dan worker yang lease nya habis tidak bisa complete/fail
+func (q *TaskQueue) claim(ctx context.Context) (*QueueTask, error) {
+	db := q.db.WithContext(ctx)
+
+	for {
+		var task QueueTask
+		now := time.Now().UTC()
+		err := db.
+			Model(&QueueTask{}).
+			Where("next_run <= ?", now).
+			Order("next_run asc").
+			Limit(1).
+			Find(&task).
+			Error
+
+		if err != nil {
+			return nil, err
+		}
+
+		if task.ID == 0 {
+			return nil, nil
+		}
+
+		// lease habis di attempt terakhir, worker mati sebelum complete/fail
+		if task.Attempts >= task.MaxAttempts {
+			err = q.deadLetter(ctx, &task, errors.New("lease expired at max attempts"))
+			if err != nil && !errors.Is(err, ErrTaskLeaseLost) {
+				return nil, err
+			}
+			continue
+		}
+
+		res := db.
+			Model(&QueueTask{}).
+			Where("id = ? and attempts = ?", task.ID, task.Attempts).
+			Where("attempts < max_attempts").
+			Updates(map[string]interface{}{
+				"attempts": task.Attempts + 1,
+				"next_run": now.Add(q.Lease),
+			})
+
+		if res.Error != nil {
+			return nil, res.Error
+		}
+
+		if res.RowsAffected == 0 {
+			continue
+		}
+
+		task.Attempts += 1
+		return &task, nil
+	}
+}
+
+func (q *TaskQueue) execute(ctx context.Context, task *QueueTask) error {
+	req, err := http.NewRequestWithContext(ctx, task.Method, task.Url, bytes.NewBuffer(task.Body))
+	if err != nil {
+		return err
+	}
+
+	for k, v := range task.Headers {
+		req.Header.Set(k, v)
+	}
+
+	res, err := q.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return fmt.Errorf("task response %d: %s", res.StatusCode, string(body))
+	}
+
+	return nil
+}
+
+func (q *TaskQueue) backoff(attempts int) time.Duration {
+	wait := q.BaseBackoff
+	for i := 1; i < attempts; i++ {
+		wait *= 2
+		if wait >= q.MaxBackoff {
+			return q.MaxBackoff
+		}
+	}
+
+	return wait
+}
+
+// leased task yang masih dipegang worker ini, attempts belum berubah oleh claim lain
+func leased(tx *gorm.DB, task *QueueTask) *gorm.DB {
+	return tx.Where("id = ? and attempts = ?", task.ID, task.Attempts)
+}
+
+func (q *TaskQueue) complete(ctx context.Context, task *QueueTask) error {
+	res := leased(q.db.WithContext(ctx), task).Delete(&QueueTask{})
+	if res.Error != nil {
+		return res.Error
+	}
+
+	if res.RowsAffected == 0 {
+		return ErrTaskLeaseLost
+	}
+
+	return nil
+}
+
+// fail menjadwalkan ulang task, atau memindahkan ke dead letter kalau sudah max attempts
+func (q *TaskQueue) fail(ctx context.Context, task *QueueTask, cause error) error {
+	if task.Attempts >= task.MaxAttempts {
+		return q.deadLetter(ctx, task, cause)
+	}
+
+	res := leased(q.db.WithContext(ctx).Model(&QueueTask{}), task).
+		Updates(map[string]interface{}{
+			"next_run":   time.Now().UTC().Add(q.backoff(task.Attempts)),
+			"last_error": cause.Error(),
+		})
+
+	if res.Error != nil {
+		return res.Error
+	}
+
+	if res.RowsAffected == 0 {
+		return ErrTaskLeaseLost
+	}
+
+	return nil
+}
+
+func (q *TaskQueue) deadLetter(ctx context.Context, task *QueueTask, cause error) error {
+	return q.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := leased(tx, task).Delete(&QueueTask{})
+		if res.Error != nil {
+			return res.Error
+		}
+
+		if res.RowsAffected == 0 {
+			return ErrTaskLeaseLost
+		}
+
+		dead := QueueDeadTask{
+			TaskID:      task.ID,
+			Queue:       task.Queue,
+			Url:         task.Url,
+			Method:      task.Method,
+			Headers:     task.Headers,
+			Body:        task.Body,
+			Attempts:    task.Attempts,
+			MaxAttempts: task.MaxAttempts,
+			LastError:   cause.Error(),
+			Created:     task.Created,
+			Failed:      time.Now().UTC(),
+		}
+
+		return tx.Save(&dead).Error
+	})
+}
+
+// ProcessOne menjalankan satu task, return false kalau antrian kosong
+func (q *TaskQueue) ProcessOne(ctx context.Context) (bool, error) {
+	task, err := q.claim(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	if task == nil {
+		return false, nil
+	}
+
+	err = q.execute(ctx, task)
+	if err != nil {
+		return true, q.fail(ctx, task, err)
+	}
+
+	return true, q.complete(ctx, task)
+}
+
+// Run menjalankan worker sebanyak Concurrency sampai context selesai
+func (q *TaskQueue) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+
+	for i := 0; i < q.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			q.worker(ctx)
+		}()
+	}
+
+	wg.Wait()
+}
+
+func (q *TaskQueue) worker(ctx context.Context) {
+	for {
+		found, err := q.ProcessOne(ctx)
+		if err != nil {
+			slog.Error("task queue", slog.Any("error", err))
+		}
+
+		if found {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-q.notify:
+		case <-time.After(q.PollInterval):
+		}
+	}
+}
+
+// Retry memindahkan dead task kembali ke antrian dengan attempts dari awal
+func (q *TaskQueue) Retry(ctx context.Context, ids []uint) (int, error) {
+	var count int
+
+	err := q.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		deads := []*QueueDeadTask{}
+		err := tx.
+			Model(&QueueDeadTask{}).
+			Where("id in ?", ids).
+			Find(&deads).
+			Error
+
+		if err != nil {
+			return err
+		}
+
+		now := time.Now().UTC()
+		for _, dead := range deads {
+			task := QueueTask{
+				Queue:       dead.Queue,
+				Url:         dead.Url,
+				Method:      dead.Method,
+				Headers:     dead.Headers,
+				Body:        dead.Body,
+				MaxAttempts: dead.MaxAttempts,
+				NextRun:     now,
+				Created:     dead.Created,
+			}
+
+			err = tx.Save(&task).Error
+			if err != nil {
+				return err
+			}
+
+			err = tx.Delete(&QueueDeadTask{}, dead.ID).Error
+			if err != nil {
+				return err
+			}
+
+			count += 1
+		}
+
+		return nil
+	})
+
+	if err == nil && count > 0 {
+		select {
+		case q.notify <- struct{}{}:
+		default:
+		}
+	}
+
+	return count, err
+}
+
+// Purge menghapus task, dead true untuk dead letter. ids kosong berarti semua task di queue,
+// queue dan ids tidak boleh kosong dua duanya supaya tidak menghapus semua antrian
+func (q *TaskQueue) Purge(ctx context.Context, dead bool, queue string, ids []uint) (int64, error) {
+	if queue == "" && len(ids) == 0 {
+		return 0, ErrPurgeScope
+	}
+
+	var model any = &QueueTask{}
+	if dead {
+		model = &QueueDeadTask{}
+	}
+
+	query := q.db.WithContext(ctx)
+	if queue != "" {
+		query = query.Where("queue = ?", queue)
+	}
+
+	if len(ids) != 0 {
+		query = query.Where("id in ?", ids)
+	}
+
+	res := query.Delete(model)
+	return res.RowsAffected, res.Error
+}
@@ -0,0 +1,191 @@
+package task_queue_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/cloudtasks/apiv2/cloudtaskspb"
+	"github.com/pdcgo/accounting_service/task_queue"
+	"github.com/pdcgo/shared/pkg/moretest"
+	"github.com/pdcgo/shared/pkg/moretest/moretest_mock"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/gorm"
+)
+
+func TestTaskQueue(t *testing.T) {
+	var db gorm.DB
+
+	var migrate moretest.SetupFunc = func(t *testing.T) func() error {
+		err := db.AutoMigrate(
+			&task_queue.QueueTask{},
+			&task_queue.QueueDeadTask{},
+		)
+		assert.Nil(t, err)
+		return nil
+	}
+
+	var hit int32
+	var failing atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hit, 1)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		if failing.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}))
+	defer server.Close()
+
+	newTask := func() *cloudtaskspb.CreateTaskRequest {
+		return &cloudtaskspb.CreateTaskRequest{
+			Parent: "projects/p/locations/l/queues/slow",
+			Task: &cloudtaskspb.Task{
+				MessageType: &cloudtaskspb.Task_HttpRequest{
+					HttpRequest: &cloudtaskspb.HttpRequest{
+						Url:        server.URL,
+						HttpMethod: cloudtaskspb.HttpMethod_POST,
+						Headers: map[string]string{
+							"Content-Type": "application/json",
+						},
+						Body: []byte("{}"),
+					},
+				},
+			},
+		}
+	}
+
+	moretest.Suite(t, "testing task queue",
+		moretest.SetupListFunc{
+			moretest_mock.MockSqliteDatabase(&db),
+			migrate,
+		},
+		func(t *testing.T) {
+			queue := task_queue.NewTaskQueue(&db)
+			queue.MaxAttempts = 2
+			queue.BaseBackoff = 0
+
+			t.Run("task sukses dihapus dari antrian", func(t *testing.T) {
+				err := queue.Dispatcher()(t.Context(), newTask())
+				assert.Nil(t, err)
+
+				found, err := queue.ProcessOne(t.Context())
+				assert.Nil(t, err)
+				assert.True(t, found)
+				assert.Equal(t, int32(1), atomic.LoadInt32(&hit))
+
+				found, err = queue.ProcessOne(t.Context())
+				assert.Nil(t, err)
+				assert.False(t, found)
+			})
+
+			t.Run("task gagal masuk dead letter lalu retry", func(t *testing.T) {
+				failing.Store(true)
+				err := queue.Enqueue(t.Context(), newTask())
+				assert.Nil(t, err)
+
+				for i := 0; i < 2; i++ {
+					found, err := queue.ProcessOne(t.Context())
+					assert.Nil(t, err)
+					assert.True(t, found)
+				}
+
+				items, total, err := queue.List(t.Context(), true, "", 1, 20)
+				assert.Nil(t, err)
+				assert.Equal(t, int64(1), total)
+				assert.Equal(t, "slow", items[0].Queue)
+				assert.Equal(t, int64(2), items[0].Attempts)
+				assert.NotEmpty(t, items[0].LastError)
+
+				failing.Store(false)
+				count, err := queue.Retry(t.Context(), []uint{uint(items[0].Id)})
+				assert.Nil(t, err)
+				assert.Equal(t, 1, count)
+
+				found, err := queue.ProcessOne(t.Context())
+				assert.Nil(t, err)
+				assert.True(t, found)
+
+				_, total, err = queue.List(t.Context(), false, "", 1, 20)
+				assert.Nil(t, err)
+				assert.Equal(t, int64(0), total)
+			})
+
+			t.Run("purge dead letter", func(t *testing.T) {
+				failing.Store(true)
+				err := queue.Enqueue(t.Context(), newTask())
+				assert.Nil(t, err)
+				for i := 0; i < 2; i++ {
+					_, err := queue.ProcessOne(t.Context())
+					assert.Nil(t, err)
+				}
+
+				count, err := queue.Purge(t.Context(), true, "slow", nil)
+				assert.Nil(t, err)
+				assert.Equal(t, int64(1), count)
+			})
+
+			t.Run("backoff", func(t *testing.T) {
+				queue := task_queue.NewTaskQueue(&db)
+				queue.Lease = time.Minute
+				failing.Store(true)
+				err := queue.Enqueue(t.Context(), newTask())
+				assert.Nil(t, err)
+
+				found, err := queue.ProcessOne(t.Context())
+				assert.Nil(t, err)
+				assert.True(t, found)
+
+				// jadwal ulang belum waktunya
+				found, err = queue.ProcessOne(t.Context())
+				assert.Nil(t, err)
+				assert.False(t, found)
+
+				items, _, err := queue.List(t.Context(), false, "", 1, 20)
+				assert.Nil(t, err)
+				assert.Greater(t, items[0].NextRun, time.Now().UnixMicro())
+			})
+
+			t.Run("purge harus pakai queue atau ids", func(t *testing.T) {
+				_, err := queue.Purge(t.Context(), false, "", nil)
+				assert.ErrorIs(t, err, task_queue.ErrPurgeScope)
+
+				count, err := queue.Purge(t.Context(), false, "slow", nil)
+				assert.Nil(t, err)
+				assert.Equal(t, int64(1), count)
+			})
+
+			t.Run("task terjadwal menunggu schedule time", func(t *testing.T) {
+				failing.Store(false)
+				schedule := time.Now().Add(time.Hour)
+				task := newTask()
+				task.Task.ScheduleTime = timestamppb.New(schedule)
+
+				err := queue.Enqueue(t.Context(), task)
+				assert.Nil(t, err)
+
+				found, err := queue.ProcessOne(t.Context())
+				assert.Nil(t, err)
+				assert.False(t, found)
+
+				items, _, err := queue.List(t.Context(), false, "", 1, 20)
+				assert.Nil(t, err)
+				assert.Len(t, items, 1)
+				assert.WithinDuration(t, schedule, time.UnixMicro(items[0].NextRun), time.Second)
+
+				// schedule time yang sudah lewat langsung jalan
+				task = newTask()
+				task.Task.ScheduleTime = timestamppb.New(time.Now().Add(-time.Hour))
+				err = queue.Enqueue(t.Context(), task)
+				assert.Nil(t, err)
+
+				found, err = queue.ProcessOne(t.Context())
+				assert.Nil(t, err)
+				assert.True(t, found)
+			})
+		},
+	)
+}
@@ -2,8 +2,13 @@ package transfer
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"connectrpc.com/connect"
+	"github.com/pdcgo/accounting_service/account"
+	"github.com/pdcgo/accounting_service/accounting_core"
+	"github.com/pdcgo/accounting_service/accounting_model"
 	"github.com/pdcgo/schema/services/accounting_iface/v1"
 	"github.com/pdcgo/shared/interfaces/authorization_iface"
 	"gorm.io/gorm"
@@ -15,16 +20,87 @@ type transferImpl struct {
 }
 
 // TransferTeam implements accounting_ifaceconnect.TransferServiceHandler.
-func (t *transferImpl) TransferTeam(context.Context, *connect.Request[accounting_iface.TransferTeamRequest]) (*connect.Response[accounting_iface.TransferTeamResponse], error) {
-	panic("unimplemented")
-}
-
-// TransferList implements accounting_ifaceconnect.TransferServiceHandler.
-func (t *transferImpl) TransferList(
+// kirim kas ke team lain, dengan require_approval jurnal menunggu team penerima
+func (t *transferImpl) TransferTeam(
 	ctx context.Context,
-	req *connect.Request[accounting_iface.TransferListRequest],
-) (*connect.Response[accounting_iface.TransferListResponse], error) {
-	panic("unimplemented")
+	req *connect.Request[accounting_iface.TransferTeamRequest],
+) (*connect.Response[accounting_iface.TransferTeamResponse], error) {
+	var err error
+	result := accounting_iface.TransferTeamResponse{}
+
+	pay := req.Msg
+	purpose := purposeFromProto(pay.Purpose)
+
+	switch {
+	case pay.FromTeamId == 0 || pay.ToTeamId == 0:
+		return connect.NewResponse(&result), connect.NewError(connect.CodeInvalidArgument, errors.New("from team and to team required"))
+	case pay.FromTeamId == pay.ToTeamId:
+		return connect.NewResponse(&result), connect.NewError(connect.CodeInvalidArgument, errors.New("cannot transfer to same team"))
+	case pay.Amount <= 0:
+		return connect.NewResponse(&result), connect.NewError(connect.CodeInvalidArgument, errors.New("amount must greater than zero"))
+	case pay.FeeAmount < 0:
+		return connect.NewResponse(&result), connect.NewError(connect.CodeInvalidArgument, errors.New("fee amount cannot negative"))
+	case !purpose.Valid():
+		return connect.NewResponse(&result), connect.NewError(connect.CodeInvalidArgument, errors.New("purpose not supported"))
+	case !pay.RequireApproval && pay.ToBankAccountId == 0:
+		return connect.NewResponse(&result), connect.NewError(connect.CodeInvalidArgument, errors.New("to bank account required"))
+	}
+
+	identity := t.auth.AuthIdentityFromHeader(req.Header())
+	agent := identity.Identity()
+	err = identity.
+		HasPermission(authorization_iface.CheckPermissionGroup{
+			&accounting_model.BankTransfer{}: &authorization_iface.CheckPermission{
+				DomainID: uint(pay.FromTeamId),
+				Actions:  []authorization_iface.Action{authorization_iface.Create},
+			},
+		}).
+		Err()
+
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	data := accounting_model.TeamTransfer{
+		FromTeamID:        uint(pay.FromTeamId),
+		ToTeamID:          uint(pay.ToTeamId),
+		Purpose:           purpose,
+		Status:            accounting_model.TeamTransferPending,
+		Amount:            pay.Amount,
+		FeeAmount:         pay.FeeAmount,
+		FromBankAccountID: uint(pay.FromBankAccountId),
+		Desc:              pay.Desc,
+		CreatedByID:       agent.IdentityID(),
+		CreatedAt:         time.Now(),
+	}
+
+	db := t.db.WithContext(ctx)
+	if pay.RequireApproval {
+		err = db.Transaction(func(tx *gorm.DB) error {
+			_, err := account.CashBank(tx, data.FromTeamID, pay.FromBankAccountId)
+			if err != nil {
+				return err
+			}
+			return tx.Save(&data).Error
+		})
+	} else {
+		data.ToBankAccountID = uint(pay.ToBankAccountId)
+		err = accounting_core.OpenTransaction(ctx, db, func(tx *gorm.DB, bookmng accounting_core.BookManage) error {
+			err := tx.Save(&data).Error
+			if err != nil {
+				return err
+			}
+
+			return postTeamTransfer(tx, bookmng, agent, &data)
+		})
+	}
+
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	result.Transfer = teamTransferItem(&data, data.FromTeamID)
+	return connect.NewResponse(&result), nil
 }
 
 func NewTransferService(db *gorm.DB, auth authorization_iface.Authorization) *transferImpl {
@@ -0,0 +1,353 @@
+package transfer_test
+
+import (
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/pdcgo/accounting_service/accounting_core"
+	"github.com/pdcgo/accounting_service/accounting_mock"
+	"github.com/pdcgo/accounting_service/accounting_model"
+	"github.com/pdcgo/accounting_service/transfer"
+	"github.com/pdcgo/schema/services/access_iface/v1"
+	"github.com/pdcgo/schema/services/accounting_iface/v1"
+	"github.com/pdcgo/schema/services/common/v1"
+	"github.com/pdcgo/shared/authorization/authorization_mock"
+	"github.com/pdcgo/shared/custom_connect"
+	"github.com/pdcgo/shared/pkg/moretest"
+	"github.com/pdcgo/shared/pkg/moretest/moretest_mock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestTeamTransfer(t *testing.T) {
+	var db gorm.DB
+
+	moretest.Suite(t, "testing transfer antar team",
+		moretest.SetupListFunc{
+			moretest_mock.MockSqliteDatabase(&db),
+			func(t *testing.T) func() error {
+				err := db.AutoMigrate(
+					&accounting_core.Account{},
+					&accounting_core.Transaction{},
+					&accounting_core.JournalEntry{},
+					&accounting_core.JournalEntryBank{},
+					&accounting_core.TypeLabel{},
+					&accounting_core.TransactionTypeLabel{},
+					&accounting_model.TeamTransfer{},
+					&accounting_model.BankAccountV2{},
+				)
+				assert.Nil(t, err)
+
+				err = db.Create([]*accounting_model.BankAccountV2{
+					{ID: 1, TeamID: 5, Name: "bca team 5", NumberID: "1001"},
+					{ID: 2, TeamID: 6, Name: "bca team 6", NumberID: "1002"},
+				}).Error
+				assert.Nil(t, err)
+				return nil
+			},
+			accounting_mock.PopulateAccountKey(&db, 5),
+			accounting_mock.PopulateAccountKey(&db, 6),
+			func(t *testing.T) func() error {
+				create := accounting_core.NewCreateAccount(&db)
+				for _, teamID := range []uint{5, 6} {
+					err := create.Create(accounting_core.CreditBalance, accounting_core.EQUITY, teamID, accounting_core.OwnerCapitalAccount, "owner capital")
+					assert.Nil(t, err)
+				}
+				return nil
+			},
+		},
+		func(t *testing.T) {
+			service := transfer.NewTransferService(&db, &authorization_mock.EmptyAuthorizationMock{
+				AuthIdentityMock: &authorization_mock.AuthIdentityMock{
+					IdentityMock: &authorization_mock.IdentityMock{
+						ID: 1,
+					},
+				},
+			})
+
+			create := func(t *testing.T, pay *accounting_iface.TransferTeamRequest) (*accounting_iface.TeamTransferItem, error) {
+				res, err := service.TransferTeam(t.Context(), connect.NewRequest(pay))
+				return res.Msg.Transfer, err
+			}
+
+			decide := func(t *testing.T, pay *accounting_iface.TransferDecideRequest) (*accounting_iface.TeamTransferItem, error) {
+				res, err := service.TransferDecide(t.Context(), connect.NewRequest(pay))
+				return res.Msg.Transfer, err
+			}
+
+			list := func(t *testing.T, teamID uint64, pay *accounting_iface.TransferListRequest) *accounting_iface.TransferListResponse {
+				ctx := custom_connect.SetRequestSource(t.Context(), &access_iface.RequestSource{
+					TeamId:      teamID,
+					RequestFrom: access_iface.RequestFrom_REQUEST_FROM_SELLING,
+				})
+				res, err := service.TransferList(ctx, connect.NewRequest(pay))
+				assert.Nil(t, err)
+				return res.Msg
+			}
+
+			// perubahan saldo akun di buku team untuk satu transaksi
+			accountChange := func(t *testing.T, txID uint, bookTeamID uint, key accounting_core.AccountKey, accTeamID uint) float64 {
+				var change float64
+				err := db.
+					Table("journal_entries je").
+					Joins("join accounts a on a.id = je.account_id").
+					Select("coalesce(sum(je.debit - je.credit), 0)").
+					Where("je.transaction_id = ?", txID).
+					Where("je.team_id = ?", bookTeamID).
+					Where("a.account_key = ?", key).
+					Where("a.team_id = ?", accTeamID).
+					Scan(&change).
+					Error
+				assert.Nil(t, err)
+				return change
+			}
+
+			bankBalance := func(t *testing.T, id uint) float64 {
+				var bank accounting_model.BankAccountV2
+				err := db.First(&bank, id).Error
+				assert.Nil(t, err)
+				return bank.Balance
+			}
+
+			t.Run("validasi request", func(t *testing.T) {
+				_, err := create(t, &accounting_iface.TransferTeamRequest{FromTeamId: 5, ToTeamId: 5, Amount: 1000})
+				assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+
+				_, err = create(t, &accounting_iface.TransferTeamRequest{FromTeamId: 5, ToTeamId: 6})
+				assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+
+				_, err = create(t, &accounting_iface.TransferTeamRequest{FromTeamId: 5, ToTeamId: 6, Amount: 1000, Purpose: 9})
+				assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+
+				// kas wajib menyebut bank masing masing team
+				_, err = create(t, &accounting_iface.TransferTeamRequest{FromTeamId: 5, ToTeamId: 6, Amount: 1000, FromBankAccountId: 1})
+				assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+
+				_, err = create(t, &accounting_iface.TransferTeamRequest{FromTeamId: 5, ToTeamId: 6, Amount: 1000, FromBankAccountId: 2, ToBankAccountId: 2})
+				assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+
+				_, err = create(t, &accounting_iface.TransferTeamRequest{FromTeamId: 5, ToTeamId: 6, Amount: 1000, FromBankAccountId: 2, RequireApproval: true})
+				assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+
+				var count int64
+				err = db.Model(&accounting_model.TeamTransfer{}).Count(&count).Error
+				assert.Nil(t, err)
+				assert.Equal(t, int64(0), count)
+			})
+
+			t.Run("pinjaman langsung dijurnal", func(t *testing.T) {
+				data, err := create(t, &accounting_iface.TransferTeamRequest{
+					FromTeamId:        5,
+					ToTeamId:          6,
+					Amount:            100000,
+					FeeAmount:         2500,
+					Desc:              "pinjam modal iklan",
+					FromBankAccountId: 1,
+					ToBankAccountId:   2,
+				})
+				assert.Nil(t, err)
+				assert.Equal(t, accounting_iface.TeamTransferStatus_TEAM_TRANSFER_STATUS_COMPLETED, data.Status)
+				assert.Equal(t, accounting_iface.TeamTransferPurpose_TEAM_TRANSFER_PURPOSE_LOAN, data.Purpose)
+				assert.Equal(t, accounting_iface.TransferDirection_TRANSFER_DIRECTION_OUT, data.Direction)
+				assert.NotZero(t, data.TxId)
+
+				txID := uint(data.TxId)
+				assert.Equal(t, -102500.0, accountChange(t, txID, 5, accounting_core.CashAccount, 5))
+				assert.Equal(t, 2500.0, accountChange(t, txID, 5, accounting_core.BankFeeAccount, 5))
+				assert.Equal(t, 100000.0, accountChange(t, txID, 5, accounting_core.LoanReceivableAccount, 6))
+
+				assert.Equal(t, 100000.0, accountChange(t, txID, 6, accounting_core.CashAccount, 6))
+				assert.Equal(t, -100000.0, accountChange(t, txID, 6, accounting_core.LoanPayableAccount, 5))
+
+				assert.Equal(t, -102500.0, bankBalance(t, 1))
+				assert.Equal(t, 100000.0, bankBalance(t, 2))
+			})
+
+			t.Run("pelunasan pinjaman", func(t *testing.T) {
+				data, err := create(t, &accounting_iface.TransferTeamRequest{
+					FromTeamId:        6,
+					ToTeamId:          5,
+					Amount:            40000,
+					Purpose:           accounting_iface.TeamTransferPurpose_TEAM_TRANSFER_PURPOSE_REPAYMENT,
+					FromBankAccountId: 2,
+					ToBankAccountId:   1,
+				})
+				assert.Nil(t, err)
+
+				txID := uint(data.TxId)
+				assert.Equal(t, -40000.0, accountChange(t, txID, 6, accounting_core.CashAccount, 6))
+				assert.Equal(t, 40000.0, accountChange(t, txID, 6, accounting_core.LoanPayableAccount, 5))
+				assert.Equal(t, 40000.0, accountChange(t, txID, 5, accounting_core.CashAccount, 5))
+				assert.Equal(t, -40000.0, accountChange(t, txID, 5, accounting_core.LoanReceivableAccount, 6))
+			})
+
+			t.Run("modal dengan approval menunggu team penerima", func(t *testing.T) {
+				pending, err := create(t, &accounting_iface.TransferTeamRequest{
+					FromTeamId:        5,
+					ToTeamId:          6,
+					Amount:            50000,
+					Purpose:           accounting_iface.TeamTransferPurpose_TEAM_TRANSFER_PURPOSE_CAPITAL,
+					RequireApproval:   true,
+					FromBankAccountId: 1,
+				})
+				assert.Nil(t, err)
+				assert.Equal(t, accounting_iface.TeamTransferStatus_TEAM_TRANSFER_STATUS_PENDING, pending.Status)
+				assert.Zero(t, pending.TxId)
+				assert.Equal(t, -62500.0, bankBalance(t, 1))
+
+				_, err = decide(t, &accounting_iface.TransferDecideRequest{
+					TeamId:     5,
+					TransferId: pending.Id,
+					Approve:    true,
+				})
+				assert.Equal(t, connect.CodePermissionDenied, connect.CodeOf(err))
+
+				_, err = decide(t, &accounting_iface.TransferDecideRequest{
+					TeamId:     6,
+					TransferId: pending.Id,
+					Approve:    true,
+				})
+				assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+
+				data, err := decide(t, &accounting_iface.TransferDecideRequest{
+					TeamId:          6,
+					TransferId:      pending.Id,
+					Approve:         true,
+					ToBankAccountId: 2,
+				})
+				assert.Nil(t, err)
+				assert.Equal(t, accounting_iface.TeamTransferStatus_TEAM_TRANSFER_STATUS_COMPLETED, data.Status)
+				assert.Equal(t, accounting_iface.TransferDirection_TRANSFER_DIRECTION_IN, data.Direction)
+				assert.NotZero(t, data.DecidedAt)
+
+				txID := uint(data.TxId)
+				assert.Equal(t, 50000.0, accountChange(t, txID, 5, accounting_core.OwnerCapitalAccount, 5))
+				assert.Equal(t, -50000.0, accountChange(t, txID, 6, accounting_core.OwnerCapitalAccount, 6))
+				assert.Equal(t, 50000.0, accountChange(t, txID, 6, accounting_core.CashAccount, 6))
+				assert.Equal(t, -112500.0, bankBalance(t, 1))
+				assert.Equal(t, 110000.0, bankBalance(t, 2))
+
+				_, err = decide(t, &accounting_iface.TransferDecideRequest{
+					TeamId:          6,
+					TransferId:      pending.Id,
+					Approve:         true,
+					ToBankAccountId: 2,
+				})
+				assert.Equal(t, connect.CodeFailedPrecondition, connect.CodeOf(err))
+			})
+
+			t.Run("tolak transfer tanpa jurnal", func(t *testing.T) {
+				data, err := create(t, &accounting_iface.TransferTeamRequest{
+					FromTeamId:        5,
+					ToTeamId:          6,
+					Amount:            7000,
+					RequireApproval:   true,
+					FromBankAccountId: 1,
+				})
+				assert.Nil(t, err)
+
+				_, err = decide(t, &accounting_iface.TransferDecideRequest{
+					TeamId:     6,
+					TransferId: data.Id,
+				})
+				assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+
+				data, err = decide(t, &accounting_iface.TransferDecideRequest{
+					TeamId:     6,
+					TransferId: data.Id,
+					Reason:     "salah team",
+				})
+				assert.Nil(t, err)
+				assert.Equal(t, accounting_iface.TeamTransferStatus_TEAM_TRANSFER_STATUS_REJECTED, data.Status)
+				assert.Equal(t, "salah team", data.Reason)
+				assert.Zero(t, data.TxId)
+
+				var count int64
+				err = db.Model(&accounting_core.Transaction{}).Count(&count).Error
+				assert.Nil(t, err)
+				assert.Equal(t, int64(3), count)
+			})
+
+			t.Run("pengirim batalkan transfer pending", func(t *testing.T) {
+				data, err := create(t, &accounting_iface.TransferTeamRequest{
+					FromTeamId:        5,
+					ToTeamId:          6,
+					Amount:            3000,
+					RequireApproval:   true,
+					FromBankAccountId: 1,
+				})
+				assert.Nil(t, err)
+
+				cancel := func(teamID uint64) (*accounting_iface.TeamTransferItem, error) {
+					res, err := service.TransferTeamCancel(t.Context(), connect.NewRequest(&accounting_iface.TransferTeamCancelRequest{
+						TeamId:     teamID,
+						TransferId: data.Id,
+						Reason:     "salah nominal",
+					}))
+					return res.Msg.Transfer, err
+				}
+
+				// team penerima tidak bisa membatalkan
+				_, err = cancel(6)
+				assert.Equal(t, connect.CodePermissionDenied, connect.CodeOf(err))
+
+				cancelled, err := cancel(5)
+				assert.Nil(t, err)
+				assert.Equal(t, accounting_iface.TeamTransferStatus_TEAM_TRANSFER_STATUS_CANCELLED, cancelled.Status)
+				assert.Equal(t, "salah nominal", cancelled.Reason)
+				assert.Zero(t, cancelled.TxId)
+
+				_, err = cancel(5)
+				assert.Equal(t, connect.CodeFailedPrecondition, connect.CodeOf(err))
+
+				_, err = decide(t, &accounting_iface.TransferDecideRequest{
+					TeamId:          6,
+					TransferId:      data.Id,
+					Approve:         true,
+					ToBankAccountId: 2,
+				})
+				assert.Equal(t, connect.CodeFailedPrecondition, connect.CodeOf(err))
+				assert.Equal(t, -112500.0, bankBalance(t, 1))
+			})
+
+			t.Run("list dua arah", func(t *testing.T) {
+				res := list(t, 6, &accounting_iface.TransferListRequest{
+					Page: &common.PageFilter{Page: 1, Limit: 10},
+				})
+				assert.Equal(t, int64(5), res.PageInfo.TotalItems)
+				assert.Len(t, res.Data, 5)
+
+				out := 0
+				for _, item := range res.Data {
+					if item.Direction == accounting_iface.TransferDirection_TRANSFER_DIRECTION_OUT {
+						out++
+					}
+				}
+				assert.Equal(t, 1, out)
+
+				res = list(t, 6, &accounting_iface.TransferListRequest{
+					Direction: accounting_iface.TransferDirection_TRANSFER_DIRECTION_IN,
+					Status:    accounting_iface.TeamTransferStatus_TEAM_TRANSFER_STATUS_COMPLETED,
+					Page:      &common.PageFilter{Page: 2, Limit: 1},
+				})
+				assert.Equal(t, int64(2), res.PageInfo.TotalItems)
+				assert.Equal(t, int64(2), res.PageInfo.TotalPage)
+				assert.Len(t, res.Data, 1)
+				assert.Equal(t, accounting_iface.TeamTransferPurpose_TEAM_TRANSFER_PURPOSE_LOAN, res.Data[0].Purpose)
+
+				res = list(t, 5, &accounting_iface.TransferListRequest{
+					Purpose: accounting_iface.TeamTransferPurpose_TEAM_TRANSFER_PURPOSE_CAPITAL,
+					Page:    &common.PageFilter{Page: 1, Limit: 10},
+				})
+				assert.Len(t, res.Data, 1)
+				assert.Equal(t, accounting_iface.TransferDirection_TRANSFER_DIRECTION_OUT, res.Data[0].Direction)
+
+				res = list(t, 7, &accounting_iface.TransferListRequest{
+					Page: &common.PageFilter{Page: 1, Limit: 10},
+				})
+				assert.Equal(t, int64(0), res.PageInfo.TotalItems)
+				assert.Empty(t, res.Data)
+			})
+		},
+	)
+}
@@ -0,0 +1,421 @@
+package transfer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/pdcgo/accounting_service/account"
+	"github.com/pdcgo/accounting_service/accounting_core"
+	"github.com/pdcgo/accounting_service/accounting_model"
+	"github.com/pdcgo/schema/services/accounting_iface/v1"
+	"github.com/pdcgo/shared/interfaces/authorization_iface"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TransferDecide implements accounting_ifaceconnect.TransferServiceHandler.
+// team penerima menyetujui atau menolak transfer yang masih pending
+func (t *transferImpl) TransferDecide(
+	ctx context.Context,
+	req *connect.Request[accounting_iface.TransferDecideRequest],
+) (*connect.Response[accounting_iface.TransferDecideResponse], error) {
+	var err error
+	result := accounting_iface.TransferDecideResponse{}
+
+	pay := req.Msg
+	pay.Reason = strings.TrimSpace(pay.Reason)
+	if !pay.Approve && pay.Reason == "" {
+		return connect.NewResponse(&result), connect.NewError(connect.CodeInvalidArgument, errors.New("reject reason required"))
+	}
+
+	identity := t.auth.AuthIdentityFromHeader(req.Header())
+	agent := identity.Identity()
+	err = identity.
+		HasPermission(authorization_iface.CheckPermissionGroup{
+			&accounting_model.BankTransfer{}: &authorization_iface.CheckPermission{
+				DomainID: uint(pay.TeamId),
+				Actions:  []authorization_iface.Action{authorization_iface.Update},
+			},
+		}).
+		Err()
+
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	decide := func(tx *gorm.DB) (*accounting_model.TeamTransfer, error) {
+		data, err := lockPendingTransfer(tx, pay.TransferId, func(data *accounting_model.TeamTransfer) bool {
+			return data.ToTeamID == uint(pay.TeamId)
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		now := time.Now()
+		data.DecidedByID = agent.IdentityID()
+		data.DecidedAt = &now
+		return data, nil
+	}
+
+	var data *accounting_model.TeamTransfer
+	db := t.db.WithContext(ctx)
+	if pay.Approve {
+		err = accounting_core.OpenTransaction(ctx, db, func(tx *gorm.DB, bookmng accounting_core.BookManage) error {
+			data, err = decide(tx)
+			if err != nil {
+				return err
+			}
+
+			data.ToBankAccountID = uint(pay.ToBankAccountId)
+			return postTeamTransfer(tx, bookmng, agent, data)
+		})
+	} else {
+		err = db.Transaction(func(tx *gorm.DB) error {
+			data, err = decide(tx)
+			if err != nil {
+				return err
+			}
+
+			data.Status = accounting_model.TeamTransferRejected
+			data.Reason = pay.Reason
+			return tx.Save(data).Error
+		})
+	}
+
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	result.Transfer = teamTransferItem(data, data.ToTeamID)
+	return connect.NewResponse(&result), nil
+}
+
+// TransferTeamCancel implements accounting_ifaceconnect.TransferServiceHandler.
+// team pengirim membatalkan transfer yang belum diputuskan team penerima, belum ada jurnal yang dibalik
+func (t *transferImpl) TransferTeamCancel(
+	ctx context.Context,
+	req *connect.Request[accounting_iface.TransferTeamCancelRequest],
+) (*connect.Response[accounting_iface.TransferTeamCancelResponse], error) {
+	var err error
+	result := accounting_iface.TransferTeamCancelResponse{}
+
+	pay := req.Msg
+	pay.Reason = strings.TrimSpace(pay.Reason)
+	if pay.Reason == "" {
+		return connect.NewResponse(&result), connect.NewError(connect.CodeInvalidArgument, errors.New("cancel reason required"))
+	}
+
+	identity := t.auth.AuthIdentityFromHeader(req.Header())
+	agent := identity.Identity()
+	err = identity.
+		HasPermission(authorization_iface.CheckPermissionGroup{
+			&accounting_model.BankTransfer{}: &authorization_iface.CheckPermission{
+				DomainID: uint(pay.TeamId),
+				Actions:  []authorization_iface.Action{authorization_iface.Update},
+			},
+		}).
+		Err()
+
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	var data *accounting_model.TeamTransfer
+	err = t.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		data, err = lockPendingTransfer(tx, pay.TransferId, func(data *accounting_model.TeamTransfer) bool {
+			return data.FromTeamID == uint(pay.TeamId)
+		})
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		data.Status = accounting_model.TeamTransferCancelled
+		data.Reason = pay.Reason
+		data.DecidedByID = agent.IdentityID()
+		data.DecidedAt = &now
+		return tx.Save(data).Error
+	})
+
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	result.Transfer = teamTransferItem(data, data.FromTeamID)
+	return connect.NewResponse(&result), nil
+}
+
+// lockPendingTransfer mengunci team transfer yang masih pending, owned mengecek team yang boleh merubah
+func lockPendingTransfer(
+	tx *gorm.DB,
+	transferID uint64,
+	owned func(data *accounting_model.TeamTransfer) bool,
+) (*accounting_model.TeamTransfer, error) {
+	var data accounting_model.TeamTransfer
+	err := tx.
+		Clauses(clause.Locking{
+			Strength: "UPDATE",
+		}).
+		Model(&accounting_model.TeamTransfer{}).
+		Where("id = ?", transferID).
+		Find(&data).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	if data.ID == 0 {
+		return nil, connect.NewError(connect.CodeNotFound, fmt.Errorf("team transfer id %d not found", transferID))
+	}
+	if !owned(&data) {
+		return nil, connect.NewError(connect.CodePermissionDenied, errors.New("team transfer not belong to your team"))
+	}
+	if data.Status != accounting_model.TeamTransferPending {
+		return nil, connect.NewError(connect.CodeFailedPrecondition, fmt.Errorf("team transfer already %s", data.Status))
+	}
+
+	return &data, nil
+}
+
+// postTeamTransfer jurnal di buku pengirim dan penerima, lawan kas tergantung purpose
+func postTeamTransfer(
+	tx *gorm.DB,
+	bookmng accounting_core.BookManage,
+	agent authorization_iface.Identity,
+	data *accounting_model.TeamTransfer,
+) error {
+	var err error
+
+	fromBank, err := account.CashBank(tx, data.FromTeamID, uint64(data.FromBankAccountID))
+	if err != nil {
+		return err
+	}
+	toBank, err := account.CashBank(tx, data.ToTeamID, uint64(data.ToBankAccountID))
+	if err != nil {
+		return err
+	}
+
+	ref := accounting_core.NewRefID(&accounting_core.RefData{
+		RefType: accounting_core.TeamTransferRef,
+		ID:      data.ID,
+	})
+
+	desc := data.Desc
+	if desc == "" {
+		desc = fmt.Sprintf("%s transfer team %d to team %d", data.Purpose, data.FromTeamID, data.ToTeamID)
+	}
+
+	trans := accounting_core.Transaction{
+		CreatedByID: agent.IdentityID(),
+		TeamID:      data.FromTeamID,
+		RefID:       ref,
+		Desc:        desc,
+		Created:     time.Now(),
+	}
+
+	err = bookmng.
+		NewTransaction().
+		Create(&trans).
+		AddTypeLabel([]*accounting_iface.TypeLabel{
+			{
+				Key:   accounting_iface.LabelKey_LABEL_KEY_TRANSFER_PURPOSE,
+				Label: string(data.Purpose),
+			},
+		}).
+		Err()
+
+	if err != nil {
+		return err
+	}
+
+	// book from
+	entry := bookmng.
+		NewCreateEntry(data.FromTeamID, agent.GetUserID()).
+		From(&accounting_core.EntryAccountPayload{
+			Key:           accounting_core.CashAccount,
+			TeamID:        data.FromTeamID,
+			BankAccountID: fromBank.ID,
+		}, data.Amount+data.FeeAmount)
+
+	if data.FeeAmount != 0 {
+		entry.
+			To(&accounting_core.EntryAccountPayload{
+				Key:    accounting_core.BankFeeAccount,
+				TeamID: data.FromTeamID,
+			}, data.FeeAmount)
+	}
+
+	switch data.Purpose {
+	case accounting_model.TeamTransferLoan:
+		entry.
+			To(&accounting_core.EntryAccountPayload{
+				Key:    accounting_core.LoanReceivableAccount,
+				TeamID: data.ToTeamID,
+			}, data.Amount)
+	case accounting_model.TeamTransferRepayment:
+		entry.
+			From(&accounting_core.EntryAccountPayload{
+				Key:    accounting_core.LoanPayableAccount,
+				TeamID: data.ToTeamID,
+			}, data.Amount)
+	case accounting_model.TeamTransferCapital:
+		entry.
+			From(&accounting_core.EntryAccountPayload{
+				Key:    accounting_core.OwnerCapitalAccount,
+				TeamID: data.FromTeamID,
+			}, data.Amount)
+	}
+
+	err = entry.
+		Transaction(&trans).
+		Desc(desc).
+		Commit().
+		Err()
+	if err != nil {
+		return err
+	}
+
+	// book to
+	entry = bookmng.
+		NewCreateEntry(data.ToTeamID, agent.GetUserID()).
+		To(&accounting_core.EntryAccountPayload{
+			Key:           accounting_core.CashAccount,
+			TeamID:        data.ToTeamID,
+			BankAccountID: toBank.ID,
+		}, data.Amount)
+
+	switch data.Purpose {
+	case accounting_model.TeamTransferLoan:
+		entry.
+			To(&accounting_core.EntryAccountPayload{
+				Key:    accounting_core.LoanPayableAccount,
+				TeamID: data.FromTeamID,
+			}, data.Amount)
+	case accounting_model.TeamTransferRepayment:
+		entry.
+			From(&accounting_core.EntryAccountPayload{
+				Key:    accounting_core.LoanReceivableAccount,
+				TeamID: data.FromTeamID,
+			}, data.Amount)
+	case accounting_model.TeamTransferCapital:
+		entry.
+			To(&accounting_core.EntryAccountPayload{
+				Key:    accounting_core.OwnerCapitalAccount,
+				TeamID: data.ToTeamID,
+			}, data.Amount)
+	}
+
+	err = entry.
+		Transaction(&trans).
+		Desc(desc).
+		Commit().
+		Err()
+	if err != nil {
+		return err
+	}
+
+	err = account.SyncBankBalance(tx, fromBank.ID, toBank.ID)
+	if err != nil {
+		return err
+	}
+
+	data.TxID = trans.ID
+	data.Status = accounting_model.TeamTransferCompleted
+	return tx.Save(data).Error
+}
+
+func purposeFromProto(purpose accounting_iface.TeamTransferPurpose) accounting_model.TeamTransferPurpose {
+	switch purpose {
+	case accounting_iface.TeamTransferPurpose_TEAM_TRANSFER_PURPOSE_UNSPECIFIED,
+		accounting_iface.TeamTransferPurpose_TEAM_TRANSFER_PURPOSE_LOAN:
+		return accounting_model.TeamTransferLoan
+	case accounting_iface.TeamTransferPurpose_TEAM_TRANSFER_PURPOSE_REPAYMENT:
+		return accounting_model.TeamTransferRepayment
+	case accounting_iface.TeamTransferPurpose_TEAM_TRANSFER_PURPOSE_CAPITAL:
+		return accounting_model.TeamTransferCapital
+	default:
+		return ""
+	}
+}
+
+func purposeProto(purpose accounting_model.TeamTransferPurpose) accounting_iface.TeamTransferPurpose {
+	switch purpose {
+	case accounting_model.TeamTransferLoan:
+		return accounting_iface.TeamTransferPurpose_TEAM_TRANSFER_PURPOSE_LOAN
+	case accounting_model.TeamTransferRepayment:
+		return accounting_iface.TeamTransferPurpose_TEAM_TRANSFER_PURPOSE_REPAYMENT
+	case accounting_model.TeamTransferCapital:
+		return accounting_iface.TeamTransferPurpose_TEAM_TRANSFER_PURPOSE_CAPITAL
+	default:
+		return accounting_iface.TeamTransferPurpose_TEAM_TRANSFER_PURPOSE_UNSPECIFIED
+	}
+}
+
+func statusFromProto(status accounting_iface.TeamTransferStatus) accounting_model.TeamTransferStatus {
+	switch status {
+	case accounting_iface.TeamTransferStatus_TEAM_TRANSFER_STATUS_PENDING:
+		return accounting_model.TeamTransferPending
+	case accounting_iface.TeamTransferStatus_TEAM_TRANSFER_STATUS_COMPLETED:
+		return accounting_model.TeamTransferCompleted
+	case accounting_iface.TeamTransferStatus_TEAM_TRANSFER_STATUS_REJECTED:
+		return accounting_model.TeamTransferRejected
+	case accounting_iface.TeamTransferStatus_TEAM_TRANSFER_STATUS_CANCELLED:
+		return accounting_model.TeamTransferCancelled
+	default:
+		return ""
+	}
+}
+
+func statusProto(status accounting_model.TeamTransferStatus) accounting_iface.TeamTransferStatus {
+	switch status {
+	case accounting_model.TeamTransferPending:
+		return accounting_iface.TeamTransferStatus_TEAM_TRANSFER_STATUS_PENDING
+	case accounting_model.TeamTransferCompleted:
+		return accounting_iface.TeamTransferStatus_TEAM_TRANSFER_STATUS_COMPLETED
+	case accounting_model.TeamTransferRejected:
+		return accounting_iface.TeamTransferStatus_TEAM_TRANSFER_STATUS_REJECTED
+	case accounting_model.TeamTransferCancelled:
+		return accounting_iface.TeamTransferStatus_TEAM_TRANSFER_STATUS_CANCELLED
+	default:
+		return accounting_iface.TeamTransferStatus_TEAM_TRANSFER_STATUS_UNSPECIFIED
+	}
+}
+
+// teamTransferItem response proto, direction dilihat dari teamID. teamID 0 direction kosong
+func teamTransferItem(data *accounting_model.TeamTransfer, teamID uint) *accounting_iface.TeamTransferItem {
+	item := accounting_iface.TeamTransferItem{
+		Id:                uint64(data.ID),
+		FromTeamId:        uint64(data.FromTeamID),
+		ToTeamId:          uint64(data.ToTeamID),
+		TxId:              uint64(data.TxID),
+		Purpose:           purposeProto(data.Purpose),
+		Status:            statusProto(data.Status),
+		Amount:            data.Amount,
+		FeeAmount:         data.FeeAmount,
+		FromBankAccountId: uint64(data.FromBankAccountID),
+		ToBankAccountId:   uint64(data.ToBankAccountID),
+		Desc:              data.Desc,
+		CreatedById:       uint64(data.CreatedByID),
+		DecidedById:       uint64(data.DecidedByID),
+		Reason:            data.Reason,
+		CreatedAt:         data.CreatedAt.UnixMicro(),
+	}
+
+	if data.DecidedAt != nil {
+		item.DecidedAt = data.DecidedAt.UnixMicro()
+	}
+
+	switch teamID {
+	case 0:
+	case data.FromTeamID:
+		item.Direction = accounting_iface.TransferDirection_TRANSFER_DIRECTION_OUT
+	case data.ToTeamID:
+		item.Direction = accounting_iface.TransferDirection_TRANSFER_DIRECTION_IN
+	}
+
+	return &item
+}
@@ -0,0 +1,165 @@
+package transfer
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/pdcgo/accounting_service/accounting_model"
+	"github.com/pdcgo/schema/services/accounting_iface/v1"
+	"github.com/pdcgo/schema/services/common/v1"
+	"github.com/pdcgo/shared/authorization"
+	"github.com/pdcgo/shared/custom_connect"
+	"github.com/pdcgo/shared/interfaces/authorization_iface"
+	"gorm.io/gorm"
+)
+
+const defaultListLimit = 100
+
+type teamTransferFilter struct {
+	teamID    uint
+	direction accounting_iface.TransferDirection
+	status    accounting_model.TeamTransferStatus
+	purpose   accounting_model.TeamTransferPurpose
+	start     time.Time
+	end       time.Time
+}
+
+// query team kosong berarti semua team, dipakai admin
+func (f *teamTransferFilter) query(db *gorm.DB) *gorm.DB {
+	query := db.Model(&accounting_model.TeamTransfer{})
+
+	switch {
+	case f.teamID == 0:
+	case f.direction == accounting_iface.TransferDirection_TRANSFER_DIRECTION_IN:
+		query = query.Where("to_team_id = ?", f.teamID)
+	case f.direction == accounting_iface.TransferDirection_TRANSFER_DIRECTION_OUT:
+		query = query.Where("from_team_id = ?", f.teamID)
+	default:
+		query = query.Where("from_team_id = ? OR to_team_id = ?", f.teamID, f.teamID)
+	}
+
+	if f.status != "" {
+		query = query.Where("status = ?", f.status)
+	}
+	if f.purpose != "" {
+		query = query.Where("purpose = ?", f.purpose)
+	}
+	if !f.start.IsZero() {
+		query = query.Where("created_at >= ?", f.start)
+	}
+	if !f.end.IsZero() {
+		query = query.Where("created_at < ?", f.end)
+	}
+	return query
+}
+
+// TransferList implements accounting_ifaceconnect.TransferServiceHandler.
+// transfer masuk dan keluar team, terbaru dulu. admin melihat semua team
+func (t *transferImpl) TransferList(
+	ctx context.Context,
+	req *connect.Request[accounting_iface.TransferListRequest],
+) (*connect.Response[accounting_iface.TransferListResponse], error) {
+	var err error
+	result := accounting_iface.TransferListResponse{
+		Data:     []*accounting_iface.TeamTransferItem{},
+		PageInfo: &common.PageInfo{},
+	}
+
+	source, err := custom_connect.GetRequestSource(ctx)
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	pay := req.Msg
+	if pay.Page == nil {
+		return connect.NewResponse(&result), errors.New("page must set")
+	}
+	if pay.Page.Limit == 0 {
+		pay.Page.Limit = defaultListLimit
+	}
+	if pay.Page.Page == 0 {
+		pay.Page.Page = 1
+	}
+
+	filter := teamTransferFilter{
+		direction: pay.Direction,
+		status:    statusFromProto(pay.Status),
+	}
+	// purpose kosong di TransferTeam berarti pinjaman, di list berarti semua purpose
+	if pay.Purpose != accounting_iface.TeamTransferPurpose_TEAM_TRANSFER_PURPOSE_UNSPECIFIED {
+		filter.purpose = purposeFromProto(pay.Purpose)
+	}
+
+	var domainID uint
+	switch pay.RequestFrom {
+	case common.RequestFrom_REQUEST_FROM_ADMIN:
+		domainID = authorization.RootDomain
+	default:
+		domainID = uint(source.TeamId)
+		filter.teamID = uint(source.TeamId)
+	}
+
+	identity := t.auth.AuthIdentityFromHeader(req.Header())
+	err = identity.
+		HasPermission(authorization_iface.CheckPermissionGroup{
+			&accounting_model.BankTransfer{}: &authorization_iface.CheckPermission{
+				DomainID: domainID,
+				Actions:  []authorization_iface.Action{authorization_iface.Read},
+			},
+		}).
+		Err()
+
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	if pay.TimeRange != nil {
+		if pay.TimeRange.StartDate != nil {
+			filter.start = pay.TimeRange.StartDate.AsTime()
+		}
+		if pay.TimeRange.EndDate != nil {
+			filter.end = pay.TimeRange.EndDate.AsTime()
+		}
+	}
+
+	query := filter.query(t.db.WithContext(ctx))
+
+	var itemcount int64
+	err = query.
+		Session(&gorm.Session{}).
+		Count(&itemcount).
+		Error
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	transfers := []*accounting_model.TeamTransfer{}
+	err = query.
+		Order("created_at desc, id desc").
+		Offset(int((pay.Page.Page - 1) * pay.Page.Limit)).
+		Limit(int(pay.Page.Limit)).
+		Find(&transfers).
+		Error
+	if err != nil {
+		return connect.NewResponse(&result), err
+	}
+
+	for _, data := range transfers {
+		result.Data = append(result.Data, teamTransferItem(data, filter.teamID))
+	}
+
+	total := (itemcount + pay.Page.Limit - 1) / pay.Page.Limit
+	if total == 0 {
+		total = 1
+	}
+
+	result.PageInfo = &common.PageInfo{
+		CurrentPage: pay.Page.Page,
+		TotalPage:   total,
+		TotalItems:  itemcount,
+	}
+
+	return connect.NewResponse(&result), nil
+}